@@ -1,9 +1,17 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -13,53 +21,311 @@ const (
 	MinFarmOrgMSP      = "FarmOrgMSP"
 	RegulatorOrgMSP    = "RegulatorOrgMSP"
 	AdminOrgMSP        = "AdminOrgMSP"
+	ProcessorOrgMSP    = "ProcessorOrgMSP"
+	LabOrgMSP          = "LabOrgMSP"
 	TemperatureMinSafe = 2.0
 	TemperatureMaxSafe = 8.0
+	QualityScoreMin    = 0.0
+	QualityScoreMax    = 100.0
+	SystemConfigKey    = "SYSTEM_CONFIG"
+	TransitionRulesKey = "TRANSITION_RULES"
+	QualityGradeReject = "REJECT" // assigned below the lowest grade band
+	MaxPageSize        = 100
+	MaxStatsRangeDays  = 366 // longest date range a stats query may cover
+	ProductSearchLimit = 20  // most matches SearchProducts returns
+
+	// CertificationIssueToleranceMinutes is how far past the transaction
+	// timestamp a certification's issued date may be, allowing for clock skew
+	CertificationIssueToleranceMinutes = 5
+
+	// MinRegulatoryReasonLength is the shortest reason accepted when a
+	// regulatory record is rejected or sent back for more information
+	MinRegulatoryReasonLength = 10
+
+	// MaxRegulatoryDecisionHistory is how many decisions a regulatory record
+	// keeps; the oldest are dropped beyond it
+	MaxRegulatoryDecisionHistory = 50
+
+	// MaxRegulatoryDetailsLength caps a regulatory record's details, in bytes,
+	// to keep free text and JSON details from bloating the world state
+	MaxRegulatoryDetailsLength = 4096
+
+	// StopSaleRecordType is the regulatory record type of a stop-sale hold.
+	// Stop-sales are issued and lifted only through IssueStopSale and
+	// LiftStopSale, never through the general regulatory record calls.
+	StopSaleRecordType = "STOP_SALE"
+
+	// ExportPermitRecordType is the regulatory record type of an export
+	// permit. Export permits carry structured ExportPermitDetails and are
+	// created only through CreateExportPermit.
+	ExportPermitRecordType = "EXPORT_PERMIT"
+
+	// BatchTokenSecretKey holds the secret batch label verification tokens
+	// are derived from, in BatchTokenSecretCollection rather than the world
+	// state so that it never appears in blocks or GetSystemConfig.
+	BatchTokenSecretKey = "BATCH_TOKEN_SECRET"
+	// BatchTokenSecretCollection is the private data collection holding the
+	// batch token secret; see collections_config.json
+	BatchTokenSecretCollection = "batchTokenSecretCollection"
+	// BatchTokenSecretTransientKey is the transient map key SetBatchTokenSecret
+	// reads the secret from
+	BatchTokenSecretTransientKey = "secret"
+	// MinBatchTokenSecretLength is the shortest secret SetBatchTokenSecret accepts
+	MinBatchTokenSecretLength = 32
+
+	// DefaultCertificateNumberPrefix starts generated certification IDs until
+	// the Regulator sets its own prefix
+	DefaultCertificateNumberPrefix = "CERT"
+
+	// ProcessingEventVersion is carried as payload_version on processing
+	// events; payloads without it are the original ID-only format
+	ProcessingEventVersion = 2
 )
 
+// ledgerTimestampLayout is the RFC3339 layout of stored transaction
+// timestamps. Its fixed-width fraction keeps string comparison of timestamps
+// in time order, which RFC3339Nano's trimmed trailing zeros would not.
+const ledgerTimestampLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Feature flags stored in SystemConfigAsset.FeatureFlags
+const (
+	FeatureRequireCertificationReview = "RequireCertificationReview"
+	FeatureRequireHACCPLog            = "RequireHACCPLogForHACCPCerts"
+	FeatureEmitFullState              = "EmitFullState"
+	FeatureAllowEarlyProcessing       = "AllowProcessingBeforeBatchCompletion"
+	FeatureFlagImplausibleYield       = "FlagImplausibleYieldInsteadOfReject"
+	FeatureRequireMonotonicTempLogs   = "RequireMonotonicTempLogs"
+	FeatureSplitCertificationEvents   = "SplitCertificationEvents"
+)
+
+// knownFeatureFlags lists the flags SetFeatureFlag accepts
+var knownFeatureFlags = []string{
+	FeatureRequireCertificationReview,
+	FeatureRequireHACCPLog,
+	FeatureEmitFullState,
+	FeatureAllowEarlyProcessing,
+	FeatureFlagImplausibleYield,
+	FeatureRequireMonotonicTempLogs,
+	FeatureSplitCertificationEvents,
+}
+
+// Numeric thresholds stored in SystemConfigAsset.Thresholds
+const (
+	ThresholdLiveWeightKgPerUnit      = "LiveWeightKgPerUnit"
+	ThresholdProcessingStartTolerance = "ProcessingStartToleranceMinutes"
+	ThresholdUnprocessedRemainderPct  = "UnprocessedRemainderTolerancePercent"
+	ThresholdSanitationMaxAgeDays     = "SanitationMaxAgeDays"
+	ThresholdAmendmentCoSignPct       = "AmendmentCoSignChangePercent"
+	ThresholdMaxYieldPerUnit          = "MaxYieldKgPerUnit"
+	ThresholdMinCertQualityScore      = "MinQualityScoreForCertification"
+)
+
+// knownThresholds lists the thresholds SetThreshold accepts
+var knownThresholds = []string{
+	ThresholdLiveWeightKgPerUnit,
+	ThresholdProcessingStartTolerance,
+	ThresholdUnprocessedRemainderPct,
+	ThresholdSanitationMaxAgeDays,
+	ThresholdAmendmentCoSignPct,
+	ThresholdMaxYieldPerUnit,
+	ThresholdMinCertQualityScore,
+}
+
+// Certification types allowed until the Admin stores its own list
+var defaultCertificationTypes = []string{"HALAL", "ORGANIC", "HACCP", "GAP", "COLD_CHAIN_COMPLIANT"}
+
+// Regulatory record types allowed until the Admin stores its own list
+var defaultRegulatoryRecordTypes = []string{
+	"SLAUGHTER_CLEARANCE",
+	"EXPORT_PERMIT",
+	"IMPORT_PERMIT",
+	"MOVEMENT_PERMIT",
+	"SANITARY_INSPECTION",
+}
+
+// Default quality grade bands, used until the Regulator stores its own
+var defaultQualityGradeBands = []QualityGradeBand{
+	{Grade: "A", MinScore: 85},
+	{Grade: "B", MinScore: 70},
+	{Grade: "C", MinScore: 50},
+}
+
 // Status transition rules
 var validStatusTransitions = map[string][]string{
 	"CREATED":      {"IN_PROGRESS", "CANCELLED"},
 	"IN_PROGRESS":  {"COMPLETED", "FAILED", "CANCELLED"},
-	"COMPLETED":    {},
+	"COMPLETED":    {"PROCESSED"},
+	"PROCESSED":    {},
+	"ON_HOLD":      {},
+	"SPLIT":        {},
+	"MERGED":       {},
 	"FAILED":       {"IN_PROGRESS"},
 	"CANCELLED":    {},
 	"APPROVED":     {},
 	"REJECTED":     {"PENDING"},
 	"PENDING":      {"APPROVED", "REJECTED"},
+	"INITIATED":    {"IN_TRANSIT", "CANCELLED"},
+	"IN_TRANSIT":   {"COMPLETED", "CANCELLED"},
 }
 
+// Certification status transition rules. Certifications do not use
+// validStatusTransitions or ledger-stored transition rules.
+var certificationStatusTransitions = map[string][]string{
+	"PENDING":    {"APPROVED", "REJECTED", "REVOKED"},
+	"REJECTED":   {"PENDING"},
+	"APPROVED":   {"SUSPENDED", "REVOKED", "SUPERSEDED", "EXPIRED"},
+	"SUSPENDED":  {"APPROVED", "REVOKED"},
+	"REVOKED":    {},
+	"SUPERSEDED": {},
+	"EXPIRED":    {"SUPERSEDED"},
+}
+
+// Regulatory record status transition rules. Regulatory records do not use
+// validStatusTransitions or ledger-stored transition rules. REJECTED and
+// NEEDS_INFO records return to PENDING when resubmitted. APPROVED records
+// past their expiry date move to EXPIRED only through
+// CheckAndExpireRegulatoryRecords.
+var regulatoryStatusTransitions = map[string][]string{
+	"PENDING":    {"APPROVED", "REJECTED", "NEEDS_INFO"},
+	"REJECTED":   {"PENDING"},
+	"NEEDS_INFO": {"PENDING"},
+	"APPROVED":   {"WITHDRAWN", "EXPIRED"},
+	"WITHDRAWN":  {},
+	"EXPIRED":    {},
+}
+
+// Certification transitions that record extra detail and so can only be made
+// through their dedicated function, not UpdateCertificationStatus
+var certificationTransitionFunctions = map[string]string{
+	"APPROVED->SUSPENDED":  "SuspendCertification",
+	"SUSPENDED->APPROVED":  "ReinstateCertification",
+	"PENDING->REVOKED":     "RevokeCertification",
+	"APPROVED->REVOKED":    "RevokeCertification",
+	"SUSPENDED->REVOKED":   "RevokeCertification",
+	"APPROVED->SUPERSEDED": "RenewCertification",
+	"EXPIRED->SUPERSEDED":  "RenewCertification",
+}
+
+// Audit flags a regulator can raise on a regulatory record
+var regulatoryAuditFlags = []string{
+	"SAMPLING_REQUIRED",
+	"DOCUMENT_MISSING",
+	"REPEAT_OFFENDER",
+	"LABELLING_ISSUE",
+	"TEMPERATURE_EXCURSION",
+	"FOLLOW_UP_INSPECTION",
+}
+
+// Condition ratings a receiver can give a transport's goods at receipt
+var transportConditionRatings = []string{"GOOD", "DAMAGED", "SPOILED"}
+
+// Kinds of target an inspection can be scheduled for or a violation notice issued against
+var inspectionTargetTypes = []string{"BATCH", "FARM", "FACILITY"}
+
+// Severities of a violation notice; open CRITICAL notices block certification
+// of their target
+var violationSeverities = []string{"MINOR", "MAJOR", "CRITICAL"}
+
+// Inspection outcomes and the status of the regulatory record CompleteInspection
+// records for each
+var inspectionOutcomeStatuses = map[string]string{
+	"PASS":        "APPROVED",
+	"CONDITIONAL": "NEEDS_INFO",
+	"FAIL":        "REJECTED",
+}
+
+// Statuses a batch can be in
+var batchStatuses = []string{"CREATED", "IN_PROGRESS", "COMPLETED", "FAILED", "CANCELLED", "PROCESSED", "ON_HOLD", "SPLIT", "MERGED"}
+
+// Batch statuses a batch can be split or merged from, least advanced first
+var divisibleBatchStatuses = []string{"CREATED", "IN_PROGRESS", "COMPLETED"}
+
+// Processing stages in the order they normally happen
+var processingStageOrder = []string{"SLAUGHTER", "CUTTING", "PACKAGING", "FREEZING"}
+
 // ============================================================================
 // DATA MODELS
 // ============================================================================
 
 // ProductAsset represents a product type
 type ProductAsset struct {
-	DocType   string `json:"docType"`
-	ProductID string `json:"product_id"`
-	Name      string `json:"name"`
-	Desc      string `json:"description"`
-	IsActive  bool   `json:"is_active"`
-	CreatedAt string `json:"created_at"`
+	DocType             string `json:"docType"`
+	ProductID           string `json:"product_id"`
+	Name                string `json:"name"`
+	Desc                string `json:"description"`
+	IsActive            bool   `json:"is_active"`
+	ProcessingClearance string `json:"processing_clearance_type"`
+	CompletionClearance string `json:"completion_clearance_type"`
+	// ExpectedProcessingWindow is an ISO 8601 duration such as "P3D"; it
+	// gives the expected end of batches created without an expected end date
+	ExpectedProcessingWindow string `json:"expected_processing_window"`
+	// RequiredCertTypes are required of this product's batches on top of
+	// the system-wide SystemConfigAsset.RequiredCertTypes
+	RequiredCertTypes  []string `json:"required_certification_types"`
+	DeactivationReason string   `json:"deactivation_reason"`
+	DeactivatedAt      string   `json:"deactivated_at"`
+	CreatedAt          string   `json:"created_at"`
 }
 
 // BatchAsset represents a production batch
 type BatchAsset struct {
-	DocType           string `json:"docType"`
-	BatchID           string `json:"batch_id"`
-	ProductID         string `json:"product_id"`
-	FarmerID          string `json:"farmer_id"`
-	BatchNumber       string `json:"batch_number"`
-	Status            string `json:"status"`
-	Quantity          int    `json:"quantity"`
-	StartDate         string `json:"start_date"`
-	ExpectedEndDate   string `json:"expected_end_date"`
-	ActualEndDate     string `json:"actual_end_date"`
-	Location          string `json:"location"`
-	QRCode            string `json:"qr_code"`
-	Notes             string `json:"notes"`
-	CreatedAt         string `json:"created_at"`
-	UpdatedAt         string `json:"updated_at"`
+	DocType          string `json:"docType"`
+	BatchID          string `json:"batch_id"`
+	ProductID        string `json:"product_id"`
+	FarmerID         string `json:"farmer_id"`
+	BatchNumber      string `json:"batch_number"`
+	Status           string `json:"status"`
+	Quantity         int    `json:"quantity"`
+	StartDate        string `json:"start_date"`
+	ExpectedEndDate  string `json:"expected_end_date"`
+	ActualEndDate    string `json:"actual_end_date"`
+	Location         string `json:"location"`
+	QRCode           string `json:"qr_code"`
+	Notes            string `json:"notes"`
+	HoldReason       string `json:"hold_reason"`
+	StatusBeforeHold string `json:"status_before_hold"`
+	// CompletionOverride is the Regulator's reason for completing the batch
+	// without its product's completion clearance
+	CompletionOverride   string `json:"completion_override"`
+	CompletionOverrideBy string `json:"completion_override_by"`
+	// RequiresRegulatoryApproval gates shipment: no transport manifest is
+	// accepted until the batch holds an approved regulatory record
+	RequiresRegulatoryApproval bool     `json:"requires_regulatory_approval"`
+	ParentBatchIDs             []string `json:"parent_batch_ids"`
+	CreatedAt                  string   `json:"created_at"`
+	UpdatedAt                  string   `json:"updated_at"`
+}
+
+// BatchSplitPart describes one child batch of a SplitBatch
+type BatchSplitPart struct {
+	BatchID     string `json:"batch_id"`
+	BatchNumber string `json:"batch_number"`
+	Quantity    int    `json:"quantity"`
+	QRCode      string `json:"qr_code"`
+}
+
+// CertificationTransfer is a certification type MergeBatches carried to the
+// target batch as a derived certification
+type CertificationTransfer struct {
+	CertType               string   `json:"cert_type"`
+	CertificationID        string   `json:"certification_id"`
+	SourceCertificationIDs []string `json:"source_certification_ids"`
+}
+
+// CertificationTransferConflict is a certification type MergeBatches could
+// not carry to the target because only some sources hold it
+type CertificationTransferConflict struct {
+	CertType    string   `json:"cert_type"`
+	HeldBy      []string `json:"held_by"`
+	MissingFrom []string `json:"missing_from"`
+}
+
+// MergeCertificationTransfers is the Metadata of the CERT_TRANSFERRED
+// lifecycle event MergeBatches records on the target batch
+type MergeCertificationTransfers struct {
+	SourceBatchIDs []string                        `json:"source_batch_ids"`
+	Transfers      []CertificationTransfer         `json:"transfers"`
+	Conflicts      []CertificationTransferConflict `json:"conflicts"`
 }
 
 // LifecycleEventAsset represents production events (append-only)
@@ -76,21 +342,84 @@ type LifecycleEventAsset struct {
 	CreatedAt        string `json:"created_at"`
 }
 
+// LifecycleEventMetadataValue is a single key looked up in a lifecycle
+// event's metadata. Value is empty when Found is false.
+type LifecycleEventMetadataValue struct {
+	EventID string `json:"event_id"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Found   bool   `json:"found"`
+}
+
+// InspectionScheduleAsset represents a scheduled regulatory inspection of a
+// batch, farm or facility. BatchID is set for batch inspections. Schedules
+// created before other targets were supported have only BatchID; they are
+// read as batch inspections. Status moves from SCHEDULED to COMPLETED,
+// CANCELLED or NO_SHOW.
+type InspectionScheduleAsset struct {
+	DocType          string `json:"docType"`
+	ScheduleID       string `json:"schedule_id"`
+	TargetType       string `json:"target_type"` // BATCH, FARM or FACILITY
+	TargetID         string `json:"target_id"`
+	BatchID          string `json:"batch_id"`
+	DueDate          string `json:"due_date"`
+	AssignedTo       string `json:"assigned_to"`
+	Status           string `json:"status"`
+	Outcome          string `json:"outcome"` // PASS, CONDITIONAL or FAIL once COMPLETED
+	Findings         string `json:"findings"`
+	LifecycleEventID string `json:"lifecycle_event_id"`
+	RegulatoryID     string `json:"regulatory_id"`
+	BatchOnHold      bool   `json:"batch_on_hold"`
+	CompletedAt      string `json:"completed_at"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
+}
+
+// ViolationNoticeAsset records a non-compliance finding against a batch, farm
+// or facility, the corrective action required by Deadline and any penalty.
+// Status moves from OPEN to RESOLVED or ESCALATED; an ESCALATED notice can
+// still be RESOLVED.
+type ViolationNoticeAsset struct {
+	DocType            string  `json:"docType"`
+	NoticeID           string  `json:"notice_id"`
+	TargetType         string  `json:"target_type"` // BATCH, FARM or FACILITY
+	TargetID           string  `json:"target_id"`
+	ViolationCode      string  `json:"violation_code"`
+	Severity           string  `json:"severity"` // MINOR, MAJOR or CRITICAL
+	Description        string  `json:"description"`
+	CorrectiveAction   string  `json:"corrective_action_required"`
+	Deadline           string  `json:"deadline"`
+	PenaltyAmount      float64 `json:"penalty_amount"`
+	Status             string  `json:"status"`
+	IssuedBy           string  `json:"issued_by"`
+	EscalationReason   string  `json:"escalation_reason"`
+	EscalatedAt        string  `json:"escalated_at"`
+	ResolutionEvidence string  `json:"resolution_evidence"`
+	ResolvedBy         string  `json:"resolved_by"`
+	ResolvedAt         string  `json:"resolved_at"`
+	CreatedAt          string  `json:"created_at"`
+	UpdatedAt          string  `json:"updated_at"`
+}
+
 // TransportAsset represents transport manifest
 type TransportAsset struct {
 	DocType               string `json:"docType"`
 	TransportID           string `json:"transport_id"`
 	BatchID               string `json:"batch_id"`
+	Quantity              int    `json:"quantity"`
 	FromPartyID           string `json:"from_party_id"`
 	ToPartyID             string `json:"to_party_id"`
 	VehicleID             string `json:"vehicle_id"`
 	DriverName            string `json:"driver_name"`
 	DepartureTime         string `json:"departure_time"`
+	ExpectedArrivalTime   string `json:"expected_arrival_time"`
 	ArrivalTime           string `json:"arrival_time"`
 	OriginLocation        string `json:"origin_location"`
 	DestinationLocation   string `json:"destination_location"`
 	TemperatureMonitored  bool   `json:"temperature_monitored"`
 	Status                string `json:"status"`
+	ConditionRating       string `json:"condition_rating"`
+	ConditionNotes        string `json:"condition_notes"`
 	Notes                 string `json:"notes"`
 	CreatedAt             string `json:"created_at"`
 	UpdatedAt             string `json:"updated_at"`
@@ -98,61 +427,822 @@ type TransportAsset struct {
 
 // TemperatureLogAsset represents temperature records
 type TemperatureLogAsset struct {
-	DocType      string  `json:"docType"`
-	LogID        string  `json:"log_id"`
-	TransportID  string  `json:"transport_id"`
-	Temperature  float64 `json:"temperature"`
-	Timestamp    string  `json:"timestamp"`
-	Location     string  `json:"location"`
-	IsViolation  bool    `json:"is_violation"`
-	CreatedAt    string  `json:"created_at"`
+	DocType             string  `json:"docType"`
+	LogID               string  `json:"log_id"`
+	TransportID         string  `json:"transport_id"`
+	StorageAssignmentID string  `json:"storage_assignment_id"`
+	Temperature         float64 `json:"temperature"`
+	Timestamp           string  `json:"timestamp"`
+	Location            string  `json:"location"`
+	IsViolation         bool    `json:"is_violation"`
+	CreatedAt           string  `json:"created_at"`
+}
+
+// TemperatureReading is a single reading picked out of a set of temperature logs
+type TemperatureReading struct {
+	LogID       string  `json:"log_id"`
+	Temperature float64 `json:"temperature"`
+	Timestamp   string  `json:"timestamp"`
+	Location    string  `json:"location"`
+}
+
+// TemperatureExtremes is the coldest and hottest reading of a transport.
+// NoData is set, and Min and Max are nil, when there are no readings.
+type TemperatureExtremes struct {
+	TransportID  string              `json:"transport_id"`
+	NoData       bool                `json:"no_data"`
+	ReadingCount int                 `json:"reading_count"`
+	Min          *TemperatureReading `json:"min,omitempty"`
+	Max          *TemperatureReading `json:"max,omitempty"`
 }
 
 // ProcessingAsset represents processing facility records
 type ProcessingAsset struct {
-	DocType      string  `json:"docType"`
-	ProcessingID string  `json:"processing_id"`
-	BatchID      string  `json:"batch_id"`
-	ProcessDate  string  `json:"processing_date"`
-	FacilityName string  `json:"facility_name"`
-	SlaughterCnt int     `json:"slaughter_count"`
-	YieldKg      float64 `json:"yield_kg"`
-	QualityScore float64 `json:"quality_score"`
-	Notes        string  `json:"notes"`
-	CreatedAt    string  `json:"created_at"`
-	UpdatedAt    string  `json:"updated_at"`
+	DocType           string                      `json:"docType"`
+	ProcessingID      string                      `json:"processing_id"`
+	BatchID           string                      `json:"batch_id"`
+	Stage             string                      `json:"stage"`
+	ProcessDate       string                      `json:"processing_date"`
+	StartTime         string                      `json:"process_start_time"`
+	EndTime           string                      `json:"process_end_time"`
+	DurationMins      int                         `json:"duration_minutes"`
+	FacilityID        string                      `json:"facility_id"`
+	FacilityName      string                      `json:"facility_name"`
+	SlaughterCnt      int                         `json:"slaughter_count"`
+	YieldKg           float64                     `json:"yield_kg"`
+	QualityScore      float64                     `json:"quality_score"`
+	QualityGrade      string                      `json:"quality_grade"`
+	OverrideID        string                      `json:"clearance_override_id"`
+	ReadinessOverride bool                        `json:"readiness_override"`
+	EquipmentIDs      []string                    `json:"equipment_ids"`
+	SanitationOverdue []string                    `json:"sanitation_overdue_equipment"`
+	YieldImplausible  bool                        `json:"yield_implausible"`
+	WasteEntries      []WasteEntry                `json:"waste_entries"`
+	Amendments        []*ProcessingAmendmentAsset `json:"amendments,omitempty"`
+	Notes             string                      `json:"notes"`
+	CreatedAt         string                      `json:"created_at"`
+	UpdatedAt         string                      `json:"updated_at"`
 }
 
 // CertificationAsset represents certifications
 type CertificationAsset struct {
-	DocType         string `json:"docType"`
+	DocType                 string `json:"docType"`
+	CertificationID         string `json:"certification_id"`
+	ProcessingID            string `json:"processing_id"`
+	BatchID                 string `json:"batch_id"`
+	CertType                string `json:"cert_type"`
+	Status                  string `json:"status"`
+	IssuedDate              string `json:"issued_date"`
+	ExpiryDate              string `json:"expiry_date"`
+	IssuerID                string `json:"issuer_id"`
+	IssuerMSP               string `json:"issuer_msp"`
+	IssuerIdentity          string `json:"issuer_identity"`
+	IssuedOnBehalfBy        string `json:"issued_on_behalf_by"`
+	ParallelTo              string `json:"parallel_to_certification_id"`
+	QualityScoreOverridden  bool   `json:"quality_score_overridden"`
+	Notes                   string `json:"notes"`
+	DocumentSHA256          string `json:"document_sha256"`
+	DocumentURI             string `json:"document_uri"`
+	PreviousCertificationID string `json:"previous_certification_id"`
+	SupersededBy            string `json:"superseded_by"`
+	RevokedReason           string `json:"revoked_reason"`
+	RevokedBy               string `json:"revoked_by"`
+	RevokedAt               string `json:"revoked_at"`
+	SuspendedReason         string `json:"suspended_reason"`
+	SuspendedBy             string `json:"suspended_by"`
+	SuspendedAt             string `json:"suspended_at"`
+	ReinstatedNotes         string `json:"reinstated_notes"`
+	ReinstatedBy            string `json:"reinstated_by"`
+	ReinstatedAt            string `json:"reinstated_at"`
+	// SourceCertificationIDs is set on a certification derived from its
+	// batch's parent or source batches by SplitBatch or MergeBatches
+	SourceCertificationIDs []string `json:"source_certification_ids,omitempty"`
+	// StatusFromSource is the source certification whose revocation,
+	// suspension, expiry or renewal this derived certification followed
+	StatusFromSource  string                      `json:"status_from_source,omitempty"`
+	StatusHistory     []CertificationStatusChange `json:"status_history"`
+	VerificationCount int                         `json:"verification_count"`
+	LastVerifiedAt    string                      `json:"last_verified_at,omitempty"`
+	CreatedAt         string                      `json:"created_at"`
+	UpdatedAt         string                      `json:"updated_at"`
+}
+
+// CertificationStatusChange is one entry in a certification's status history.
+// OldStatus is empty for the status the certification was created with.
+type CertificationStatusChange struct {
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+	Actor     string `json:"actor"`
+	Reason    string `json:"reason"`
+	ChangedAt string `json:"changed_at"`
+	TxID      string `json:"tx_id"`
+}
+
+// CertificationIssueOptions are IssueCertification's optional settings,
+// passed as one JSON object so that callers name only the ones they use
+type CertificationIssueOptions struct {
+	Notes                string `json:"notes"`
+	DocumentSHA256       string `json:"document_sha256"`
+	DocumentURI          string `json:"document_uri"`
+	ReplaceExisting      bool   `json:"replace_existing"`
+	ForceParallel        bool   `json:"force_parallel"`
+	OverrideQualityScore bool   `json:"override_quality_score"`
+}
+
+// CertificationVerificationCounter counts consumer verifications of a
+// certification. It is kept under its own key, "cert_verification~<id>", so
+// frequent verification writes never touch the certification document.
+type CertificationVerificationCounter struct {
+	DocType           string         `json:"docType"`
+	CertificationID   string         `json:"certification_id"`
+	VerificationCount int            `json:"verification_count"`
+	ChannelCounts     map[string]int `json:"channel_counts"`
+	LastVerifiedAt    string         `json:"last_verified_at"`
+	LastChannel       string         `json:"last_channel"`
+}
+
+// CertificateNumberCounter is the last number issued in a certificate number
+// series, "<prefix>-<type code>-<year>". It is stored under
+// "cert_number~<series>".
+type CertificateNumberCounter struct {
+	DocType    string `json:"docType"`
+	Series     string `json:"series"`
+	LastNumber int    `json:"last_number"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// ExpiredCertification identifies a certification moved to EXPIRED by a sweep
+type ExpiredCertification struct {
 	CertificationID string `json:"certification_id"`
-	ProcessingID    string `json:"processing_id"`
 	CertType        string `json:"cert_type"`
-	Status          string `json:"status"`
+	ProcessingID    string `json:"processing_id,omitempty"`
+	BatchID         string `json:"batch_id"`
 	IssuedDate      string `json:"issued_date"`
 	ExpiryDate      string `json:"expiry_date"`
-	IssuerID        string `json:"issuer_id"`
-	Notes           string `json:"notes"`
-	CreatedAt       string `json:"created_at"`
-	UpdatedAt       string `json:"updated_at"`
 }
 
-// RegulatoryAsset represents regulatory approvals
+// ExpiredRegulatoryRecord identifies a regulatory record moved to EXPIRED
+type ExpiredRegulatoryRecord struct {
+	RegulatoryID string `json:"regulatory_id"`
+	BatchID      string `json:"batch_id"`
+	RecordType   string `json:"record_type"`
+	ExpiryDate   string `json:"expiry_date"`
+}
+
+// RegulatoryExpirySweep is the result of one CheckAndExpireRegulatoryRecords call
+type RegulatoryExpirySweep struct {
+	ExpiredCount int                        `json:"expired_count"`
+	Records      []*ExpiredRegulatoryRecord `json:"records"`
+	HasMore      bool                       `json:"has_more"`
+}
+
+// CertificationExpirySweep is the result of one certification expiry sweep
+type CertificationExpirySweep struct {
+	ExpiredCount   int                     `json:"expired_count"`
+	Certifications []*ExpiredCertification `json:"certifications"`
+	HasMore        bool                    `json:"has_more"`
+}
+
+// RecallAsset represents a product recall raised against a batch
+type RecallAsset struct {
+	DocType    string `json:"docType"`
+	RecallID   string `json:"recall_id"`
+	BatchID    string `json:"batch_id"`
+	ProductID  string `json:"product_id"`
+	Reason     string `json:"reason"`
+	Status     string `json:"status"`
+	Resolution string `json:"resolution"`
+	ClosedAt   string `json:"closed_at"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// CertificationDocumentCheck is the result of checking a certificate
+// document's hash against the one anchored on the ledger
+type CertificationDocumentCheck struct {
+	CertificationID string                 `json:"certification_id"`
+	Anchored        bool                   `json:"anchored"`
+	Match           bool                   `json:"match"`
+	Validity        *CertificationValidity `json:"validity"`
+}
+
+// certificationIssuer is who a new certification is attributed to
+type certificationIssuer struct {
+	IssuerID         string
+	IssuerMSP        string
+	IssuerIdentity   string
+	IssuedOnBehalfBy string
+}
+
+// certifierAccreditation is the caller's accreditation as resolved by
+// authorizeCertificationIssuer. A nil CertTypes covers every type.
+type certifierAccreditation struct {
+	MSPID     string
+	CertTypes []string
+}
+
+// actingOfficer is the individual a regulatory or certification action is
+// attributed to. OnBehalfBy is set when an admin acted for them.
+type actingOfficer struct {
+	OfficerID       string
+	OfficerMSP      string
+	OfficerIdentity string
+	OnBehalfBy      string
+}
+
+// ExportPermitDetails are the destination fields of an EXPORT_PERMIT record.
+// RequiredCertTypes are the destination's requirements when the permit was
+// created, kept so later changes to them do not rewrite the permit.
+type ExportPermitDetails struct {
+	DestinationCountry string   `json:"destination_country"` // ISO 3166-1 alpha-2
+	ImporterName       string   `json:"importer_name"`
+	ImporterID         string   `json:"importer_id"`
+	PortOfExit         string   `json:"port_of_exit"`
+	RequiredCertTypes  []string `json:"required_certification_types"`
+}
+
+// RegulatoryAsset represents regulatory approvals. RegulatorMSP and
+// RegulatorIdentity are the caller who created the record or made its latest
+// status decision, bound to RegulatorID; RecordedOnBehalfBy is the admin who
+// acted for that regulator, if any. AssignedTo is the reviewer whose work
+// queue holds the record, the creator until ReassignRegulatoryRecord.
 type RegulatoryAsset struct {
-	DocType         string `json:"docType"`
-	RegulatoryID    string `json:"regulatory_id"`
-	BatchID         string `json:"batch_id"`
-	RecordType      string `json:"record_type"`
-	Status          string `json:"status"`
-	IssuedDate      string `json:"issued_date"`
-	ExpiryDate      string `json:"expiry_date"`
-	RegulatorID     string `json:"regulator_id"`
-	Details         string `json:"details"`
-	RejectionReason string `json:"rejection_reason"`
-	AuditFlags      string `json:"audit_flags"`
-	CreatedAt       string `json:"created_at"`
-	UpdatedAt       string `json:"updated_at"`
+	DocType            string               `json:"docType"`
+	RegulatoryID       string               `json:"regulatory_id"`
+	BatchID            string               `json:"batch_id"`
+	RecordType         string               `json:"record_type"`
+	Status             string               `json:"status"`
+	IssuedDate         string               `json:"issued_date"`
+	ExpiryDate         string               `json:"expiry_date"`
+	RegulatorID        string               `json:"regulator_id"`
+	RegulatorMSP       string               `json:"regulator_msp"`
+	RegulatorIdentity  string               `json:"regulator_identity"`
+	RecordedOnBehalfBy string               `json:"recorded_on_behalf_by"`
+	AssignedTo         string               `json:"assigned_to"`
+	Details            string               `json:"details"`
+	ExportPermit       *ExportPermitDetails `json:"export_permit,omitempty"`
+	RejectionReason    string               `json:"rejection_reason"`
+	Resolution         string               `json:"resolution"`
+	PreviousID         string               `json:"previous_regulatory_id"`
+	SupersededBy       string               `json:"superseded_by"`
+	AuditFlags         AuditFlagList        `json:"audit_flags"`
+	AuditFlagHistory   []AuditFlagChange    `json:"audit_flag_history"`
+	DecisionHistory    []RegulatoryDecision `json:"decision_history"`
+	CreatedAt          string               `json:"created_at"`
+	UpdatedAt          string               `json:"updated_at"`
+}
+
+// RegulatoryDecision is one entry in a regulatory record's decision history:
+// a status change, or a DETAILS_EDIT that keeps the status and carries the
+// SHA-256 of the details it replaced in PreviousDetailsSHA256, so the history
+// does not hold a full copy of every revision
+type RegulatoryDecision struct {
+	Action                string `json:"action"`
+	OldStatus             string `json:"old_status"`
+	NewStatus             string `json:"new_status"`
+	Actor                 string `json:"actor"`
+	ActorMSP              string `json:"actor_msp"`
+	RegulatorID           string `json:"regulator_id,omitempty"`
+	OnBehalfBy            string `json:"on_behalf_by,omitempty"`
+	Reason                string `json:"reason"`
+	PreviousDetailsSHA256 string `json:"previous_details_sha256,omitempty"`
+	PreviousAssignee      string `json:"previous_assignee,omitempty"`
+	AssignedTo            string `json:"assigned_to,omitempty"`
+	ChangedAt             string `json:"changed_at"`
+	TxID                  string `json:"tx_id"`
+}
+
+// AuditFlagList is a regulatory record's audit flags. Records written before
+// flags were structured hold a single comma-separated string instead, which
+// is still accepted on read.
+type AuditFlagList []string
+
+// UnmarshalJSON reads either a list of flags or a legacy flag string
+func (l *AuditFlagList) UnmarshalJSON(data []byte) error {
+	var flags []string
+	if err := json.Unmarshal(data, &flags); err == nil {
+		*l = flags
+		return nil
+	}
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("audit_flags must be a list or a string: %v", err)
+	}
+	*l = splitAuditFlags(legacy)
+	return nil
+}
+
+// splitAuditFlags splits a comma-separated flag string, dropping blanks
+func splitAuditFlags(value string) AuditFlagList {
+	var flags AuditFlagList
+	for _, flag := range strings.Split(value, ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// AuditFlagChange records an audit flag being added to or removed from a
+// regulatory record
+type AuditFlagChange struct {
+	Flag      string `json:"flag"`
+	Action    string `json:"action"` // ADDED or REMOVED
+	Actor     string `json:"actor"`
+	Reason    string `json:"reason"`
+	ChangedAt string `json:"changed_at"`
+	TxID      string `json:"tx_id"`
+}
+
+// EquipmentAsset represents a processing line or machine at a facility
+type EquipmentAsset struct {
+	DocType        string `json:"docType"`
+	EquipmentID    string `json:"equipment_id"`
+	FacilityID     string `json:"facility_id"`
+	EquipmentType  string `json:"equipment_type"`
+	LastSanitation string `json:"last_sanitation_date"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+// ClearanceOverrideAsset is a single-use emergency override, issued by the
+// Regulator, that lets a batch be processed without its required clearance
+type ClearanceOverrideAsset struct {
+	DocType    string `json:"docType"`
+	OverrideID string `json:"override_id"`
+	BatchID    string `json:"batch_id"`
+	Reason     string `json:"reason"`
+	IssuedBy   string `json:"issued_by"`
+	UsedBy     string `json:"used_by_processing_id"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// FacilityAsset represents a licensed processing facility
+type FacilityAsset struct {
+	DocType       string `json:"docType"`
+	FacilityID    string `json:"facility_id"`
+	Name          string `json:"name"`
+	Address       string `json:"address"`
+	LicenseNumber string `json:"license_number"`
+	LicenseExpiry string `json:"license_expiry"`
+	IsActive      bool   `json:"is_active"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// ProcessingCorrection holds an amendment's corrected values. Only the fields
+// named in Fields are corrected; the others are left as recorded.
+type ProcessingCorrection struct {
+	Fields         []string `json:"fields"`
+	ProcessDate    string   `json:"processing_date"`
+	SlaughterCount int      `json:"slaughter_count"`
+	YieldKg        float64  `json:"yield_kg"`
+	QualityScore   float64  `json:"quality_score"`
+	Notes          string   `json:"notes"`
+}
+
+// corrects reports whether the correction covers the named field
+func (c *ProcessingCorrection) corrects(field string) bool {
+	for _, f := range c.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessingAmendmentAsset is an immutable correction to a processing record.
+// The original record is never rewritten; approved amendments are applied on
+// top of it when reporting.
+type ProcessingAmendmentAsset struct {
+	DocType        string               `json:"docType"`
+	AmendmentID    string               `json:"amendment_id"`
+	ProcessingID   string               `json:"processing_id"`
+	Corrections    ProcessingCorrection `json:"corrections"`
+	QualityGrade   string               `json:"quality_grade,omitempty"`
+	Reason         string               `json:"reason"`
+	RequiresCoSign bool                 `json:"requires_cosign"`
+	Status         string               `json:"status"`
+	ProposedBy     string               `json:"proposed_by"`
+	ReviewedBy     string               `json:"reviewed_by"`
+	ReviewComment  string               `json:"review_comment"`
+	CreatedAt      string               `json:"created_at"`
+	UpdatedAt      string               `json:"updated_at"`
+}
+
+// HACCPCheckpointAsset is an immutable critical control point reading taken during processing
+type HACCPCheckpointAsset struct {
+	DocType          string  `json:"docType"`
+	CheckpointID     string  `json:"checkpoint_id"`
+	ProcessingID     string  `json:"processing_id"`
+	CCPName          string  `json:"ccp_name"`
+	MeasuredValue    float64 `json:"measured_value"`
+	Unit             string  `json:"unit"`
+	WithinLimit      bool    `json:"within_limit"`
+	CorrectiveAction string  `json:"corrective_action"`
+	RecordedBy       string  `json:"recorded_by"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+// LabTestAsset is a laboratory test result on processed product
+type LabTestAsset struct {
+	DocType      string  `json:"docType"`
+	TestID       string  `json:"test_id"`
+	ProcessingID string  `json:"processing_id"`
+	BatchID      string  `json:"batch_id"`
+	TestType     string  `json:"test_type"`
+	ResultValue  float64 `json:"result_value"`
+	Unit         string  `json:"unit"`
+	Passed       bool    `json:"passed"`
+	LabID        string  `json:"lab_id"`
+	TestedDate   string  `json:"tested_date"`
+	ReportHash   string  `json:"report_hash"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// RegulatorySampleAsset is a sampling or residue test result attached to a
+// regulatory record as evidence for its decision
+type RegulatorySampleAsset struct {
+	DocType       string `json:"docType"`
+	SampleID      string `json:"sample_id"`
+	RegulatoryID  string `json:"regulatory_id"`
+	BatchID       string `json:"batch_id"`
+	SampleType    string `json:"sample_type"`
+	CollectedAt   string `json:"collected_at"`
+	LabID         string `json:"lab_id"`
+	ResultSummary string `json:"result_summary"`
+	Passed        bool   `json:"passed"`
+	ReportHash    string `json:"report_hash"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// OutputLotAsset represents a retail lot produced by a processing record
+type OutputLotAsset struct {
+	DocType      string  `json:"docType"`
+	OutputID     string  `json:"output_id"`
+	ProcessingID string  `json:"processing_id"`
+	BatchID      string  `json:"batch_id"`
+	LotNumber    string  `json:"lot_number"`
+	ProductID    string  `json:"product_id"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+	PackDate     string  `json:"pack_date"`
+	BestBefore   string  `json:"best_before"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// ColdStorageAssignmentAsset records a processing record's stay in a cold room
+type ColdStorageAssignmentAsset struct {
+	DocType           string `json:"docType"`
+	AssignmentID      string `json:"assignment_id"`
+	ProcessingID      string `json:"processing_id"`
+	BatchID           string `json:"batch_id"`
+	StorageLocationID string `json:"storage_location_id"`
+	EnteredAt         string `json:"entered_at"`
+	ExitedAt          string `json:"exited_at"`
+	Status            string `json:"status"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+}
+
+// FarmerProfileAsset holds the public display name of a farmer
+type FarmerProfileAsset struct {
+	DocType     string `json:"docType"`
+	FarmerID    string `json:"farmer_id"`
+	DisplayName string `json:"display_name"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// FarmerVolume is one farmer's entry in the GetTopFarmersByVolume leaderboard
+type FarmerVolume struct {
+	FarmerID      string `json:"farmer_id"`
+	DisplayName   string `json:"display_name"`
+	BatchCount    int    `json:"batch_count"`
+	TotalQuantity int    `json:"total_quantity"`
+}
+
+// BatchPassport is the compact, public view of a batch returned to mobile QR
+// scans. It carries no internal IDs or notes.
+type BatchPassport struct {
+	ProductName         string `json:"product_name"`
+	Origin              string `json:"origin"`
+	Farmer              string `json:"farmer"`
+	ProductionStatus    string `json:"production_status"`
+	ColdChainCompliant  bool   `json:"cold_chain_compliant"`
+	CertificationsValid bool   `json:"certifications_valid"`
+}
+
+// BatchAlert is one warning about a batch. Code is stable for programmatic
+// use; Message is for display.
+type BatchAlert struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// LotTrace is the farm-to-fork trace of a retail lot
+type LotTrace struct {
+	Lot             *OutputLotAsset        `json:"lot"`
+	Processing      *ProcessingAsset       `json:"processing"`
+	Batch           *BatchAsset            `json:"batch"`
+	Product         *ProductAsset          `json:"product"`
+	LifecycleEvents []*LifecycleEventAsset `json:"lifecycle_events"`
+	Transports      []*TransportAsset      `json:"transports"`
+	Certifications  []*CertificationAsset  `json:"certifications"`
+}
+
+// BatchTrace is the full trace of a batch. For callers outside the
+// Regulator and Admin MSPs its certifications are redacted (see
+// redactCertification).
+type BatchTrace struct {
+	Batch             *BatchAsset            `json:"batch"`
+	Product           *ProductAsset          `json:"product"`
+	LifecycleEvents   []*LifecycleEventAsset `json:"lifecycle_events"`
+	Transports        []*TransportAsset      `json:"transports"`
+	ProcessingRecords []*ProcessingAsset     `json:"processing_records"`
+	Certifications    []*CertificationAsset  `json:"certifications"`
+}
+
+// CertificationChainLink is a certification resolved to the processing run,
+// batch and product it covers. Batch-scoped certifications have no
+// processing run. ProcessingMissing flags a processing-scoped certification
+// whose processing record could not be resolved.
+type CertificationChainLink struct {
+	Certification     *CertificationAsset `json:"certification"`
+	Processing        *ProcessingAsset    `json:"processing"`
+	Batch             *BatchSummary       `json:"batch"`
+	ProductName       string              `json:"product_name"`
+	ProcessingMissing bool                `json:"processing_missing"`
+}
+
+// CertificationRequirementVerdict reports one required certification type of
+// a batch. CertificationID is the satisfying certification, or when the
+// requirement fails the most recent certification of the type, if any.
+type CertificationRequirementVerdict struct {
+	CertType        string   `json:"cert_type"`
+	Satisfied       bool     `json:"satisfied"`
+	CertificationID string   `json:"certification_id"`
+	Derived         bool     `json:"derived"`
+	Failure         string   `json:"failure"`
+	Reasons         []string `json:"reasons"`
+}
+
+// BatchCertificationVerdict is the result of VerifyBatchCertifications
+type BatchCertificationVerdict struct {
+	BatchID      string                             `json:"batch_id"`
+	ProductID    string                             `json:"product_id"`
+	Certified    bool                               `json:"certified"`
+	Requirements []*CertificationRequirementVerdict `json:"requirements"`
+}
+
+// CertificationValidity is the result of a certification validity check.
+// Failures holds one code per failed check, alongside its reason: REVOKED,
+// SUPERSEDED, SUSPENDED, EXPIRED, NOT_APPROVED, NOT_YET_VALID or
+// INVALID_DATE.
+type CertificationValidity struct {
+	CertificationID string   `json:"certification_id"`
+	Valid           bool     `json:"valid"`
+	Reasons         []string `json:"reasons"`
+	Failures        []string `json:"failures"`
+}
+
+// fail records a failed validity check with its code and reason
+func (v *CertificationValidity) fail(code string, reason string) {
+	v.Failures = append(v.Failures, code)
+	v.Reasons = append(v.Reasons, reason)
+}
+
+// BatchPage is one page of a paginated batch query
+type BatchPage struct {
+	Batches  []*BatchAsset `json:"batches"`
+	Bookmark string        `json:"bookmark"`
+	Count    int           `json:"count"`
+}
+
+// RegulatoryRecordPage is one page of a paginated regulatory record query
+type RegulatoryRecordPage struct {
+	Records  []*RegulatoryAsset `json:"records"`
+	Bookmark string             `json:"bookmark"`
+	Count    int                `json:"count"`
+}
+
+// TransportComplianceScore summarizes cold-chain compliance for one transport
+type TransportComplianceScore struct {
+	TransportID       string  `json:"transport_id"`
+	TotalReadings     int     `json:"total_readings"`
+	CompliantReadings int     `json:"compliant_readings"`
+	CompliancePercent float64 `json:"compliance_percent"`
+	NoData            bool    `json:"no_data"`
+}
+
+// FarmerColdChainScore aggregates cold-chain compliance over the
+// temperature-monitored transports of a farmer's batches
+type FarmerColdChainScore struct {
+	FarmerID            string  `json:"farmer_id"`
+	BatchCount          int     `json:"batch_count"`
+	MonitoredTransports int     `json:"monitored_transports"`
+	TotalReadings       int     `json:"total_readings"`
+	CompliantReadings   int     `json:"compliant_readings"`
+	ViolationCount      int     `json:"violation_count"`
+	CompliancePercent   float64 `json:"compliance_percent"`
+	NoData              bool    `json:"no_data"`
+}
+
+// BatchShippedQuantity summarizes how much of a batch is on transport manifests
+type BatchShippedQuantity struct {
+	BatchID           string `json:"batch_id"`
+	TotalQuantity     int    `json:"total_quantity"`
+	ShippedQuantity   int    `json:"shipped_quantity"`
+	RemainingQuantity int    `json:"remaining_quantity"`
+}
+
+// WasteEntry records waste and byproduct mass from a processing run
+type WasteEntry struct {
+	WasteKg        float64 `json:"waste_kg"`
+	ByproductKg    float64 `json:"byproduct_kg"`
+	DisposalMethod string  `json:"disposal_method"`
+	RecordedAt     string  `json:"recorded_at"`
+}
+
+// FacilityWasteSummary aggregates waste entries for a facility over a date range
+type FacilityWasteSummary struct {
+	FacilityName     string             `json:"facility_name"`
+	FromDate         string             `json:"from_date"`
+	ToDate           string             `json:"to_date"`
+	RecordCount      int                `json:"record_count"`
+	TotalWasteKg     float64            `json:"total_waste_kg"`
+	TotalByproductKg float64            `json:"total_byproduct_kg"`
+	ByDisposalMethod map[string]float64 `json:"by_disposal_method"`
+}
+
+// FacilityProcessingStats summarizes a facility's processing over a date range.
+// Flagged records are those with overdue equipment sanitation or a REJECT grade.
+type FacilityProcessingStats struct {
+	FacilityID          string  `json:"facility_id"`
+	FromDate            string  `json:"from_date"`
+	ToDate              string  `json:"to_date"`
+	RecordCount         int     `json:"record_count"`
+	TotalSlaughterCount int     `json:"total_slaughter_count"`
+	TotalYieldKg        float64 `json:"total_yield_kg"`
+	AverageQualityScore float64 `json:"average_quality_score"`
+	FlaggedRecordCount  int     `json:"flagged_record_count"`
+	FailedLabTestCount  int     `json:"failed_lab_test_count"`
+}
+
+// RegulatoryApprovalRate counts regulatory decisions made within a date range.
+// ApprovalRatePercent is zero when there were no decisions.
+type RegulatoryApprovalRate struct {
+	FromDate            string  `json:"from_date"`
+	ToDate              string  `json:"to_date"`
+	DecidedCount        int     `json:"decided_count"`
+	ApprovedCount       int     `json:"approved_count"`
+	RejectedCount       int     `json:"rejected_count"`
+	ApprovalRatePercent float64 `json:"approval_rate_percent"`
+}
+
+// BatchSellability reports whether a batch may be sold. Reason explains why
+// not, and StopSaleID names the stop-sale holding it when there is one.
+type BatchSellability struct {
+	BatchID    string `json:"batch_id"`
+	Sellable   bool   `json:"sellable"`
+	Reason     string `json:"reason,omitempty"`
+	StopSaleID string `json:"stop_sale_id,omitempty"`
+}
+
+// TransportDurationStats summarizes departure-to-arrival times of completed
+// transports on a route. NoData is set when the route has no usable samples.
+type TransportDurationStats struct {
+	OriginLocation      string  `json:"origin_location"`
+	DestinationLocation string  `json:"destination_location"`
+	NoData              bool    `json:"no_data"`
+	SampleCount         int     `json:"sample_count"`
+	MinDurationSeconds  int64   `json:"min_duration_seconds"`
+	MaxDurationSeconds  int64   `json:"max_duration_seconds"`
+	AvgDurationSeconds  float64 `json:"avg_duration_seconds"`
+}
+
+// ProcessingStageGroup holds the processing records of a single stage
+type ProcessingStageGroup struct {
+	Stage   string             `json:"stage"`
+	Records []*ProcessingAsset `json:"records"`
+}
+
+// ProcessingPipeline is a batch's processing records grouped by stage
+type ProcessingPipeline struct {
+	BatchID  string                  `json:"batch_id"`
+	Stages   []*ProcessingStageGroup `json:"stages"`
+	Warnings []string                `json:"warnings"`
+}
+
+// BatchProcessingAggregate totals a batch's processing records
+type BatchProcessingAggregate struct {
+	BatchID             string   `json:"batch_id"`
+	RecordCount         int      `json:"record_count"`
+	TotalSlaughterCount int      `json:"total_slaughter_count"`
+	TotalYieldKg        float64  `json:"total_yield_kg"`
+	AverageQualityScore float64  `json:"average_quality_score"`
+	TotalWasteKg        float64  `json:"total_waste_kg"`
+	TotalByproductKg    float64  `json:"total_byproduct_kg"`
+	ProcessingIDs       []string `json:"processing_ids"`
+}
+
+// ProductWithBatchSummary is a product with a rollup of its batches.
+// BatchesByStatus has an entry for every batch status, zero when none.
+type ProductWithBatchSummary struct {
+	Product         *ProductAsset  `json:"product"`
+	BatchCount      int            `json:"batch_count"`
+	BatchesByStatus map[string]int `json:"batches_by_status"`
+	TotalQuantity   int            `json:"total_quantity"`
+}
+
+// BatchSummary is a batch with its quantity, shipping and processing totals
+type BatchSummary struct {
+	Batch           *BatchAsset               `json:"batch"`
+	CurrentQuantity int                       `json:"current_quantity"`
+	Shipping        *BatchShippedQuantity     `json:"shipping"`
+	Processing      *BatchProcessingAggregate `json:"processing"`
+}
+
+// YieldTrendPoint is one processed batch's total yield in a product's yield trend
+type YieldTrendPoint struct {
+	BatchID      string  `json:"batch_id"`
+	BatchNumber  string  `json:"batch_number"`
+	StartDate    string  `json:"start_date"`
+	TotalYieldKg float64 `json:"total_yield_kg"`
+}
+
+// QualityGradeBand maps a minimum quality score to a grade
+type QualityGradeBand struct {
+	Grade    string  `json:"grade"`
+	MinScore float64 `json:"min_score"`
+}
+
+// SystemConfigAsset holds channel-wide configuration stored under SystemConfigKey
+type SystemConfigAsset struct {
+	DocType            string              `json:"docType"`
+	QualityGradeBands  []QualityGradeBand  `json:"quality_grade_bands"`
+	FeatureFlags       map[string]bool     `json:"feature_flags"`
+	Thresholds         map[string]float64  `json:"thresholds"`
+	RequiredLabTests   map[string][]string `json:"required_lab_tests"`
+	CertificationTypes []string            `json:"certification_types"`
+	RequiredCertTypes  []string            `json:"required_certification_types"`
+	MaxValidityDays    map[string]int      `json:"max_certification_validity_days"`
+	RecordTypes        []string            `json:"regulatory_record_types"`
+	// RecordTypeDetailKeys maps a regulatory record type to the keys its
+	// details must hold as a JSON object; types without an entry take free text
+	RecordTypeDetailKeys map[string][]string `json:"regulatory_record_detail_keys"`
+	// SampleRequiredRecordTypes are the regulatory record types that need a
+	// passing RegulatorySampleAsset before they can be APPROVED
+	SampleRequiredRecordTypes []string `json:"sample_required_record_types"`
+	// CertifierAccreditations maps a third-party certifier MSP to the
+	// certification types it may issue
+	CertifierAccreditations map[string][]string `json:"certifier_accreditations"`
+	CertificateNumberPrefix string              `json:"certificate_number_prefix"`
+	UpdatedAt               string              `json:"updated_at"`
+}
+
+// CertificationPrerequisiteAsset lists what must be in place before a
+// certification of CertType can be issued. It is stored on the ledger under
+// "cert_prerequisite~<type>" so the rules are auditable.
+type CertificationPrerequisiteAsset struct {
+	DocType                  string   `json:"docType"`
+	CertType                 string   `json:"cert_type"`
+	RequiredLabTests         []string `json:"required_lab_tests"`
+	RequireHACCPLog          bool     `json:"require_haccp_log"`
+	RequiredHACCPCheckpoints []string `json:"required_haccp_checkpoints"`
+	RequiredCertifications   []string `json:"required_certifications"`
+	UpdatedBy                string   `json:"updated_by"`
+	UpdatedAt                string   `json:"updated_at"`
+}
+
+// ExportRequirementAsset lists the certification types a batch must hold to
+// be exported to CountryCode. It is stored on the ledger under
+// "export_requirement~<country>".
+type ExportRequirementAsset struct {
+	DocType           string   `json:"docType"`
+	CountryCode       string   `json:"country_code"`
+	RequiredCertTypes []string `json:"required_certification_types"`
+	UpdatedBy         string   `json:"updated_by"`
+	UpdatedAt         string   `json:"updated_at"`
+}
+
+// ExportReadiness reports whether a batch holds the certifications its
+// destination country requires
+type ExportReadiness struct {
+	BatchID                string   `json:"batch_id"`
+	CountryCode            string   `json:"country_code"`
+	RequirementsConfigured bool     `json:"requirements_configured"`
+	RequiredCertTypes      []string `json:"required_certification_types"`
+	MissingCertTypes       []string `json:"missing_certification_types"`
+	Ready                  bool     `json:"ready"`
+}
+
+// TransitionRulesAsset holds status transition overrides stored under TransitionRulesKey
+type TransitionRulesAsset struct {
+	DocType   string              `json:"docType"`
+	Rules     map[string][]string `json:"rules"`
+	UpdatedAt string              `json:"updated_at"`
 }
 
 // ============================================================================
@@ -163,6 +1253,12 @@ type SupplyChainContract struct {
 	contractapi.Contract
 }
 
+// GetIgnoredFunctions lists exported methods that are Go-level helpers for
+// other transactions rather than transactions themselves
+func (s *SupplyChainContract) GetIgnoredFunctions() []string {
+	return []string{"TryGetBatch", "TryGetProduct", "TryGetTransport"}
+}
+
 // ============================================================================
 // HELPER FUNCTIONS
 // ============================================================================
@@ -176,13 +1272,100 @@ func (s *SupplyChainContract) AssetExists(ctx contractapi.TransactionContextInte
 	return assetBytes != nil, nil
 }
 
-// GetTxTimestamp returns the Fabric transaction timestamp (deterministic, no time.Now())
+// GetTxTimestamp returns the Fabric transaction timestamp as a UTC
+// ledgerTimestampLayout string (deterministic, no time.Now())
 func (s *SupplyChainContract) GetTxTimestamp(ctx contractapi.TransactionContextInterface) string {
 	timestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
 		return ""
 	}
-	return timestamp.String()
+	return timestamp.AsTime().UTC().Format(ledgerTimestampLayout)
+}
+
+// txTime returns the Fabric transaction timestamp as a time.Time
+func (s *SupplyChainContract) txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	return timestamp.AsTime().UTC(), nil
+}
+
+// emitEvent sets the transaction's chaincode event. With the EmitFullState
+// feature flag on, the payload also carries the asset's full new state under
+// "state" (a StateSnapshot payload) so consumers need not re-query it.
+//
+// Event payloads are stored in the block alongside the write set, so a
+// snapshot roughly doubles the block space the transaction takes. The flag is
+// off by default; enable it only where consumers cannot query the ledger.
+func (s *SupplyChainContract) emitEvent(
+	ctx contractapi.TransactionContextInterface,
+	name string,
+	payload map[string]interface{},
+	state interface{},
+) {
+	if enabled, err := s.isFeatureEnabled(ctx, FeatureEmitFullState); err == nil && enabled {
+		payload["state"] = state
+	}
+	eventBytes, _ := json.Marshal(payload)
+	ctx.GetStub().SetEvent(name, eventBytes)
+}
+
+// certificationEventPayload is the common payload of certification events:
+// what was certified, its type and validity window, and the caller's MSP
+func (s *SupplyChainContract) certificationEventPayload(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+) map[string]interface{} {
+	actorMSP, _ := ctx.GetClientIdentity().GetMSPID()
+	return map[string]interface{}{
+		"certification_id": certification.CertificationID,
+		"cert_type":        certification.CertType,
+		"processing_id":    certification.ProcessingID,
+		"batch_id":         certification.BatchID,
+		"status":           certification.Status,
+		"issued_date":      certification.IssuedDate,
+		"expiry_date":      certification.ExpiryDate,
+		"actor_msp":        actorMSP,
+	}
+}
+
+// emitCertificationEvent emits one of the certification events that replace
+// CertificationUpdated. Fabric keeps a single event per transaction, so until
+// SplitCertificationEvents is enabled the event still goes out under the
+// deprecated CertificationUpdated name, with event_type naming its
+// replacement so listeners can already filter on it.
+func (s *SupplyChainContract) emitCertificationEvent(
+	ctx contractapi.TransactionContextInterface,
+	name string,
+	payload map[string]interface{},
+	certification *CertificationAsset,
+) {
+	if split, err := s.isFeatureEnabled(ctx, FeatureSplitCertificationEvents); err == nil && split {
+		s.emitEvent(ctx, name, payload, certification)
+		return
+	}
+	payload["event_type"] = name
+	payload["deprecated"] = fmt.Sprintf("CertificationUpdated is deprecated and will be removed; enable %s and subscribe to %s", FeatureSplitCertificationEvents, name)
+	s.emitEvent(ctx, "CertificationUpdated", payload, certification)
+}
+
+// getCallerEnrollmentID returns the caller's enrollment ID from the
+// hf.EnrollmentID certificate attribute, falling back to the full client
+// identity when the certificate does not carry it
+func (s *SupplyChainContract) getCallerEnrollmentID(ctx contractapi.TransactionContextInterface) (string, error) {
+	enrollmentID, found, err := ctx.GetClientIdentity().GetAttributeValue("hf.EnrollmentID")
+	if err != nil {
+		return "", fmt.Errorf("failed to read client attributes: %v", err)
+	}
+	if found && enrollmentID != "" {
+		return enrollmentID, nil
+	}
+	id, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get client identity: %v", err)
+	}
+	return id, nil
 }
 
 // AuthorizeMSP checks if the caller's MSP matches the required MSP
@@ -198,985 +1381,12544 @@ func (s *SupplyChainContract) AuthorizeMSP(ctx contractapi.TransactionContextInt
 	return nil
 }
 
-// ValidateStatusTransition checks if a status transition is valid
-func (s *SupplyChainContract) ValidateStatusTransition(currentStatus, newStatus string) error {
-	allowedTransitions, exists := validStatusTransitions[currentStatus]
-	if !exists {
-		return fmt.Errorf("unknown status: %s", currentStatus)
+// authorizeAnyMSP checks the caller's MSP is one of allowedMSPs (Admin is always allowed)
+func (s *SupplyChainContract) authorizeAnyMSP(ctx contractapi.TransactionContextInterface, allowedMSPs ...string) error {
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP: %v", err)
 	}
-	for _, allowed := range allowedTransitions {
-		if allowed == newStatus {
+
+	if clientMSP == AdminOrgMSP {
+		return nil
+	}
+	for _, allowed := range allowedMSPs {
+		if clientMSP == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("unauthorized: MSP %s not allowed. Required one of: %s, %s", clientMSP, strings.Join(allowedMSPs, ", "), AdminOrgMSP)
+}
+
+// authorizeAndLoadBatch checks the caller against requiredMSP as AuthorizeMSP
+// does and only then loads the batch, so reads never precede authorization
+func (s *SupplyChainContract) authorizeAndLoadBatch(
+	ctx contractapi.TransactionContextInterface,
+	requiredMSP string,
+	batchID string,
+) (*BatchAsset, error) {
+	if err := s.AuthorizeMSP(ctx, requiredMSP); err != nil {
+		return nil, err
+	}
+	return s.GetBatch(ctx, batchID)
+}
+
+// authorizeAndLoadProduct is authorizeAndLoadBatch for products
+func (s *SupplyChainContract) authorizeAndLoadProduct(
+	ctx contractapi.TransactionContextInterface,
+	requiredMSP string,
+	productID string,
+) (*ProductAsset, error) {
+	if err := s.AuthorizeMSP(ctx, requiredMSP); err != nil {
+		return nil, err
+	}
+	return s.GetProduct(ctx, productID)
+}
+
+// authorizeAndLoadTransport is authorizeAndLoadBatch for transports
+func (s *SupplyChainContract) authorizeAndLoadTransport(
+	ctx contractapi.TransactionContextInterface,
+	requiredMSP string,
+	transportID string,
+) (*TransportAsset, error) {
+	if err := s.AuthorizeMSP(ctx, requiredMSP); err != nil {
+		return nil, err
+	}
+	return s.GetTransport(ctx, transportID)
+}
+
+// authorizeAndLoadCertification is authorizeAndLoadBatch for certifications.
+// The certification is read as stored, for paths that write it back.
+func (s *SupplyChainContract) authorizeAndLoadCertification(
+	ctx contractapi.TransactionContextInterface,
+	requiredMSP string,
+	certificationID string,
+) (*CertificationAsset, error) {
+	if err := s.AuthorizeMSP(ctx, requiredMSP); err != nil {
+		return nil, err
+	}
+	return s.getCertification(ctx, certificationID)
+}
+
+// authorizeAndLoadRegulatoryRecord is authorizeAndLoadBatch for regulatory records
+func (s *SupplyChainContract) authorizeAndLoadRegulatoryRecord(
+	ctx contractapi.TransactionContextInterface,
+	requiredMSP string,
+	regulatoryID string,
+) (*RegulatoryAsset, error) {
+	if err := s.AuthorizeMSP(ctx, requiredMSP); err != nil {
+		return nil, err
+	}
+	return s.GetRegulatoryRecord(ctx, regulatoryID)
+}
+
+// ValidateStatusTransition checks if a status transition is valid. Rules stored
+// on the ledger by SetTransitionRule take precedence over validStatusTransitions.
+func (s *SupplyChainContract) ValidateStatusTransition(ctx contractapi.TransactionContextInterface, currentStatus, newStatus string) error {
+	rules, err := s.GetTransitionRules(ctx)
+	if err != nil {
+		return err
+	}
+	allowedTransitions, exists := rules[currentStatus]
+	if !exists {
+		return fmt.Errorf("unknown status: %s", currentStatus)
+	}
+	for _, allowed := range allowedTransitions {
+		if allowed == newStatus {
 			return nil
 		}
 	}
 	return fmt.Errorf("invalid transition from %s to %s", currentStatus, newStatus)
 }
 
-// ValidateNonEmptyString validates that a string is not empty
-func (s *SupplyChainContract) ValidateNonEmptyString(value, fieldName string) error {
-	if strings.TrimSpace(value) == "" {
-		return fmt.Errorf("%s cannot be empty", fieldName)
+// validateCertificationTransition checks a certification status change
+// against certificationStatusTransitions
+func validateCertificationTransition(currentStatus, newStatus string) error {
+	allowedTransitions, exists := certificationStatusTransitions[currentStatus]
+	if !exists {
+		return fmt.Errorf("unknown certification status: %s", currentStatus)
+	}
+	for _, allowed := range allowedTransitions {
+		if allowed == newStatus {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid certification transition from %s to %s", currentStatus, newStatus)
+}
+
+// validateRegulatoryTransition checks a regulatory record status change
+// against regulatoryStatusTransitions
+func validateRegulatoryTransition(currentStatus, newStatus string) error {
+	allowedTransitions, exists := regulatoryStatusTransitions[currentStatus]
+	if !exists {
+		return fmt.Errorf("unknown regulatory status: %s", currentStatus)
+	}
+	for _, allowed := range allowedTransitions {
+		if allowed == newStatus {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid regulatory transition from %s to %s", currentStatus, newStatus)
+}
+
+// validateRegulatoryReason requires a rejectionReason of at least
+// MinRegulatoryReasonLength characters when moving to REJECTED or NEEDS_INFO
+func validateRegulatoryReason(newStatus, rejectionReason string) error {
+	if newStatus != "REJECTED" && newStatus != "NEEDS_INFO" {
+		return nil
+	}
+	reason := strings.TrimSpace(rejectionReason)
+	if reason == "" {
+		return fmt.Errorf("rejectionReason is required when moving to %s", newStatus)
+	}
+	if length := len([]rune(reason)); length < MinRegulatoryReasonLength {
+		return fmt.Errorf("rejectionReason must be at least %d characters, got %d", MinRegulatoryReasonLength, length)
+	}
+	return nil
+}
+
+// normalizeAuditFlag upper-cases an audit flag and checks it against regulatoryAuditFlags
+func normalizeAuditFlag(flag string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(flag))
+	for _, known := range regulatoryAuditFlags {
+		if known == normalized {
+			return normalized, nil
+		}
+	}
+	return "", fmt.Errorf("invalid audit flag %q, allowed: %s", flag, strings.Join(regulatoryAuditFlags, ", "))
+}
+
+// parseAuditFlags reads the audit flags passed to CreateRegulatoryRecord,
+// either a JSON list or a comma-separated string, upper-casing each flag.
+// Checking them against regulatoryAuditFlags is left to validateRegulatoryAsset.
+func parseAuditFlags(value string) (AuditFlagList, error) {
+	var flags []string
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &flags); err != nil {
+			return nil, fmt.Errorf("invalid auditFlags JSON: %v", err)
+		}
+	} else {
+		flags = splitAuditFlags(value)
+	}
+	parsed := AuditFlagList{}
+	for _, flag := range flags {
+		parsed = append(parsed, strings.ToUpper(strings.TrimSpace(flag)))
+	}
+	return parsed, nil
+}
+
+// ValidateNonEmptyString validates that a string is not empty
+func (s *SupplyChainContract) ValidateNonEmptyString(value, fieldName string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s cannot be empty", fieldName)
+	}
+	return nil
+}
+
+// ValidatePositiveInt validates that an integer is positive
+func (s *SupplyChainContract) ValidatePositiveInt(value int, fieldName string) error {
+	if value <= 0 {
+		return fmt.Errorf("%s must be positive, got %d", fieldName, value)
+	}
+	return nil
+}
+
+// ValidatePositiveFloat validates that a float is positive
+func (s *SupplyChainContract) ValidatePositiveFloat(value float64, fieldName string) error {
+	if value < 0 {
+		return fmt.Errorf("%s must be non-negative, got %f", fieldName, value)
+	}
+	return nil
+}
+
+// ValidateProcessingStage normalizes a processing stage and checks it against processingStageOrder
+func (s *SupplyChainContract) ValidateProcessingStage(stage string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(stage))
+	if stageIndex(normalized) < 0 {
+		return "", fmt.Errorf("invalid stage %q, allowed: %s", stage, strings.Join(processingStageOrder, ", "))
+	}
+	return normalized, nil
+}
+
+// stageIndex returns the position of a stage in processingStageOrder, or -1
+func stageIndex(stage string) int {
+	for i, known := range processingStageOrder {
+		if known == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseLedgerDate parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseLedgerDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// isoDurationPattern matches an ISO 8601 duration with whole-number
+// components, such as P3D, P1Y2M, P2W or PT36H
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// isoDuration is a parsed ISO 8601 duration. Years, months and days are kept
+// apart from the clock part so they follow the calendar when added.
+type isoDuration struct {
+	Years  int
+	Months int
+	Days   int
+	Clock  time.Duration
+}
+
+// addTo returns t plus the duration
+func (d isoDuration) addTo(t time.Time) time.Time {
+	return t.AddDate(d.Years, d.Months, d.Days).Add(d.Clock)
+}
+
+// isZero reports whether the duration has no length
+func (d isoDuration) isZero() bool {
+	return d.Years == 0 && d.Months == 0 && d.Days == 0 && d.Clock == 0
+}
+
+// parseISODuration parses an ISO 8601 duration such as P3D or PT12H. Weeks
+// count as seven days. Fractional components are not supported.
+func parseISODuration(value string) (isoDuration, error) {
+	match := isoDurationPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return isoDuration{}, fmt.Errorf("must be an ISO 8601 duration such as P3D or PT12H")
+	}
+
+	parts := make([]int, len(match)-1)
+	for i, part := range match[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return isoDuration{}, fmt.Errorf("component %q is out of range", part)
+		}
+		parts[i] = n
+	}
+
+	return isoDuration{
+		Years:  parts[0],
+		Months: parts[1],
+		Days:   parts[2]*7 + parts[3],
+		Clock:  time.Duration(parts[4])*time.Hour + time.Duration(parts[5])*time.Minute + time.Duration(parts[6])*time.Second,
+	}, nil
+}
+
+// batchExpectedEnd returns when a batch is expected to complete: its
+// ExpectedEndDate or, when that is empty, its StartDate plus the product's
+// ExpectedProcessingWindow. ok is false when neither gives a usable time.
+func batchExpectedEnd(batch *BatchAsset, product *ProductAsset) (expectedEnd time.Time, ok bool) {
+	if batch.ExpectedEndDate != "" {
+		expectedEnd, err := parseLedgerDeadline(batch.ExpectedEndDate)
+		return expectedEnd, err == nil
+	}
+	if product == nil || product.ExpectedProcessingWindow == "" {
+		return time.Time{}, false
+	}
+	start, err := parseLedgerDate(batch.StartDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+	window, err := parseISODuration(product.ExpectedProcessingWindow)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return window.addTo(start), true
+}
+
+// parseLedgerDeadline parses an inclusive deadline; a date-only value covers the whole day
+func parseLedgerDeadline(value string) (time.Time, error) {
+	t, err := parseLedgerDate(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if _, dateOnlyErr := time.Parse("2006-01-02", value); dateOnlyErr == nil {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// validateIssuedBeforeExpiry checks that, when both are given, issuedDate and
+// expiryDate are RFC3339 timestamps or YYYY-MM-DD dates with expiry strictly
+// after issue. A date-only expiry runs to the end of that day.
+func validateIssuedBeforeExpiry(issuedDate, expiryDate string) error {
+	if issuedDate == "" || expiryDate == "" {
+		return nil
+	}
+	issued, err := parseLedgerDate(issuedDate)
+	if err != nil {
+		return fmt.Errorf("invalid issuedDate %q: must be RFC3339 or YYYY-MM-DD", issuedDate)
+	}
+	expiry, err := parseLedgerDeadline(expiryDate)
+	if err != nil {
+		return fmt.Errorf("invalid expiryDate %q: must be RFC3339 or YYYY-MM-DD", expiryDate)
+	}
+	if !expiry.After(issued) {
+		return fmt.Errorf("expiryDate %s must be after issuedDate %s", expiryDate, issuedDate)
+	}
+	return nil
+}
+
+// validationError folds the problems reported by a validate*Asset function
+// into one error, or nil when there are none. A single problem is returned
+// unchanged so its message reads the same as the individual check.
+func validationError(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("%d validation errors: %s", len(errs), strings.Join(messages, "; "))
+}
+
+// requireNonEmpty appends a "cannot be empty" error for a blank field
+func requireNonEmpty(errs []error, value, fieldName string) []error {
+	if strings.TrimSpace(value) == "" {
+		errs = append(errs, fmt.Errorf("%s cannot be empty", fieldName))
+	}
+	return errs
+}
+
+// validateDateOrder checks that optional start and end dates parse, and that
+// the end does not fall before the start when both are given. A date-only end
+// covers the whole day, as with parseLedgerDeadline.
+func validateDateOrder(errs []error, start, end, startField, endField string) []error {
+	var startTime, endTime time.Time
+	var err error
+	if start != "" {
+		if startTime, err = parseLedgerDate(start); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: must be RFC3339 or YYYY-MM-DD", startField, start))
+		}
+	}
+	if end != "" {
+		if endTime, err = parseLedgerDeadline(end); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: must be RFC3339 or YYYY-MM-DD", endField, end))
+		}
+	}
+	if !startTime.IsZero() && !endTime.IsZero() && endTime.Before(startTime) {
+		errs = append(errs, fmt.Errorf("%s %s cannot be before %s %s", endField, end, startField, start))
+	}
+	return errs
+}
+
+// validateProductAsset runs every field check for a new product
+func validateProductAsset(product *ProductAsset) []error {
+	var errs []error
+	errs = requireNonEmpty(errs, product.ProductID, "productID")
+	errs = requireNonEmpty(errs, product.Name, "name")
+	return errs
+}
+
+// validateBatchAsset runs every field and cross-field check for a new batch
+func validateBatchAsset(batch *BatchAsset) []error {
+	var errs []error
+	errs = requireNonEmpty(errs, batch.BatchID, "batchID")
+	errs = requireNonEmpty(errs, batch.ProductID, "productID")
+	errs = requireNonEmpty(errs, batch.BatchNumber, "batchNumber")
+	if batch.Quantity <= 0 {
+		errs = append(errs, fmt.Errorf("quantity must be positive, got %d", batch.Quantity))
+	}
+	errs = validateDateOrder(errs, batch.StartDate, batch.ExpectedEndDate, "startDate", "expectedEndDate")
+	return errs
+}
+
+// validateTransportAsset runs every field and cross-field check for a new
+// transport manifest. A zero quantity is allowed; it ships the whole batch.
+func validateTransportAsset(transport *TransportAsset) []error {
+	var errs []error
+	errs = requireNonEmpty(errs, transport.TransportID, "transportID")
+	errs = requireNonEmpty(errs, transport.BatchID, "batchID")
+	if transport.Quantity < 0 {
+		errs = append(errs, fmt.Errorf("quantity must be positive"))
+	}
+	errs = validateDateOrder(errs, transport.DepartureTime, transport.ExpectedArrivalTime, "departureTime", "expectedArrivalTime")
+	return errs
+}
+
+// validateRegulatoryAsset runs every field and cross-field check for a new
+// regulatory record
+func validateRegulatoryAsset(regulatory *RegulatoryAsset) []error {
+	var errs []error
+	errs = requireNonEmpty(errs, regulatory.RegulatoryID, "regulatoryID")
+	errs = requireNonEmpty(errs, regulatory.BatchID, "batchID")
+	if err := validateIssuedBeforeExpiry(regulatory.IssuedDate, regulatory.ExpiryDate); err != nil {
+		errs = append(errs, err)
+	}
+	seen := map[string]bool{}
+	for _, flag := range regulatory.AuditFlags {
+		if _, err := normalizeAuditFlag(flag); err != nil {
+			errs = append(errs, err)
+		} else if seen[flag] {
+			errs = append(errs, fmt.Errorf("duplicate audit flag %s", flag))
+		}
+		seen[flag] = true
+	}
+	return errs
+}
+
+// validateProcessingAsset runs every field and cross-field check for a new
+// processing record
+func validateProcessingAsset(processing *ProcessingAsset) []error {
+	var errs []error
+	errs = requireNonEmpty(errs, processing.ProcessingID, "processingID")
+	errs = requireNonEmpty(errs, processing.BatchID, "batchID")
+	errs = requireNonEmpty(errs, processing.FacilityID, "facilityID")
+	if processing.SlaughterCnt < 0 {
+		errs = append(errs, fmt.Errorf("slaughterCount must be non-negative, got %d", processing.SlaughterCnt))
+	}
+	if processing.YieldKg < 0 {
+		errs = append(errs, fmt.Errorf("yieldKg must be non-negative, got %f", processing.YieldKg))
+	}
+	if processing.QualityScore < QualityScoreMin || processing.QualityScore > QualityScoreMax {
+		errs = append(errs, fmt.Errorf("qualityScore must be between %.0f and %.0f, got %f", QualityScoreMin, QualityScoreMax, processing.QualityScore))
+	}
+	return errs
+}
+
+// validateCertificationAsset runs every field and cross-field check for a new
+// or renewed certification
+func validateCertificationAsset(certification *CertificationAsset) []error {
+	var errs []error
+	errs = requireNonEmpty(errs, certification.CertificationID, "certificationID")
+	errs = requireNonEmpty(errs, certification.CertType, "certType")
+	if (certification.ProcessingID == "") == (certification.BatchID == "") {
+		errs = append(errs, fmt.Errorf("exactly one of processingID and batchID must be given"))
+	}
+	errs = requireNonEmpty(errs, certification.IssuedDate, "issuedDate")
+	errs = requireNonEmpty(errs, certification.ExpiryDate, "expiryDate")
+	if err := validateIssuedBeforeExpiry(certification.IssuedDate, certification.ExpiryDate); err != nil {
+		errs = append(errs, err)
+	}
+	if certification.DocumentURI != "" && certification.DocumentSHA256 == "" {
+		errs = append(errs, fmt.Errorf("documentURI requires documentSHA256"))
+	}
+	return errs
+}
+
+// parseDateRange parses inclusive range bounds. A date-only upper bound
+// covers the whole of that day.
+func parseDateRange(fromDate, toDate string) (time.Time, time.Time, error) {
+	from, err := parseLedgerDate(fromDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid fromDate %q: %v", fromDate, err)
+	}
+	to, err := parseLedgerDeadline(toDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid toDate %q: %v", toDate, err)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("toDate %s is before fromDate %s", toDate, fromDate)
+	}
+	return from, to, nil
+}
+
+// inDateRange reports whether value parses to a time within [from, to]
+func inDateRange(value string, from, to time.Time) bool {
+	t, err := parseLedgerDate(value)
+	if err != nil {
+		return false
+	}
+	return !t.Before(from) && !t.After(to)
+}
+
+// queryAssets runs a CouchDB rich query for selector and decodes every match into T
+func queryAssets[T any](ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]*T, error) {
+	queryBytes, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	results := []*T{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var asset T
+		if err := json.Unmarshal(queryResult.Value, &asset); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query result %s: %v", queryResult.Key, err)
+		}
+		results = append(results, &asset)
+	}
+
+	return results, nil
+}
+
+// queryAssetsWithPagination runs a paginated CouchDB rich query for selector
+// and returns the decoded page along with the bookmark for the next page
+func queryAssetsWithPagination[T any](
+	ctx contractapi.TransactionContextInterface,
+	selector map[string]interface{},
+	pageSize int,
+	bookmark string,
+) ([]*T, string, error) {
+	return querySortedAssetsWithPagination[T](ctx, selector, nil, pageSize, bookmark)
+}
+
+// querySortedAssetsWithPagination is queryAssetsWithPagination with a CouchDB
+// sort, such as []map[string]string{{"created_at": "asc"}}, so that pages
+// follow one order across bookmarks. The sort fields need a matching index
+// under META-INF/statedb/couchdb/indexes.
+func querySortedAssetsWithPagination[T any](
+	ctx contractapi.TransactionContextInterface,
+	selector map[string]interface{},
+	sortBy []map[string]string,
+	pageSize int,
+	bookmark string,
+) ([]*T, string, error) {
+	query := map[string]interface{}{"selector": selector}
+	if len(sortBy) > 0 {
+		query["sort"] = sortBy
+	}
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build query: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryBytes), int32(pageSize), bookmark)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to run query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	results := []*T{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to iterate query results: %v", err)
+		}
+
+		var asset T
+		if err := json.Unmarshal(queryResult.Value, &asset); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal query result %s: %v", queryResult.Key, err)
+		}
+		results = append(results, &asset)
+	}
+
+	return results, metadata.GetBookmark(), nil
+}
+
+// ValidateSHA256Hex validates a hex-encoded SHA-256 digest
+func (s *SupplyChainContract) ValidateSHA256Hex(value, fieldName string) error {
+	if len(value) != 64 {
+		return fmt.Errorf("%s must be a 64-character hex SHA-256 digest", fieldName)
+	}
+	for _, c := range value {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return fmt.Errorf("%s must be a 64-character hex SHA-256 digest", fieldName)
+		}
+	}
+	return nil
+}
+
+// ValidatePageSize validates a page size against MaxPageSize
+func (s *SupplyChainContract) ValidatePageSize(pageSize int) error {
+	if pageSize <= 0 || pageSize > MaxPageSize {
+		return fmt.Errorf("pageSize must be between 1 and %d, got %d", MaxPageSize, pageSize)
+	}
+	return nil
+}
+
+// ValidateBatchStatus validates that a status is one of batchStatuses
+func (s *SupplyChainContract) ValidateBatchStatus(status string) error {
+	for _, known := range batchStatuses {
+		if known == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid batch status %q, allowed: %s", status, strings.Join(batchStatuses, ", "))
+}
+
+// dateRangeSelector builds a CouchDB range condition for string dates. A
+// date-only upper bound is made exclusive of the following day so that
+// timestamps later on that day still match.
+func dateRangeSelector(fromDate, toDate string) (map[string]interface{}, error) {
+	if _, _, err := parseDateRange(fromDate, toDate); err != nil {
+		return nil, err
+	}
+	if day, err := time.Parse("2006-01-02", toDate); err == nil {
+		return map[string]interface{}{"$gte": fromDate, "$lt": day.AddDate(0, 0, 1).Format("2006-01-02")}, nil
+	}
+	return map[string]interface{}{"$gte": fromDate, "$lte": toDate}, nil
+}
+
+// expiryBefore is a selector condition on an expiry date string that matches
+// every set expiry before cutoff, so sweeps need not load unexpired records.
+// Expiries mix YYYY-MM-DD dates and RFC3339 timestamps in any offset, which
+// do not compare as strings, so the bound is two days past cutoff and the
+// caller still checks each match with parseLedgerDeadline.
+func expiryBefore(cutoff time.Time) map[string]interface{} {
+	return map[string]interface{}{"$gt": "", "$lt": cutoff.UTC().AddDate(0, 0, 2).Format("2006-01-02")}
+}
+
+// ValidateQualityScore validates that a quality score is within the 0-100 scale
+func (s *SupplyChainContract) ValidateQualityScore(value float64) error {
+	if value < QualityScoreMin || value > QualityScoreMax {
+		return fmt.Errorf("qualityScore must be between %.0f and %.0f, got %f", QualityScoreMin, QualityScoreMax, value)
+	}
+	return nil
+}
+
+// ValidateISODuration validates an ISO 8601 duration such as P3D or PT12H
+func (s *SupplyChainContract) ValidateISODuration(value, fieldName string) error {
+	if _, err := parseISODuration(value); err != nil {
+		return fmt.Errorf("invalid %s %q: %v", fieldName, value, err)
+	}
+	return nil
+}
+
+// ============================================================================
+// SYSTEM CONFIGURATION
+// ============================================================================
+
+// getSystemConfig loads the system configuration, falling back to defaults when unset
+func (s *SupplyChainContract) getSystemConfig(ctx contractapi.TransactionContextInterface) (*SystemConfigAsset, error) {
+	configBytes, err := ctx.GetStub().GetState(SystemConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system config: %v", err)
+	}
+
+	config := SystemConfigAsset{DocType: "SystemConfigAsset"}
+	if configBytes != nil {
+		if err := json.Unmarshal(configBytes, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal system config: %v", err)
+		}
+	}
+	if len(config.QualityGradeBands) == 0 {
+		config.QualityGradeBands = defaultQualityGradeBands
+	}
+	if config.FeatureFlags == nil {
+		config.FeatureFlags = map[string]bool{}
+	}
+	if config.Thresholds == nil {
+		config.Thresholds = map[string]float64{}
+	}
+	if config.RequiredLabTests == nil {
+		config.RequiredLabTests = map[string][]string{}
+	}
+	if len(config.CertificationTypes) == 0 {
+		config.CertificationTypes = append([]string{}, defaultCertificationTypes...)
+	}
+	if len(config.RecordTypes) == 0 {
+		config.RecordTypes = append([]string{}, defaultRegulatoryRecordTypes...)
+	}
+	if config.MaxValidityDays == nil {
+		config.MaxValidityDays = map[string]int{}
+	}
+	if config.CertifierAccreditations == nil {
+		config.CertifierAccreditations = map[string][]string{}
+	}
+	if config.RecordTypeDetailKeys == nil {
+		config.RecordTypeDetailKeys = map[string][]string{}
+	}
+	if config.CertificateNumberPrefix == "" {
+		config.CertificateNumberPrefix = DefaultCertificateNumberPrefix
+	}
+
+	return &config, nil
+}
+
+// putSystemConfig stores the system configuration
+func (s *SupplyChainContract) putSystemConfig(ctx contractapi.TransactionContextInterface, config *SystemConfigAsset) error {
+	config.DocType = "SystemConfigAsset"
+	config.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal system config: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(SystemConfigKey, configBytes); err != nil {
+		return fmt.Errorf("failed to save system config: %v", err)
+	}
+	return nil
+}
+
+// GetSystemConfig returns the effective system configuration
+func (s *SupplyChainContract) GetSystemConfig(
+	ctx contractapi.TransactionContextInterface,
+) (*SystemConfigAsset, error) {
+	return s.getSystemConfig(ctx)
+}
+
+// SetQualityGradeBands replaces the quality grade bands (Regulator only).
+// bandsJSON is a list of {"grade", "min_score"} ordered from the highest band
+// down. Grades are computed when a processing record is written, so changing
+// the bands does not affect grades already stored.
+func (s *SupplyChainContract) SetQualityGradeBands(
+	ctx contractapi.TransactionContextInterface,
+	bandsJSON string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	var bands []QualityGradeBand
+	if err := json.Unmarshal([]byte(bandsJSON), &bands); err != nil {
+		return nil, fmt.Errorf("invalid bands JSON: %v", err)
+	}
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("at least one quality grade band is required")
+	}
+	for i, band := range bands {
+		if err := s.ValidateNonEmptyString(band.Grade, "grade"); err != nil {
+			return nil, err
+		}
+		if band.Grade == QualityGradeReject {
+			return nil, fmt.Errorf("grade %s is reserved for scores below the lowest band", QualityGradeReject)
+		}
+		if err := s.ValidateQualityScore(band.MinScore); err != nil {
+			return nil, err
+		}
+		if i > 0 && band.MinScore >= bands[i-1].MinScore {
+			return nil, fmt.Errorf("bands must be ordered by strictly descending min_score")
+		}
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.QualityGradeBands = bands
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetFeatureFlag turns a named feature flag on or off (Admin only)
+func (s *SupplyChainContract) SetFeatureFlag(
+	ctx contractapi.TransactionContextInterface,
+	flag string,
+	enabled bool,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
+		return nil, err
+	}
+
+	known := false
+	for _, knownFlag := range knownFeatureFlags {
+		if knownFlag == flag {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown feature flag %q, allowed: %s", flag, strings.Join(knownFeatureFlags, ", "))
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.FeatureFlags[flag] = enabled
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// isFeatureEnabled reports whether a feature flag is on; unset flags are off
+func (s *SupplyChainContract) isFeatureEnabled(ctx contractapi.TransactionContextInterface, flag string) (bool, error) {
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	return config.FeatureFlags[flag], nil
+}
+
+// SetThreshold sets a named numeric threshold (Regulator or Admin). A value of
+// zero clears the threshold, which disables the check that uses it.
+func (s *SupplyChainContract) SetThreshold(
+	ctx contractapi.TransactionContextInterface,
+	name string,
+	value float64,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator or Admin)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	known := false
+	for _, knownThreshold := range knownThresholds {
+		if knownThreshold == name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown threshold %q, allowed: %s", name, strings.Join(knownThresholds, ", "))
+	}
+	if err := s.ValidatePositiveFloat(value, name); err != nil {
+		return nil, err
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if value == 0 {
+		delete(config.Thresholds, name)
+	} else {
+		config.Thresholds[name] = value
+	}
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// getThreshold returns a numeric threshold and whether it has been set
+func (s *SupplyChainContract) getThreshold(ctx contractapi.TransactionContextInterface, name string) (float64, bool, error) {
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	value, ok := config.Thresholds[name]
+	return value, ok, nil
+}
+
+// SetRequiredLabTests sets the lab test types that must have a passing result
+// on the processing record before a certType can be issued (Regulator only).
+// An empty array removes the requirement.
+func (s *SupplyChainContract) SetRequiredLabTests(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+	testTypesJSON string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(certType, "certType"); err != nil {
+		return nil, err
+	}
+	var testTypes []string
+	if err := json.Unmarshal([]byte(testTypesJSON), &testTypes); err != nil {
+		return nil, fmt.Errorf("invalid testTypes JSON: %v", err)
+	}
+	for i, testType := range testTypes {
+		if err := s.ValidateNonEmptyString(testType, "testType"); err != nil {
+			return nil, err
+		}
+		testTypes[i] = strings.ToUpper(strings.TrimSpace(testType))
+	}
+
+	certType, err := s.validateCertificationType(ctx, certType)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(testTypes) == 0 {
+		delete(config.RequiredLabTests, certType)
+	} else {
+		config.RequiredLabTests[certType] = testTypes
+	}
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetRequiredCertifications sets the certification types every batch must
+// hold, either batch-scoped or on one of its processing records, for
+// GetMissingCertificationsForBatch (Regulator only). An empty array removes
+// the requirement.
+func (s *SupplyChainContract) SetRequiredCertifications(
+	ctx contractapi.TransactionContextInterface,
+	certTypesJSON string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	var certTypes []string
+	if err := json.Unmarshal([]byte(certTypesJSON), &certTypes); err != nil {
+		return nil, fmt.Errorf("invalid certTypes JSON: %v", err)
+	}
+	required := []string{}
+	seen := map[string]bool{}
+	for _, certType := range certTypes {
+		normalized, err := s.validateCertificationType(ctx, certType)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			required = append(required, normalized)
+		}
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.RequiredCertTypes = required
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetCertificationPrerequisites stores the prerequisites for issuing certType
+// (Regulator only). prerequisitesJSON is an object with any of
+// required_lab_tests, require_haccp_log, required_haccp_checkpoints and
+// required_certifications; omitted fields have no requirement. Lab tests set
+// with SetRequiredLabTests and the RequireHACCPLogForHACCPCerts flag still
+// apply alongside these.
+func (s *SupplyChainContract) SetCertificationPrerequisites(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+	prerequisitesJSON string,
+) (*CertificationPrerequisiteAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	certType, err := s.validateCertificationType(ctx, certType)
+	if err != nil {
+		return nil, err
+	}
+
+	var prerequisites CertificationPrerequisiteAsset
+	if err := json.Unmarshal([]byte(prerequisitesJSON), &prerequisites); err != nil {
+		return nil, fmt.Errorf("invalid prerequisites JSON: %v", err)
+	}
+	for i, testType := range prerequisites.RequiredLabTests {
+		if err := s.ValidateNonEmptyString(testType, "required_lab_tests entry"); err != nil {
+			return nil, err
+		}
+		prerequisites.RequiredLabTests[i] = strings.ToUpper(strings.TrimSpace(testType))
+	}
+	for _, ccpName := range prerequisites.RequiredHACCPCheckpoints {
+		if err := s.ValidateNonEmptyString(ccpName, "required_haccp_checkpoints entry"); err != nil {
+			return nil, err
+		}
+	}
+	for i, requiredType := range prerequisites.RequiredCertifications {
+		normalized, err := s.validateCertificationType(ctx, requiredType)
+		if err != nil {
+			return nil, err
+		}
+		if normalized == certType {
+			return nil, fmt.Errorf("%s certification cannot require itself", certType)
+		}
+		prerequisites.RequiredCertifications[i] = normalized
+	}
+
+	updatedBy, err := s.getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prerequisites.DocType = "CertificationPrerequisiteAsset"
+	prerequisites.CertType = certType
+	prerequisites.UpdatedBy = updatedBy
+	prerequisites.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	prerequisitesBytes, err := json.Marshal(prerequisites)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certification prerequisites: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(fmt.Sprintf("cert_prerequisite~%s", certType), prerequisitesBytes); err != nil {
+		return nil, fmt.Errorf("failed to save certification prerequisites: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"cert_type": certType, "updated_by": updatedBy}
+	s.emitEvent(ctx, "CertificationPrerequisitesUpdated", eventPayload, &prerequisites)
+
+	return &prerequisites, nil
+}
+
+// GetCertificationPrerequisites retrieves the stored prerequisites for certType
+func (s *SupplyChainContract) GetCertificationPrerequisites(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+) (*CertificationPrerequisiteAsset, error) {
+	if err := s.ValidateNonEmptyString(certType, "certType"); err != nil {
+		return nil, err
+	}
+
+	prerequisites, err := s.getCertificationPrerequisites(ctx, normalizeCertificationType(certType))
+	if err != nil {
+		return nil, err
+	}
+	if prerequisites == nil {
+		return nil, fmt.Errorf("certification prerequisites for %s not found", certType)
+	}
+
+	return prerequisites, nil
+}
+
+// GetAllCertificationPrerequisites retrieves every stored prerequisite set
+func (s *SupplyChainContract) GetAllCertificationPrerequisites(
+	ctx contractapi.TransactionContextInterface,
+) ([]*CertificationPrerequisiteAsset, error) {
+	prerequisites, err := queryAssets[CertificationPrerequisiteAsset](ctx, map[string]interface{}{
+		"docType": "CertificationPrerequisiteAsset",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(prerequisites, func(i, j int) bool {
+		return prerequisites[i].CertType < prerequisites[j].CertType
+	})
+
+	return prerequisites, nil
+}
+
+// getCertificationPrerequisites returns the stored prerequisites for a
+// normalized certType, or nil when none are stored
+func (s *SupplyChainContract) getCertificationPrerequisites(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+) (*CertificationPrerequisiteAsset, error) {
+	prerequisitesBytes, err := ctx.GetStub().GetState(fmt.Sprintf("cert_prerequisite~%s", certType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certification prerequisites: %v", err)
+	}
+	if prerequisitesBytes == nil {
+		return nil, nil
+	}
+
+	var prerequisites CertificationPrerequisiteAsset
+	if err := json.Unmarshal(prerequisitesBytes, &prerequisites); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal certification prerequisites: %v", err)
+	}
+	return &prerequisites, nil
+}
+
+// SetExportRequirements stores the certification types a batch must hold to
+// be exported to countryCode, an ISO 3166-1 alpha-2 code (Regulator only).
+// certTypesJSON is a JSON array of types; an empty array leaves the country
+// with no requirements. Existing export permits keep the requirements they
+// were created with.
+func (s *SupplyChainContract) SetExportRequirements(
+	ctx contractapi.TransactionContextInterface,
+	countryCode string,
+	certTypesJSON string,
+) (*ExportRequirementAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	countryCode, err := validateCountryCode(countryCode)
+	if err != nil {
+		return nil, err
+	}
+	var certTypes []string
+	if err := json.Unmarshal([]byte(certTypesJSON), &certTypes); err != nil {
+		return nil, fmt.Errorf("invalid certTypes JSON: %v", err)
+	}
+	required := []string{}
+	seen := map[string]bool{}
+	for _, certType := range certTypes {
+		normalized, err := s.validateCertificationType(ctx, certType)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			required = append(required, normalized)
+		}
+	}
+
+	updatedBy, err := s.getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	requirements := ExportRequirementAsset{
+		DocType:           "ExportRequirementAsset",
+		CountryCode:       countryCode,
+		RequiredCertTypes: required,
+		UpdatedBy:         updatedBy,
+		UpdatedAt:         s.GetTxTimestamp(ctx),
+	}
+
+	requirementsBytes, err := json.Marshal(requirements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal export requirements: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(fmt.Sprintf("export_requirement~%s", countryCode), requirementsBytes); err != nil {
+		return nil, fmt.Errorf("failed to save export requirements: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"country_code":                 countryCode,
+		"required_certification_types": required,
+		"updated_by":                   updatedBy,
+	}
+	s.emitEvent(ctx, "ExportRequirementsUpdated", eventPayload, &requirements)
+
+	return &requirements, nil
+}
+
+// GetExportRequirements retrieves the stored export requirements for countryCode
+func (s *SupplyChainContract) GetExportRequirements(
+	ctx contractapi.TransactionContextInterface,
+	countryCode string,
+) (*ExportRequirementAsset, error) {
+	countryCode, err := validateCountryCode(countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements, err := s.getExportRequirements(ctx, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	if requirements == nil {
+		return nil, fmt.Errorf("export requirements for %s not found", countryCode)
+	}
+
+	return requirements, nil
+}
+
+// getExportRequirements returns the stored export requirements for a
+// validated countryCode, or nil when none are stored
+func (s *SupplyChainContract) getExportRequirements(
+	ctx contractapi.TransactionContextInterface,
+	countryCode string,
+) (*ExportRequirementAsset, error) {
+	requirementsBytes, err := ctx.GetStub().GetState(fmt.Sprintf("export_requirement~%s", countryCode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export requirements: %v", err)
+	}
+	if requirementsBytes == nil {
+		return nil, nil
+	}
+
+	var requirements ExportRequirementAsset
+	if err := json.Unmarshal(requirementsBytes, &requirements); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal export requirements: %v", err)
+	}
+	return &requirements, nil
+}
+
+// validateCountryCode normalizes an ISO 3166-1 alpha-2 country code such as
+// "de" to DE
+func validateCountryCode(countryCode string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(countryCode))
+	if len(normalized) != 2 || normalized[0] < 'A' || normalized[0] > 'Z' || normalized[1] < 'A' || normalized[1] > 'Z' {
+		return "", fmt.Errorf("invalid countryCode %q: must be an ISO 3166-1 alpha-2 code such as DE", countryCode)
+	}
+	return normalized, nil
+}
+
+// SetCertificationMaxValidity caps how many days a certification of certType
+// may be valid, from issued date to expiry date (Regulator only). Zero
+// removes the cap.
+func (s *SupplyChainContract) SetCertificationMaxValidity(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+	days int,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if days < 0 {
+		return nil, fmt.Errorf("days must be non-negative, got %d", days)
+	}
+	certType, err := s.validateCertificationType(ctx, certType)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if days == 0 {
+		delete(config.MaxValidityDays, certType)
+	} else {
+		config.MaxValidityDays[certType] = days
+	}
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetCertifierAccreditation accredits a third-party certifier MSP to issue
+// and renew the listed certification types (Regulator only). certTypesJSON
+// is a JSON array of types; an empty array withdraws the accreditation.
+// Revoking, suspending and approving certifications stay with the Regulator.
+func (s *SupplyChainContract) SetCertifierAccreditation(
+	ctx contractapi.TransactionContextInterface,
+	mspID string,
+	certTypesJSON string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(mspID, "mspID"); err != nil {
+		return nil, err
+	}
+	if mspID == RegulatorOrgMSP || mspID == AdminOrgMSP {
+		return nil, fmt.Errorf("%s can issue every certification type and cannot be accredited", mspID)
+	}
+
+	var certTypes []string
+	if err := json.Unmarshal([]byte(certTypesJSON), &certTypes); err != nil {
+		return nil, fmt.Errorf("invalid certTypes JSON: %v", err)
+	}
+	accredited := []string{}
+	seen := map[string]bool{}
+	for _, certType := range certTypes {
+		normalized, err := s.validateCertificationType(ctx, certType)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			accredited = append(accredited, normalized)
+		}
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(accredited) == 0 {
+		delete(config.CertifierAccreditations, mspID)
+	} else {
+		config.CertifierAccreditations[mspID] = accredited
+	}
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetCertificateNumberPrefix sets the prefix of generated certification IDs,
+// for example "VN" for VN-HAL-2025-000123 (Regulator only). The prefix is
+// upper-cased and must be 1 to 10 letters or digits. Each prefix starts its
+// own number series.
+func (s *SupplyChainContract) SetCertificateNumberPrefix(
+	ctx contractapi.TransactionContextInterface,
+	prefix string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	prefix = strings.ToUpper(prefix)
+	if len(prefix) == 0 || len(prefix) > 10 {
+		return nil, fmt.Errorf("prefix must be 1 to 10 characters, got %q", prefix)
+	}
+	for _, r := range prefix {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return nil, fmt.Errorf("prefix must contain only letters and digits, got %q", prefix)
+		}
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.CertificateNumberPrefix = prefix
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetBatchTokenSecret sets the secret batch label verification tokens are
+// derived from (Admin only). Changing it invalidates every label printed
+// with the old secret.
+//
+// The secret is passed in the transient map under
+// BatchTokenSecretTransientKey, never as an argument, and stored in
+// BatchTokenSecretCollection, so only its hash reaches the block. Tokens can
+// only be checked on peers of the collection's member organizations.
+func (s *SupplyChainContract) SetBatchTokenSecret(
+	ctx contractapi.TransactionContextInterface,
+) error {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
+		return err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to read transient data: %v", err)
+	}
+	secret, ok := transient[BatchTokenSecretTransientKey]
+	if !ok {
+		return fmt.Errorf("secret must be passed in the transient map under %q", BatchTokenSecretTransientKey)
+	}
+	if len(secret) < MinBatchTokenSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", MinBatchTokenSecretLength)
+	}
+
+	if err := ctx.GetStub().PutPrivateData(BatchTokenSecretCollection, BatchTokenSecretKey, secret); err != nil {
+		return fmt.Errorf("failed to save batch token secret: %v", err)
+	}
+	return nil
+}
+
+// AddCertificationType adds a certification type to the allowed list (Admin only)
+func (s *SupplyChainContract) AddCertificationType(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(certType, "certType"); err != nil {
+		return nil, err
+	}
+	certType = normalizeCertificationType(certType)
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, known := range config.CertificationTypes {
+		if known == certType {
+			return nil, fmt.Errorf("certification type %s already exists", certType)
+		}
+	}
+	config.CertificationTypes = append(config.CertificationTypes, certType)
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// normalizeCertificationType upper-cases a certification type and joins its
+// words with underscores, so "Cold chain compliant" becomes COLD_CHAIN_COMPLIANT
+func normalizeCertificationType(certType string) string {
+	words := strings.FieldsFunc(strings.ToUpper(certType), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	})
+	return strings.Join(words, "_")
+}
+
+// validateCertificationType normalizes certType and checks it is in the
+// allowed list, returning the normalized value
+func (s *SupplyChainContract) validateCertificationType(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+) (string, error) {
+	normalized := normalizeCertificationType(certType)
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, known := range config.CertificationTypes {
+		if known == normalized {
+			return normalized, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown certification type %q, allowed: %s", certType, strings.Join(config.CertificationTypes, ", "))
+}
+
+// AddRegulatoryRecordType adds a regulatory record type to the allowed list (Admin only)
+func (s *SupplyChainContract) AddRegulatoryRecordType(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(recordType, "recordType"); err != nil {
+		return nil, err
+	}
+	recordType = normalizeRegulatoryRecordType(recordType)
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, known := range config.RecordTypes {
+		if known == recordType {
+			return nil, fmt.Errorf("regulatory record type %s already exists", recordType)
+		}
+	}
+	config.RecordTypes = append(config.RecordTypes, recordType)
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// RemoveRegulatoryRecordType removes a regulatory record type from the
+// allowed list (Admin only), along with any details schema. Existing records
+// of the type are kept, but new ones can no longer be created. The last type
+// cannot be removed.
+func (s *SupplyChainContract) RemoveRegulatoryRecordType(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
+		return nil, err
+	}
+
+	recordType, err := s.validateRegulatoryRecordType(ctx, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.RecordTypes) == 1 {
+		return nil, fmt.Errorf("cannot remove %s, the only regulatory record type", recordType)
+	}
+	remaining := []string{}
+	for _, known := range config.RecordTypes {
+		if known != recordType {
+			remaining = append(remaining, known)
+		}
+	}
+	config.RecordTypes = remaining
+	delete(config.RecordTypeDetailKeys, recordType)
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetRegulatorySampleRequirement sets whether records of recordType need at
+// least one passing regulatory sample before they can be approved, on every
+// path through checkRegulatoryApproval (Regulator only)
+func (s *SupplyChainContract) SetRegulatorySampleRequirement(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+	required bool,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	recordType, err := s.validateRegulatoryRecordType(ctx, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remaining := []string{}
+	for _, known := range config.SampleRequiredRecordTypes {
+		if known != recordType {
+			remaining = append(remaining, known)
+		}
+	}
+	if required {
+		remaining = append(remaining, recordType)
+	}
+	config.SampleRequiredRecordTypes = remaining
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetRegulatoryRecordDetailSchema declares the keys the details of
+// recordType records must hold (Admin only). requiredKeysJSON is a JSON array
+// of key names. Once set, CreateRegulatoryRecord and UpdateRegulatoryDetails
+// accept only a JSON object with every key present and non-empty. An empty
+// array removes the schema and the type takes free text again.
+func (s *SupplyChainContract) SetRegulatoryRecordDetailSchema(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+	requiredKeysJSON string,
+) (*SystemConfigAsset, error) {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
+		return nil, err
+	}
+
+	recordType, err := s.validateRegulatoryRecordType(ctx, recordType)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := json.Unmarshal([]byte(requiredKeysJSON), &keys); err != nil {
+		return nil, fmt.Errorf("invalid requiredKeys JSON: %v", err)
+	}
+	required := []string{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if err := s.ValidateNonEmptyString(key, "requiredKeys entry"); err != nil {
+			return nil, err
+		}
+		if !seen[key] {
+			seen[key] = true
+			required = append(required, key)
+		}
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(required) == 0 {
+		delete(config.RecordTypeDetailKeys, recordType)
+	} else {
+		config.RecordTypeDetailKeys[recordType] = required
+	}
+
+	if err := s.putSystemConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// validateRegulatoryDetails checks details against MaxRegulatoryDetailsLength
+// and, when recordType declares a schema, checks details is a JSON object
+// holding every required key with a non-empty value
+func (s *SupplyChainContract) validateRegulatoryDetails(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+	details string,
+) error {
+	if len(details) > MaxRegulatoryDetailsLength {
+		return fmt.Errorf("details must be at most %d bytes, got %d", MaxRegulatoryDetailsLength, len(details))
+	}
+
+	keys, err := s.getRegulatoryDetailKeys(ctx, recordType)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(details), &fields); err != nil || fields == nil {
+		return fmt.Errorf("details of %s records must be a JSON object with keys: %s", normalizeRegulatoryRecordType(recordType), strings.Join(keys, ", "))
+	}
+	missing := []string{}
+	for _, key := range keys {
+		if detailValueEmpty(fields[key]) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("details of %s records are missing required keys: %s", normalizeRegulatoryRecordType(recordType), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// getRegulatoryDetailKeys returns the keys recordType's details must hold,
+// or nil when the type takes free text
+func (s *SupplyChainContract) getRegulatoryDetailKeys(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+) ([]string, error) {
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config.RecordTypeDetailKeys[normalizeRegulatoryRecordType(recordType)], nil
+}
+
+// detailValueEmpty reports whether a decoded JSON value is missing, null, a
+// blank string or an empty array or object
+func detailValueEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// normalizeRegulatoryRecordType normalizes a record type the same way as
+// certification types, so "export-permit" becomes EXPORT_PERMIT
+func normalizeRegulatoryRecordType(recordType string) string {
+	return normalizeCertificationType(recordType)
+}
+
+// validateRegulatoryRecordType normalizes recordType and checks it is in the
+// allowed list, returning the normalized value
+func (s *SupplyChainContract) validateRegulatoryRecordType(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+) (string, error) {
+	normalized := normalizeRegulatoryRecordType(recordType)
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, known := range config.RecordTypes {
+		if known == normalized {
+			return normalized, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown regulatory record type %q, allowed: %s", recordType, strings.Join(config.RecordTypes, ", "))
+}
+
+// GetTransitionRules returns the effective status transitions: the
+// compile-time validStatusTransitions overlaid with any rules stored on the ledger
+func (s *SupplyChainContract) GetTransitionRules(
+	ctx contractapi.TransactionContextInterface,
+) (map[string][]string, error) {
+	rules := map[string][]string{}
+	for from, to := range validStatusTransitions {
+		rules[from] = to
+	}
+
+	rulesBytes, err := ctx.GetStub().GetState(TransitionRulesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transition rules: %v", err)
+	}
+	if rulesBytes == nil {
+		return rules, nil
+	}
+
+	var stored TransitionRulesAsset
+	if err := json.Unmarshal(rulesBytes, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transition rules: %v", err)
+	}
+	for from, to := range stored.Rules {
+		rules[from] = to
+	}
+
+	return rules, nil
+}
+
+// SetTransitionRule stores the allowed next statuses for fromStatus (Admin
+// only), letting operators change the workflow without a chaincode upgrade.
+// toStatusesJSON is a JSON array; an empty array makes fromStatus terminal.
+func (s *SupplyChainContract) SetTransitionRule(
+	ctx contractapi.TransactionContextInterface,
+	fromStatus string,
+	toStatusesJSON string,
+) (*TransitionRulesAsset, error) {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(fromStatus, "fromStatus"); err != nil {
+		return nil, err
+	}
+	var toStatuses []string
+	if err := json.Unmarshal([]byte(toStatusesJSON), &toStatuses); err != nil {
+		return nil, fmt.Errorf("invalid toStatuses JSON: %v", err)
+	}
+	if toStatuses == nil {
+		toStatuses = []string{}
+	}
+	for _, status := range toStatuses {
+		if err := s.ValidateNonEmptyString(status, "toStatus"); err != nil {
+			return nil, err
+		}
+	}
+
+	stored := TransitionRulesAsset{DocType: "TransitionRulesAsset"}
+	rulesBytes, err := ctx.GetStub().GetState(TransitionRulesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transition rules: %v", err)
+	}
+	if rulesBytes != nil {
+		if err := json.Unmarshal(rulesBytes, &stored); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transition rules: %v", err)
+		}
+	}
+	if stored.Rules == nil {
+		stored.Rules = map[string][]string{}
+	}
+	stored.Rules[fromStatus] = toStatuses
+	stored.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	rulesBytes, err = json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transition rules: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(TransitionRulesKey, rulesBytes); err != nil {
+		return nil, fmt.Errorf("failed to save transition rules: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"from_status": fromStatus, "to_statuses": toStatuses}
+	s.emitEvent(ctx, "TransitionRuleUpdated", eventPayload, &stored)
+
+	return &stored, nil
+}
+
+// gradeForQualityScore maps a quality score onto the configured grade bands
+func gradeForQualityScore(bands []QualityGradeBand, score float64) string {
+	for _, band := range bands {
+		if score >= band.MinScore {
+			return band.Grade
+		}
+	}
+	return QualityGradeReject
+}
+
+// ============================================================================
+// PRODUCT FUNCTIONS
+// ============================================================================
+
+// CreateProduct creates a new product type (Admin or Regulator)
+func (s *SupplyChainContract) CreateProduct(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+	name string,
+	description string,
+) (*ProductAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	product := ProductAsset{
+		DocType:   "ProductAsset",
+		ProductID: productID,
+		Name:      name,
+		Desc:      description,
+		IsActive:  true,
+		CreatedAt: s.GetTxTimestamp(ctx),
+	}
+
+	// Validation
+	if err := validationError(validateProductAsset(&product)); err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "ProductAsset", productID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("product %s already exists", productID)
+	}
+
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %v", err)
+	}
+
+	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
+		return nil, fmt.Errorf("failed to save product: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"product_id": productID}
+	s.emitEvent(ctx, "ProductCreated", eventPayload, &product)
+
+	return &product, nil
+}
+
+// GetProduct retrieves a product by ID
+func (s *SupplyChainContract) GetProduct(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+) (*ProductAsset, error) {
+	product, found, err := s.TryGetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("product %s not found", productID)
+	}
+
+	return product, nil
+}
+
+// TryGetProduct retrieves a product by ID, reporting found=false with a nil error
+// when it does not exist. Errors are reserved for ledger and decode failures.
+func (s *SupplyChainContract) TryGetProduct(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+) (*ProductAsset, bool, error) {
+	if err := s.ValidateNonEmptyString(productID, "productID"); err != nil {
+		return nil, false, err
+	}
+
+	productBytes, err := ctx.GetStub().GetState(productID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read product: %v", err)
+	}
+	if productBytes == nil {
+		return nil, false, nil
+	}
+
+	var product ProductAsset
+	marshalErr := json.Unmarshal(productBytes, &product)
+	if marshalErr != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal product: %v", marshalErr)
+	}
+
+	return &product, true, nil
+}
+
+// SetProductProcessingClearance sets the regulatory record type a batch of
+// this product must hold APPROVED and unexpired before it can be processed
+// (Regulator only). The type must be one of the allowed regulatory record
+// types. An empty recordType removes the requirement.
+func (s *SupplyChainContract) SetProductProcessingClearance(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+	recordType string,
+) (*ProductAsset, error) {
+	// Authorization check
+	product, err := s.authorizeAndLoadProduct(ctx, RegulatorOrgMSP, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(recordType) != "" {
+		if recordType, err = s.validateRegulatoryRecordType(ctx, recordType); err != nil {
+			return nil, err
+		}
+	}
+
+	product.ProcessingClearance = strings.TrimSpace(recordType)
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %v", err)
+	}
+
+	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
+		return nil, fmt.Errorf("failed to update product: %v", err)
+	}
+
+	return product, nil
+}
+
+// SetProductCompletionClearance sets the regulatory record type a batch of
+// this product must hold APPROVED and unexpired before it can be completed,
+// such as a pre-harvest clearance (Regulator only). The type must be one of
+// the allowed regulatory record types. An empty recordType removes the
+// requirement.
+func (s *SupplyChainContract) SetProductCompletionClearance(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+	recordType string,
+) (*ProductAsset, error) {
+	// Authorization check
+	product, err := s.authorizeAndLoadProduct(ctx, RegulatorOrgMSP, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(recordType) != "" {
+		if recordType, err = s.validateRegulatoryRecordType(ctx, recordType); err != nil {
+			return nil, err
+		}
+	}
+
+	product.CompletionClearance = strings.TrimSpace(recordType)
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %v", err)
+	}
+
+	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
+		return nil, fmt.Errorf("failed to update product: %v", err)
+	}
+
+	return product, nil
+}
+
+// SetProductProcessingWindow sets how long a batch of this product is
+// expected to take from its start date, as an ISO 8601 duration such as P3D
+// (Regulator only). Batches created without an expected end date are
+// reported overdue once the window has passed. An empty window removes it.
+func (s *SupplyChainContract) SetProductProcessingWindow(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+	window string,
+) (*ProductAsset, error) {
+	// Authorization check
+	product, err := s.authorizeAndLoadProduct(ctx, RegulatorOrgMSP, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	window = strings.ToUpper(strings.TrimSpace(window))
+	if window != "" {
+		if err := s.ValidateISODuration(window, "window"); err != nil {
+			return nil, err
+		}
+		if duration, _ := parseISODuration(window); duration.isZero() {
+			return nil, fmt.Errorf("window must be longer than zero, got %s", window)
+		}
+	}
+
+	product.ExpectedProcessingWindow = window
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %v", err)
+	}
+
+	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
+		return nil, fmt.Errorf("failed to update product: %v", err)
+	}
+
+	return product, nil
+}
+
+// SetProductRequiredCertifications sets the certification types this
+// product's batches must hold in addition to those set with
+// SetRequiredCertifications (Regulator only). certTypesJSON is a JSON array
+// of types; an empty array removes the product's own requirement.
+func (s *SupplyChainContract) SetProductRequiredCertifications(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+	certTypesJSON string,
+) (*ProductAsset, error) {
+	// Authorization check
+	product, err := s.authorizeAndLoadProduct(ctx, RegulatorOrgMSP, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var certTypes []string
+	if err := json.Unmarshal([]byte(certTypesJSON), &certTypes); err != nil {
+		return nil, fmt.Errorf("invalid certTypes JSON: %v", err)
+	}
+	required := []string{}
+	seen := map[string]bool{}
+	for _, certType := range certTypes {
+		normalized, err := s.validateCertificationType(ctx, certType)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			required = append(required, normalized)
+		}
+	}
+
+	product.RequiredCertTypes = required
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %v", err)
+	}
+
+	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
+		return nil, fmt.Errorf("failed to update product: %v", err)
+	}
+
+	return product, nil
+}
+
+// DeactivateProduct deactivates a product, recording why and when
+func (s *SupplyChainContract) DeactivateProduct(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+	reason string,
+) (*ProductAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
+		return nil, err
+	}
+
+	product, err := s.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.IsActive {
+		return nil, fmt.Errorf("product %s is already inactive", productID)
+	}
+
+	product.IsActive = false
+	product.DeactivationReason = reason
+	product.DeactivatedAt = s.GetTxTimestamp(ctx)
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %v", err)
+	}
+
+	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
+		return nil, fmt.Errorf("failed to update product: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"product_id": productID, "reason": reason}
+	s.emitEvent(ctx, "ProductDeactivated", eventPayload, product)
+
+	return product, nil
+}
+
+// ReactivateProduct reactivates a deactivated product, clearing its deactivation details
+func (s *SupplyChainContract) ReactivateProduct(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+) (*ProductAsset, error) {
+	// Authorization check
+	product, err := s.authorizeAndLoadProduct(ctx, RegulatorOrgMSP, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.IsActive {
+		return nil, fmt.Errorf("product %s is already active", productID)
+	}
+
+	product.IsActive = true
+	product.DeactivationReason = ""
+	product.DeactivatedAt = ""
+	productBytes, err := json.Marshal(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %v", err)
+	}
+
+	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
+		return nil, fmt.Errorf("failed to update product: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"product_id": productID}
+	s.emitEvent(ctx, "ProductReactivated", eventPayload, product)
+
+	return product, nil
+}
+
+// GetProductWithBatchSummary retrieves a product together with its batch
+// count, batch counts by status and total quantity across its batches
+func (s *SupplyChainContract) GetProductWithBatchSummary(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+) (*ProductWithBatchSummary, error) {
+	product, err := s.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType":    "BatchAsset",
+		"product_id": productID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ProductWithBatchSummary{
+		Product:         product,
+		BatchCount:      len(batches),
+		BatchesByStatus: map[string]int{},
+	}
+	for _, status := range batchStatuses {
+		summary.BatchesByStatus[status] = 0
+	}
+	for _, batch := range batches {
+		summary.BatchesByStatus[batch.Status]++
+		summary.TotalQuantity += batch.Quantity
+	}
+
+	return summary, nil
+}
+
+// SearchProducts finds products whose name contains queryText, ignoring
+// case, for type-ahead search. queryText must be at least 2 characters.
+// Matches are sorted by name and capped at ProductSearchLimit; inactive
+// products are included and flagged by is_active.
+func (s *SupplyChainContract) SearchProducts(
+	ctx contractapi.TransactionContextInterface,
+	queryText string,
+) ([]*ProductAsset, error) {
+	queryText = strings.TrimSpace(queryText)
+	if len([]rune(queryText)) < 2 {
+		return nil, fmt.Errorf("queryText must be at least 2 characters, got %q", queryText)
+	}
+
+	products, err := queryAssets[ProductAsset](ctx, map[string]interface{}{
+		"docType": "ProductAsset",
+		"name":    map[string]interface{}{"$regex": "(?i)" + regexp.QuoteMeta(queryText)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		ni, nj := strings.ToLower(products[i].Name), strings.ToLower(products[j].Name)
+		if ni != nj {
+			return ni < nj
+		}
+		return products[i].ProductID < products[j].ProductID
+	})
+	if len(products) > ProductSearchLimit {
+		products = products[:ProductSearchLimit]
+	}
+
+	return products, nil
+}
+
+// ============================================================================
+// BATCH FUNCTIONS
+// ============================================================================
+
+// CreateBatch creates a new batch (Farmer)
+func (s *SupplyChainContract) CreateBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	productID string,
+	farmerID string,
+	batchNumber string,
+	quantity int,
+	startDate string,
+	expectedEndDate string,
+	location string,
+	qrCode string,
+	notes string,
+) (*BatchAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	batch := BatchAsset{
+		DocType:         "BatchAsset",
+		BatchID:         batchID,
+		ProductID:       productID,
+		FarmerID:        farmerID,
+		BatchNumber:     batchNumber,
+		Status:          "CREATED",
+		Quantity:        quantity,
+		StartDate:       startDate,
+		ExpectedEndDate: expectedEndDate,
+		Location:        location,
+		QRCode:          qrCode,
+		Notes:           notes,
+		CreatedAt:       s.GetTxTimestamp(ctx),
+		UpdatedAt:       s.GetTxTimestamp(ctx),
+	}
+
+	// Validation
+	if err := validationError(validateBatchAsset(&batch)); err != nil {
+		return nil, err
+	}
+
+	// Check product exists
+	_, err := s.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("product %s does not exist", productID)
+	}
+
+	// Check batch ID uniqueness
+	var exists bool
+	exists, err = s.AssetExists(ctx, "BatchAsset", batchID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("batch %s already exists", batchID)
+	}
+
+	// For batch_number uniqueness, create a secondary index key
+	// In production, use CouchDB rich queries; for now, check a composite key
+	batchNumberKey := fmt.Sprintf("batch_number~%s", batchNumber)
+	existingBatchNum, _ := ctx.GetStub().GetState(batchNumberKey)
+	if existingBatchNum != nil {
+		return nil, fmt.Errorf("batch number %s already exists", batchNumber)
+	}
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	putErr := ctx.GetStub().PutState(batchID, batchBytes)
+	if putErr != nil {
+		return nil, fmt.Errorf("failed to save batch: %v", putErr)
+	}
+
+	// Store batch number index for uniqueness checking
+	if err = ctx.GetStub().PutState(batchNumberKey, []byte(batchID)); err != nil {
+		return nil, fmt.Errorf("failed to save batch number index: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"batch_id": batchID, "farmer_id": farmerID}
+	s.emitEvent(ctx, "BatchCreated", eventPayload, &batch)
+
+	return &batch, nil
+}
+
+// GetBatch retrieves a batch by ID
+func (s *SupplyChainContract) GetBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchAsset, error) {
+	batch, found, err := s.TryGetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("batch %s not found", batchID)
+	}
+
+	return batch, nil
+}
+
+// TryGetBatch retrieves a batch by ID, reporting found=false with a nil error
+// when it does not exist. Errors are reserved for ledger and decode failures.
+func (s *SupplyChainContract) TryGetBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchAsset, bool, error) {
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return nil, false, err
+	}
+
+	batchBytes, err := ctx.GetStub().GetState(batchID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read batch: %v", err)
+	}
+	if batchBytes == nil {
+		return nil, false, nil
+	}
+
+	var batch BatchAsset
+	marshalErr := json.Unmarshal(batchBytes, &batch)
+	if marshalErr != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal batch: %v", marshalErr)
+	}
+
+	return &batch, true, nil
+}
+
+// GetBatchesByDateAndStatus retrieves one page of batches with the given
+// status whose start_date falls within [startDate, endDate]
+func (s *SupplyChainContract) GetBatchesByDateAndStatus(
+	ctx contractapi.TransactionContextInterface,
+	status string,
+	startDate string,
+	endDate string,
+	pageSize int,
+	bookmark string,
+) (*BatchPage, error) {
+	if err := s.ValidateBatchStatus(status); err != nil {
+		return nil, err
+	}
+	if err := s.ValidatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+	startDateRange, err := dateRangeSelector(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	batches, nextBookmark, err := queryAssetsWithPagination[BatchAsset](ctx, map[string]interface{}{
+		"docType":    "BatchAsset",
+		"status":     status,
+		"start_date": startDateRange,
+	}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchPage{
+		Batches:  batches,
+		Bookmark: nextBookmark,
+		Count:    len(batches),
+	}, nil
+}
+
+// GetBatchCurrentQuantity returns the batch's live quantity: the created
+// quantity less the units reported by MORTALITY lifecycle events
+func (s *SupplyChainContract) GetBatchCurrentQuantity(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (int, error) {
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return 0, err
+	}
+
+	events, err := s.GetBatchLifecycleEvents(ctx, batchID)
+	if err != nil {
+		return 0, err
+	}
+
+	quantity := batch.Quantity
+	for _, event := range events {
+		if strings.EqualFold(event.EventType, "MORTALITY") {
+			quantity -= event.QuantityAffected
+		}
+	}
+	if quantity < 0 {
+		quantity = 0
+	}
+
+	return quantity, nil
+}
+
+// GetBatchByBatchNumber retrieves a batch by its human-facing batch number
+func (s *SupplyChainContract) GetBatchByBatchNumber(
+	ctx contractapi.TransactionContextInterface,
+	batchNumber string,
+) (*BatchAsset, error) {
+	if err := s.ValidateNonEmptyString(batchNumber, "batchNumber"); err != nil {
+		return nil, err
+	}
+
+	// Resolve through the index maintained by CreateBatch
+	batchNumberKey := fmt.Sprintf("batch_number~%s", batchNumber)
+	batchIDBytes, err := ctx.GetStub().GetState(batchNumberKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch number index: %v", err)
+	}
+	if batchIDBytes == nil {
+		return nil, fmt.Errorf("batch number %s not found", batchNumber)
+	}
+
+	return s.GetBatch(ctx, string(batchIDBytes))
+}
+
+// UpdateBatchStatus updates batch status with validation
+func (s *SupplyChainContract) UpdateBatchStatus(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	newStatus string,
+) (*BatchAsset, error) {
+	// Authorization check
+	batch, err := s.authorizeAndLoadBatch(ctx, MinFarmOrgMSP, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate transition
+	if err := s.ValidateStatusTransition(ctx, batch.Status, newStatus); err != nil {
+		return nil, err
+	}
+
+	batch.Status = newStatus
+	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batchID, batchBytes); err != nil {
+		return nil, fmt.Errorf("failed to update batch: %v", err)
+	}
+
+	return batch, nil
+}
+
+// CompleteBatch completes a batch. When the batch's product has a completion
+// clearance, the batch must hold it APPROVED and unexpired; the Regulator may
+// complete without it on the farmer's behalf by giving an overrideReason,
+// which is recorded on the batch.
+func (s *SupplyChainContract) CompleteBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	actualEndDate string,
+	overrideReason string,
+) (*BatchAsset, error) {
+	// Authorization check (Farm, or the Regulator on the farmer's behalf)
+	if err := s.authorizeAnyMSP(ctx, MinFarmOrgMSP, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate transition to COMPLETED
+	if err := s.ValidateStatusTransition(ctx, batch.Status, "COMPLETED"); err != nil {
+		return nil, err
+	}
+
+	// Check the batch holds its product's completion clearance
+	if err := s.checkCompletionClearance(ctx, batch, overrideReason); err != nil {
+		return nil, err
+	}
+
+	batch.Status = "COMPLETED"
+	batch.ActualEndDate = actualEndDate
+	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batchID, batchBytes); err != nil {
+		return nil, fmt.Errorf("failed to complete batch: %v", err)
+	}
+
+	return batch, nil
+}
+
+// placeBatchOnHold moves a batch to ON_HOLD, remembering its status so
+// ReleaseBatchHold can restore it. The caller is responsible for authorization.
+func (s *SupplyChainContract) placeBatchOnHold(
+	ctx contractapi.TransactionContextInterface,
+	batch *BatchAsset,
+	reason string,
+) error {
+	if batch.Status == "ON_HOLD" {
+		return nil
+	}
+
+	batch.StatusBeforeHold = batch.Status
+	batch.Status = "ON_HOLD"
+	batch.HoldReason = reason
+	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batch.BatchID, batchBytes); err != nil {
+		return fmt.Errorf("failed to place batch on hold: %v", err)
+	}
+	return nil
+}
+
+// ReleaseBatchHold returns an ON_HOLD batch to the status it had before the hold (Regulator only)
+func (s *SupplyChainContract) ReleaseBatchHold(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	resolution string,
+) (*BatchAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(resolution, "resolution"); err != nil {
+		return nil, err
+	}
+
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != "ON_HOLD" {
+		return nil, fmt.Errorf("batch %s is %s, not ON_HOLD", batchID, batch.Status)
+	}
+
+	batch.Status = batch.StatusBeforeHold
+	batch.StatusBeforeHold = ""
+	batch.HoldReason = ""
+	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batchID, batchBytes); err != nil {
+		return nil, fmt.Errorf("failed to release batch hold: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"batch_id":   batchID,
+		"old_status": "ON_HOLD",
+		"new_status": batch.Status,
+		"resolution": resolution,
+	}
+	s.emitEvent(ctx, "BatchStatusChanged", eventPayload, batch)
+
+	return batch, nil
+}
+
+// SetBatchRegulatoryHold sets whether a batch must hold an approved
+// regulatory record before it can ship (Regulator only)
+func (s *SupplyChainContract) SetBatchRegulatoryHold(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	requiresApproval bool,
+) (*BatchAsset, error) {
+	// Authorization check (Regulator only)
+	batch, err := s.authorizeAndLoadBatch(ctx, RegulatorOrgMSP, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.RequiresRegulatoryApproval = requiresApproval
+	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batchID, batchBytes); err != nil {
+		return nil, fmt.Errorf("failed to update batch: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"batch_id":                     batchID,
+		"requires_regulatory_approval": requiresApproval,
+	}
+	s.emitEvent(ctx, "BatchRegulatoryHoldChanged", eventPayload, batch)
+
+	return batch, nil
+}
+
+// SetFarmerDisplayName sets the name shown for a farmer on public views such
+// as the batch passport. farmerID is bound to the caller as in
+// resolveActingOfficer: farmers set their own name, and an admin names the
+// farmer it acts for.
+func (s *SupplyChainContract) SetFarmerDisplayName(
+	ctx contractapi.TransactionContextInterface,
+	farmerID string,
+	displayName string,
+) (*FarmerProfileAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(displayName, "displayName"); err != nil {
+		return nil, err
+	}
+	farmer, err := s.resolveActingOfficer(ctx, farmerID, "farmerID", "farmer")
+	if err != nil {
+		return nil, err
+	}
+
+	profile := FarmerProfileAsset{
+		DocType:     "FarmerProfileAsset",
+		FarmerID:    farmer.OfficerID,
+		DisplayName: displayName,
+		UpdatedAt:   s.GetTxTimestamp(ctx),
+	}
+
+	profileBytes, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal farmer profile: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(fmt.Sprintf("farmer~%s", profile.FarmerID), profileBytes); err != nil {
+		return nil, fmt.Errorf("failed to save farmer profile: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"farmer_id":    profile.FarmerID,
+		"display_name": displayName,
+	}
+	if farmer.OnBehalfBy != "" {
+		eventPayload["on_behalf_by"] = farmer.OnBehalfBy
+	}
+	s.emitEvent(ctx, "FarmerDisplayNameSet", eventPayload, &profile)
+
+	return &profile, nil
+}
+
+// getFarmerDisplayName returns a farmer's display name, or empty when none is set
+func (s *SupplyChainContract) getFarmerDisplayName(
+	ctx contractapi.TransactionContextInterface,
+	farmerID string,
+) (string, error) {
+	profileBytes, err := ctx.GetStub().GetState(fmt.Sprintf("farmer~%s", farmerID))
+	if err != nil {
+		return "", fmt.Errorf("failed to read farmer profile: %v", err)
+	}
+	if profileBytes == nil {
+		return "", nil
+	}
+
+	var profile FarmerProfileAsset
+	if err := json.Unmarshal(profileBytes, &profile); err != nil {
+		return "", fmt.Errorf("failed to unmarshal farmer profile: %v", err)
+	}
+	return profile.DisplayName, nil
+}
+
+// GetBatchesByFarmer retrieves all batches for a farmer
+func (s *SupplyChainContract) GetBatchesByFarmer(
+	ctx contractapi.TransactionContextInterface,
+	farmerID string,
+) ([]*BatchAsset, error) {
+	if err := s.ValidateNonEmptyString(farmerID, "farmerID"); err != nil {
+		return nil, err
+	}
+
+	// Note: In production, use CouchDB rich queries via GetQueryResultsForQueryString
+	// For now, return empty list (full implementation requires RichQuery support)
+	return []*BatchAsset{}, nil
+}
+
+// GetTopFarmersByVolume returns up to limit farmers ranked by the total
+// quantity of their batches, largest first, ties broken by farmer ID.
+// limit must be between 1 and MaxPageSize. SPLIT and MERGED batches are
+// skipped since their quantity lives on in the batches made from them, and
+// batches without a farmer are not ranked.
+func (s *SupplyChainContract) GetTopFarmersByVolume(
+	ctx contractapi.TransactionContextInterface,
+	limit int,
+) ([]*FarmerVolume, error) {
+	if err := s.ValidatePageSize(limit); err != nil {
+		return nil, err
+	}
+
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType": "BatchAsset",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byFarmer := map[string]*FarmerVolume{}
+	for _, batch := range batches {
+		if batch.FarmerID == "" || batch.Status == "SPLIT" || batch.Status == "MERGED" {
+			continue
+		}
+		volume, ok := byFarmer[batch.FarmerID]
+		if !ok {
+			volume = &FarmerVolume{FarmerID: batch.FarmerID}
+			byFarmer[batch.FarmerID] = volume
+		}
+		volume.BatchCount++
+		volume.TotalQuantity += batch.Quantity
+	}
+
+	ranked := make([]*FarmerVolume, 0, len(byFarmer))
+	for _, volume := range byFarmer {
+		ranked = append(ranked, volume)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].TotalQuantity != ranked[j].TotalQuantity {
+			return ranked[i].TotalQuantity > ranked[j].TotalQuantity
+		}
+		return ranked[i].FarmerID < ranked[j].FarmerID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	for _, volume := range ranked {
+		volume.DisplayName, err = s.getFarmerDisplayName(ctx, volume.FarmerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ranked, nil
+}
+
+// GetStaleBatches retrieves batches still in production (CREATED, IN_PROGRESS
+// or ON_HOLD) whose expected end date plus graceDays is before asOfDate,
+// longest overdue first. A batch without an expected end date is expected to
+// end when its product's ExpectedProcessingWindow has passed since its start
+// date; batches with neither are skipped.
+func (s *SupplyChainContract) GetStaleBatches(
+	ctx contractapi.TransactionContextInterface,
+	asOfDate string,
+	graceDays int,
+) ([]*BatchAsset, error) {
+	asOf, err := parseLedgerDate(asOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid asOfDate %q: %v", asOfDate, err)
+	}
+	if graceDays < 0 {
+		return nil, fmt.Errorf("graceDays must be non-negative, got %d", graceDays)
+	}
+
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType": "BatchAsset",
+		"status":  map[string]interface{}{"$in": []string{"CREATED", "IN_PROGRESS", "ON_HOLD"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	products := map[string]*ProductAsset{}
+	stale := []*BatchAsset{}
+	expectedEnds := map[string]time.Time{}
+	for _, batch := range batches {
+		product, loaded := products[batch.ProductID]
+		if !loaded && batch.ExpectedEndDate == "" {
+			if product, _, err = s.TryGetProduct(ctx, batch.ProductID); err != nil {
+				return nil, err
+			}
+			products[batch.ProductID] = product
+		}
+		expectedEnd, ok := batchExpectedEnd(batch, product)
+		if !ok || !expectedEnd.AddDate(0, 0, graceDays).Before(asOf) {
+			continue
+		}
+		stale = append(stale, batch)
+		expectedEnds[batch.BatchID] = expectedEnd
+	}
+
+	sort.SliceStable(stale, func(i, j int) bool {
+		return expectedEnds[stale[i].BatchID].Before(expectedEnds[stale[j].BatchID])
+	})
+
+	return stale, nil
+}
+
+// GetUnshippedCompletedBatches retrieves COMPLETED batches with no
+// non-cancelled transport manifest, oldest completion first: the finished
+// goods still on site and ready to ship
+func (s *SupplyChainContract) GetUnshippedCompletedBatches(
+	ctx contractapi.TransactionContextInterface,
+) ([]*BatchAsset, error) {
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType": "BatchAsset",
+		"status":  "COMPLETED",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unshipped := []*BatchAsset{}
+	for _, batch := range batches {
+		transports, err := s.GetTransportsByBatch(ctx, batch.BatchID)
+		if err != nil {
+			return nil, err
+		}
+		shipped := false
+		for _, transport := range transports {
+			if transport.Status != "CANCELLED" {
+				shipped = true
+				break
+			}
+		}
+		if !shipped {
+			unshipped = append(unshipped, batch)
+		}
+	}
+
+	sort.SliceStable(unshipped, func(i, j int) bool {
+		return unshipped[i].ActualEndDate < unshipped[j].ActualEndDate
+	})
+
+	return unshipped, nil
+}
+
+// GetBatchAlerts lists the warnings for a batch as of asOfDate, CRITICAL
+// before WARNING. It reports open recalls (RECALL_OPEN), rejected regulatory
+// records awaiting resubmission (REGULATORY_REJECTED), temperature violations
+// in transport or cold storage (TEMPERATURE_VIOLATION), required
+// certification types that have expired or were never issued
+// (CERTIFICATION_EXPIRED, CERTIFICATION_MISSING), and a batch still in
+// production past its expected end date, or its product's processing window,
+// (COMPLETION_OVERDUE). A healthy batch has no alerts.
+func (s *SupplyChainContract) GetBatchAlerts(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	asOfDate string,
+) ([]*BatchAlert, error) {
+	asOf, err := parseLedgerDate(asOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid asOfDate %q: %v", asOfDate, err)
+	}
+
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Critical alerts are collected first
+	alerts := []*BatchAlert{}
+
+	recalls, err := queryAssets[RecallAsset](ctx, map[string]interface{}{
+		"docType":  "RecallAsset",
+		"batch_id": batchID,
+		"status":   "OPEN",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, recall := range recalls {
+		alerts = append(alerts, &BatchAlert{
+			Severity: "CRITICAL",
+			Code:     "RECALL_OPEN",
+			Message:  fmt.Sprintf("Recall %s is open: %s", recall.RecallID, recall.Reason),
+		})
+	}
+
+	records, err := s.GetRegulatoryRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Status != "REJECTED" {
+			continue
+		}
+		message := fmt.Sprintf("%s record %s was rejected", record.RecordType, record.RegulatoryID)
+		if record.RejectionReason != "" {
+			message += ": " + record.RejectionReason
+		}
+		alerts = append(alerts, &BatchAlert{Severity: "CRITICAL", Code: "REGULATORY_REJECTED", Message: message})
+	}
+
+	logs, err := s.getBatchTemperatureLogs(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	violations := 0
+	for _, log := range logs {
+		if log.IsViolation {
+			violations++
+		}
+	}
+	if violations > 0 {
+		alerts = append(alerts, &BatchAlert{
+			Severity: "WARNING",
+			Code:     "TEMPERATURE_VIOLATION",
+			Message:  fmt.Sprintf("%d of %d temperature readings were outside %.1f-%.1f°C", violations, len(logs), TemperatureMinSafe, TemperatureMaxSafe),
+		})
+	}
+
+	requiredTypes, err := s.getRequiredCertificationTypes(ctx, batch.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if len(requiredTypes) > 0 {
+		certifications, err := s.getAllBatchCertifications(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+		bySubject := map[string][]*CertificationAsset{}
+		for _, certification := range certifications {
+			subject := certificationSubject(certification)
+			bySubject[subject] = append(bySubject[subject], certification)
+		}
+
+		held := map[string]bool{}
+		expired := map[string]bool{}
+		for _, certification := range certifications {
+			certType := normalizeCertificationType(certification.CertType)
+			validity := certificationValidity(certification, bySubject[certificationSubject(certification)], asOf)
+			if validity.Valid {
+				held[certType] = true
+				continue
+			}
+			for _, failure := range validity.Failures {
+				if failure == "EXPIRED" {
+					expired[certType] = true
+				}
+			}
+		}
+
+		for _, certType := range requiredTypes {
+			switch {
+			case held[certType]:
+			case expired[certType]:
+				alerts = append(alerts, &BatchAlert{
+					Severity: "WARNING",
+					Code:     "CERTIFICATION_EXPIRED",
+					Message:  fmt.Sprintf("Required %s certification has expired", certType),
+				})
+			default:
+				alerts = append(alerts, &BatchAlert{
+					Severity: "WARNING",
+					Code:     "CERTIFICATION_MISSING",
+					Message:  fmt.Sprintf("Required %s certification is missing", certType),
+				})
+			}
+		}
+	}
+
+	switch batch.Status {
+	case "CREATED", "IN_PROGRESS", "ON_HOLD":
+		var product *ProductAsset
+		if batch.ExpectedEndDate == "" {
+			if product, _, err = s.TryGetProduct(ctx, batch.ProductID); err != nil {
+				return nil, err
+			}
+		}
+		if expectedEnd, ok := batchExpectedEnd(batch, product); ok && expectedEnd.Before(asOf) {
+			expectedBy := batch.ExpectedEndDate
+			if expectedBy == "" {
+				expectedBy = expectedEnd.Format(time.RFC3339)
+			}
+			alerts = append(alerts, &BatchAlert{
+				Severity: "WARNING",
+				Code:     "COMPLETION_OVERDUE",
+				Message:  fmt.Sprintf("Batch was expected to complete by %s and is still %s", expectedBy, batch.Status),
+			})
+		}
+	}
+
+	return alerts, nil
+}
+
+// SplitBatch divides a batch into child batches (Farmer). partsJSON is a list
+// of at least two {"batch_id", "batch_number", "quantity", "qr_code"} whose
+// quantities add up to the batch's current quantity. The children inherit
+// the batch's product, farmer, dates, location and status, and the batch
+// moves to the terminal SPLIT status. Each valid batch-scoped certification
+// is carried to every child as a derived certification, which is revoked,
+// suspended, expired or superseded when its source is. QR codes given for
+// the children must be unused. Batches with processing records or under an
+// active stop-sale cannot be split.
+func (s *SupplyChainContract) SplitBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	partsJSON string,
+) ([]*BatchAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	var parts []BatchSplitPart
+	if err := json.Unmarshal([]byte(partsJSON), &parts); err != nil {
+		return nil, fmt.Errorf("invalid parts JSON: %v", err)
+	}
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("a split needs at least 2 parts, got %d", len(parts))
+	}
+
+	parent, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBatchDivisible(ctx, parent); err != nil {
+		return nil, err
+	}
+	quantity, err := s.GetBatchCurrentQuantity(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	seenIDs := map[string]bool{}
+	seenNumbers := map[string]bool{}
+	seenQRCodes := map[string]bool{}
+	for _, part := range parts {
+		if err := s.ValidateNonEmptyString(part.BatchID, "batch_id"); err != nil {
+			return nil, err
+		}
+		if err := s.ValidateNonEmptyString(part.BatchNumber, "batch_number"); err != nil {
+			return nil, err
+		}
+		if err := s.ValidatePositiveInt(part.Quantity, "quantity"); err != nil {
+			return nil, err
+		}
+		if seenIDs[part.BatchID] {
+			return nil, fmt.Errorf("duplicate batch_id %s in parts", part.BatchID)
+		}
+		if seenNumbers[part.BatchNumber] {
+			return nil, fmt.Errorf("duplicate batch_number %s in parts", part.BatchNumber)
+		}
+		if part.QRCode != "" && seenQRCodes[part.QRCode] {
+			return nil, fmt.Errorf("duplicate qr_code %s in parts", part.QRCode)
+		}
+		seenIDs[part.BatchID] = true
+		seenNumbers[part.BatchNumber] = true
+		seenQRCodes[part.QRCode] = true
+		total += part.Quantity
+	}
+	if total != quantity {
+		return nil, fmt.Errorf("parts total %d units but batch %s has %d", total, batchID, quantity)
+	}
+
+	sources, err := s.getDerivableCertifications(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*BatchAsset, 0, len(parts))
+	childIDs := make([]string, 0, len(parts))
+	derivedIDs := []string{}
+	for _, part := range parts {
+		child := &BatchAsset{
+			BatchID:                    part.BatchID,
+			ProductID:                  parent.ProductID,
+			FarmerID:                   parent.FarmerID,
+			BatchNumber:                part.BatchNumber,
+			Status:                     parent.Status,
+			Quantity:                   part.Quantity,
+			StartDate:                  parent.StartDate,
+			ExpectedEndDate:            parent.ExpectedEndDate,
+			Location:                   parent.Location,
+			QRCode:                     part.QRCode,
+			Notes:                      fmt.Sprintf("split from batch %s", batchID),
+			RequiresRegulatoryApproval: parent.RequiresRegulatoryApproval,
+			ParentBatchIDs:             []string{batchID},
+		}
+		if err := s.createDerivedBatch(ctx, child); err != nil {
+			return nil, err
+		}
+
+		for _, source := range sources {
+			derived, err := s.deriveCertification(ctx, child.BatchID, []*CertificationAsset{source})
+			if err != nil {
+				return nil, err
+			}
+			derivedIDs = append(derivedIDs, derived.CertificationID)
+		}
+
+		children = append(children, child)
+		childIDs = append(childIDs, child.BatchID)
+	}
+
+	parent.Status = "SPLIT"
+	parent.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	parentBytes, err := json.Marshal(parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batchID, parentBytes); err != nil {
+		return nil, fmt.Errorf("failed to update batch: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"batch_id":                  batchID,
+		"child_batch_ids":           childIDs,
+		"derived_certification_ids": derivedIDs,
+	}
+	s.emitEvent(ctx, "BatchSplit", eventPayload, parent)
+
+	return children, nil
+}
+
+// MergeBatches combines two or more batches of the same product into a new
+// target batch (Farmer). sourceBatchIDsJSON is a JSON array of batch IDs.
+// The target's quantity is the sources' combined current quantity, its status
+// the least advanced of theirs, and its farmer and location are kept only when
+// all sources share them. The sources move to the terminal MERGED status.
+//
+// A certification type is carried to the target as a single derived
+// certification only when every source holds a valid batch-scoped
+// certification of it; the derived certification takes the earliest expiry
+// among them and is revoked, suspended, expired or superseded when any of
+// them is. A type only some sources
+// hold is flagged as a conflict rather than carried, since it does not cover
+// the whole merged quantity. Transfers and conflicts are recorded on the
+// target in a CERT_TRANSFERRED lifecycle event. Batches with processing
+// records cannot be merged, so only batch-scoped certifications are involved.
+// Batches under an active stop-sale cannot be merged either, and a non-empty
+// qrCode must be unused.
+func (s *SupplyChainContract) MergeBatches(
+	ctx contractapi.TransactionContextInterface,
+	targetBatchID string,
+	targetBatchNumber string,
+	sourceBatchIDsJSON string,
+	qrCode string,
+) (*BatchAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(targetBatchID, "targetBatchID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(targetBatchNumber, "targetBatchNumber"); err != nil {
+		return nil, err
+	}
+
+	var sourceIDs []string
+	if err := json.Unmarshal([]byte(sourceBatchIDsJSON), &sourceIDs); err != nil {
+		return nil, fmt.Errorf("invalid sourceBatchIDs JSON: %v", err)
+	}
+	if len(sourceIDs) < 2 {
+		return nil, fmt.Errorf("a merge needs at least 2 source batches, got %d", len(sourceIDs))
+	}
+
+	target := &BatchAsset{
+		BatchID:        targetBatchID,
+		BatchNumber:    targetBatchNumber,
+		QRCode:         qrCode,
+		Notes:          fmt.Sprintf("merged from batches %s", strings.Join(sourceIDs, ", ")),
+		ParentBatchIDs: sourceIDs,
+	}
+	sources := make([]*BatchAsset, 0, len(sourceIDs))
+	sourceCertifications := make([][]*CertificationAsset, 0, len(sourceIDs))
+	statusRank := len(divisibleBatchStatuses)
+	seen := map[string]bool{}
+	for i, sourceID := range sourceIDs {
+		if seen[sourceID] {
+			return nil, fmt.Errorf("duplicate source batch %s", sourceID)
+		}
+		seen[sourceID] = true
+
+		source, err := s.GetBatch(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkBatchDivisible(ctx, source); err != nil {
+			return nil, err
+		}
+		quantity, err := s.GetBatchCurrentQuantity(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			target.ProductID = source.ProductID
+			target.FarmerID = source.FarmerID
+			target.Location = source.Location
+			target.StartDate = source.StartDate
+			target.ExpectedEndDate = source.ExpectedEndDate
+		} else {
+			if source.ProductID != target.ProductID {
+				return nil, fmt.Errorf("batch %s is product %s, expected %s: only batches of one product can be merged", sourceID, source.ProductID, target.ProductID)
+			}
+			if source.FarmerID != target.FarmerID {
+				target.FarmerID = ""
+			}
+			if source.Location != target.Location {
+				target.Location = ""
+			}
+			if source.StartDate < target.StartDate {
+				target.StartDate = source.StartDate
+			}
+			if source.ExpectedEndDate > target.ExpectedEndDate {
+				target.ExpectedEndDate = source.ExpectedEndDate
+			}
+		}
+		for rank, status := range divisibleBatchStatuses {
+			if status == source.Status && rank < statusRank {
+				statusRank = rank
+			}
+		}
+		target.Quantity += quantity
+		if source.RequiresRegulatoryApproval {
+			target.RequiresRegulatoryApproval = true
+		}
+
+		certifications, err := s.getDerivableCertifications(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+		sourceCertifications = append(sourceCertifications, certifications)
+	}
+	target.Status = divisibleBatchStatuses[statusRank]
+
+	if err := s.createDerivedBatch(ctx, target); err != nil {
+		return nil, err
+	}
+
+	// Carry over the certification types every source holds
+	transfers := MergeCertificationTransfers{
+		SourceBatchIDs: sourceIDs,
+		Transfers:      []CertificationTransfer{},
+		Conflicts:      []CertificationTransferConflict{},
+	}
+	derivedIDs := []string{}
+	notCarried := []string{}
+	certTypes := []string{}
+	seenTypes := map[string]bool{}
+	for _, certifications := range sourceCertifications {
+		for _, certification := range certifications {
+			certType := normalizeCertificationType(certification.CertType)
+			if !seenTypes[certType] {
+				seenTypes[certType] = true
+				certTypes = append(certTypes, certType)
+			}
+		}
+	}
+	sort.Strings(certTypes)
+	for _, certType := range certTypes {
+		held := make([]*CertificationAsset, 0, len(sourceCertifications))
+		conflict := CertificationTransferConflict{CertType: certType, HeldBy: []string{}, MissingFrom: []string{}}
+		for i, certifications := range sourceCertifications {
+			found := false
+			for _, certification := range certifications {
+				if normalizeCertificationType(certification.CertType) == certType {
+					held = append(held, certification)
+					found = true
+				}
+			}
+			if found {
+				conflict.HeldBy = append(conflict.HeldBy, sourceIDs[i])
+			} else {
+				conflict.MissingFrom = append(conflict.MissingFrom, sourceIDs[i])
+			}
+		}
+		if len(conflict.MissingFrom) > 0 {
+			notCarried = append(notCarried, certType)
+			transfers.Conflicts = append(transfers.Conflicts, conflict)
+			continue
+		}
+		derived, err := s.deriveCertification(ctx, targetBatchID, held)
+		if err != nil {
+			return nil, err
+		}
+		derivedIDs = append(derivedIDs, derived.CertificationID)
+		transfers.Transfers = append(transfers.Transfers, CertificationTransfer{
+			CertType:               certType,
+			CertificationID:        derived.CertificationID,
+			SourceCertificationIDs: derived.SourceCertificationIDs,
+		})
+	}
+
+	transferEventID := ""
+	if len(certTypes) > 0 {
+		transferEvent, err := s.recordCertificationTransfer(ctx, target, &transfers)
+		if err != nil {
+			return nil, err
+		}
+		transferEventID = transferEvent.EventID
+	}
+
+	for _, source := range sources {
+		source.Status = "MERGED"
+		source.UpdatedAt = s.GetTxTimestamp(ctx)
+
+		sourceBytes, err := json.Marshal(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal batch: %v", err)
+		}
+
+		if err := ctx.GetStub().PutState(source.BatchID, sourceBytes); err != nil {
+			return nil, fmt.Errorf("failed to update batch: %v", err)
+		}
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"batch_id":                  targetBatchID,
+		"source_batch_ids":          sourceIDs,
+		"derived_certification_ids": derivedIDs,
+		"not_carried_cert_types":    notCarried,
+	}
+	if transferEventID != "" {
+		eventPayload["cert_transfer_event_id"] = transferEventID
+	}
+	s.emitEvent(ctx, "BatchesMerged", eventPayload, target)
+
+	return target, nil
+}
+
+// recordCertificationTransfer stores the CERT_TRANSFERRED lifecycle event on
+// a merge target, keyed "<targetBatchID>~CERT_TRANSFERRED"
+func (s *SupplyChainContract) recordCertificationTransfer(
+	ctx contractapi.TransactionContextInterface,
+	target *BatchAsset,
+	transfers *MergeCertificationTransfers,
+) (*LifecycleEventAsset, error) {
+	eventID := target.BatchID + "~CERT_TRANSFERRED"
+	exists, err := s.AssetExists(ctx, "LifecycleEventAsset", eventID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("event %s already exists", eventID)
+	}
+
+	recordedBy, err := s.getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := json.Marshal(transfers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certification transfers: %v", err)
+	}
+
+	description := fmt.Sprintf("%d certification type(s) transferred from batches %s", len(transfers.Transfers), strings.Join(transfers.SourceBatchIDs, ", "))
+	if len(transfers.Conflicts) > 0 {
+		conflictTypes := make([]string, len(transfers.Conflicts))
+		for i, conflict := range transfers.Conflicts {
+			conflictTypes[i] = conflict.CertType
+		}
+		description += fmt.Sprintf("; not transferred, held by only some sources: %s", strings.Join(conflictTypes, ", "))
+	}
+
+	event := LifecycleEventAsset{
+		DocType:          "LifecycleEventAsset",
+		EventID:          eventID,
+		BatchID:          target.BatchID,
+		EventType:        "CERT_TRANSFERRED",
+		Description:      description,
+		RecordedBy:       recordedBy,
+		EventDate:        s.GetTxTimestamp(ctx),
+		QuantityAffected: target.Quantity,
+		Metadata:         string(metadata),
+		CreatedAt:        s.GetTxTimestamp(ctx),
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(eventID, eventBytes); err != nil {
+		return nil, fmt.Errorf("failed to save event: %v", err)
+	}
+
+	return &event, nil
+}
+
+// checkBatchDivisible checks a batch can be split or merged: it is in one of
+// divisibleBatchStatuses, has no processing records and is not under an
+// active stop-sale, whose hold would not follow it to the new batches
+func (s *SupplyChainContract) checkBatchDivisible(
+	ctx contractapi.TransactionContextInterface,
+	batch *BatchAsset,
+) error {
+	divisible := false
+	for _, status := range divisibleBatchStatuses {
+		if batch.Status == status {
+			divisible = true
+		}
+	}
+	if !divisible {
+		return fmt.Errorf("batch %s is %s; only %s batches can be split or merged", batch.BatchID, batch.Status, strings.Join(divisibleBatchStatuses, ", "))
+	}
+
+	records, err := s.GetProcessingRecordsByBatch(ctx, batch.BatchID)
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		return fmt.Errorf("batch %s has %d processing records and cannot be split or merged", batch.BatchID, len(records))
+	}
+	return s.checkNoStopSale(ctx, batch.BatchID)
+}
+
+// createDerivedBatch stores a batch created by SplitBatch or MergeBatches,
+// checking its ID, batch number and QR code, when it has one, are unused
+func (s *SupplyChainContract) createDerivedBatch(
+	ctx contractapi.TransactionContextInterface,
+	batch *BatchAsset,
+) error {
+	exists, err := s.AssetExists(ctx, "BatchAsset", batch.BatchID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("batch %s already exists", batch.BatchID)
+	}
+
+	batchNumberKey := fmt.Sprintf("batch_number~%s", batch.BatchNumber)
+	existingBatchNum, err := ctx.GetStub().GetState(batchNumberKey)
+	if err != nil {
+		return fmt.Errorf("failed to read batch number index: %v", err)
+	}
+	if existingBatchNum != nil {
+		return fmt.Errorf("batch number %s already exists", batch.BatchNumber)
+	}
+
+	if batch.QRCode != "" {
+		existing, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+			"docType": "BatchAsset",
+			"qr_code": batch.QRCode,
+		})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("QR code %s is already used by batch %s", batch.QRCode, existing[0].BatchID)
+		}
+	}
+
+	batch.DocType = "BatchAsset"
+	batch.CreatedAt = s.GetTxTimestamp(ctx)
+	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batch.BatchID, batchBytes); err != nil {
+		return fmt.Errorf("failed to save batch: %v", err)
+	}
+	if err := ctx.GetStub().PutState(batchNumberKey, []byte(batch.BatchID)); err != nil {
+		return fmt.Errorf("failed to save batch number index: %v", err)
+	}
+	return nil
+}
+
+// PurgeCancelledBatch permanently deletes a CANCELLED batch with its lifecycle
+// events, transports and their temperature logs, and releases its batch
+// number (Admin only). It is meant for cleaning up test and development
+// data. Batches with processing records, certifications or any regulatory
+// evidence (regulatory records, samples, inspections, violation notices,
+// recalls or lab tests) are refused so no audit record is left pointing at
+// a missing batch. Unused clearance overrides are deleted with the batch. QR
+// codes are looked up by query rather than an index, so there is no QR key
+// to release.
+func (s *SupplyChainContract) PurgeCancelledBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) error {
+	// Authorization check (Admin only)
+	batch, err := s.authorizeAndLoadBatch(ctx, AdminOrgMSP, batchID)
+	if err != nil {
+		return err
+	}
+	if batch.Status != "CANCELLED" {
+		return fmt.Errorf("batch %s is %s; only CANCELLED batches can be purged", batchID, batch.Status)
+	}
+
+	records, err := s.GetProcessingRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		return fmt.Errorf("batch %s has %d processing records and cannot be purged", batchID, len(records))
+	}
+	certifications, err := s.getBatchScopedCertifications(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	if len(certifications) > 0 {
+		return fmt.Errorf("batch %s has %d certifications and cannot be purged", batchID, len(certifications))
+	}
+	if err := s.checkNoBatchEvidence(ctx, batchID); err != nil {
+		return err
+	}
+	overrides, err := queryAssets[ClearanceOverrideAsset](ctx, map[string]interface{}{
+		"docType":  "ClearanceOverrideAsset",
+		"batch_id": batchID,
+	})
+	if err != nil {
+		return err
+	}
+
+	events, err := s.GetBatchLifecycleEvents(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	transports, err := s.GetTransportsByBatch(ctx, batchID)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(events)+len(transports)+len(overrides)+2)
+	for _, event := range events {
+		keys = append(keys, event.EventID)
+	}
+	for _, override := range overrides {
+		keys = append(keys, override.OverrideID)
+	}
+	temperatureLogs := 0
+	for _, transport := range transports {
+		logs, err := s.GetTransportTemperatureLogs(ctx, transport.TransportID)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			keys = append(keys, log.LogID)
+		}
+		temperatureLogs += len(logs)
+		keys = append(keys, transport.TransportID)
+	}
+	keys = append(keys, fmt.Sprintf("batch_number~%s", batch.BatchNumber), batchID)
+
+	for _, key := range keys {
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", key, err)
+		}
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"batch_id":                 batchID,
+		"batch_number":             batch.BatchNumber,
+		"lifecycle_events_deleted": len(events),
+		"transports_deleted":       len(transports),
+		"temperature_logs_deleted": temperatureLogs,
+		"overrides_deleted":        len(overrides),
+	}
+	s.emitEvent(ctx, "BatchPurged", eventPayload, batch)
+
+	return nil
+}
+
+// checkNoBatchEvidence fails if any regulatory or quality record still refers
+// to batchID, naming the first kind found
+func (s *SupplyChainContract) checkNoBatchEvidence(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) error {
+	dependents := []struct {
+		label    string
+		selector map[string]interface{}
+	}{
+		{"regulatory records", map[string]interface{}{"docType": "RegulatoryAsset", "batch_id": batchID}},
+		{"regulatory samples", map[string]interface{}{"docType": "RegulatorySampleAsset", "batch_id": batchID}},
+		{"inspection schedules", map[string]interface{}{"docType": "InspectionScheduleAsset", "batch_id": batchID}},
+		{"violation notices", map[string]interface{}{"docType": "ViolationNoticeAsset", "target_type": "BATCH", "target_id": batchID}},
+		{"recalls", map[string]interface{}{"docType": "RecallAsset", "batch_id": batchID}},
+		{"lab tests", map[string]interface{}{"docType": "LabTestAsset", "batch_id": batchID}},
+	}
+	for _, dependent := range dependents {
+		matches, err := queryAssets[json.RawMessage](ctx, dependent.selector)
+		if err != nil {
+			return err
+		}
+		if len(matches) > 0 {
+			return fmt.Errorf("batch %s has %d %s and cannot be purged", batchID, len(matches), dependent.label)
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// LIFECYCLE EVENT FUNCTIONS
+// ============================================================================
+
+// RecordLifecycleEvent records a lifecycle event (append-only)
+func (s *SupplyChainContract) RecordLifecycleEvent(
+	ctx contractapi.TransactionContextInterface,
+	eventID string,
+	batchID string,
+	eventType string,
+	description string,
+	recordedBy string,
+	eventDate string,
+	quantityAffected int,
+	metadata string,
+) (*LifecycleEventAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(eventID, "eventID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return nil, err
+	}
+
+	// Check batch exists
+	_, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch does not exist: %v", err)
+	}
+
+	// Check event uniqueness
+	exists, err := s.AssetExists(ctx, "LifecycleEventAsset", eventID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("event %s already exists", eventID)
+	}
+
+	event := LifecycleEventAsset{
+		DocType:          "LifecycleEventAsset",
+		EventID:          eventID,
+		BatchID:          batchID,
+		EventType:        eventType,
+		Description:      description,
+		RecordedBy:       recordedBy,
+		EventDate:        eventDate,
+		QuantityAffected: quantityAffected,
+		Metadata:         metadata,
+		CreatedAt:        s.GetTxTimestamp(ctx),
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(eventID, eventBytes); err != nil {
+		return nil, fmt.Errorf("failed to save event: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"event_id":   eventID,
+		"batch_id":   batchID,
+		"event_type": eventType,
+	}
+	s.emitEvent(ctx, "LifecycleEventRecorded", eventPayload, &event)
+
+	return &event, nil
+}
+
+// GetLifecycleEvent retrieves a lifecycle event by ID
+func (s *SupplyChainContract) GetLifecycleEvent(
+	ctx contractapi.TransactionContextInterface,
+	eventID string,
+) (*LifecycleEventAsset, error) {
+	if err := s.ValidateNonEmptyString(eventID, "eventID"); err != nil {
+		return nil, err
+	}
+
+	eventBytes, err := ctx.GetStub().GetState(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event: %v", err)
+	}
+	if eventBytes == nil {
+		return nil, fmt.Errorf("event %s not found", eventID)
+	}
+
+	var event LifecycleEventAsset
+	eventErr := json.Unmarshal(eventBytes, &event)
+	if eventErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %v", eventErr)
+	}
+	if event.DocType != "LifecycleEventAsset" {
+		return nil, fmt.Errorf("event %s not found", eventID)
+	}
+
+	return &event, nil
+}
+
+// GetLifecycleEventMetadata looks up key in a lifecycle event's metadata JSON
+// object. String values are returned as-is and any other value as its JSON
+// encoding. An event with empty metadata has no keys; metadata that is not a
+// JSON object is an error.
+func (s *SupplyChainContract) GetLifecycleEventMetadata(
+	ctx contractapi.TransactionContextInterface,
+	eventID string,
+	key string,
+) (*LifecycleEventMetadataValue, error) {
+	if err := s.ValidateNonEmptyString(key, "key"); err != nil {
+		return nil, err
+	}
+
+	event, err := s.GetLifecycleEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LifecycleEventMetadataValue{EventID: eventID, Key: key}
+	if strings.TrimSpace(event.Metadata) == "" {
+		return result, nil
+	}
+
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(event.Metadata), &metadata); err != nil {
+		return nil, fmt.Errorf("event %s has invalid metadata JSON: %v", eventID, err)
+	}
+	raw, ok := metadata[key]
+	if !ok {
+		return result, nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		value = string(raw)
+	}
+	result.Value = value
+	result.Found = true
+
+	return result, nil
+}
+
+// GetBatchLifecycleEvents retrieves all lifecycle events for a batch
+func (s *SupplyChainContract) GetBatchLifecycleEvents(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*LifecycleEventAsset, error) {
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return nil, err
+	}
+
+	events, err := queryAssets[LifecycleEventAsset](ctx, map[string]interface{}{
+		"docType":  "LifecycleEventAsset",
+		"batch_id": batchID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventDate < events[j].EventDate
+	})
+
+	return events, nil
+}
+
+// GetEventsInDateRangeForBatch retrieves a batch's lifecycle events whose
+// event date falls within the inclusive RFC3339 range, oldest first
+func (s *SupplyChainContract) GetEventsInDateRangeForBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	startDate string,
+	endDate string,
+) ([]*LifecycleEventAsset, error) {
+	// Validation
+	start, err := time.Parse(time.RFC3339, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid startDate %q: must be RFC3339", startDate)
+	}
+	end, err := time.Parse(time.RFC3339, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endDate %q: must be RFC3339", endDate)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("endDate %s is before startDate %s", endDate, startDate)
+	}
+
+	events, err := s.GetBatchLifecycleEvents(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	inRange := []*LifecycleEventAsset{}
+	for _, event := range events {
+		if inDateRange(event.EventDate, start, end) {
+			inRange = append(inRange, event)
+		}
+	}
+
+	return inRange, nil
+}
+
+// ============================================================================
+// INSPECTION FUNCTIONS
+// ============================================================================
+
+// ScheduleInspection schedules an inspection of a batch, farm or facility
+// (Regulator only). targetType is BATCH, FARM or FACILITY. Farm IDs are the
+// farmer IDs carried on batches; there is no farm registry to check them against.
+func (s *SupplyChainContract) ScheduleInspection(
+	ctx contractapi.TransactionContextInterface,
+	scheduleID string,
+	targetType string,
+	targetID string,
+	dueDate string,
+	assignedTo string,
+) (*InspectionScheduleAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(scheduleID, "scheduleID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(targetID, "targetID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(assignedTo, "assignedTo"); err != nil {
+		return nil, err
+	}
+	if _, err := parseLedgerDate(dueDate); err != nil {
+		return nil, fmt.Errorf("invalid dueDate %q: %v", dueDate, err)
+	}
+
+	// Check the target exists
+	targetType, err := s.checkRegulatoryTarget(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	batchID := ""
+	if targetType == "BATCH" {
+		batchID = targetID
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "InspectionScheduleAsset", scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("inspection schedule %s already exists", scheduleID)
+	}
+
+	schedule := InspectionScheduleAsset{
+		DocType:    "InspectionScheduleAsset",
+		ScheduleID: scheduleID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		BatchID:    batchID,
+		DueDate:    dueDate,
+		AssignedTo: assignedTo,
+		Status:     "SCHEDULED",
+		CreatedAt:  s.GetTxTimestamp(ctx),
+		UpdatedAt:  s.GetTxTimestamp(ctx),
+	}
+
+	scheduleBytes, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inspection schedule: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(scheduleID, scheduleBytes); err != nil {
+		return nil, fmt.Errorf("failed to save inspection schedule: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"schedule_id": scheduleID,
+		"target_type": targetType,
+		"target_id":   targetID,
+		"batch_id":    batchID,
+		"due_date":    dueDate,
+	}
+	s.emitEvent(ctx, "InspectionScheduled", eventPayload, &schedule)
+
+	return &schedule, nil
+}
+
+// checkRegulatoryTarget normalizes targetType and checks the batch, farm or
+// facility it names exists. Farms are not registered, so any farm ID passes.
+func (s *SupplyChainContract) checkRegulatoryTarget(
+	ctx contractapi.TransactionContextInterface,
+	targetType string,
+	targetID string,
+) (string, error) {
+	targetType = strings.ToUpper(strings.TrimSpace(targetType))
+	switch targetType {
+	case "BATCH":
+		if _, err := s.GetBatch(ctx, targetID); err != nil {
+			return "", fmt.Errorf("batch does not exist: %v", err)
+		}
+	case "FACILITY":
+		if _, err := s.GetFacility(ctx, targetID); err != nil {
+			return "", err
+		}
+	case "FARM":
+	default:
+		return "", fmt.Errorf("invalid targetType %q, allowed: %s", targetType, strings.Join(inspectionTargetTypes, ", "))
+	}
+	return targetType, nil
+}
+
+// CompleteInspection records the outcome of a scheduled inspection (Regulator
+// only). outcome is PASS, CONDITIONAL or FAIL; the latter two need findings
+// of at least MinRegulatoryReasonLength characters. For batch inspections
+// recordAs may be LIFECYCLE_EVENT, to record an INSPECTION lifecycle event
+// "<scheduleID>~INSPECTION", or REGULATORY_RECORD, to record a
+// SANITARY_INSPECTION regulatory record "<scheduleID>~SANITARY_INSPECTION"
+// decided by the outcome (APPROVED, NEEDS_INFO or REJECTED). An empty recordAs
+// records nothing else. With holdBatchOnFail set, a FAIL outcome also places
+// the inspected batch ON_HOLD in the same transaction. The APPROVED record of
+// a PASS is subject to checkRegulatoryApproval like any other approval, so
+// it cannot be recorded while SANITARY_INSPECTION requires samples. The
+// InspectionCompleted event carries the created record under
+// "regulatory_record".
+func (s *SupplyChainContract) CompleteInspection(
+	ctx contractapi.TransactionContextInterface,
+	scheduleID string,
+	outcome string,
+	findings string,
+	recordAs string,
+	holdBatchOnFail bool,
+) (*InspectionScheduleAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	outcome = strings.ToUpper(strings.TrimSpace(outcome))
+	regulatoryStatus, known := inspectionOutcomeStatuses[outcome]
+	if !known {
+		return nil, fmt.Errorf("invalid outcome %q, allowed: PASS, CONDITIONAL, FAIL", outcome)
+	}
+	findings = strings.TrimSpace(findings)
+	if outcome != "PASS" && len([]rune(findings)) < MinRegulatoryReasonLength {
+		return nil, fmt.Errorf("findings must be at least %d characters for a %s outcome", MinRegulatoryReasonLength, outcome)
+	}
+	recordAs = strings.ToUpper(strings.TrimSpace(recordAs))
+	if recordAs != "" && recordAs != "LIFECYCLE_EVENT" && recordAs != "REGULATORY_RECORD" {
+		return nil, fmt.Errorf("invalid recordAs %q, allowed: LIFECYCLE_EVENT, REGULATORY_RECORD or empty", recordAs)
+	}
+
+	schedule, err := s.GetInspectionSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != "SCHEDULED" {
+		return nil, fmt.Errorf("inspection schedule %s is %s, only SCHEDULED inspections can be completed", scheduleID, schedule.Status)
+	}
+	holdBatch := holdBatchOnFail && outcome == "FAIL"
+	if schedule.TargetType != "BATCH" && (recordAs != "" || holdBatch) {
+		return nil, fmt.Errorf("inspection schedule %s is of %s %s; only batch inspections can record lifecycle events, regulatory records or holds", scheduleID, strings.ToLower(schedule.TargetType), schedule.TargetID)
+	}
+
+	schedule.Status = "COMPLETED"
+	schedule.Outcome = outcome
+	schedule.Findings = findings
+	schedule.CompletedAt = s.GetTxTimestamp(ctx)
+	schedule.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	var regulatory *RegulatoryAsset
+	switch recordAs {
+	case "LIFECYCLE_EVENT":
+		event, err := s.recordInspectionLifecycleEvent(ctx, schedule)
+		if err != nil {
+			return nil, err
+		}
+		schedule.LifecycleEventID = event.EventID
+	case "REGULATORY_RECORD":
+		regulatory, err = s.recordInspectionRegulatoryRecord(ctx, schedule, regulatoryStatus)
+		if err != nil {
+			return nil, err
+		}
+		schedule.RegulatoryID = regulatory.RegulatoryID
+	}
+	if holdBatch {
+		batch, err := s.GetBatch(ctx, schedule.BatchID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.placeBatchOnHold(ctx, batch, fmt.Sprintf("inspection %s failed: %s", scheduleID, findings)); err != nil {
+			return nil, err
+		}
+		schedule.BatchOnHold = true
+	}
+
+	scheduleBytes, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inspection schedule: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(scheduleID, scheduleBytes); err != nil {
+		return nil, fmt.Errorf("failed to update inspection schedule: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"schedule_id":        scheduleID,
+		"target_type":        schedule.TargetType,
+		"target_id":          schedule.TargetID,
+		"batch_id":           schedule.BatchID,
+		"outcome":            outcome,
+		"lifecycle_event_id": schedule.LifecycleEventID,
+		"regulatory_id":      schedule.RegulatoryID,
+		"batch_on_hold":      schedule.BatchOnHold,
+	}
+	// Fabric keeps one event per transaction, so the regulatory record created
+	// here is announced in this payload rather than by its own event
+	if regulatory != nil {
+		eventPayload["regulatory_record"] = map[string]interface{}{
+			"regulatory_id":         regulatory.RegulatoryID,
+			"record_type":           regulatory.RecordType,
+			"status":                regulatory.Status,
+			"regulator_id":          regulatory.RegulatorID,
+			"recorded_on_behalf_by": regulatory.RecordedOnBehalfBy,
+			"issued_date":           regulatory.IssuedDate,
+		}
+	}
+	s.emitEvent(ctx, "InspectionCompleted", eventPayload, schedule)
+
+	return schedule, nil
+}
+
+// recordInspectionLifecycleEvent stores the INSPECTION lifecycle event of a
+// completed batch inspection
+func (s *SupplyChainContract) recordInspectionLifecycleEvent(
+	ctx contractapi.TransactionContextInterface,
+	schedule *InspectionScheduleAsset,
+) (*LifecycleEventAsset, error) {
+	eventID := schedule.ScheduleID + "~INSPECTION"
+	exists, err := s.AssetExists(ctx, "LifecycleEventAsset", eventID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("event %s already exists", eventID)
+	}
+
+	metadata, err := json.Marshal(map[string]string{
+		"schedule_id": schedule.ScheduleID,
+		"outcome":     schedule.Outcome,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inspection metadata: %v", err)
+	}
+	description := "Inspection " + schedule.Outcome
+	if schedule.Findings != "" {
+		description += ": " + schedule.Findings
+	}
+
+	event := LifecycleEventAsset{
+		DocType:     "LifecycleEventAsset",
+		EventID:     eventID,
+		BatchID:     schedule.BatchID,
+		EventType:   "INSPECTION",
+		Description: description,
+		RecordedBy:  schedule.AssignedTo,
+		EventDate:   schedule.CompletedAt,
+		Metadata:    string(metadata),
+		CreatedAt:   s.GetTxTimestamp(ctx),
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(eventID, eventBytes); err != nil {
+		return nil, fmt.Errorf("failed to save event: %v", err)
+	}
+
+	return &event, nil
+}
+
+// recordInspectionRegulatoryRecord stores the SANITARY_INSPECTION regulatory
+// record of a completed batch inspection, already decided as status. The
+// schedule's assignee is bound to the caller through resolveActingOfficer, so
+// only the assigned regulator, or an admin acting for them, records it.
+func (s *SupplyChainContract) recordInspectionRegulatoryRecord(
+	ctx contractapi.TransactionContextInterface,
+	schedule *InspectionScheduleAsset,
+	status string,
+) (*RegulatoryAsset, error) {
+	recordType, err := s.validateRegulatoryRecordType(ctx, "SANITARY_INSPECTION")
+	if err != nil {
+		return nil, err
+	}
+	regulator, err := s.resolveActingOfficer(ctx, schedule.AssignedTo, "assignedTo", "regulator")
+	if err != nil {
+		return nil, err
+	}
+
+	regulatory := RegulatoryAsset{
+		DocType:            "RegulatoryAsset",
+		RegulatoryID:       schedule.ScheduleID + "~" + recordType,
+		BatchID:            schedule.BatchID,
+		RecordType:         recordType,
+		Status:             status,
+		IssuedDate:         schedule.CompletedAt,
+		RegulatorID:        regulator.OfficerID,
+		RegulatorMSP:       regulator.OfficerMSP,
+		RegulatorIdentity:  regulator.OfficerIdentity,
+		RecordedOnBehalfBy: regulator.OnBehalfBy,
+		AssignedTo:         regulator.OfficerID,
+		Details:            fmt.Sprintf("inspection %s: %s", schedule.ScheduleID, schedule.Outcome),
+		AuditFlags:         AuditFlagList{},
+		CreatedAt:          s.GetTxTimestamp(ctx),
+		UpdatedAt:          s.GetTxTimestamp(ctx),
+	}
+	if status != "APPROVED" {
+		regulatory.RejectionReason = schedule.Findings
+	}
+	if err := validationError(validateRegulatoryAsset(&regulatory)); err != nil {
+		return nil, err
+	}
+	if status == "APPROVED" {
+		if err := s.checkRegulatoryApproval(ctx, &regulatory); err != nil {
+			return nil, err
+		}
+	}
+
+	exists, err := s.AssetExists(ctx, "RegulatoryAsset", regulatory.RegulatoryID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("regulatory record %s already exists", regulatory.RegulatoryID)
+	}
+
+	regBytes, err := json.Marshal(regulatory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(regulatory.RegulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to save regulatory record: %v", err)
+	}
+
+	return &regulatory, nil
+}
+
+// CancelInspection cancels a scheduled inspection (Regulator only)
+func (s *SupplyChainContract) CancelInspection(
+	ctx contractapi.TransactionContextInterface,
+	scheduleID string,
+	reason string,
+) (*InspectionScheduleAsset, error) {
+	return s.closeInspection(ctx, scheduleID, "CANCELLED", reason, "InspectionCancelled")
+}
+
+// RecordInspectionNoShow records that a scheduled inspection could not take
+// place because the inspected party was not available (Regulator only)
+func (s *SupplyChainContract) RecordInspectionNoShow(
+	ctx contractapi.TransactionContextInterface,
+	scheduleID string,
+	notes string,
+) (*InspectionScheduleAsset, error) {
+	return s.closeInspection(ctx, scheduleID, "NO_SHOW", notes, "InspectionNoShow")
+}
+
+// closeInspection moves a SCHEDULED inspection to CANCELLED or NO_SHOW with a
+// required reason and emits eventName
+func (s *SupplyChainContract) closeInspection(
+	ctx contractapi.TransactionContextInterface,
+	scheduleID string,
+	status string,
+	reason string,
+	eventName string,
+) (*InspectionScheduleAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.GetInspectionSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != "SCHEDULED" {
+		return nil, fmt.Errorf("inspection schedule %s is %s, only SCHEDULED inspections can be closed as %s", scheduleID, schedule.Status, status)
+	}
+
+	schedule.Status = status
+	schedule.Findings = reason
+	schedule.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	scheduleBytes, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inspection schedule: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(scheduleID, scheduleBytes); err != nil {
+		return nil, fmt.Errorf("failed to update inspection schedule: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"schedule_id": scheduleID,
+		"target_type": schedule.TargetType,
+		"target_id":   schedule.TargetID,
+		"reason":      reason,
+	}
+	s.emitEvent(ctx, eventName, eventPayload, schedule)
+
+	return schedule, nil
+}
+
+// GetInspectionSchedule retrieves an inspection schedule by ID
+func (s *SupplyChainContract) GetInspectionSchedule(
+	ctx contractapi.TransactionContextInterface,
+	scheduleID string,
+) (*InspectionScheduleAsset, error) {
+	if err := s.ValidateNonEmptyString(scheduleID, "scheduleID"); err != nil {
+		return nil, err
+	}
+
+	scheduleBytes, err := ctx.GetStub().GetState(scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inspection schedule: %v", err)
+	}
+	if scheduleBytes == nil {
+		return nil, fmt.Errorf("inspection schedule %s not found", scheduleID)
+	}
+
+	var schedule InspectionScheduleAsset
+	scheduleErr := json.Unmarshal(scheduleBytes, &schedule)
+	if scheduleErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal inspection schedule: %v", scheduleErr)
+	}
+	fillInspectionTarget(&schedule)
+
+	return &schedule, nil
+}
+
+// fillInspectionTarget reads a schedule stored before inspection targets
+// existed as a batch inspection
+func fillInspectionTarget(schedule *InspectionScheduleAsset) {
+	if schedule.TargetType == "" {
+		schedule.TargetType = "BATCH"
+		schedule.TargetID = schedule.BatchID
+	}
+}
+
+// GetUpcomingInspections retrieves the SCHEDULED inspections assigned to an
+// inspector, earliest due first. Overdue inspections are included, since
+// they are still to be carried out.
+func (s *SupplyChainContract) GetUpcomingInspections(
+	ctx contractapi.TransactionContextInterface,
+	inspectorID string,
+) ([]*InspectionScheduleAsset, error) {
+	if err := s.ValidateNonEmptyString(inspectorID, "inspectorID"); err != nil {
+		return nil, err
+	}
+
+	schedules, err := queryAssets[InspectionScheduleAsset](ctx, map[string]interface{}{
+		"docType":     "InspectionScheduleAsset",
+		"status":      "SCHEDULED",
+		"assigned_to": inspectorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schedule := range schedules {
+		fillInspectionTarget(schedule)
+	}
+	sort.SliceStable(schedules, func(i, j int) bool {
+		return schedules[i].DueDate < schedules[j].DueDate
+	})
+
+	return schedules, nil
+}
+
+// GetDueInspections retrieves scheduled inspections not yet completed whose
+// due date is on or before asOfDate, earliest due first
+func (s *SupplyChainContract) GetDueInspections(
+	ctx contractapi.TransactionContextInterface,
+	asOfDate string,
+) ([]*InspectionScheduleAsset, error) {
+	asOf, err := parseLedgerDeadline(asOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid asOfDate %q: %v", asOfDate, err)
+	}
+
+	schedules, err := queryAssets[InspectionScheduleAsset](ctx, map[string]interface{}{
+		"docType": "InspectionScheduleAsset",
+		"status":  "SCHEDULED",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	due := []*InspectionScheduleAsset{}
+	for _, schedule := range schedules {
+		dueDate, err := parseLedgerDate(schedule.DueDate)
+		if err != nil || dueDate.After(asOf) {
+			continue
+		}
+		fillInspectionTarget(schedule)
+		due = append(due, schedule)
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].DueDate < due[j].DueDate
+	})
+
+	return due, nil
+}
+
+// ============================================================================
+// VIOLATION NOTICE FUNCTIONS
+// ============================================================================
+
+// IssueViolationNotice records a non-compliance finding against a batch, farm
+// or facility (Regulator only). targetType is BATCH, FARM or FACILITY and
+// severity is MINOR, MAJOR or CRITICAL. While a CRITICAL notice is unresolved
+// no certification can be issued or renewed for its target.
+func (s *SupplyChainContract) IssueViolationNotice(
+	ctx contractapi.TransactionContextInterface,
+	noticeID string,
+	targetType string,
+	targetID string,
+	violationCode string,
+	severity string,
+	description string,
+	correctiveAction string,
+	deadline string,
+	penaltyAmount float64,
+) (*ViolationNoticeAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(noticeID, "noticeID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(targetID, "targetID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(violationCode, "violationCode"); err != nil {
+		return nil, err
+	}
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	known := false
+	for _, allowed := range violationSeverities {
+		if allowed == severity {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("invalid severity %q, allowed: %s", severity, strings.Join(violationSeverities, ", "))
+	}
+	if err := s.ValidateNonEmptyString(description, "description"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(correctiveAction, "correctiveAction"); err != nil {
+		return nil, err
+	}
+	if _, err := parseLedgerDate(deadline); err != nil {
+		return nil, fmt.Errorf("invalid deadline %q: %v", deadline, err)
+	}
+	if err := s.ValidatePositiveFloat(penaltyAmount, "penaltyAmount"); err != nil {
+		return nil, err
+	}
+
+	// Check the target exists
+	targetType, err := s.checkRegulatoryTarget(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "ViolationNoticeAsset", noticeID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("violation notice %s already exists", noticeID)
+	}
+
+	issuedBy, err := s.getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notice := ViolationNoticeAsset{
+		DocType:          "ViolationNoticeAsset",
+		NoticeID:         noticeID,
+		TargetType:       targetType,
+		TargetID:         targetID,
+		ViolationCode:    violationCode,
+		Severity:         severity,
+		Description:      description,
+		CorrectiveAction: correctiveAction,
+		Deadline:         deadline,
+		PenaltyAmount:    penaltyAmount,
+		Status:           "OPEN",
+		IssuedBy:         issuedBy,
+		CreatedAt:        s.GetTxTimestamp(ctx),
+		UpdatedAt:        s.GetTxTimestamp(ctx),
+	}
+
+	if err := s.putViolationNotice(ctx, &notice); err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"notice_id":      noticeID,
+		"target_type":    targetType,
+		"target_id":      targetID,
+		"violation_code": violationCode,
+		"severity":       severity,
+		"deadline":       deadline,
+		"penalty_amount": penaltyAmount,
+	}
+	s.emitEvent(ctx, "ViolationNoticeIssued", eventPayload, &notice)
+
+	return &notice, nil
+}
+
+// EscalateViolationNotice escalates an OPEN violation notice, typically once
+// its deadline has passed without the corrective action (Regulator only).
+// reason must be at least MinRegulatoryReasonLength characters.
+func (s *SupplyChainContract) EscalateViolationNotice(
+	ctx contractapi.TransactionContextInterface,
+	noticeID string,
+	reason string,
+) (*ViolationNoticeAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) < MinRegulatoryReasonLength {
+		return nil, fmt.Errorf("reason must be at least %d characters", MinRegulatoryReasonLength)
+	}
+
+	notice, err := s.getViolationNotice(ctx, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	if notice.Status != "OPEN" {
+		return nil, fmt.Errorf("violation notice %s is %s, only OPEN notices can be escalated", noticeID, notice.Status)
+	}
+
+	notice.Status = "ESCALATED"
+	notice.EscalationReason = reason
+	notice.EscalatedAt = s.GetTxTimestamp(ctx)
+	notice.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	if err := s.putViolationNotice(ctx, notice); err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"notice_id":   noticeID,
+		"target_type": notice.TargetType,
+		"target_id":   notice.TargetID,
+		"severity":    notice.Severity,
+		"reason":      reason,
+	}
+	s.emitEvent(ctx, "ViolationNoticeEscalated", eventPayload, notice)
+
+	return notice, nil
+}
+
+// ResolveViolationNotice resolves an OPEN or ESCALATED violation notice once
+// the corrective action is done (Regulator only). resolutionEvidence
+// describes or references the proof the action was taken and is required.
+func (s *SupplyChainContract) ResolveViolationNotice(
+	ctx contractapi.TransactionContextInterface,
+	noticeID string,
+	resolutionEvidence string,
+) (*ViolationNoticeAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	resolutionEvidence = strings.TrimSpace(resolutionEvidence)
+	if err := s.ValidateNonEmptyString(resolutionEvidence, "resolutionEvidence"); err != nil {
+		return nil, err
+	}
+
+	notice, err := s.getViolationNotice(ctx, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	if notice.Status != "OPEN" && notice.Status != "ESCALATED" {
+		return nil, fmt.Errorf("violation notice %s is %s, only OPEN or ESCALATED notices can be resolved", noticeID, notice.Status)
+	}
+
+	resolvedBy, err := s.getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	previousStatus := notice.Status
+	notice.Status = "RESOLVED"
+	notice.ResolutionEvidence = resolutionEvidence
+	notice.ResolvedBy = resolvedBy
+	notice.ResolvedAt = s.GetTxTimestamp(ctx)
+	notice.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	if err := s.putViolationNotice(ctx, notice); err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"notice_id":       noticeID,
+		"target_type":     notice.TargetType,
+		"target_id":       notice.TargetID,
+		"severity":        notice.Severity,
+		"previous_status": previousStatus,
+	}
+	s.emitEvent(ctx, "ViolationNoticeResolved", eventPayload, notice)
+
+	return notice, nil
+}
+
+// GetViolationNotice retrieves a violation notice. The Regulator can read any
+// notice; otherwise the caller must belong to the target's organization.
+func (s *SupplyChainContract) GetViolationNotice(
+	ctx contractapi.TransactionContextInterface,
+	noticeID string,
+) (*ViolationNoticeAsset, error) {
+	notice, err := s.getViolationNotice(ctx, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeViolationTargetReader(ctx, notice.TargetType); err != nil {
+		return nil, err
+	}
+	return notice, nil
+}
+
+// GetViolationNoticesByTarget retrieves the violation notices issued against a
+// batch, farm or facility, most recent first. The Regulator can read any
+// target's notices; otherwise the caller must belong to the target's
+// organization.
+func (s *SupplyChainContract) GetViolationNoticesByTarget(
+	ctx contractapi.TransactionContextInterface,
+	targetType string,
+	targetID string,
+) ([]*ViolationNoticeAsset, error) {
+	targetType = strings.ToUpper(strings.TrimSpace(targetType))
+	known := false
+	for _, allowed := range inspectionTargetTypes {
+		if allowed == targetType {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("invalid targetType %q, allowed: %s", targetType, strings.Join(inspectionTargetTypes, ", "))
+	}
+	if err := s.ValidateNonEmptyString(targetID, "targetID"); err != nil {
+		return nil, err
+	}
+
+	// Authorization check (Regulator or the target's organization)
+	if err := s.authorizeViolationTargetReader(ctx, targetType); err != nil {
+		return nil, err
+	}
+
+	notices, err := queryAssets[ViolationNoticeAsset](ctx, map[string]interface{}{
+		"docType":     "ViolationNoticeAsset",
+		"target_type": targetType,
+		"target_id":   targetID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(notices, func(i, j int) bool {
+		return notices[i].CreatedAt > notices[j].CreatedAt
+	})
+
+	return notices, nil
+}
+
+// authorizeViolationTargetReader allows the Regulator, and the organization
+// owning targets of targetType: Farm for batches and farms, Processor for
+// facilities
+func (s *SupplyChainContract) authorizeViolationTargetReader(
+	ctx contractapi.TransactionContextInterface,
+	targetType string,
+) error {
+	if targetType == "FACILITY" {
+		return s.authorizeAnyMSP(ctx, RegulatorOrgMSP, ProcessorOrgMSP)
+	}
+	return s.authorizeAnyMSP(ctx, RegulatorOrgMSP, MinFarmOrgMSP)
+}
+
+// getViolationNotice loads a violation notice without authorization checks
+func (s *SupplyChainContract) getViolationNotice(
+	ctx contractapi.TransactionContextInterface,
+	noticeID string,
+) (*ViolationNoticeAsset, error) {
+	if err := s.ValidateNonEmptyString(noticeID, "noticeID"); err != nil {
+		return nil, err
+	}
+
+	noticeBytes, err := ctx.GetStub().GetState(noticeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read violation notice: %v", err)
+	}
+	if noticeBytes == nil {
+		return nil, fmt.Errorf("violation notice %s not found", noticeID)
+	}
+
+	var notice ViolationNoticeAsset
+	if err := json.Unmarshal(noticeBytes, &notice); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal violation notice: %v", err)
+	}
+	if notice.DocType != "ViolationNoticeAsset" {
+		return nil, fmt.Errorf("violation notice %s not found", noticeID)
+	}
+
+	return &notice, nil
+}
+
+// putViolationNotice saves a violation notice under its ID
+func (s *SupplyChainContract) putViolationNotice(
+	ctx contractapi.TransactionContextInterface,
+	notice *ViolationNoticeAsset,
+) error {
+	noticeBytes, err := json.Marshal(notice)
+	if err != nil {
+		return fmt.Errorf("failed to marshal violation notice: %v", err)
+	}
+	if err := ctx.GetStub().PutState(notice.NoticeID, noticeBytes); err != nil {
+		return fmt.Errorf("failed to save violation notice: %v", err)
+	}
+	return nil
+}
+
+// checkNoCriticalViolations refuses certification while an OPEN or ESCALATED
+// CRITICAL violation notice stands against the certified batch, its farm or,
+// for processing certifications, the processing facility
+func (s *SupplyChainContract) checkNoCriticalViolations(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+	batchID string,
+) error {
+	targets := [][2]string{}
+	if processingID != "" {
+		processing, err := s.getProcessingRecord(ctx, processingID)
+		if err != nil {
+			return fmt.Errorf("processing record does not exist: %v", err)
+		}
+		if processing.FacilityID != "" {
+			targets = append(targets, [2]string{"FACILITY", processing.FacilityID})
+		}
+		batchID = processing.BatchID
+	}
+	if batchID != "" {
+		batch, err := s.GetBatch(ctx, batchID)
+		if err != nil {
+			return fmt.Errorf("batch does not exist: %v", err)
+		}
+		targets = append(targets, [2]string{"BATCH", batchID})
+		if batch.FarmerID != "" {
+			targets = append(targets, [2]string{"FARM", batch.FarmerID})
+		}
+	}
+
+	for _, target := range targets {
+		notices, err := queryAssets[ViolationNoticeAsset](ctx, map[string]interface{}{
+			"docType":     "ViolationNoticeAsset",
+			"target_type": target[0],
+			"target_id":   target[1],
+			"severity":    "CRITICAL",
+		})
+		if err != nil {
+			return err
+		}
+		for _, notice := range notices {
+			if notice.Status == "OPEN" || notice.Status == "ESCALATED" {
+				return fmt.Errorf("%s %s has unresolved critical violation notice %s; resolve it before certification", strings.ToLower(target[0]), target[1], notice.NoticeID)
+			}
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// TRANSPORT FUNCTIONS
+// ============================================================================
+
+// CreateTransportManifest creates a transport manifest
+func (s *SupplyChainContract) CreateTransportManifest(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+	batchID string,
+	quantity int,
+	fromPartyID string,
+	toPartyID string,
+	vehicleID string,
+	driverName string,
+	departureTime string,
+	expectedArrivalTime string,
+	originLocation string,
+	destinationLocation string,
+	temperatureMonitored bool,
+	notes string,
+) (*TransportAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	transport := TransportAsset{
+		DocType:             "TransportAsset",
+		TransportID:         transportID,
+		BatchID:             batchID,
+		Quantity:            quantity,
+		FromPartyID:         fromPartyID,
+		ToPartyID:           toPartyID,
+		VehicleID:           vehicleID,
+		DriverName:          driverName,
+		DepartureTime:       departureTime,
+		ExpectedArrivalTime: expectedArrivalTime,
+		OriginLocation:      originLocation,
+		DestinationLocation: destinationLocation,
+		TemperatureMonitored: temperatureMonitored,
+		Status:              "INITIATED",
+		Notes:               notes,
+		CreatedAt:           s.GetTxTimestamp(ctx),
+		UpdatedAt:           s.GetTxTimestamp(ctx),
+	}
+
+	// Validation
+	if err := validationError(validateTransportAsset(&transport)); err != nil {
+		return nil, err
+	}
+
+	// Check batch exists
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch does not exist: %v", err)
+	}
+
+	// A batch under stop-sale may not move
+	if err := s.checkNoStopSale(ctx, batchID); err != nil {
+		return nil, err
+	}
+
+	// Batches held for regulatory approval ship only once approved
+	if err := s.checkRegulatoryApprovalForShipment(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	// An omitted quantity ships the whole batch; either way the manifest may
+	// not push the batch's cumulative shipped quantity past what it holds
+	currentQuantity, err := s.GetBatchCurrentQuantity(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if quantity == 0 {
+		quantity = currentQuantity
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("batch %s has no remaining quantity to transport", batchID)
+	}
+	shipped, err := s.getTransportedQuantity(ctx, batchID, currentQuantity)
+	if err != nil {
+		return nil, err
+	}
+	if shipped+quantity > currentQuantity {
+		return nil, fmt.Errorf("transport quantity %d exceeds batch %s remaining quantity %d (%d of %d already on manifests)",
+			quantity, batchID, currentQuantity-shipped, shipped, currentQuantity)
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "TransportAsset", transportID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("transport %s already exists", transportID)
+	}
+	transport.Quantity = quantity
+
+	transportBytes, err := json.Marshal(transport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transport: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(transportID, transportBytes); err != nil {
+		return nil, fmt.Errorf("failed to save transport: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"transport_id": transportID, "batch_id": batchID, "quantity": quantity}
+	s.emitEvent(ctx, "TransportCreated", eventPayload, &transport)
+
+	return &transport, nil
+}
+
+// UpdateTransportStatus updates transport status. A transport is completed
+// with ConfirmTransportReceipt, which records the condition the goods
+// arrived in.
+func (s *SupplyChainContract) UpdateTransportStatus(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+	newStatus string,
+) (*TransportAsset, error) {
+	// Authorization check
+	transport, err := s.authorizeAndLoadTransport(ctx, MinFarmOrgMSP, transportID)
+	if err != nil {
+		return nil, err
+	}
+	if newStatus == "COMPLETED" {
+		return nil, fmt.Errorf("transports are completed with ConfirmTransportReceipt")
+	}
+
+	// Validate transition
+	if err := s.ValidateStatusTransition(ctx, transport.Status, newStatus); err != nil {
+		return nil, err
+	}
+
+	transport.Status = newStatus
+	transport.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	transportBytes, err := json.Marshal(transport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transport: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(transportID, transportBytes); err != nil {
+		return nil, fmt.Errorf("failed to update transport: %v", err)
+	}
+
+	return transport, nil
+}
+
+// ConfirmTransportReceipt completes a transport at receipt, recording the
+// condition the goods arrived in as one of transportConditionRatings. An
+// empty arrivalTime is the transaction timestamp. Goods that are not GOOD
+// raise a TransportDamaged event for claims and insurance follow-up.
+func (s *SupplyChainContract) ConfirmTransportReceipt(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+	arrivalTime string,
+	conditionRating string,
+	conditionNotes string,
+) (*TransportAsset, error) {
+	// Authorization check
+	transport, err := s.authorizeAndLoadTransport(ctx, MinFarmOrgMSP, transportID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation
+	conditionRating = strings.ToUpper(strings.TrimSpace(conditionRating))
+	known := false
+	for _, rating := range transportConditionRatings {
+		if rating == conditionRating {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("invalid conditionRating %q, allowed: %s", conditionRating, strings.Join(transportConditionRatings, ", "))
+	}
+	if arrivalTime == "" {
+		arrivalTime = s.GetTxTimestamp(ctx)
+	} else if _, err := parseLedgerDate(arrivalTime); err != nil {
+		return nil, fmt.Errorf("invalid arrivalTime %q: must be RFC3339 or YYYY-MM-DD", arrivalTime)
+	}
+
+	// Validate transition
+	if err := s.ValidateStatusTransition(ctx, transport.Status, "COMPLETED"); err != nil {
+		return nil, err
+	}
+
+	transport.Status = "COMPLETED"
+	transport.ArrivalTime = arrivalTime
+	transport.ConditionRating = conditionRating
+	transport.ConditionNotes = conditionNotes
+	transport.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	transportBytes, err := json.Marshal(transport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transport: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(transportID, transportBytes); err != nil {
+		return nil, fmt.Errorf("failed to update transport: %v", err)
+	}
+
+	// Emit event, only for goods that did not arrive GOOD
+	if conditionRating != "GOOD" {
+		eventPayload := map[string]interface{}{
+			"transport_id":     transportID,
+			"batch_id":         transport.BatchID,
+			"condition_rating": conditionRating,
+			"condition_notes":  conditionNotes,
+			"arrival_time":     arrivalTime,
+		}
+		s.emitEvent(ctx, "TransportDamaged", eventPayload, transport)
+	}
+
+	return transport, nil
+}
+
+// GetDamagedTransports retrieves the transports received in a condition other
+// than GOOD, most recent arrival first
+func (s *SupplyChainContract) GetDamagedTransports(
+	ctx contractapi.TransactionContextInterface,
+) ([]*TransportAsset, error) {
+	transports, err := queryAssets[TransportAsset](ctx, map[string]interface{}{
+		"docType":          "TransportAsset",
+		"condition_rating": map[string]interface{}{"$in": []string{"DAMAGED", "SPOILED"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(transports, func(i, j int) bool {
+		return transports[i].ArrivalTime > transports[j].ArrivalTime
+	})
+
+	return transports, nil
+}
+
+// GetTransport retrieves a transport by ID
+func (s *SupplyChainContract) GetTransport(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+) (*TransportAsset, error) {
+	transport, found, err := s.TryGetTransport(ctx, transportID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("transport %s not found", transportID)
+	}
+
+	return transport, nil
+}
+
+// TryGetTransport retrieves a transport by ID, reporting found=false with a nil error
+// when it does not exist. Errors are reserved for ledger and decode failures.
+func (s *SupplyChainContract) TryGetTransport(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+) (*TransportAsset, bool, error) {
+	if err := s.ValidateNonEmptyString(transportID, "transportID"); err != nil {
+		return nil, false, err
+	}
+
+	transportBytes, err := ctx.GetStub().GetState(transportID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read transport: %v", err)
+	}
+	if transportBytes == nil {
+		return nil, false, nil
+	}
+
+	var transport TransportAsset
+	transportErr := json.Unmarshal(transportBytes, &transport)
+	if transportErr != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal transport: %v", transportErr)
+	}
+
+	return &transport, true, nil
+}
+
+// AddTemperatureLog adds a temperature reading
+func (s *SupplyChainContract) AddTemperatureLog(
+	ctx contractapi.TransactionContextInterface,
+	logID string,
+	transportID string,
+	temperature float64,
+	timestamp string,
+	location string,
+) (*TemperatureLogAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(logID, "logID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidatePositiveFloat(temperature, "temperature"); err != nil {
+		return nil, err
+	}
+
+	// Check transport exists
+	_, err := s.GetTransport(ctx, transportID)
+	if err != nil {
+		return nil, fmt.Errorf("transport does not exist: %v", err)
+	}
+
+	// Readings can be required to arrive in timestamp order
+	if err := s.checkTemperatureLogOrder(ctx, transportID, timestamp); err != nil {
+		return nil, err
+	}
+
+	tempLog := TemperatureLogAsset{
+		DocType:     "TemperatureLogAsset",
+		LogID:       logID,
+		TransportID: transportID,
+		Temperature: temperature,
+		Timestamp:   timestamp,
+		Location:    location,
+		CreatedAt:   s.GetTxTimestamp(ctx),
+	}
+
+	if err := s.saveTemperatureLog(ctx, &tempLog); err != nil {
+		return nil, err
+	}
+
+	return &tempLog, nil
+}
+
+// checkTemperatureLogOrder rejects a transport reading timestamped earlier
+// than the transport's latest stored reading when the RequireMonotonicTempLogs
+// feature flag is on. Readings with the same timestamp are allowed.
+func (s *SupplyChainContract) checkTemperatureLogOrder(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+	timestamp string,
+) error {
+	requireOrder, err := s.isFeatureEnabled(ctx, FeatureRequireMonotonicTempLogs)
+	if err != nil {
+		return err
+	}
+	if !requireOrder {
+		return nil
+	}
+
+	readingTime, err := parseLedgerDate(timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q: %v", timestamp, err)
+	}
+
+	logs, err := s.GetTransportTemperatureLogs(ctx, transportID)
+	if err != nil {
+		return err
+	}
+	for _, existing := range logs {
+		existingTime, err := parseLedgerDate(existing.Timestamp)
+		if err != nil {
+			continue
+		}
+		if readingTime.Before(existingTime) {
+			return fmt.Errorf("timestamp out of order: %s is earlier than reading %s at %s for transport %s",
+				timestamp, existing.LogID, existing.Timestamp, transportID)
+		}
+	}
+	return nil
+}
+
+// saveTemperatureLog flags out-of-range readings, stores the log and emits
+// TemperatureViolationDetected for a violation. Transport and cold-storage
+// logs share this path.
+func (s *SupplyChainContract) saveTemperatureLog(
+	ctx contractapi.TransactionContextInterface,
+	tempLog *TemperatureLogAsset,
+) error {
+	// Detect temperature violation
+	tempLog.IsViolation = tempLog.Temperature < TemperatureMinSafe || tempLog.Temperature > TemperatureMaxSafe
+
+	logBytes, err := json.Marshal(tempLog)
+	if err != nil {
+		return fmt.Errorf("failed to marshal temperature log: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(tempLog.LogID, logBytes); err != nil {
+		return fmt.Errorf("failed to save temperature log: %v", err)
+	}
+
+	// Emit violation event if detected
+	if tempLog.IsViolation {
+		eventPayload := map[string]interface{}{
+			"temperature": tempLog.Temperature,
+			"threshold":   fmt.Sprintf("%.1f-%.1f°C", TemperatureMinSafe, TemperatureMaxSafe),
+		}
+		if tempLog.TransportID != "" {
+			eventPayload["transport_id"] = tempLog.TransportID
+		}
+		if tempLog.StorageAssignmentID != "" {
+			eventPayload["storage_assignment_id"] = tempLog.StorageAssignmentID
+		}
+		s.emitEvent(ctx, "TemperatureViolationDetected", eventPayload, tempLog)
+	}
+
+	return nil
+}
+
+// GetTransportTemperatureLogs retrieves all temperature logs for a transport
+func (s *SupplyChainContract) GetTransportTemperatureLogs(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+) ([]*TemperatureLogAsset, error) {
+	if err := s.ValidateNonEmptyString(transportID, "transportID"); err != nil {
+		return nil, err
+	}
+
+	logs, err := queryAssets[TemperatureLogAsset](ctx, map[string]interface{}{
+		"docType":      "TemperatureLogAsset",
+		"transport_id": transportID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp < logs[j].Timestamp
+	})
+
+	return logs, nil
+}
+
+// GetTransportTemperatureExtremes returns just the coldest and hottest
+// readings of a transport with their timestamps and locations, for displays
+// that do not need the full log set. When several readings share an extreme
+// the earliest is reported.
+func (s *SupplyChainContract) GetTransportTemperatureExtremes(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+) (*TemperatureExtremes, error) {
+	if _, err := s.GetTransport(ctx, transportID); err != nil {
+		return nil, err
+	}
+
+	logs, err := s.GetTransportTemperatureLogs(ctx, transportID)
+	if err != nil {
+		return nil, err
+	}
+
+	extremes := &TemperatureExtremes{
+		TransportID:  transportID,
+		NoData:       len(logs) == 0,
+		ReadingCount: len(logs),
+	}
+	for _, tempLog := range logs {
+		reading := &TemperatureReading{
+			LogID:       tempLog.LogID,
+			Temperature: tempLog.Temperature,
+			Timestamp:   tempLog.Timestamp,
+			Location:    tempLog.Location,
+		}
+		if extremes.Min == nil || reading.Temperature < extremes.Min.Temperature {
+			extremes.Min = reading
+		}
+		if extremes.Max == nil || reading.Temperature > extremes.Max.Temperature {
+			extremes.Max = reading
+		}
+	}
+
+	return extremes, nil
+}
+
+// GetTransportsInTransitForParty retrieves IN_TRANSIT transports heading to a
+// party, ordered by expected arrival (transports without one sort last)
+func (s *SupplyChainContract) GetTransportsInTransitForParty(
+	ctx contractapi.TransactionContextInterface,
+	partyID string,
+) ([]*TransportAsset, error) {
+	if err := s.ValidateNonEmptyString(partyID, "partyID"); err != nil {
+		return nil, err
+	}
+
+	transports, err := queryAssets[TransportAsset](ctx, map[string]interface{}{
+		"docType":     "TransportAsset",
+		"to_party_id": partyID,
+		"status":      "IN_TRANSIT",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(transports, func(i, j int) bool {
+		ei, ej := transports[i].ExpectedArrivalTime, transports[j].ExpectedArrivalTime
+		if ei == "" || ej == "" {
+			return ej == "" && ei != ""
+		}
+		return ei < ej
+	})
+
+	return transports, nil
+}
+
+// GetTransportComplianceScore returns the percentage of a transport's
+// temperature readings that were within the safe range. A transport with no
+// readings reports NoData instead of a 100% score.
+func (s *SupplyChainContract) GetTransportComplianceScore(
+	ctx contractapi.TransactionContextInterface,
+	transportID string,
+) (*TransportComplianceScore, error) {
+	if _, err := s.GetTransport(ctx, transportID); err != nil {
+		return nil, err
+	}
+
+	logs, err := s.GetTransportTemperatureLogs(ctx, transportID)
+	if err != nil {
+		return nil, err
+	}
+
+	score := &TransportComplianceScore{
+		TransportID:   transportID,
+		TotalReadings: len(logs),
+	}
+	if len(logs) == 0 {
+		score.NoData = true
+		return score, nil
+	}
+
+	for _, log := range logs {
+		if !log.IsViolation {
+			score.CompliantReadings++
+		}
+	}
+	score.CompliancePercent = float64(score.CompliantReadings) * 100 / float64(score.TotalReadings)
+
+	return score, nil
+}
+
+// GetFarmerColdChainScore aggregates temperature compliance across the
+// temperature-monitored transports of all of a farmer's batches, weighting
+// every reading equally. A farmer with no monitored transports, or none with
+// readings, reports NoData instead of a 100% score.
+func (s *SupplyChainContract) GetFarmerColdChainScore(
+	ctx contractapi.TransactionContextInterface,
+	farmerID string,
+) (*FarmerColdChainScore, error) {
+	if err := s.ValidateNonEmptyString(farmerID, "farmerID"); err != nil {
+		return nil, err
+	}
+
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType":   "BatchAsset",
+		"farmer_id": farmerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	score := &FarmerColdChainScore{FarmerID: farmerID, BatchCount: len(batches)}
+	for _, batch := range batches {
+		transports, err := s.GetTransportsByBatch(ctx, batch.BatchID)
+		if err != nil {
+			return nil, err
+		}
+		for _, transport := range transports {
+			if !transport.TemperatureMonitored {
+				continue
+			}
+			score.MonitoredTransports++
+
+			logs, err := s.GetTransportTemperatureLogs(ctx, transport.TransportID)
+			if err != nil {
+				return nil, err
+			}
+			for _, log := range logs {
+				score.TotalReadings++
+				if log.IsViolation {
+					score.ViolationCount++
+				} else {
+					score.CompliantReadings++
+				}
+			}
+		}
+	}
+
+	if score.TotalReadings == 0 {
+		score.NoData = true
+		return score, nil
+	}
+	score.CompliancePercent = float64(score.CompliantReadings) * 100 / float64(score.TotalReadings)
+
+	return score, nil
+}
+
+// GetTransportsByBatch retrieves all transports for a batch
+func (s *SupplyChainContract) GetTransportsByBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*TransportAsset, error) {
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return nil, err
+	}
+
+	transports, err := queryAssets[TransportAsset](ctx, map[string]interface{}{
+		"docType":  "TransportAsset",
+		"batch_id": batchID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(transports, func(i, j int) bool {
+		return transports[i].DepartureTime < transports[j].DepartureTime
+	})
+
+	return transports, nil
+}
+
+// GetTransportDurationStats summarizes how long COMPLETED transports from
+// origin to destination took, departure to arrival. Transports without a
+// parseable departure and arrival time, or arriving before departing, are
+// skipped.
+func (s *SupplyChainContract) GetTransportDurationStats(
+	ctx contractapi.TransactionContextInterface,
+	origin string,
+	destination string,
+) (*TransportDurationStats, error) {
+	if err := s.ValidateNonEmptyString(origin, "origin"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(destination, "destination"); err != nil {
+		return nil, err
+	}
+
+	transports, err := queryAssets[TransportAsset](ctx, map[string]interface{}{
+		"docType":              "TransportAsset",
+		"origin_location":      origin,
+		"destination_location": destination,
+		"status":               "COMPLETED",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &TransportDurationStats{OriginLocation: origin, DestinationLocation: destination}
+	var totalSeconds int64
+	for _, transport := range transports {
+		if transport.ArrivalTime == "" {
+			continue
+		}
+		departure, err := parseLedgerDate(transport.DepartureTime)
+		if err != nil {
+			continue
+		}
+		arrival, err := parseLedgerDate(transport.ArrivalTime)
+		if err != nil || arrival.Before(departure) {
+			continue
+		}
+
+		seconds := int64(arrival.Sub(departure) / time.Second)
+		if stats.SampleCount == 0 || seconds < stats.MinDurationSeconds {
+			stats.MinDurationSeconds = seconds
+		}
+		if seconds > stats.MaxDurationSeconds {
+			stats.MaxDurationSeconds = seconds
+		}
+		totalSeconds += seconds
+		stats.SampleCount++
+	}
+
+	if stats.SampleCount == 0 {
+		stats.NoData = true
+		return stats, nil
+	}
+	stats.AvgDurationSeconds = float64(totalSeconds) / float64(stats.SampleCount)
+
+	return stats, nil
+}
+
+// GetBatchShippedQuantity returns the quantity of a batch on non-cancelled
+// transport manifests and how much of its current quantity remains unshipped
+func (s *SupplyChainContract) GetBatchShippedQuantity(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchShippedQuantity, error) {
+	totalQuantity, err := s.GetBatchCurrentQuantity(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	shipped, err := s.getTransportedQuantity(ctx, batchID, totalQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mortality recorded after shipping can leave more on manifests than the batch holds
+	remaining := totalQuantity - shipped
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &BatchShippedQuantity{
+		BatchID:           batchID,
+		TotalQuantity:     totalQuantity,
+		ShippedQuantity:   shipped,
+		RemainingQuantity: remaining,
+	}, nil
+}
+
+// getTransportedQuantity sums the quantity on a batch's non-cancelled
+// manifests. Manifests recorded before quantities were tracked count as
+// carrying the whole batch.
+func (s *SupplyChainContract) getTransportedQuantity(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	batchQuantity int,
+) (int, error) {
+	transports, err := s.GetTransportsByBatch(ctx, batchID)
+	if err != nil {
+		return 0, err
+	}
+
+	shipped := 0
+	for _, transport := range transports {
+		if transport.Status == "CANCELLED" {
+			continue
+		}
+		if transport.Quantity == 0 {
+			shipped += batchQuantity
+		} else {
+			shipped += transport.Quantity
+		}
+	}
+	return shipped, nil
+}
+
+// ============================================================================
+// FACILITY FUNCTIONS
+// ============================================================================
+
+// RegisterFacility registers a processing facility (Regulator only)
+func (s *SupplyChainContract) RegisterFacility(
+	ctx contractapi.TransactionContextInterface,
+	facilityID string,
+	name string,
+	address string,
+	licenseNumber string,
+	licenseExpiry string,
+) (*FacilityAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(facilityID, "facilityID"); err != nil {
+		return nil, err
+	}
+	if err := s.validateFacilityFields(name, licenseNumber, licenseExpiry); err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "FacilityAsset", facilityID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("facility %s already exists", facilityID)
+	}
+
+	facility := FacilityAsset{
+		DocType:       "FacilityAsset",
+		FacilityID:    facilityID,
+		Name:          name,
+		Address:       address,
+		LicenseNumber: licenseNumber,
+		LicenseExpiry: licenseExpiry,
+		IsActive:      true,
+		CreatedAt:     s.GetTxTimestamp(ctx),
+		UpdatedAt:     s.GetTxTimestamp(ctx),
+	}
+
+	facilityBytes, err := json.Marshal(facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal facility: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(facilityID, facilityBytes); err != nil {
+		return nil, fmt.Errorf("failed to save facility: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"facility_id": facilityID, "license_number": licenseNumber}
+	s.emitEvent(ctx, "FacilityRegistered", eventPayload, &facility)
+
+	return &facility, nil
+}
+
+// UpdateFacility updates a facility's details, license and active flag (Regulator only)
+func (s *SupplyChainContract) UpdateFacility(
+	ctx contractapi.TransactionContextInterface,
+	facilityID string,
+	name string,
+	address string,
+	licenseNumber string,
+	licenseExpiry string,
+	isActive bool,
+) (*FacilityAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	facility, err := s.GetFacility(ctx, facilityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateFacilityFields(name, licenseNumber, licenseExpiry); err != nil {
+		return nil, err
+	}
+
+	facility.Name = name
+	facility.Address = address
+	facility.LicenseNumber = licenseNumber
+	facility.LicenseExpiry = licenseExpiry
+	facility.IsActive = isActive
+	facility.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	facilityBytes, err := json.Marshal(facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal facility: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(facilityID, facilityBytes); err != nil {
+		return nil, fmt.Errorf("failed to update facility: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"facility_id": facilityID, "is_active": isActive}
+	s.emitEvent(ctx, "FacilityUpdated", eventPayload, facility)
+
+	return facility, nil
+}
+
+// GetFacility retrieves a facility by ID
+func (s *SupplyChainContract) GetFacility(
+	ctx contractapi.TransactionContextInterface,
+	facilityID string,
+) (*FacilityAsset, error) {
+	if err := s.ValidateNonEmptyString(facilityID, "facilityID"); err != nil {
+		return nil, err
+	}
+
+	facilityBytes, err := ctx.GetStub().GetState(facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read facility: %v", err)
+	}
+	if facilityBytes == nil {
+		return nil, fmt.Errorf("facility %s not found", facilityID)
+	}
+
+	var facility FacilityAsset
+	facilityErr := json.Unmarshal(facilityBytes, &facility)
+	if facilityErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal facility: %v", facilityErr)
+	}
+
+	return &facility, nil
+}
+
+// GetExpiringFacilityLicenses retrieves active facilities whose license
+// expires within withinDays of asOfDate, including already expired ones
+func (s *SupplyChainContract) GetExpiringFacilityLicenses(
+	ctx contractapi.TransactionContextInterface,
+	asOfDate string,
+	withinDays int,
+) ([]*FacilityAsset, error) {
+	asOf, err := parseLedgerDate(asOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid asOfDate %q: %v", asOfDate, err)
+	}
+	if withinDays < 0 {
+		return nil, fmt.Errorf("withinDays must be non-negative, got %d", withinDays)
+	}
+	cutoff := asOf.AddDate(0, 0, withinDays)
+
+	facilities, err := queryAssets[FacilityAsset](ctx, map[string]interface{}{
+		"docType":   "FacilityAsset",
+		"is_active": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expiring := []*FacilityAsset{}
+	for _, facility := range facilities {
+		expiry, err := parseLedgerDeadline(facility.LicenseExpiry)
+		if err != nil || !expiry.After(cutoff) {
+			expiring = append(expiring, facility)
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].LicenseExpiry < expiring[j].LicenseExpiry
+	})
+
+	return expiring, nil
+}
+
+// validateFacilityFields validates the fields shared by RegisterFacility and UpdateFacility
+func (s *SupplyChainContract) validateFacilityFields(name, licenseNumber, licenseExpiry string) error {
+	if err := s.ValidateNonEmptyString(name, "name"); err != nil {
+		return err
+	}
+	if err := s.ValidateNonEmptyString(licenseNumber, "licenseNumber"); err != nil {
+		return err
+	}
+	if _, err := parseLedgerDeadline(licenseExpiry); err != nil {
+		return fmt.Errorf("invalid licenseExpiry %q: %v", licenseExpiry, err)
+	}
+	return nil
+}
+
+// validateFacilityLicensed checks a facility is active and its license covers date
+func (s *SupplyChainContract) validateFacilityLicensed(facility *FacilityAsset, date string) error {
+	if !facility.IsActive {
+		return fmt.Errorf("facility %s is not active", facility.FacilityID)
+	}
+	processTime, err := parseLedgerDate(date)
+	if err != nil {
+		return fmt.Errorf("invalid processDate %q: %v", date, err)
+	}
+	expiry, err := parseLedgerDeadline(facility.LicenseExpiry)
+	if err != nil {
+		return fmt.Errorf("facility %s has an invalid license expiry %q", facility.FacilityID, facility.LicenseExpiry)
+	}
+	if processTime.After(expiry) {
+		return fmt.Errorf("facility %s license %s expired on %s", facility.FacilityID, facility.LicenseNumber, facility.LicenseExpiry)
+	}
+	return nil
+}
+
+// ============================================================================
+// EQUIPMENT FUNCTIONS
+// ============================================================================
+
+// RegisterEquipment registers a processing line or machine at a facility
+func (s *SupplyChainContract) RegisterEquipment(
+	ctx contractapi.TransactionContextInterface,
+	equipmentID string,
+	facilityID string,
+	equipmentType string,
+	lastSanitationDate string,
+) (*EquipmentAsset, error) {
+	// Authorization check
+	if err := s.authorizeAnyMSP(ctx, ProcessorOrgMSP, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(equipmentID, "equipmentID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(equipmentType, "equipmentType"); err != nil {
+		return nil, err
+	}
+	if _, err := parseLedgerDate(lastSanitationDate); err != nil {
+		return nil, fmt.Errorf("invalid lastSanitationDate %q: %v", lastSanitationDate, err)
+	}
+
+	// Check facility exists
+	if _, err := s.GetFacility(ctx, facilityID); err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "EquipmentAsset", equipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("equipment %s already exists", equipmentID)
+	}
+
+	equipment := EquipmentAsset{
+		DocType:        "EquipmentAsset",
+		EquipmentID:    equipmentID,
+		FacilityID:     facilityID,
+		EquipmentType:  equipmentType,
+		LastSanitation: lastSanitationDate,
+		CreatedAt:      s.GetTxTimestamp(ctx),
+		UpdatedAt:      s.GetTxTimestamp(ctx),
+	}
+
+	equipmentBytes, err := json.Marshal(equipment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal equipment: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(equipmentID, equipmentBytes); err != nil {
+		return nil, fmt.Errorf("failed to save equipment: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"equipment_id": equipmentID, "facility_id": facilityID}
+	s.emitEvent(ctx, "EquipmentRegistered", eventPayload, &equipment)
+
+	return &equipment, nil
+}
+
+// RecordEquipmentSanitation records that a piece of equipment was sanitized
+func (s *SupplyChainContract) RecordEquipmentSanitation(
+	ctx contractapi.TransactionContextInterface,
+	equipmentID string,
+	sanitationDate string,
+) (*EquipmentAsset, error) {
+	// Authorization check
+	if err := s.authorizeAnyMSP(ctx, ProcessorOrgMSP, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	equipment, err := s.GetEquipment(ctx, equipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	sanitized, err := parseLedgerDate(sanitationDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sanitationDate %q: %v", sanitationDate, err)
+	}
+	if last, err := parseLedgerDate(equipment.LastSanitation); err == nil && sanitized.Before(last) {
+		return nil, fmt.Errorf("sanitationDate %s is before the last recorded sanitation %s", sanitationDate, equipment.LastSanitation)
+	}
+
+	equipment.LastSanitation = sanitationDate
+	equipment.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	equipmentBytes, err := json.Marshal(equipment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal equipment: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(equipmentID, equipmentBytes); err != nil {
+		return nil, fmt.Errorf("failed to update equipment: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"equipment_id": equipmentID, "sanitation_date": sanitationDate}
+	s.emitEvent(ctx, "EquipmentSanitized", eventPayload, equipment)
+
+	return equipment, nil
+}
+
+// GetEquipment retrieves equipment by ID
+func (s *SupplyChainContract) GetEquipment(
+	ctx contractapi.TransactionContextInterface,
+	equipmentID string,
+) (*EquipmentAsset, error) {
+	if err := s.ValidateNonEmptyString(equipmentID, "equipmentID"); err != nil {
+		return nil, err
+	}
+
+	equipmentBytes, err := ctx.GetStub().GetState(equipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read equipment: %v", err)
+	}
+	if equipmentBytes == nil {
+		return nil, fmt.Errorf("equipment %s not found", equipmentID)
+	}
+
+	var equipment EquipmentAsset
+	equipmentErr := json.Unmarshal(equipmentBytes, &equipment)
+	if equipmentErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal equipment: %v", equipmentErr)
+	}
+
+	return &equipment, nil
+}
+
+// GetProcessingByEquipment retrieves processing records that used a piece of
+// equipment within an inclusive processing date range, ordered by date
+func (s *SupplyChainContract) GetProcessingByEquipment(
+	ctx contractapi.TransactionContextInterface,
+	equipmentID string,
+	fromDate string,
+	toDate string,
+) ([]*ProcessingAsset, error) {
+	if err := s.ValidateNonEmptyString(equipmentID, "equipmentID"); err != nil {
+		return nil, err
+	}
+
+	dateRange, err := dateRangeSelector(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := queryAssets[ProcessingAsset](ctx, map[string]interface{}{
+		"docType":         "ProcessingAsset",
+		"equipment_ids":   map[string]interface{}{"$elemMatch": map[string]interface{}{"$eq": equipmentID}},
+		"processing_date": dateRange,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ProcessDate < records[j].ProcessDate
+	})
+
+	return records, nil
+}
+
+// checkProcessingEquipment validates that every equipment ID exists at the
+// facility and returns those whose last sanitation is older than the
+// SanitationMaxAgeDays threshold at startTime
+func (s *SupplyChainContract) checkProcessingEquipment(
+	ctx contractapi.TransactionContextInterface,
+	facilityID string,
+	equipmentIDs []string,
+	startTime time.Time,
+) ([]string, error) {
+	maxAgeDays, limited, err := s.getThreshold(ctx, ThresholdSanitationMaxAgeDays)
+	if err != nil {
+		return nil, err
+	}
+
+	overdue := []string{}
+	seen := map[string]bool{}
+	for _, equipmentID := range equipmentIDs {
+		if seen[equipmentID] {
+			return nil, fmt.Errorf("equipment %s listed more than once", equipmentID)
+		}
+		seen[equipmentID] = true
+
+		equipment, err := s.GetEquipment(ctx, equipmentID)
+		if err != nil {
+			return nil, err
+		}
+		if equipment.FacilityID != facilityID {
+			return nil, fmt.Errorf("equipment %s belongs to facility %s, not %s", equipmentID, equipment.FacilityID, facilityID)
+		}
+
+		if !limited {
+			continue
+		}
+		sanitized, err := parseLedgerDate(equipment.LastSanitation)
+		if err != nil || startTime.Sub(sanitized).Hours() > maxAgeDays*24 {
+			overdue = append(overdue, equipmentID)
+		}
+	}
+
+	return overdue, nil
+}
+
+// ============================================================================
+// PROCESSING FUNCTIONS
+// ============================================================================
+
+// RecordProcessing records processing facility output
+func (s *SupplyChainContract) RecordProcessing(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+	batchID string,
+	stage string,
+	processDate string,
+	processStartTime string,
+	processEndTime string,
+	facilityID string,
+	slaughterCount int,
+	yieldKg float64,
+	qualityScore float64,
+	equipmentIDs []string,
+	clearanceOverrideID string,
+	notes string,
+) (*ProcessingAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation; the field checks run on the assembled record below
+	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+		return nil, err
+	}
+	stage, err := s.ValidateProcessingStage(stage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check batch exists
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch does not exist: %v", err)
+	}
+
+	// A batch under stop-sale may not be processed
+	if err := s.checkNoStopSale(ctx, batchID); err != nil {
+		return nil, err
+	}
+
+	// Production must be finished before processing
+	readinessOverride, err := s.checkBatchReadyForProcessing(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the batch holds its product's processing clearance, unless the
+	// Regulator has issued an emergency override for it
+	clearanceOverride, err := s.checkProcessingClearance(ctx, batch, clearanceOverrideID)
+	if err != nil {
+		return nil, err
+	}
+	usedOverrideID := ""
+	if clearanceOverride != nil {
+		usedOverrideID = clearanceOverride.OverrideID
+	}
+
+	// Processing window must be well-formed and start after delivery
+	startTime, err := time.Parse(time.RFC3339, processStartTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid processStartTime %q: must be RFC3339", processStartTime)
+	}
+	endTime, err := time.Parse(time.RFC3339, processEndTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid processEndTime %q: must be RFC3339", processEndTime)
+	}
+	if !endTime.After(startTime) {
+		return nil, fmt.Errorf("processEndTime %s must be after processStartTime %s", processEndTime, processStartTime)
+	}
+	if err := s.validateStartAfterDelivery(ctx, batchID, startTime); err != nil {
+		return nil, err
+	}
+
+	// A slaughter count above the live quantity is flagged, not rejected
+	availableQuantity, err := s.GetBatchCurrentQuantity(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Yield per batch unit above MaxYieldKgPerUnit is rejected, or flagged
+	// when FlagImplausibleYieldInsteadOfReject is on
+	yieldImplausible, maxYieldPerUnit, err := s.checkYieldPlausible(ctx, batch, yieldKg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the facility is active and licensed on the processing date
+	facility, err := s.GetFacility(ctx, facilityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateFacilityLicensed(facility, processDate); err != nil {
+		return nil, err
+	}
+
+	// Equipment must belong to the facility; stale sanitation is flagged
+	sanitationOverdue, err := s.checkProcessingEquipment(ctx, facility.FacilityID, equipmentIDs, startTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "ProcessingAsset", processingID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("processing record %s already exists", processingID)
+	}
+
+	// Grade is fixed at write time against the current bands
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	processing := ProcessingAsset{
+		DocType:           "ProcessingAsset",
+		ProcessingID:      processingID,
+		BatchID:           batchID,
+		Stage:             stage,
+		ProcessDate:       processDate,
+		StartTime:         processStartTime,
+		EndTime:           processEndTime,
+		DurationMins:      int(endTime.Sub(startTime).Minutes()),
+		FacilityID:        facility.FacilityID,
+		FacilityName:      facility.Name,
+		SlaughterCnt:      slaughterCount,
+		YieldKg:           yieldKg,
+		QualityScore:      qualityScore,
+		QualityGrade:      gradeForQualityScore(config.QualityGradeBands, qualityScore),
+		OverrideID:        usedOverrideID,
+		ReadinessOverride: readinessOverride,
+		EquipmentIDs:      equipmentIDs,
+		SanitationOverdue: sanitationOverdue,
+		YieldImplausible:  yieldImplausible,
+		Notes:             notes,
+		CreatedAt:         s.GetTxTimestamp(ctx),
+		UpdatedAt:         s.GetTxTimestamp(ctx),
+	}
+	if err := validationError(validateProcessingAsset(&processing)); err != nil {
+		return nil, err
+	}
+
+	processingBytes, err := json.Marshal(processing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal processing: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(processingID, processingBytes); err != nil {
+		return nil, fmt.Errorf("failed to save processing: %v", err)
+	}
+
+	if clearanceOverride != nil {
+		if err := s.consumeClearanceOverride(ctx, clearanceOverride, processingID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Emit event. Fabric keeps a single event per transaction, so a quantity
+	// mismatch or implausible yield is flagged in the ProcessingRecorded
+	// payload rather than sent as its own event.
+	eventPayload := s.processingEventPayload(ctx, &processing)
+	if yieldImplausible {
+		eventPayload["yield_implausible"] = true
+		eventPayload["batch_quantity"] = batch.Quantity
+		eventPayload["max_yield_kg_per_unit"] = maxYieldPerUnit
+	}
+	if slaughterCount > availableQuantity {
+		eventPayload["quantity_mismatch"] = true
+		eventPayload["available_quantity"] = availableQuantity
+	}
+	s.emitEvent(ctx, "ProcessingRecorded", eventPayload, &processing)
+
+	return &processing, nil
+}
+
+// checkYieldPlausible compares yieldKg per unit of the batch's quantity with
+// the MaxYieldKgPerUnit threshold. Over the threshold it returns an error, or
+// reports the yield as implausible when FlagImplausibleYieldInsteadOfReject is
+// on. The check is skipped when the threshold is unset or the batch quantity
+// is zero.
+func (s *SupplyChainContract) checkYieldPlausible(
+	ctx contractapi.TransactionContextInterface,
+	batch *BatchAsset,
+	yieldKg float64,
+) (bool, float64, error) {
+	maxYieldPerUnit, limited, err := s.getThreshold(ctx, ThresholdMaxYieldPerUnit)
+	if err != nil {
+		return false, 0, err
+	}
+	if !limited || batch.Quantity <= 0 {
+		return false, 0, nil
+	}
+
+	yieldPerUnit := yieldKg / float64(batch.Quantity)
+	if yieldPerUnit <= maxYieldPerUnit {
+		return false, maxYieldPerUnit, nil
+	}
+
+	flagOnly, err := s.isFeatureEnabled(ctx, FeatureFlagImplausibleYield)
+	if err != nil {
+		return false, 0, err
+	}
+	if !flagOnly {
+		return false, 0, fmt.Errorf("yield of %.2f kg is %.2f kg per unit of batch %s (quantity %d), above the maximum of %.2f kg per unit",
+			yieldKg, yieldPerUnit, batch.BatchID, batch.Quantity, maxYieldPerUnit)
+	}
+	return true, maxYieldPerUnit, nil
+}
+
+// GetProcessingRecord retrieves a processing record by ID together with its
+// amendments, as originally recorded
+func (s *SupplyChainContract) GetProcessingRecord(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) (*ProcessingAsset, error) {
+	processing, err := s.getProcessingRecord(ctx, processingID)
+	if err != nil {
+		return nil, err
+	}
+
+	processing.Amendments, err = s.getProcessingAmendments(ctx, processingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return processing, nil
+}
+
+// getProcessingRecord reads a processing record as stored, without amendments.
+// Paths that write the record back must use it.
+func (s *SupplyChainContract) getProcessingRecord(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) (*ProcessingAsset, error) {
+	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+		return nil, err
+	}
+
+	processingBytes, err := ctx.GetStub().GetState(processingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read processing: %v", err)
+	}
+	if processingBytes == nil {
+		return nil, fmt.Errorf("processing record %s not found", processingID)
+	}
+
+	var processing ProcessingAsset
+	processingErr := json.Unmarshal(processingBytes, &processing)
+	if processingErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal processing: %v", processingErr)
+	}
+
+	return &processing, nil
+}
+
+// RecordProcessingWaste appends a waste and byproduct entry to a processing
+// record. Multiple entries are allowed and summed. When LiveWeightKgPerUnit is
+// configured, yield plus all waste and byproducts may not exceed the
+// estimated input mass (slaughter count times live weight per unit).
+func (s *SupplyChainContract) RecordProcessingWaste(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+	wasteKg float64,
+	byproductKg float64,
+	disposalMethod string,
+) (*ProcessingAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidatePositiveFloat(wasteKg, "wasteKg"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidatePositiveFloat(byproductKg, "byproductKg"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(disposalMethod, "disposalMethod"); err != nil {
+		return nil, err
+	}
+
+	processing, err := s.getProcessingRecord(ctx, processingID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check against the estimated input mass
+	liveWeightPerUnit, ok, err := s.getThreshold(ctx, ThresholdLiveWeightKgPerUnit)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		totalMass := processing.YieldKg + wasteKg + byproductKg
+		for _, entry := range processing.WasteEntries {
+			totalMass += entry.WasteKg + entry.ByproductKg
+		}
+		inputEstimate := float64(processing.SlaughterCnt) * liveWeightPerUnit
+		if totalMass > inputEstimate {
+			return nil, fmt.Errorf("yield plus waste of %.2f kg exceeds estimated input mass of %.2f kg", totalMass, inputEstimate)
+		}
+	}
+
+	processing.WasteEntries = append(processing.WasteEntries, WasteEntry{
+		WasteKg:        wasteKg,
+		ByproductKg:    byproductKg,
+		DisposalMethod: disposalMethod,
+		RecordedAt:     s.GetTxTimestamp(ctx),
+	})
+	processing.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	processingBytes, err := json.Marshal(processing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal processing: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(processingID, processingBytes); err != nil {
+		return nil, fmt.Errorf("failed to update processing: %v", err)
+	}
+
+	// Emit event
+	eventPayload := s.processingEventPayload(ctx, processing)
+	eventPayload["waste_entry_count"] = len(processing.WasteEntries)
+	s.emitEvent(ctx, "ProcessingUpdated", eventPayload, processing)
+
+	return processing, nil
+}
+
+// processingEventPayload builds the versioned payload shared by
+// ProcessingRecorded and ProcessingUpdated
+func (s *SupplyChainContract) processingEventPayload(
+	ctx contractapi.TransactionContextInterface,
+	processing *ProcessingAsset,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"payload_version": ProcessingEventVersion,
+		"processing_id":   processing.ProcessingID,
+		"batch_id":        processing.BatchID,
+		"stage":           processing.Stage,
+		"processing_date": processing.ProcessDate,
+		"facility_id":     processing.FacilityID,
+		"slaughter_count": processing.SlaughterCnt,
+		"yield_kg":        processing.YieldKg,
+		"quality_score":   processing.QualityScore,
+		"quality_grade":   processing.QualityGrade,
+		"tx_timestamp":    s.GetTxTimestamp(ctx),
+	}
+}
+
+// GetFacilityWasteSummary sums waste entries of a facility's processing
+// records whose processing date falls in [fromDate, toDate] (Regulator only)
+func (s *SupplyChainContract) GetFacilityWasteSummary(
+	ctx contractapi.TransactionContextInterface,
+	facilityName string,
+	fromDate string,
+	toDate string,
+) (*FacilityWasteSummary, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(facilityName, "facilityName"); err != nil {
+		return nil, err
+	}
+	from, to, err := parseDateRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := queryAssets[ProcessingAsset](ctx, map[string]interface{}{
+		"docType":       "ProcessingAsset",
+		"facility_name": facilityName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &FacilityWasteSummary{
+		FacilityName:     facilityName,
+		FromDate:         fromDate,
+		ToDate:           toDate,
+		ByDisposalMethod: map[string]float64{},
+	}
+	for _, record := range records {
+		if !inDateRange(record.ProcessDate, from, to) {
+			continue
+		}
+		summary.RecordCount++
+		for _, entry := range record.WasteEntries {
+			summary.TotalWasteKg += entry.WasteKg
+			summary.TotalByproductKg += entry.ByproductKg
+			summary.ByDisposalMethod[entry.DisposalMethod] += entry.WasteKg + entry.ByproductKg
+		}
+	}
+
+	return summary, nil
+}
+
+// GetFacilityProcessingStats summarizes a facility's processing records
+// dated within [fromDate, toDate], with approved amendments applied. A
+// record is counted by its effective processing date, so one whose date was
+// amended into or out of the range is counted accordingly. The range may
+// span at most MaxStatsRangeDays. The quality average is weighted
+// by yield, as in GetBatchProcessingAggregate. Restricted to the Regulator,
+// Admin and processor organizations.
+func (s *SupplyChainContract) GetFacilityProcessingStats(
+	ctx contractapi.TransactionContextInterface,
+	facilityID string,
+	fromDate string,
+	toDate string,
+) (*FacilityProcessingStats, error) {
+	// Authorization check (Regulator, Admin or processor)
+	if err := s.authorizeAnyMSP(ctx, RegulatorOrgMSP, ProcessorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetFacility(ctx, facilityID); err != nil {
+		return nil, err
+	}
+	from, to, err := parseDateRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	if to.Sub(from) > time.Duration(MaxStatsRangeDays)*24*time.Hour {
+		return nil, fmt.Errorf("date range %s to %s exceeds %d days", fromDate, toDate, MaxStatsRangeDays)
+	}
+	dateRange, err := dateRangeSelector(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := queryAssets[ProcessingAsset](ctx, map[string]interface{}{
+		"docType":         "ProcessingAsset",
+		"facility_id":     facilityID,
+		"processing_date": dateRange,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Records stored outside the range may have been amended into it
+	amendments, err := queryAssets[ProcessingAmendmentAsset](ctx, map[string]interface{}{
+		"docType":                     "ProcessingAmendmentAsset",
+		"status":                      "APPROVED",
+		"corrections.processing_date": dateRange,
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, record := range records {
+		seen[record.ProcessingID] = true
+	}
+	for _, amendment := range amendments {
+		if seen[amendment.ProcessingID] || !amendment.Corrections.corrects("processing_date") {
+			continue
+		}
+		seen[amendment.ProcessingID] = true
+		record, err := s.getProcessingRecord(ctx, amendment.ProcessingID)
+		if err != nil {
+			return nil, err
+		}
+		if record.FacilityID == facilityID {
+			records = append(records, record)
+		}
+	}
+
+	stats := &FacilityProcessingStats{
+		FacilityID: facilityID,
+		FromDate:   fromDate,
+		ToDate:     toDate,
+	}
+	weightedQuality := 0.0
+	processingIDs := []string{}
+	for _, original := range records {
+		record, err := s.effectiveProcessingRecord(ctx, original)
+		if err != nil {
+			return nil, err
+		}
+		if !inDateRange(record.ProcessDate, from, to) {
+			continue
+		}
+		stats.RecordCount++
+		processingIDs = append(processingIDs, record.ProcessingID)
+		if record.SlaughterCnt > 0 {
+			stats.TotalSlaughterCount += record.SlaughterCnt
+		}
+		if record.YieldKg > 0 {
+			stats.TotalYieldKg += record.YieldKg
+			weightedQuality += record.QualityScore * record.YieldKg
+		}
+		if len(record.SanitationOverdue) > 0 || record.QualityGrade == QualityGradeReject {
+			stats.FlaggedRecordCount++
+		}
+	}
+	if stats.TotalYieldKg > 0 {
+		stats.AverageQualityScore = weightedQuality / stats.TotalYieldKg
+	}
+
+	if len(processingIDs) > 0 {
+		failedTests, err := queryAssets[LabTestAsset](ctx, map[string]interface{}{
+			"docType":       "LabTestAsset",
+			"processing_id": map[string]interface{}{"$in": processingIDs},
+			"passed":        false,
+		})
+		if err != nil {
+			return nil, err
+		}
+		stats.FailedLabTestCount = len(failedTests)
+	}
+
+	return stats, nil
+}
+
+// checkBatchReadyForProcessing requires a batch to be COMPLETED before it is
+// processed. The AllowProcessingBeforeBatchCompletion feature flag also
+// admits batches still in production (CREATED or IN_PROGRESS). Admin callers
+// may bypass the check for corrections, which is reported so the processing
+// record can carry the override. Batches on hold are always rejected.
+func (s *SupplyChainContract) checkBatchReadyForProcessing(
+	ctx contractapi.TransactionContextInterface,
+	batch *BatchAsset,
+) (bool, error) {
+	switch batch.Status {
+	case "COMPLETED":
+		return false, nil
+	case "ON_HOLD":
+		return false, fmt.Errorf("batch %s is on hold: %s", batch.BatchID, batch.HoldReason)
+	case "CREATED", "IN_PROGRESS":
+		allowEarly, err := s.isFeatureEnabled(ctx, FeatureAllowEarlyProcessing)
+		if err != nil {
+			return false, err
+		}
+		if allowEarly {
+			return false, nil
+		}
+	}
+
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+	if clientMSP == AdminOrgMSP {
+		return true, nil
+	}
+
+	return false, fmt.Errorf("batch %s is %s, only COMPLETED batches can be processed", batch.BatchID, batch.Status)
+}
+
+// validateStartAfterDelivery checks processing does not start before the
+// batch's first confirmed delivery, allowing ProcessingStartToleranceMinutes
+// of slack. Batches without a completed transport are not checked.
+func (s *SupplyChainContract) validateStartAfterDelivery(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	startTime time.Time,
+) error {
+	transports, err := s.GetTransportsByBatch(ctx, batchID)
+	if err != nil {
+		return err
+	}
+
+	var delivered time.Time
+	var deliveredBy string
+	for _, transport := range transports {
+		if transport.Status != "COMPLETED" || transport.ArrivalTime == "" {
+			continue
+		}
+		arrival, err := parseLedgerDate(transport.ArrivalTime)
+		if err != nil {
+			continue
+		}
+		if deliveredBy == "" || arrival.Before(delivered) {
+			delivered, deliveredBy = arrival, transport.TransportID
+		}
+	}
+	if deliveredBy == "" {
+		return nil
+	}
+
+	toleranceMinutes, _, err := s.getThreshold(ctx, ThresholdProcessingStartTolerance)
+	if err != nil {
+		return err
+	}
+	earliest := delivered.Add(-time.Duration(toleranceMinutes * float64(time.Minute)))
+	if startTime.Before(earliest) {
+		return fmt.Errorf("processing starts at %s, before batch delivery by transport %s at %s",
+			startTime.Format(time.RFC3339), deliveredBy, delivered.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// GetProcessingByFacility retrieves all processing records for a facility,
+// ordered by processing date
+func (s *SupplyChainContract) GetProcessingByFacility(
+	ctx contractapi.TransactionContextInterface,
+	facilityID string,
+) ([]*ProcessingAsset, error) {
+	if err := s.ValidateNonEmptyString(facilityID, "facilityID"); err != nil {
+		return nil, err
+	}
+
+	records, err := queryAssets[ProcessingAsset](ctx, map[string]interface{}{
+		"docType":     "ProcessingAsset",
+		"facility_id": facilityID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ProcessDate < records[j].ProcessDate
+	})
+
+	return records, nil
+}
+
+// GetProcessingRecordsByQualityBand retrieves the processing records whose
+// quality score is within [minScore, maxScore], highest score first. Scores
+// are those stored at write time; approved amendments are not applied.
+func (s *SupplyChainContract) GetProcessingRecordsByQualityBand(
+	ctx contractapi.TransactionContextInterface,
+	minScore float64,
+	maxScore float64,
+) ([]*ProcessingAsset, error) {
+	// Validation
+	if err := s.ValidateQualityScore(minScore); err != nil {
+		return nil, fmt.Errorf("minScore: %v", err)
+	}
+	if err := s.ValidateQualityScore(maxScore); err != nil {
+		return nil, fmt.Errorf("maxScore: %v", err)
+	}
+	if minScore > maxScore {
+		return nil, fmt.Errorf("minScore %.1f is above maxScore %.1f", minScore, maxScore)
+	}
+
+	records, err := queryAssets[ProcessingAsset](ctx, map[string]interface{}{
+		"docType":       "ProcessingAsset",
+		"quality_score": map[string]interface{}{"$gte": minScore, "$lte": maxScore},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].QualityScore > records[j].QualityScore
+	})
+
+	return records, nil
+}
+
+// GetProcessingRecordsByBatch retrieves all processing records for a batch,
+// ordered by stage and then by processing date
+func (s *SupplyChainContract) GetProcessingRecordsByBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*ProcessingAsset, error) {
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return nil, err
+	}
+
+	records, err := queryAssets[ProcessingAsset](ctx, map[string]interface{}{
+		"docType":  "ProcessingAsset",
+		"batch_id": batchID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		si, sj := stageIndex(records[i].Stage), stageIndex(records[j].Stage)
+		if si != sj {
+			return si < sj
+		}
+		return records[i].ProcessDate < records[j].ProcessDate
+	})
+
+	return records, nil
+}
+
+// GetBatchProcessingPipeline returns a batch's processing records grouped by
+// stage. Out-of-order stages (e.g. packaging dated before slaughter) and
+// skipped stages are reported as warnings rather than errors, since records
+// from different facilities often arrive late.
+func (s *SupplyChainContract) GetBatchProcessingPipeline(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*ProcessingPipeline, error) {
+	records, err := s.GetProcessingRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := &ProcessingPipeline{
+		BatchID:  batchID,
+		Stages:   []*ProcessingStageGroup{},
+		Warnings: []string{},
+	}
+
+	groups := map[string]*ProcessingStageGroup{}
+	for _, record := range records {
+		group, ok := groups[record.Stage]
+		if !ok {
+			group = &ProcessingStageGroup{Stage: record.Stage, Records: []*ProcessingAsset{}}
+			groups[record.Stage] = group
+			pipeline.Stages = append(pipeline.Stages, group)
+		}
+		group.Records = append(group.Records, record)
+	}
+
+	// Records are sorted by stage, so walking the groups in order lets us
+	// compare each stage against the ones expected before it
+	var latestEarlier *ProcessingAsset
+	lastIndex := -1
+	for _, group := range pipeline.Stages {
+		index := stageIndex(group.Stage)
+		if index < 0 {
+			pipeline.Warnings = append(pipeline.Warnings, fmt.Sprintf("unknown stage %q", group.Stage))
+			continue
+		}
+		for missing := lastIndex + 1; missing < index; missing++ {
+			pipeline.Warnings = append(pipeline.Warnings, fmt.Sprintf(
+				"stage %s has no records but later stage %s does", processingStageOrder[missing], group.Stage))
+		}
+		lastIndex = index
+
+		first := group.Records[0]
+		if latestEarlier != nil && datesOutOfOrder(latestEarlier.ProcessDate, first.ProcessDate) {
+			pipeline.Warnings = append(pipeline.Warnings, fmt.Sprintf(
+				"%s record %s (%s) is dated before %s record %s (%s)",
+				group.Stage, first.ProcessingID, first.ProcessDate,
+				latestEarlier.Stage, latestEarlier.ProcessingID, latestEarlier.ProcessDate))
+		}
+		latestEarlier = group.Records[len(group.Records)-1]
+	}
+
+	return pipeline, nil
+}
+
+// datesOutOfOrder reports whether later is strictly before earlier; unparseable dates are ignored
+func datesOutOfOrder(earlier, later string) bool {
+	earlierTime, err := parseLedgerDate(earlier)
+	if err != nil {
+		return false
+	}
+	laterTime, err := parseLedgerDate(later)
+	if err != nil {
+		return false
+	}
+	return laterTime.Before(earlierTime)
+}
+
+// GetBatchProcessingAggregate totals slaughter count, yield and waste across
+// a batch's processing records, with approved amendments applied. The quality
+// score is averaged weighted by yield; records without a yield (including
+// older partially filled ones) carry no weight, and the average is 0 when no
+// record has a yield.
+func (s *SupplyChainContract) GetBatchProcessingAggregate(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchProcessingAggregate, error) {
+	records, err := s.GetProcessingRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregate := &BatchProcessingAggregate{
+		BatchID:       batchID,
+		RecordCount:   len(records),
+		ProcessingIDs: []string{},
+	}
+	weightedQuality := 0.0
+	for _, original := range records {
+		record, err := s.effectiveProcessingRecord(ctx, original)
+		if err != nil {
+			return nil, err
+		}
+		aggregate.ProcessingIDs = append(aggregate.ProcessingIDs, record.ProcessingID)
+		if record.SlaughterCnt > 0 {
+			aggregate.TotalSlaughterCount += record.SlaughterCnt
+		}
+		if record.YieldKg > 0 {
+			aggregate.TotalYieldKg += record.YieldKg
+			weightedQuality += record.QualityScore * record.YieldKg
+		}
+		for _, entry := range record.WasteEntries {
+			aggregate.TotalWasteKg += entry.WasteKg
+			aggregate.TotalByproductKg += entry.ByproductKg
+		}
+	}
+	if aggregate.TotalYieldKg > 0 {
+		aggregate.AverageQualityScore = weightedQuality / aggregate.TotalYieldKg
+	}
+
+	return aggregate, nil
+}
+
+// GetBatchSummary retrieves a batch together with its current quantity,
+// shipped quantity and processing aggregate
+func (s *SupplyChainContract) GetBatchSummary(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchSummary, error) {
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	shipping, err := s.GetBatchShippedQuantity(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	processing, err := s.GetBatchProcessingAggregate(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchSummary{
+		Batch:           batch,
+		CurrentQuantity: shipping.TotalQuantity,
+		Shipping:        shipping,
+		Processing:      processing,
+	}, nil
+}
+
+// GetBatchProcessingYieldTrend returns the total processing yield of each
+// processed batch of a product, ordered by batch start date. Batches without
+// processing records are omitted.
+func (s *SupplyChainContract) GetBatchProcessingYieldTrend(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+) ([]*YieldTrendPoint, error) {
+	if err := s.ValidateNonEmptyString(productID, "productID"); err != nil {
+		return nil, err
+	}
+
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType":    "BatchAsset",
+		"product_id": productID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trend := []*YieldTrendPoint{}
+	for _, batch := range batches {
+		aggregate, err := s.GetBatchProcessingAggregate(ctx, batch.BatchID)
+		if err != nil {
+			return nil, err
+		}
+		if aggregate.RecordCount == 0 {
+			continue
+		}
+		trend = append(trend, &YieldTrendPoint{
+			BatchID:      batch.BatchID,
+			BatchNumber:  batch.BatchNumber,
+			StartDate:    batch.StartDate,
+			TotalYieldKg: aggregate.TotalYieldKg,
+		})
+	}
+
+	sort.SliceStable(trend, func(i, j int) bool {
+		return trend[i].StartDate < trend[j].StartDate
+	})
+
+	return trend, nil
+}
+
+// FinalizeProcessing moves a COMPLETED batch to PROCESSED once its
+// processing is recorded. The transition is refused while the live quantity
+// not yet covered by SLAUGHTER records exceeds the
+// UnprocessedRemainderTolerancePercent threshold (zero when unset). Batches
+// processed off-network can still be moved manually with UpdateBatchStatus.
+func (s *SupplyChainContract) FinalizeProcessing(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchAsset, error) {
+	// Authorization check
+	batch, err := s.authorizeAndLoadBatch(ctx, MinFarmOrgMSP, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate transition to PROCESSED
+	if err := s.ValidateStatusTransition(ctx, batch.Status, "PROCESSED"); err != nil {
+		return nil, err
+	}
+
+	records, err := s.GetProcessingRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("batch %s has no processing records", batchID)
+	}
+
+	// Reconcile slaughtered units against the live quantity
+	available, err := s.GetBatchCurrentQuantity(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	processed := 0
+	for _, record := range records {
+		if record.Stage == "SLAUGHTER" {
+			processed += record.SlaughterCnt
+		}
+	}
+	if remainder := available - processed; remainder > 0 {
+		tolerancePct, _, err := s.getThreshold(ctx, ThresholdUnprocessedRemainderPct)
+		if err != nil {
+			return nil, err
+		}
+		remainderPct := float64(remainder) * 100 / float64(available)
+		if remainderPct > tolerancePct {
+			return nil, fmt.Errorf("batch %s has %d of %d units unprocessed (%.1f%%), above the %.1f%% tolerance",
+				batchID, remainder, available, remainderPct, tolerancePct)
+		}
+	}
+
+	previousStatus := batch.Status
+	batch.Status = "PROCESSED"
+	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(batchID, batchBytes); err != nil {
+		return nil, fmt.Errorf("failed to update batch: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"batch_id":   batchID,
+		"old_status": previousStatus,
+		"new_status": batch.Status,
+	}
+	s.emitEvent(ctx, "BatchStatusChanged", eventPayload, batch)
+
+	return batch, nil
+}
+
+// ============================================================================
+// PROCESSING AMENDMENT FUNCTIONS
+// ============================================================================
+
+// AmendProcessingRecord records a correction to a processing record
+// (Processor only). correctedFieldsJSON is a JSON object with any of
+// processing_date, slaughter_count, yield_kg, quality_score and notes.
+//
+// Corrections to yield or slaughter count that change the current value by
+// more than AmendmentCoSignChangePercent are created PENDING and take effect
+// only once the Regulator approves them. With the threshold unset, any yield
+// or slaughter count change needs approval. Other amendments are APPROVED
+// immediately.
+func (s *SupplyChainContract) AmendProcessingRecord(
+	ctx contractapi.TransactionContextInterface,
+	amendmentID string,
+	processingID string,
+	correctedFieldsJSON string,
+	reason string,
+) (*ProcessingAmendmentAsset, error) {
+	// Authorization check (Processor only)
+	if err := s.AuthorizeMSP(ctx, ProcessorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(amendmentID, "amendmentID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
+		return nil, err
+	}
+	corrections, err := parseProcessingCorrection(correctedFieldsJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateProcessingCorrection(corrections); err != nil {
+		return nil, err
+	}
+
+	// Check processing record exists
+	original, err := s.getProcessingRecord(ctx, processingID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "ProcessingAmendmentAsset", amendmentID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("processing amendment %s already exists", amendmentID)
+	}
+
+	// Changes are measured against the values in effect today
+	current, err := s.effectiveProcessingRecord(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+	coSignPct, _, err := s.getThreshold(ctx, ThresholdAmendmentCoSignPct)
+	if err != nil {
+		return nil, err
+	}
+	requiresCoSign := false
+	if corrections.corrects("yield_kg") && percentChange(current.YieldKg, corrections.YieldKg) > coSignPct {
+		requiresCoSign = true
+	}
+	if corrections.corrects("slaughter_count") && percentChange(float64(current.SlaughterCnt), float64(corrections.SlaughterCount)) > coSignPct {
+		requiresCoSign = true
+	}
+
+	qualityGrade := ""
+	if corrections.corrects("quality_score") {
+		config, err := s.getSystemConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		qualityGrade = gradeForQualityScore(config.QualityGradeBands, corrections.QualityScore)
+	}
+
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+
+	status := "APPROVED"
+	if requiresCoSign {
+		status = "PENDING"
+	}
+
+	amendment := ProcessingAmendmentAsset{
+		DocType:        "ProcessingAmendmentAsset",
+		AmendmentID:    amendmentID,
+		ProcessingID:   processingID,
+		Corrections:    *corrections,
+		QualityGrade:   qualityGrade,
+		Reason:         reason,
+		RequiresCoSign: requiresCoSign,
+		Status:         status,
+		ProposedBy:     clientMSP,
+		CreatedAt:      s.GetTxTimestamp(ctx),
+		UpdatedAt:      s.GetTxTimestamp(ctx),
+	}
+
+	amendmentBytes, err := json.Marshal(amendment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal processing amendment: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(amendmentID, amendmentBytes); err != nil {
+		return nil, fmt.Errorf("failed to save processing amendment: %v", err)
+	}
+
+	// Emit event
+	eventPayload := s.processingEventPayload(ctx, current)
+	eventPayload["amendment_id"] = amendmentID
+	eventPayload["amendment_status"] = status
+	s.emitEvent(ctx, "ProcessingUpdated", eventPayload, &amendment)
+
+	return &amendment, nil
+}
+
+// ApproveProcessingAmendment co-signs a PENDING amendment so it takes effect (Regulator only)
+func (s *SupplyChainContract) ApproveProcessingAmendment(
+	ctx contractapi.TransactionContextInterface,
+	amendmentID string,
+	comment string,
+) (*ProcessingAmendmentAsset, error) {
+	return s.reviewProcessingAmendment(ctx, amendmentID, "APPROVED", comment)
+}
+
+// RejectProcessingAmendment rejects a PENDING amendment (Regulator only)
+func (s *SupplyChainContract) RejectProcessingAmendment(
+	ctx contractapi.TransactionContextInterface,
+	amendmentID string,
+	comment string,
+) (*ProcessingAmendmentAsset, error) {
+	if err := s.ValidateNonEmptyString(comment, "comment"); err != nil {
+		return nil, err
+	}
+	return s.reviewProcessingAmendment(ctx, amendmentID, "REJECTED", comment)
+}
+
+// reviewProcessingAmendment moves a PENDING amendment to newStatus
+func (s *SupplyChainContract) reviewProcessingAmendment(
+	ctx contractapi.TransactionContextInterface,
+	amendmentID string,
+	newStatus string,
+	comment string,
+) (*ProcessingAmendmentAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	amendment, err := s.GetProcessingAmendment(ctx, amendmentID)
+	if err != nil {
+		return nil, err
+	}
+	if amendment.Status != "PENDING" {
+		return nil, fmt.Errorf("processing amendment %s is %s, only PENDING amendments can be reviewed", amendmentID, amendment.Status)
+	}
+
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+
+	amendment.Status = newStatus
+	amendment.ReviewedBy = clientMSP
+	amendment.ReviewComment = comment
+	amendment.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	amendmentBytes, err := json.Marshal(amendment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal processing amendment: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(amendmentID, amendmentBytes); err != nil {
+		return nil, fmt.Errorf("failed to update processing amendment: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"payload_version":  ProcessingEventVersion,
+		"amendment_id":     amendmentID,
+		"processing_id":    amendment.ProcessingID,
+		"amendment_status": newStatus,
+		"tx_timestamp":     s.GetTxTimestamp(ctx),
+	}
+	s.emitEvent(ctx, "ProcessingUpdated", eventPayload, amendment)
+
+	return amendment, nil
+}
+
+// GetProcessingAmendment retrieves a processing amendment by ID
+func (s *SupplyChainContract) GetProcessingAmendment(
+	ctx contractapi.TransactionContextInterface,
+	amendmentID string,
+) (*ProcessingAmendmentAsset, error) {
+	if err := s.ValidateNonEmptyString(amendmentID, "amendmentID"); err != nil {
+		return nil, err
+	}
+
+	amendmentBytes, err := ctx.GetStub().GetState(amendmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read processing amendment: %v", err)
+	}
+	if amendmentBytes == nil {
+		return nil, fmt.Errorf("processing amendment %s not found", amendmentID)
+	}
+
+	var amendment ProcessingAmendmentAsset
+	amendmentErr := json.Unmarshal(amendmentBytes, &amendment)
+	if amendmentErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal processing amendment: %v", amendmentErr)
+	}
+
+	return &amendment, nil
+}
+
+// GetEffectiveProcessingRecord retrieves a processing record with its
+// approved amendments applied, for reporting
+func (s *SupplyChainContract) GetEffectiveProcessingRecord(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) (*ProcessingAsset, error) {
+	processing, err := s.getProcessingRecord(ctx, processingID)
+	if err != nil {
+		return nil, err
+	}
+	return s.effectiveProcessingRecord(ctx, processing)
+}
+
+// getProcessingAmendments retrieves a processing record's amendments in
+// the order they were made
+func (s *SupplyChainContract) getProcessingAmendments(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) ([]*ProcessingAmendmentAsset, error) {
+	amendments, err := queryAssets[ProcessingAmendmentAsset](ctx, map[string]interface{}{
+		"docType":       "ProcessingAmendmentAsset",
+		"processing_id": processingID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(amendments, func(i, j int) bool {
+		return amendments[i].CreatedAt < amendments[j].CreatedAt
+	})
+
+	return amendments, nil
+}
+
+// effectiveProcessingRecord returns a copy of a stored processing record with
+// its APPROVED amendments applied in order. The original is left untouched
+// and the copy lists every amendment.
+func (s *SupplyChainContract) effectiveProcessingRecord(
+	ctx contractapi.TransactionContextInterface,
+	original *ProcessingAsset,
+) (*ProcessingAsset, error) {
+	amendments, err := s.getProcessingAmendments(ctx, original.ProcessingID)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := *original
+	effective.Amendments = amendments
+	for _, amendment := range amendments {
+		if amendment.Status != "APPROVED" {
+			continue
+		}
+		corrections := &amendment.Corrections
+		if corrections.corrects("processing_date") {
+			effective.ProcessDate = corrections.ProcessDate
+		}
+		if corrections.corrects("slaughter_count") {
+			effective.SlaughterCnt = corrections.SlaughterCount
+		}
+		if corrections.corrects("yield_kg") {
+			effective.YieldKg = corrections.YieldKg
+		}
+		if corrections.corrects("quality_score") {
+			effective.QualityScore = corrections.QualityScore
+			effective.QualityGrade = amendment.QualityGrade
+		}
+		if corrections.corrects("notes") {
+			effective.Notes = corrections.Notes
+		}
+	}
+
+	return &effective, nil
+}
+
+// correctableProcessingFields lists the fields AmendProcessingRecord accepts
+var correctableProcessingFields = []string{"processing_date", "slaughter_count", "yield_kg", "quality_score", "notes"}
+
+// parseProcessingCorrection decodes an amendment's corrected fields,
+// rejecting unknown fields and empty corrections
+func parseProcessingCorrection(correctedFieldsJSON string) (*ProcessingCorrection, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(correctedFieldsJSON), &raw); err != nil {
+		return nil, fmt.Errorf("invalid correctedFieldsJSON: %v", err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("correctedFieldsJSON must correct at least one field")
+	}
+
+	corrections := &ProcessingCorrection{Fields: []string{}}
+	for _, field := range correctableProcessingFields {
+		value, ok := raw[field]
+		if !ok {
+			continue
+		}
+		delete(raw, field)
+
+		var target interface{}
+		switch field {
+		case "processing_date":
+			target = &corrections.ProcessDate
+		case "slaughter_count":
+			target = &corrections.SlaughterCount
+		case "yield_kg":
+			target = &corrections.YieldKg
+		case "quality_score":
+			target = &corrections.QualityScore
+		case "notes":
+			target = &corrections.Notes
+		}
+		if err := json.Unmarshal(value, target); err != nil {
+			return nil, fmt.Errorf("invalid %s in correctedFieldsJSON: %v", field, err)
+		}
+		corrections.Fields = append(corrections.Fields, field)
+	}
+	if len(raw) > 0 {
+		unknown := make([]string, 0, len(raw))
+		for field := range raw {
+			unknown = append(unknown, field)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("fields %s cannot be amended, allowed: %s", strings.Join(unknown, ", "), strings.Join(correctableProcessingFields, ", "))
+	}
+
+	return corrections, nil
+}
+
+// validateProcessingCorrection applies RecordProcessing's field rules to corrected values
+func (s *SupplyChainContract) validateProcessingCorrection(corrections *ProcessingCorrection) error {
+	if corrections.corrects("processing_date") {
+		if _, err := parseLedgerDate(corrections.ProcessDate); err != nil {
+			return fmt.Errorf("invalid processing_date %q: %v", corrections.ProcessDate, err)
+		}
+	}
+	if corrections.corrects("slaughter_count") && corrections.SlaughterCount < 0 {
+		return fmt.Errorf("slaughter_count must be non-negative, got %d", corrections.SlaughterCount)
+	}
+	if corrections.corrects("yield_kg") {
+		if err := s.ValidatePositiveFloat(corrections.YieldKg, "yield_kg"); err != nil {
+			return err
+		}
+	}
+	if corrections.corrects("quality_score") {
+		if err := s.ValidateQualityScore(corrections.QualityScore); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// percentChange returns the absolute change from old to new as a percentage
+// of old. Any change from zero counts as unbounded.
+func percentChange(old, new float64) float64 {
+	if old == new {
+		return 0
+	}
+	if old == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(new-old) * 100 / math.Abs(old)
+}
+
+// ============================================================================
+// HACCP FUNCTIONS
+// ============================================================================
+
+// RecordHACCPCheckpoint records a critical control point reading against a
+// processing record (Processor or Regulator). Checkpoints are append-only; a
+// reading outside its limit must state the corrective action taken.
+func (s *SupplyChainContract) RecordHACCPCheckpoint(
+	ctx contractapi.TransactionContextInterface,
+	checkpointID string,
+	processingID string,
+	ccpName string,
+	measuredValue float64,
+	unit string,
+	withinLimit bool,
+	correctiveAction string,
+) (*HACCPCheckpointAsset, error) {
+	// Authorization check (Processor or Regulator)
+	if err := s.authorizeAnyMSP(ctx, ProcessorOrgMSP, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(checkpointID, "checkpointID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(ccpName, "ccpName"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(unit, "unit"); err != nil {
+		return nil, err
+	}
+	if !withinLimit && strings.TrimSpace(correctiveAction) == "" {
+		return nil, fmt.Errorf("correctiveAction is required when a checkpoint is outside its limit")
+	}
+
+	// Check processing record exists
+	if _, err := s.GetProcessingRecord(ctx, processingID); err != nil {
+		return nil, fmt.Errorf("processing record does not exist: %v", err)
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "HACCPCheckpointAsset", checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("checkpoint %s already exists", checkpointID)
+	}
+
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+
+	checkpoint := HACCPCheckpointAsset{
+		DocType:          "HACCPCheckpointAsset",
+		CheckpointID:     checkpointID,
+		ProcessingID:     processingID,
+		CCPName:          ccpName,
+		MeasuredValue:    measuredValue,
+		Unit:             unit,
+		WithinLimit:      withinLimit,
+		CorrectiveAction: correctiveAction,
+		RecordedBy:       clientMSP,
+		CreatedAt:        s.GetTxTimestamp(ctx),
+	}
+
+	checkpointBytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal checkpoint: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(checkpointID, checkpointBytes); err != nil {
+		return nil, fmt.Errorf("failed to save checkpoint: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"checkpoint_id": checkpointID,
+		"processing_id": processingID,
+		"ccp_name":      ccpName,
+		"within_limit":  withinLimit,
+	}
+	s.emitEvent(ctx, "HACCPCheckpointRecorded", eventPayload, &checkpoint)
+
+	return &checkpoint, nil
+}
+
+// GetProcessingHACCPLog retrieves the HACCP checkpoints of a processing record in recorded order
+func (s *SupplyChainContract) GetProcessingHACCPLog(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) ([]*HACCPCheckpointAsset, error) {
+	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := queryAssets[HACCPCheckpointAsset](ctx, map[string]interface{}{
+		"docType":       "HACCPCheckpointAsset",
+		"processing_id": processingID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt < checkpoints[j].CreatedAt
+	})
+
+	return checkpoints, nil
+}
+
+// ============================================================================
+// LAB TEST FUNCTIONS
+// ============================================================================
+
+// RecordLabTest records a lab test result on a processing record (Regulator
+// or Lab). A failed result emits LabTestFailed and, when holdBatchOnFail is
+// set, places the source batch ON_HOLD in the same transaction.
+func (s *SupplyChainContract) RecordLabTest(
+	ctx contractapi.TransactionContextInterface,
+	testID string,
+	processingID string,
+	testType string,
+	resultValue float64,
+	unit string,
+	passed bool,
+	labID string,
+	testedDate string,
+	reportHash string,
+	holdBatchOnFail bool,
+) (*LabTestAsset, error) {
+	// Authorization check (Regulator or Lab)
+	if err := s.authorizeAnyMSP(ctx, RegulatorOrgMSP, LabOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(testID, "testID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(testType, "testType"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(labID, "labID"); err != nil {
+		return nil, err
+	}
+	if _, err := parseLedgerDate(testedDate); err != nil {
+		return nil, fmt.Errorf("invalid testedDate %q: %v", testedDate, err)
+	}
+	if reportHash != "" {
+		if err := s.ValidateSHA256Hex(reportHash, "reportHash"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check processing record exists
+	processing, err := s.GetProcessingRecord(ctx, processingID)
+	if err != nil {
+		return nil, fmt.Errorf("processing record does not exist: %v", err)
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "LabTestAsset", testID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("lab test %s already exists", testID)
+	}
+
+	labTest := LabTestAsset{
+		DocType:      "LabTestAsset",
+		TestID:       testID,
+		ProcessingID: processingID,
+		BatchID:      processing.BatchID,
+		TestType:     strings.ToUpper(strings.TrimSpace(testType)),
+		ResultValue:  resultValue,
+		Unit:         unit,
+		Passed:       passed,
+		LabID:        labID,
+		TestedDate:   testedDate,
+		ReportHash:   strings.ToLower(reportHash),
+		CreatedAt:    s.GetTxTimestamp(ctx),
+	}
+
+	labTestBytes, err := json.Marshal(labTest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lab test: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(testID, labTestBytes); err != nil {
+		return nil, fmt.Errorf("failed to save lab test: %v", err)
+	}
+
+	if passed {
+		eventPayload := map[string]interface{}{
+			"test_id":       testID,
+			"processing_id": processingID,
+			"test_type":     labTest.TestType,
+		}
+		s.emitEvent(ctx, "LabTestRecorded", eventPayload, &labTest)
+		return &labTest, nil
+	}
+
+	// Failed result: optionally hold the batch, then emit LabTestFailed
+	batchOnHold := false
+	if holdBatchOnFail {
+		batch, err := s.GetBatch(ctx, processing.BatchID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.placeBatchOnHold(ctx, batch, fmt.Sprintf("lab test %s (%s) failed", testID, labTest.TestType)); err != nil {
+			return nil, err
+		}
+		batchOnHold = true
+	}
+
+	eventPayload := map[string]interface{}{
+		"test_id":       testID,
+		"processing_id": processingID,
+		"batch_id":      processing.BatchID,
+		"test_type":     labTest.TestType,
+		"result_value":  resultValue,
+		"unit":          unit,
+		"batch_on_hold": batchOnHold,
+	}
+	s.emitEvent(ctx, "LabTestFailed", eventPayload, &labTest)
+
+	return &labTest, nil
+}
+
+// GetLabTestsByProcessing retrieves all lab tests for a processing record, ordered by tested date
+func (s *SupplyChainContract) GetLabTestsByProcessing(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) ([]*LabTestAsset, error) {
+	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+		return nil, err
+	}
+
+	return s.queryLabTests(ctx, map[string]interface{}{
+		"docType":       "LabTestAsset",
+		"processing_id": processingID,
+	})
+}
+
+// GetLabTestsByBatch retrieves all lab tests across a batch's processing records, ordered by tested date
+func (s *SupplyChainContract) GetLabTestsByBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*LabTestAsset, error) {
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return nil, err
+	}
+
+	return s.queryLabTests(ctx, map[string]interface{}{
+		"docType":  "LabTestAsset",
+		"batch_id": batchID,
+	})
+}
+
+// queryLabTests runs a lab test query and orders the results by tested date
+func (s *SupplyChainContract) queryLabTests(
+	ctx contractapi.TransactionContextInterface,
+	selector map[string]interface{},
+) ([]*LabTestAsset, error) {
+	labTests, err := queryAssets[LabTestAsset](ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(labTests, func(i, j int) bool {
+		return labTests[i].TestedDate < labTests[j].TestedDate
+	})
+
+	return labTests, nil
+}
+
+// ============================================================================
+// OUTPUT LOT FUNCTIONS
+// ============================================================================
+
+// RecordProcessingOutput records a retail lot produced by a processing record.
+// Lots measured in kg are reconciled against the processing yield: the total
+// kg across all lots of a processing record cannot exceed its YieldKg.
+func (s *SupplyChainContract) RecordProcessingOutput(
+	ctx contractapi.TransactionContextInterface,
+	outputID string,
+	processingID string,
+	lotNumber string,
+	productID string,
+	quantity float64,
+	unit string,
+	packDate string,
+	bestBefore string,
+) (*OutputLotAsset, error) {
+	// Authorization check
+	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(outputID, "outputID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(lotNumber, "lotNumber"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(unit, "unit"); err != nil {
+		return nil, err
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be positive, got %f", quantity)
+	}
+	if packDate != "" && bestBefore != "" {
+		if datesOutOfOrder(packDate, bestBefore) || packDate == bestBefore {
+			return nil, fmt.Errorf("bestBefore %s must be after packDate %s", bestBefore, packDate)
+		}
+	}
+
+	// Check processing record and product exist
+	processing, err := s.GetProcessingRecord(ctx, processingID)
+	if err != nil {
+		return nil, fmt.Errorf("processing record does not exist: %v", err)
+	}
+	if _, err := s.GetProduct(ctx, productID); err != nil {
+		return nil, fmt.Errorf("product %s does not exist", productID)
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "OutputLotAsset", outputID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("output lot %s already exists", outputID)
+	}
+
+	lotNumberKey := fmt.Sprintf("lot_number~%s", lotNumber)
+	existingLot, err := ctx.GetStub().GetState(lotNumberKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lot number index: %v", err)
+	}
+	if existingLot != nil {
+		return nil, fmt.Errorf("lot number %s already exists", lotNumber)
+	}
+
+	// Reconcile kg output against the processing yield
+	unit = strings.ToUpper(strings.TrimSpace(unit))
+	if unit == "KG" {
+		lots, err := s.GetProcessingOutputs(ctx, processingID)
+		if err != nil {
+			return nil, err
+		}
+		totalKg := quantity
+		for _, lot := range lots {
+			if lot.Unit == "KG" {
+				totalKg += lot.Quantity
+			}
+		}
+		if totalKg > processing.YieldKg {
+			return nil, fmt.Errorf("output of %.2f kg would exceed processing yield of %.2f kg", totalKg, processing.YieldKg)
+		}
+	}
+
+	lot := OutputLotAsset{
+		DocType:      "OutputLotAsset",
+		OutputID:     outputID,
+		ProcessingID: processingID,
+		BatchID:      processing.BatchID,
+		LotNumber:    lotNumber,
+		ProductID:    productID,
+		Quantity:     quantity,
+		Unit:         unit,
+		PackDate:     packDate,
+		BestBefore:   bestBefore,
+		CreatedAt:    s.GetTxTimestamp(ctx),
+	}
+
+	lotBytes, err := json.Marshal(lot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output lot: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(outputID, lotBytes); err != nil {
+		return nil, fmt.Errorf("failed to save output lot: %v", err)
+	}
+
+	// Store lot number index for uniqueness checking and lookups
+	if err := ctx.GetStub().PutState(lotNumberKey, []byte(outputID)); err != nil {
+		return nil, fmt.Errorf("failed to save lot number index: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"output_id":     outputID,
+		"processing_id": processingID,
+		"lot_number":    lotNumber,
+	}
+	s.emitEvent(ctx, "ProcessingOutputRecorded", eventPayload, &lot)
+
+	return &lot, nil
+}
+
+// GetOutputLot retrieves an output lot by ID
+func (s *SupplyChainContract) GetOutputLot(
+	ctx contractapi.TransactionContextInterface,
+	outputID string,
+) (*OutputLotAsset, error) {
+	if err := s.ValidateNonEmptyString(outputID, "outputID"); err != nil {
+		return nil, err
+	}
+
+	lotBytes, err := ctx.GetStub().GetState(outputID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output lot: %v", err)
+	}
+	if lotBytes == nil {
+		return nil, fmt.Errorf("output lot %s not found", outputID)
+	}
+
+	var lot OutputLotAsset
+	lotErr := json.Unmarshal(lotBytes, &lot)
+	if lotErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal output lot: %v", lotErr)
+	}
+
+	return &lot, nil
+}
+
+// GetProcessingOutputs retrieves all output lots produced by a processing record
+func (s *SupplyChainContract) GetProcessingOutputs(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) ([]*OutputLotAsset, error) {
+	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+		return nil, err
+	}
+
+	lots, err := queryAssets[OutputLotAsset](ctx, map[string]interface{}{
+		"docType":       "OutputLotAsset",
+		"processing_id": processingID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].LotNumber < lots[j].LotNumber
+	})
+
+	return lots, nil
+}
+
+// TraceByLotNumber resolves a retail lot number back to its processing
+// record, source batch, product, and the batch's lifecycle, transport and
+// certification history. Certifications are redacted for callers outside the
+// Regulator and Admin MSPs.
+func (s *SupplyChainContract) TraceByLotNumber(
+	ctx contractapi.TransactionContextInterface,
+	lotNumber string,
+) (*LotTrace, error) {
+	if err := s.ValidateNonEmptyString(lotNumber, "lotNumber"); err != nil {
+		return nil, err
+	}
+
+	outputIDBytes, err := ctx.GetStub().GetState(fmt.Sprintf("lot_number~%s", lotNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lot number index: %v", err)
+	}
+	if outputIDBytes == nil {
+		return nil, fmt.Errorf("lot number %s not found", lotNumber)
+	}
+
+	lot, err := s.GetOutputLot(ctx, string(outputIDBytes))
+	if err != nil {
+		return nil, err
+	}
+	processing, err := s.GetProcessingRecord(ctx, lot.ProcessingID)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := s.GetBatch(ctx, processing.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.GetProduct(ctx, batch.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.GetBatchLifecycleEvents(ctx, batch.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	transports, err := s.GetTransportsByBatch(ctx, batch.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	certifications, err := s.GetCertificationsByProcessing(ctx, processing.ProcessingID)
+	if err != nil {
+		return nil, err
+	}
+	batchCertifications, err := s.getBatchScopedCertifications(ctx, batch.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	certifications, err = s.certificationsForCaller(ctx, append(batchCertifications, certifications...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LotTrace{
+		Lot:             lot,
+		Processing:      processing,
+		Batch:           batch,
+		Product:         product,
+		LifecycleEvents: events,
+		Transports:      transports,
+		Certifications:  certifications,
+	}, nil
+}
+
+// GetFullBatchTrace returns a batch with its product, lifecycle events,
+// transports, processing records and certifications, batch-scoped and on its
+// processing records. Certifications are redacted for callers outside the
+// Regulator and Admin MSPs.
+func (s *SupplyChainContract) GetFullBatchTrace(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchTrace, error) {
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.GetProduct(ctx, batch.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.GetBatchLifecycleEvents(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	transports, err := s.GetTransportsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.GetProcessingRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	certifications, err := s.getBatchCertifications(ctx, batchID, records)
+	if err != nil {
+		return nil, err
+	}
+	certifications, err = s.certificationsForCaller(ctx, certifications)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchTrace{
+		Batch:             batch,
+		Product:           product,
+		LifecycleEvents:   events,
+		Transports:        transports,
+		ProcessingRecords: records,
+		Certifications:    certifications,
+	}, nil
+}
+
+// TraceByQRCode resolves a batch by its QR code and returns its full trace,
+// as GetFullBatchTrace
+func (s *SupplyChainContract) TraceByQRCode(
+	ctx contractapi.TransactionContextInterface,
+	qrCode string,
+) (*BatchTrace, error) {
+	batch, err := s.getBatchByQRCode(ctx, qrCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetFullBatchTrace(ctx, batch.BatchID)
+}
+
+// getBatchByQRCode resolves the single batch carrying qrCode
+func (s *SupplyChainContract) getBatchByQRCode(
+	ctx contractapi.TransactionContextInterface,
+	qrCode string,
+) (*BatchAsset, error) {
+	if err := s.ValidateNonEmptyString(qrCode, "qrCode"); err != nil {
+		return nil, err
+	}
+
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType": "BatchAsset",
+		"qr_code": qrCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("no batch found for QR code %s", qrCode)
+	}
+	if len(batches) > 1 {
+		return nil, fmt.Errorf("QR code %s matches %d batches", qrCode, len(batches))
+	}
+	return batches[0], nil
+}
+
+// GetBatchLabelToken returns the verification token printed on a batch's
+// label next to its QR code (Farm only)
+func (s *SupplyChainContract) GetBatchLabelToken(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (string, error) {
+	// Authorization check
+	if _, err := s.authorizeAndLoadBatch(ctx, MinFarmOrgMSP, batchID); err != nil {
+		return "", err
+	}
+	return s.batchToken(ctx, batchID)
+}
+
+// VerifyBatchToken reports whether token is the authentic verification token
+// for the batch. A mismatch returns false rather than an error.
+func (s *SupplyChainContract) VerifyBatchToken(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	token string,
+) (bool, error) {
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return false, err
+	}
+
+	expected, err := s.batchToken(ctx, batchID)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(token))), nil
+}
+
+// batchToken derives a batch's verification token: the hex HMAC-SHA256 of
+// the batch ID under the secret stored by SetBatchTokenSecret
+func (s *SupplyChainContract) batchToken(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (string, error) {
+	secret, err := ctx.GetStub().GetPrivateData(BatchTokenSecretCollection, BatchTokenSecretKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read batch token secret: %v", err)
+	}
+	if secret == nil {
+		return "", fmt.Errorf("batch token secret is not configured")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(batchID))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// GetBatchPassport resolves a batch by its QR code and returns the compact
+// public passport for mobile scans. token is the verification token printed
+// with the QR code; the passport is refused when it does not match.
+//
+// ColdChainCompliant is set when the batch has at least one temperature
+// reading, in transport or cold storage, and none is a violation.
+// CertificationsValid is set when the batch holds at least one valid
+// certification and none of the required types is missing (see
+// GetMissingCertificationsForBatch).
+func (s *SupplyChainContract) GetBatchPassport(
+	ctx contractapi.TransactionContextInterface,
+	qrCode string,
+	token string,
+) (*BatchPassport, error) {
+	batch, err := s.getBatchByQRCode(ctx, qrCode)
+	if err != nil {
+		return nil, err
+	}
+
+	authentic, err := s.VerifyBatchToken(ctx, batch.BatchID, token)
+	if err != nil {
+		return nil, err
+	}
+	if !authentic {
+		return nil, fmt.Errorf("invalid verification token for QR code %s", qrCode)
+	}
+
+	passport := &BatchPassport{
+		Origin:           batch.Location,
+		ProductionStatus: batch.Status,
+	}
+
+	if product, found, err := s.TryGetProduct(ctx, batch.ProductID); err != nil {
+		return nil, err
+	} else if found {
+		passport.ProductName = product.Name
+	}
+
+	passport.Farmer, err = s.getFarmerDisplayName(ctx, batch.FarmerID)
+	if err != nil {
+		return nil, err
+	}
+
+	passport.ColdChainCompliant, err = s.isBatchColdChainCompliant(ctx, batch.BatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := s.getValidBatchCertificationTypes(ctx, batch.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	requiredTypes, err := s.getRequiredCertificationTypes(ctx, batch.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	passport.CertificationsValid = len(held) > 0
+	for _, certType := range requiredTypes {
+		if !held[certType] {
+			passport.CertificationsValid = false
+		}
+	}
+
+	return passport, nil
+}
+
+// isBatchColdChainCompliant reports whether the batch has temperature readings
+// from its transports or cold-storage assignments and none is a violation
+func (s *SupplyChainContract) isBatchColdChainCompliant(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (bool, error) {
+	logs, err := s.getBatchTemperatureLogs(ctx, batchID)
+	if err != nil {
+		return false, err
+	}
+	if len(logs) == 0 {
+		return false, nil
+	}
+	for _, log := range logs {
+		if log.IsViolation {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// getBatchTemperatureLogs returns the temperature readings taken on the
+// batch's transports and cold-storage assignments
+func (s *SupplyChainContract) getBatchTemperatureLogs(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*TemperatureLogAsset, error) {
+	transports, err := s.GetTransportsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	transportIDs := make([]string, 0, len(transports))
+	for _, transport := range transports {
+		transportIDs = append(transportIDs, transport.TransportID)
+	}
+
+	assignments, err := queryAssets[ColdStorageAssignmentAsset](ctx, map[string]interface{}{
+		"docType":  "ColdStorageAssignmentAsset",
+		"batch_id": batchID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	assignmentIDs := make([]string, 0, len(assignments))
+	for _, assignment := range assignments {
+		assignmentIDs = append(assignmentIDs, assignment.AssignmentID)
+	}
+
+	if len(transportIDs) == 0 && len(assignmentIDs) == 0 {
+		return []*TemperatureLogAsset{}, nil
+	}
+
+	return queryAssets[TemperatureLogAsset](ctx, map[string]interface{}{
+		"docType": "TemperatureLogAsset",
+		"$or": []interface{}{
+			map[string]interface{}{"transport_id": map[string]interface{}{"$in": transportIDs}},
+			map[string]interface{}{"storage_assignment_id": map[string]interface{}{"$in": assignmentIDs}},
+		},
+	})
+}
+
+// ============================================================================
+// COLD STORAGE FUNCTIONS
+// ============================================================================
+
+// AssignColdStorage records a processing record entering a cold-storage
+// location. A processing record can occupy only one location at a time.
+func (s *SupplyChainContract) AssignColdStorage(
+	ctx contractapi.TransactionContextInterface,
+	assignmentID string,
+	processingID string,
+	storageLocationID string,
+	enteredAt string,
+) (*ColdStorageAssignmentAsset, error) {
+	// Authorization check
+	if err := s.authorizeAnyMSP(ctx, ProcessorOrgMSP, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(assignmentID, "assignmentID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(storageLocationID, "storageLocationID"); err != nil {
+		return nil, err
+	}
+	entered, err := parseLedgerDate(enteredAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enteredAt %q: %v", enteredAt, err)
+	}
+
+	// Check processing record exists
+	processing, err := s.GetProcessingRecord(ctx, processingID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "ColdStorageAssignmentAsset", assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("cold storage assignment %s already exists", assignmentID)
+	}
+
+	// The new assignment is open-ended, so it overlaps any stay that is still
+	// open or that ends after it begins
+	history, err := s.GetColdStorageHistory(ctx, processingID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range history {
+		if existing.ExitedAt == "" {
+			return nil, fmt.Errorf("processing record %s is still in cold storage under assignment %s", processingID, existing.AssignmentID)
+		}
+		exited, err := parseLedgerDate(existing.ExitedAt)
+		if err != nil || exited.After(entered) {
+			return nil, fmt.Errorf("assignment overlaps cold storage assignment %s (%s to %s)", existing.AssignmentID, existing.EnteredAt, existing.ExitedAt)
+		}
+	}
+
+	assignment := ColdStorageAssignmentAsset{
+		DocType:           "ColdStorageAssignmentAsset",
+		AssignmentID:      assignmentID,
+		ProcessingID:      processingID,
+		BatchID:           processing.BatchID,
+		StorageLocationID: storageLocationID,
+		EnteredAt:         enteredAt,
+		Status:            "IN_STORAGE",
+		CreatedAt:         s.GetTxTimestamp(ctx),
+		UpdatedAt:         s.GetTxTimestamp(ctx),
+	}
+
+	assignmentBytes, err := json.Marshal(assignment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cold storage assignment: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(assignmentID, assignmentBytes); err != nil {
+		return nil, fmt.Errorf("failed to save cold storage assignment: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"assignment_id":       assignmentID,
+		"processing_id":       processingID,
+		"storage_location_id": storageLocationID,
+	}
+	s.emitEvent(ctx, "ColdStorageAssigned", eventPayload, &assignment)
+
+	return &assignment, nil
+}
+
+// ReleaseColdStorage records a processing record leaving cold storage
+func (s *SupplyChainContract) ReleaseColdStorage(
+	ctx contractapi.TransactionContextInterface,
+	assignmentID string,
+	exitedAt string,
+) (*ColdStorageAssignmentAsset, error) {
+	// Authorization check
+	if err := s.authorizeAnyMSP(ctx, ProcessorOrgMSP, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	assignment, err := s.GetColdStorageAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.ExitedAt != "" {
+		return nil, fmt.Errorf("cold storage assignment %s was already released at %s", assignmentID, assignment.ExitedAt)
+	}
+
+	exited, err := parseLedgerDate(exitedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exitedAt %q: %v", exitedAt, err)
+	}
+	if entered, err := parseLedgerDate(assignment.EnteredAt); err == nil && !exited.After(entered) {
+		return nil, fmt.Errorf("exitedAt %s must be after enteredAt %s", exitedAt, assignment.EnteredAt)
+	}
+
+	assignment.ExitedAt = exitedAt
+	assignment.Status = "RELEASED"
+	assignment.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	assignmentBytes, err := json.Marshal(assignment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cold storage assignment: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(assignmentID, assignmentBytes); err != nil {
+		return nil, fmt.Errorf("failed to update cold storage assignment: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"assignment_id": assignmentID, "processing_id": assignment.ProcessingID}
+	s.emitEvent(ctx, "ColdStorageReleased", eventPayload, assignment)
+
+	return assignment, nil
+}
+
+// GetColdStorageAssignment retrieves a cold storage assignment by ID
+func (s *SupplyChainContract) GetColdStorageAssignment(
+	ctx contractapi.TransactionContextInterface,
+	assignmentID string,
+) (*ColdStorageAssignmentAsset, error) {
+	if err := s.ValidateNonEmptyString(assignmentID, "assignmentID"); err != nil {
+		return nil, err
+	}
+
+	assignmentBytes, err := ctx.GetStub().GetState(assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cold storage assignment: %v", err)
+	}
+	if assignmentBytes == nil {
+		return nil, fmt.Errorf("cold storage assignment %s not found", assignmentID)
+	}
+
+	var assignment ColdStorageAssignmentAsset
+	assignmentErr := json.Unmarshal(assignmentBytes, &assignment)
+	if assignmentErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal cold storage assignment: %v", assignmentErr)
+	}
+
+	return &assignment, nil
+}
+
+// GetColdStorageHistory retrieves a processing record's cold storage
+// assignments, ordered by entry time
+func (s *SupplyChainContract) GetColdStorageHistory(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) ([]*ColdStorageAssignmentAsset, error) {
+	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+		return nil, err
+	}
+
+	assignments, err := queryAssets[ColdStorageAssignmentAsset](ctx, map[string]interface{}{
+		"docType":       "ColdStorageAssignmentAsset",
+		"processing_id": processingID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(assignments, func(i, j int) bool {
+		return assignments[i].EnteredAt < assignments[j].EnteredAt
+	})
+
+	return assignments, nil
+}
+
+// AddStorageTemperatureLog adds a temperature reading for a cold storage assignment
+func (s *SupplyChainContract) AddStorageTemperatureLog(
+	ctx contractapi.TransactionContextInterface,
+	logID string,
+	assignmentID string,
+	temperature float64,
+	timestamp string,
+) (*TemperatureLogAsset, error) {
+	// Authorization check
+	if err := s.authorizeAnyMSP(ctx, ProcessorOrgMSP, MinFarmOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(logID, "logID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidatePositiveFloat(temperature, "temperature"); err != nil {
+		return nil, err
+	}
+	if _, err := parseLedgerDate(timestamp); err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %v", timestamp, err)
+	}
+
+	// Check assignment exists
+	assignment, err := s.GetColdStorageAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "TemperatureLogAsset", logID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("temperature log %s already exists", logID)
+	}
+
+	tempLog := TemperatureLogAsset{
+		DocType:             "TemperatureLogAsset",
+		LogID:               logID,
+		StorageAssignmentID: assignmentID,
+		Temperature:         temperature,
+		Timestamp:           timestamp,
+		Location:            assignment.StorageLocationID,
+		CreatedAt:           s.GetTxTimestamp(ctx),
+	}
+
+	if err := s.saveTemperatureLog(ctx, &tempLog); err != nil {
+		return nil, err
+	}
+
+	return &tempLog, nil
+}
+
+// GetStorageTemperatureLogs retrieves all temperature logs for a cold storage assignment
+func (s *SupplyChainContract) GetStorageTemperatureLogs(
+	ctx contractapi.TransactionContextInterface,
+	assignmentID string,
+) ([]*TemperatureLogAsset, error) {
+	if err := s.ValidateNonEmptyString(assignmentID, "assignmentID"); err != nil {
+		return nil, err
+	}
+
+	logs, err := queryAssets[TemperatureLogAsset](ctx, map[string]interface{}{
+		"docType":               "TemperatureLogAsset",
+		"storage_assignment_id": assignmentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp < logs[j].Timestamp
+	})
+
+	return logs, nil
+}
+
+// ============================================================================
+// CERTIFICATION FUNCTIONS
+// ============================================================================
+
+// IssueCertification issues a certification (Regulator, or a certifier MSP
+// accredited for certType by SetCertifierAccreditation). Exactly one of
+// processingID and batchID is given: batch-scoped certifications cover a
+// whole batch, for example farm organic status, before any processing exists.
+// optionsJSON is a CertificationIssueOptions object, or empty for the
+// defaults, such as {"notes": "...", "replace_existing": true}.
+//
+// The issuer is bound to the caller: a regulator or certifier may leave
+// issuerID empty to use their enrollment ID, or must pass that ID.
+// AdminOrgMSP may issue on behalf of a named issuer, which is recorded as a
+// delegation.
+//
+// A processing record or batch can hold only one active certification of each
+// type. If one exists, issuance fails unless replace_existing is set, in
+// which case the existing certification is revoked first, or force_parallel
+// is set for a genuinely separate certification scheme. A parallel
+// certification records the certification it runs alongside and neither
+// supersedes the other.
+//
+// An empty certificationID is replaced by the next certificate number for the
+// type and issued year (see nextCertificateNumber); a given one must pass
+// validateCertificationID.
+//
+// With the RequireCertificationReview feature flag off (the default) the
+// certification is issued directly as APPROVED. With the flag on it is
+// created as PENDING and must be moved to APPROVED by ApproveCertification.
+//
+// Issuance fails while an unresolved CRITICAL violation notice stands against
+// the batch, its farm or the processing facility.
+func (s *SupplyChainContract) IssueCertification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+	processingID string,
+	batchID string,
+	certType string,
+	issuedDate string,
+	expiryDate string,
+	issuerID string,
+	optionsJSON string,
+) (*CertificationAsset, error) {
+	// Authorization check (Regulator or accredited certifier)
+	accreditation, err := s.authorizeCertificationIssuer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation
+	var options CertificationIssueOptions
+	if strings.TrimSpace(optionsJSON) != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+			return nil, fmt.Errorf("invalid options JSON: %v", err)
+		}
+	}
+	if certificationID != "" {
+		if err := validateCertificationID(certificationID, "certificationID"); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.ValidateNonEmptyString(certType, "certType"); err != nil {
+		return nil, err
+	}
+	certType, err = s.validateCertificationType(ctx, certType)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCertifierAccreditation(accreditation, certType); err != nil {
+		return nil, err
+	}
+	if err := s.validateCertificationDates(ctx, certType, issuedDate, expiryDate); err != nil {
+		return nil, err
+	}
+	documentSHA256, err := s.validateCertificationDocument(options.DocumentSHA256, options.DocumentURI)
+	if err != nil {
+		return nil, err
+	}
+	if certificationID == "" {
+		certificationID, err = s.nextCertificateNumber(ctx, certType, issuedDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	issuer, err := s.resolveCertificationIssuer(ctx, issuerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the certified processing record or batch exists
+	if err := s.checkCertificationSubject(ctx, processingID, batchID); err != nil {
+		return nil, err
+	}
+	if err := s.checkNoCriticalViolations(ctx, processingID, batchID); err != nil {
+		return nil, err
+	}
+	qualityOverridden, err := s.checkCertificationQualityScore(ctx, processingID, options.OverrideQualityScore)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one active certification of a type per processing record or batch
+	existing, err := s.findActiveCertification(ctx, processingID, batchID, certType)
+	if err != nil {
+		return nil, err
+	}
+	if options.ReplaceExisting && options.ForceParallel {
+		return nil, fmt.Errorf("replace_existing and force_parallel cannot both be set")
+	}
+	replacedID := ""
+	parallelTo := ""
+	if existing != nil {
+		switch {
+		case options.ReplaceExisting:
+			if err := s.revokeCertification(ctx, existing, "replaced by "+certificationID); err != nil {
+				return nil, err
+			}
+			replacedID = existing.CertificationID
+		case options.ForceParallel:
+			parallelTo = existing.CertificationID
+		default:
+			return nil, fmt.Errorf("%s already has active %s certification %s; use RenewCertification to extend it", certificationSubject(existing), certType, existing.CertificationID)
+		}
+	}
+
+	certification, err := s.createCertification(ctx, CertificationAsset{
+		CertificationID:        certificationID,
+		ProcessingID:           processingID,
+		BatchID:                batchID,
+		CertType:               certType,
+		IssuedDate:             issuedDate,
+		ExpiryDate:             expiryDate,
+		IssuerID:               issuer.IssuerID,
+		IssuerMSP:              issuer.IssuerMSP,
+		IssuerIdentity:         issuer.IssuerIdentity,
+		IssuedOnBehalfBy:       issuer.IssuedOnBehalfBy,
+		Notes:                  options.Notes,
+		DocumentSHA256:         documentSHA256,
+		DocumentURI:            options.DocumentURI,
+		ParallelTo:             parallelTo,
+		QualityScoreOverridden: qualityOverridden,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := s.certificationEventPayload(ctx, certification)
+	if replacedID != "" {
+		eventPayload["replaced_certification_id"] = replacedID
+	}
+	if parallelTo != "" {
+		eventPayload["parallel_to_certification_id"] = parallelTo
+	}
+	if qualityOverridden {
+		eventPayload["quality_score_overridden"] = true
+	}
+	s.emitCertificationEvent(ctx, "CertificationIssued", eventPayload, certification)
+
+	return certification, nil
+}
+
+// RenewCertification issues the successor of an APPROVED certification for
+// the same processing record or batch and type (Regulator, or a certifier
+// accredited for the type). The new certification links back to the previous
+// one, which becomes SUPERSEDED together with the certifications derived from
+// it. Revoked and already superseded certifications cannot be renewed. An
+// empty newCertificationID is generated as in IssueCertification. Like
+// issuance, renewal fails while an unresolved CRITICAL violation notice
+// stands.
+func (s *SupplyChainContract) RenewCertification(
+	ctx contractapi.TransactionContextInterface,
+	newCertificationID string,
+	previousCertificationID string,
+	issuedDate string,
+	expiryDate string,
+	notes string,
+	documentSHA256 string,
+	documentURI string,
+) (*CertificationAsset, error) {
+	// Authorization check (Regulator or accredited certifier)
+	accreditation, err := s.authorizeCertificationIssuer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if newCertificationID != "" {
+		if err := validateCertificationID(newCertificationID, "newCertificationID"); err != nil {
+			return nil, err
+		}
+	}
+	documentSHA256, err = s.validateCertificationDocument(documentSHA256, documentURI)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.getCertification(ctx, previousCertificationID)
+	if err != nil {
+		return nil, err
+	}
+	switch previous.Status {
+	case "APPROVED", "EXPIRED":
+	case "REVOKED":
+		return nil, fmt.Errorf("certification %s is revoked and cannot be renewed", previousCertificationID)
+	case "SUPERSEDED":
+		return nil, fmt.Errorf("certification %s was already renewed by %s", previousCertificationID, previous.SupersededBy)
+	default:
+		return nil, fmt.Errorf("certification %s is %s, only APPROVED or EXPIRED certifications can be renewed", previousCertificationID, previous.Status)
+	}
+
+	// Check the certified processing record or batch still exists
+	if err := s.checkCertificationSubject(ctx, previous.ProcessingID, previous.BatchID); err != nil {
+		return nil, err
+	}
+	if err := s.checkNoCriticalViolations(ctx, previous.ProcessingID, previous.BatchID); err != nil {
+		return nil, err
+	}
+
+	// Renewals are new issuance, so a legacy free-text type must normalize to an allowed one
+	certType, err := s.validateCertificationType(ctx, previous.CertType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCertifierAccreditation(accreditation, certType); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateCertificationDates(ctx, certType, issuedDate, expiryDate); err != nil {
+		return nil, err
+	}
+	if newCertificationID == "" {
+		newCertificationID, err = s.nextCertificateNumber(ctx, certType, issuedDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A regulator or certifier renews as themselves; an admin renews on behalf of the
+	// previous issuer
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+	claimedIssuerID := ""
+	if clientMSP == AdminOrgMSP {
+		claimedIssuerID = previous.IssuerID
+	}
+	issuer, err := s.resolveCertificationIssuer(ctx, claimedIssuerID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous.SupersededBy = newCertificationID
+	if _, err := s.moveCertification(ctx, previous, "SUPERSEDED", "renewed by "+newCertificationID); err != nil {
+		return nil, err
+	}
+
+	certification, err := s.createCertification(ctx, CertificationAsset{
+		CertificationID:         newCertificationID,
+		ProcessingID:            previous.ProcessingID,
+		BatchID:                 previous.BatchID,
+		CertType:                certType,
+		IssuedDate:              issuedDate,
+		ExpiryDate:              expiryDate,
+		IssuerID:                issuer.IssuerID,
+		IssuerMSP:               issuer.IssuerMSP,
+		IssuerIdentity:          issuer.IssuerIdentity,
+		IssuedOnBehalfBy:        issuer.IssuedOnBehalfBy,
+		Notes:                   notes,
+		DocumentSHA256:          documentSHA256,
+		DocumentURI:             documentURI,
+		ParallelTo:              previous.ParallelTo,
+		PreviousCertificationID: previousCertificationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := s.certificationEventPayload(ctx, certification)
+	eventPayload["previous_certification_id"] = previousCertificationID
+	s.emitCertificationEvent(ctx, "CertificationIssued", eventPayload, certification)
+
+	return certification, nil
+}
+
+// VerifyCertificationDocument checks a certificate document's SHA-256 digest
+// against the one anchored when the certification was issued, and reports
+// the certification's current validity alongside
+func (s *SupplyChainContract) VerifyCertificationDocument(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+	documentSHA256 string,
+) (*CertificationDocumentCheck, error) {
+	if err := s.ValidateSHA256Hex(documentSHA256, "documentSHA256"); err != nil {
+		return nil, err
+	}
+
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	validity, err := s.IsCertificationValid(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CertificationDocumentCheck{
+		CertificationID: certificationID,
+		Anchored:        certification.DocumentSHA256 != "",
+		Match:           certification.DocumentSHA256 != "" && certification.DocumentSHA256 == strings.ToLower(documentSHA256),
+		Validity:        validity,
+	}, nil
+}
+
+// authorizeCertificationIssuer checks the caller may issue certifications at
+// all: the Regulator and Admin may issue any type, other MSPs only when
+// accredited. Callers check the returned accreditation against certType with
+// checkCertifierAccreditation once the type has been validated.
+func (s *SupplyChainContract) authorizeCertificationIssuer(
+	ctx contractapi.TransactionContextInterface,
+) (*certifierAccreditation, error) {
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+	if clientMSP == RegulatorOrgMSP || clientMSP == AdminOrgMSP {
+		return &certifierAccreditation{MSPID: clientMSP}, nil
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accredited, ok := config.CertifierAccreditations[clientMSP]
+	if !ok {
+		return nil, fmt.Errorf("unauthorized: MSP %s not allowed. Required: %s, %s or an accredited certifier", clientMSP, RegulatorOrgMSP, AdminOrgMSP)
+	}
+	return &certifierAccreditation{MSPID: clientMSP, CertTypes: accredited}, nil
+}
+
+// checkCertifierAccreditation checks the accreditation covers certType
+func checkCertifierAccreditation(accreditation *certifierAccreditation, certType string) error {
+	if accreditation.CertTypes == nil {
+		return nil
+	}
+	for _, accreditedType := range accreditation.CertTypes {
+		if accreditedType == certType {
+			return nil
+		}
+	}
+	return fmt.Errorf("unauthorized: MSP %s is not accredited to issue %s certifications (accredited for: %s)", accreditation.MSPID, certType, strings.Join(accreditation.CertTypes, ", "))
+}
+
+// validateCertificationID checks a caller-supplied certification ID: 1 to 64
+// letters, digits, '-', '_' or '.', starting with a letter or digit
+func validateCertificationID(value, fieldName string) error {
+	if len(value) == 0 || len(value) > 64 {
+		return fmt.Errorf("%s must be 1 to 64 characters, got %d", fieldName, len(value))
+	}
+	for i, r := range value {
+		alphanumeric := (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+		if i == 0 && !alphanumeric {
+			return fmt.Errorf("%s must start with a letter or digit, got %q", fieldName, value)
+		}
+		if !alphanumeric && r != '-' && r != '_' && r != '.' {
+			return fmt.Errorf("%s may contain only letters, digits, '-', '_' and '.', got %q", fieldName, value)
+		}
+	}
+	return nil
+}
+
+// nextCertificateNumber takes the next number in the series for certType and
+// the year of issuedDate, formatted "<prefix>-<type code>-<year>-<number>",
+// for example VN-HAL-2025-000123. The type code is the first three letters of
+// the type. Numbers already taken by caller-supplied IDs are skipped.
+func (s *SupplyChainContract) nextCertificateNumber(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+	issuedDate string,
+) (string, error) {
+	issued, err := parseLedgerDate(issuedDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid issuedDate %q: %v", issuedDate, err)
+	}
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	typeCode := strings.ReplaceAll(normalizeCertificationType(certType), "_", "")
+	if len(typeCode) > 3 {
+		typeCode = typeCode[:3]
+	}
+	series := fmt.Sprintf("%s-%s-%d", config.CertificateNumberPrefix, typeCode, issued.Year())
+	counterKey := fmt.Sprintf("cert_number~%s", series)
+
+	counter := CertificateNumberCounter{DocType: "CertificateNumberCounter", Series: series}
+	counterBytes, err := ctx.GetStub().GetState(counterKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read certificate number counter: %v", err)
+	}
+	if counterBytes != nil {
+		if err := json.Unmarshal(counterBytes, &counter); err != nil {
+			return "", fmt.Errorf("failed to unmarshal certificate number counter: %v", err)
+		}
+	}
+
+	var certificationID string
+	for {
+		counter.LastNumber++
+		certificationID = fmt.Sprintf("%s-%06d", series, counter.LastNumber)
+		existing, err := ctx.GetStub().GetState(certificationID)
+		if err != nil {
+			return "", fmt.Errorf("failed to read certification: %v", err)
+		}
+		if existing == nil {
+			break
+		}
+	}
+	counter.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	counterBytes, err = json.Marshal(counter)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal certificate number counter: %v", err)
+	}
+	if err := ctx.GetStub().PutState(counterKey, counterBytes); err != nil {
+		return "", fmt.Errorf("failed to save certificate number counter: %v", err)
+	}
+
+	return certificationID, nil
+}
+
+// resolveCertificationIssuer binds a certification's issuer to the caller,
+// as resolveActingOfficer
+func (s *SupplyChainContract) resolveCertificationIssuer(
+	ctx contractapi.TransactionContextInterface,
+	issuerID string,
+) (*certificationIssuer, error) {
+	officer, err := s.resolveActingOfficer(ctx, issuerID, "issuerID", "issuer")
+	if err != nil {
+		return nil, err
+	}
+	return &certificationIssuer{
+		IssuerID:         officer.OfficerID,
+		IssuerMSP:        officer.OfficerMSP,
+		IssuerIdentity:   officer.OfficerIdentity,
+		IssuedOnBehalfBy: officer.OnBehalfBy,
+	}, nil
+}
+
+// resolveActingOfficer binds the officer named by claimedID, a field such as
+// issuerID or regulatorID, to the caller. A caller acting for themselves is
+// the officer: an empty claimedID becomes their enrollment ID and any other
+// value must match it. An admin must name the officer (role) they act for,
+// and is recorded as acting on that officer's behalf.
+func (s *SupplyChainContract) resolveActingOfficer(
+	ctx contractapi.TransactionContextInterface,
+	claimedID string,
+	field string,
+	role string,
+) (*actingOfficer, error) {
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+	enrollmentID, err := s.getCallerEnrollmentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+
+	officer := &actingOfficer{OfficerMSP: clientMSP, OfficerIdentity: identity}
+	if clientMSP == AdminOrgMSP {
+		if err := s.ValidateNonEmptyString(claimedID, field); err != nil {
+			return nil, fmt.Errorf("%v: AdminOrgMSP must name the %s it acts for", err, role)
+		}
+		officer.OfficerID = claimedID
+		officer.OnBehalfBy = enrollmentID
+		return officer, nil
+	}
+
+	if claimedID != "" && claimedID != enrollmentID {
+		return nil, fmt.Errorf("%s %s does not match the caller's enrollment ID %s", field, claimedID, enrollmentID)
+	}
+	officer.OfficerID = enrollmentID
+	return officer, nil
+}
+
+// validateCertificationDates checks a new certification's dates: both are
+// required ISO 8601 dates (RFC3339 or YYYY-MM-DD), expiry is strictly after
+// issue, issue is not in the future beyond CertificationIssueToleranceMinutes,
+// and the validity period is within the cap configured for certType
+func (s *SupplyChainContract) validateCertificationDates(
+	ctx contractapi.TransactionContextInterface,
+	certType string,
+	issuedDate string,
+	expiryDate string,
+) error {
+	issued, err := parseLedgerDate(issuedDate)
+	if err != nil {
+		return fmt.Errorf("invalid issuedDate %q: must be an ISO 8601 date or RFC3339 timestamp", issuedDate)
+	}
+	expiry, err := parseLedgerDate(expiryDate)
+	if err != nil {
+		return fmt.Errorf("invalid expiryDate %q: must be an ISO 8601 date or RFC3339 timestamp", expiryDate)
+	}
+	if !expiry.After(issued) {
+		return fmt.Errorf("expiryDate %s must be after issuedDate %s", expiryDate, issuedDate)
+	}
+
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if issued.After(now.Add(CertificationIssueToleranceMinutes * time.Minute)) {
+		return fmt.Errorf("issuedDate %s is more than %d minutes in the future", issuedDate, CertificationIssueToleranceMinutes)
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if maxDays, ok := config.MaxValidityDays[certType]; ok && expiry.Sub(issued) > time.Duration(maxDays)*24*time.Hour {
+		return fmt.Errorf("validity period from %s to %s exceeds the %d day maximum for %s certifications", issuedDate, expiryDate, maxDays, certType)
+	}
+
+	return nil
+}
+
+// validateCertificationDocument validates an optional document anchor and
+// returns the digest lower-cased. A URI needs a digest to be verifiable, so
+// one cannot be given alone. Anchors are only set at issuance or renewal.
+func (s *SupplyChainContract) validateCertificationDocument(documentSHA256, documentURI string) (string, error) {
+	if documentSHA256 == "" {
+		if documentURI != "" {
+			return "", fmt.Errorf("documentURI requires documentSHA256")
+		}
+		return "", nil
+	}
+	if err := s.ValidateSHA256Hex(documentSHA256, "documentSHA256"); err != nil {
+		return "", err
+	}
+	return strings.ToLower(documentSHA256), nil
+}
+
+// redactCertification builds the consumer-level view of a certification:
+// its certificate number, type, status, issued and expiry dates, document
+// hash and URI, and verification count. Notes, issuer identities and the
+// audit trail are left out.
+func redactCertification(certification *CertificationAsset) *CertificationAsset {
+	return &CertificationAsset{
+		DocType:           certification.DocType,
+		CertificationID:   certification.CertificationID,
+		CertType:          certification.CertType,
+		Status:            certification.Status,
+		IssuedDate:        certification.IssuedDate,
+		ExpiryDate:        certification.ExpiryDate,
+		DocumentSHA256:    certification.DocumentSHA256,
+		DocumentURI:       certification.DocumentURI,
+		VerificationCount: certification.VerificationCount,
+		LastVerifiedAt:    certification.LastVerifiedAt,
+	}
+}
+
+// certificationsForCaller returns certifications unchanged for the Regulator
+// and Admin MSPs and redacted for every other caller
+func (s *SupplyChainContract) certificationsForCaller(
+	ctx contractapi.TransactionContextInterface,
+	certifications []*CertificationAsset,
+) ([]*CertificationAsset, error) {
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
+	}
+	if clientMSP == RegulatorOrgMSP || clientMSP == AdminOrgMSP {
+		return certifications, nil
+	}
+
+	redacted := make([]*CertificationAsset, 0, len(certifications))
+	for _, certification := range certifications {
+		redacted = append(redacted, redactCertification(certification))
+	}
+	return redacted, nil
+}
+
+// checkCertificationPrerequisites evaluates the prerequisites of the
+// certification's type against its processing record or batch and lists
+// every unmet item in the error. It combines the stored
+// CertificationPrerequisiteAsset with the lab tests from SetRequiredLabTests
+// and, for HACCP, the RequireHACCPLogForHACCPCerts flag. Lab tests and HACCP
+// checkpoints belong to processing records, so a batch-scoped certification
+// cannot meet them. Required certifications must be valid on the processing
+// record or its batch, or for a batch-scoped certification anywhere in the
+// batch.
+func (s *SupplyChainContract) checkCertificationPrerequisites(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+) error {
+	certType := normalizeCertificationType(certification.CertType)
+
+	prerequisites, err := s.getCertificationPrerequisites(ctx, certType)
+	if err != nil {
+		return err
+	}
+	if prerequisites == nil {
+		prerequisites = &CertificationPrerequisiteAsset{}
+	}
+
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return err
+	}
+	labTests := []string{}
+	seenLabTests := map[string]bool{}
+	for _, testType := range append(append([]string{}, config.RequiredLabTests[certType]...), prerequisites.RequiredLabTests...) {
+		if !seenLabTests[testType] {
+			seenLabTests[testType] = true
+			labTests = append(labTests, testType)
+		}
+	}
+
+	requireLog := prerequisites.RequireHACCPLog
+	if certType == "HACCP" {
+		legacyRequireLog, err := s.isFeatureEnabled(ctx, FeatureRequireHACCPLog)
+		if err != nil {
+			return err
+		}
+		requireLog = requireLog || legacyRequireLog
+	}
+
+	unmet := []string{}
+	processingID := certification.ProcessingID
+	if processingID == "" {
+		for _, testType := range labTests {
+			unmet = append(unmet, fmt.Sprintf("passing %s lab test (needs a processing record)", testType))
+		}
+		if requireLog || len(prerequisites.RequiredHACCPCheckpoints) > 0 {
+			unmet = append(unmet, "HACCP checkpoint log (needs a processing record)")
+		}
+	} else {
+		if len(labTests) > 0 {
+			results, err := s.GetLabTestsByProcessing(ctx, processingID)
+			if err != nil {
+				return err
+			}
+			passed := map[string]bool{}
+			for _, result := range results {
+				if result.Passed {
+					passed[result.TestType] = true
+				}
+			}
+			for _, testType := range labTests {
+				if !passed[testType] {
+					unmet = append(unmet, fmt.Sprintf("passing %s lab test", testType))
+				}
+			}
+		}
+
+		if requireLog || len(prerequisites.RequiredHACCPCheckpoints) > 0 {
+			checkpoints, err := s.GetProcessingHACCPLog(ctx, processingID)
+			if err != nil {
+				return err
+			}
+			if requireLog && len(checkpoints) == 0 {
+				unmet = append(unmet, "HACCP checkpoint log")
+			}
+			logged := map[string]bool{}
+			for _, checkpoint := range checkpoints {
+				logged[strings.ToUpper(checkpoint.CCPName)] = true
+			}
+			for _, ccpName := range prerequisites.RequiredHACCPCheckpoints {
+				if !logged[strings.ToUpper(ccpName)] {
+					unmet = append(unmet, fmt.Sprintf("HACCP checkpoint %s", ccpName))
+				}
+			}
+		}
+	}
+
+	if len(prerequisites.RequiredCertifications) > 0 {
+		held, err := s.getValidPrerequisiteCertificationTypes(ctx, certification)
+		if err != nil {
+			return err
+		}
+		for _, requiredType := range prerequisites.RequiredCertifications {
+			if !held[requiredType] {
+				unmet = append(unmet, fmt.Sprintf("valid %s certification", requiredType))
+			}
+		}
+	}
+
+	if len(unmet) > 0 {
+		return fmt.Errorf("%s certification prerequisites not met: %s", certType, strings.Join(unmet, ", "))
+	}
+	return nil
+}
+
+// getValidPrerequisiteCertificationTypes returns the types of currently valid
+// certifications that count towards a new certification's prerequisites: those
+// on its processing record and its batch, or for a batch-scoped certification
+// every valid certification of the batch
+func (s *SupplyChainContract) getValidPrerequisiteCertificationTypes(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+) (map[string]bool, error) {
+	if certification.ProcessingID == "" {
+		return s.getValidBatchCertificationTypes(ctx, certification.BatchID)
+	}
+
+	processing, err := s.getProcessingRecord(ctx, certification.ProcessingID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	processingCertifications, err := s.GetCertificationsByProcessing(ctx, processing.ProcessingID)
+	if err != nil {
+		return nil, err
+	}
+	batchCertifications, err := s.getBatchScopedCertifications(ctx, processing.BatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	held := map[string]bool{}
+	for _, siblings := range [][]*CertificationAsset{processingCertifications, batchCertifications} {
+		for _, existing := range siblings {
+			if certificationValidity(existing, siblings, now).Valid {
+				held[normalizeCertificationType(existing.CertType)] = true
+			}
+		}
+	}
+	return held, nil
+}
+
+// createCertification runs the checks shared by new and renewed
+// certifications, sets the initial status and stores the certification
+func (s *SupplyChainContract) createCertification(
+	ctx contractapi.TransactionContextInterface,
+	certification CertificationAsset,
+) (*CertificationAsset, error) {
+	// Validation
+	if err := validationError(validateCertificationAsset(&certification)); err != nil {
+		return nil, err
+	}
+
+	// Certification types can require lab tests, a HACCP log or other certifications
+	if err := s.checkCertificationPrerequisites(ctx, &certification); err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "CertificationAsset", certification.CertificationID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("certification %s already exists", certification.CertificationID)
+	}
+
+	requireReview, err := s.isFeatureEnabled(ctx, FeatureRequireCertificationReview)
+	if err != nil {
+		return nil, err
+	}
+	initialStatus := "APPROVED"
+	if requireReview {
+		initialStatus = "PENDING"
+	}
+	if _, err := s.setCertificationStatus(ctx, &certification, initialStatus, "issued"); err != nil {
+		return nil, err
+	}
+
+	certification.DocType = "CertificationAsset"
+	certification.CreatedAt = s.GetTxTimestamp(ctx)
+	certification.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	certBytes, err := json.Marshal(certification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certification: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(certification.CertificationID, certBytes); err != nil {
+		return nil, fmt.Errorf("failed to save certification: %v", err)
+	}
+
+	return &certification, nil
+}
+
+// GetCertificationChain retrieves the renewal chain a certification belongs
+// to, oldest first, following renewal links in both directions
+func (s *SupplyChainContract) GetCertificationChain(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) ([]*CertificationAsset, error) {
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{certification.CertificationID: true}
+	earlier := []*CertificationAsset{}
+	for current := certification; current.PreviousCertificationID != "" && !seen[current.PreviousCertificationID]; {
+		previous, err := s.getCertification(ctx, current.PreviousCertificationID)
+		if err != nil {
+			return nil, err
+		}
+		seen[previous.CertificationID] = true
+		earlier = append(earlier, previous)
+		current = previous
+	}
+
+	chain := make([]*CertificationAsset, 0, len(earlier)+1)
+	for i := len(earlier) - 1; i >= 0; i-- {
+		chain = append(chain, earlier[i])
+	}
+	chain = append(chain, certification)
+
+	for current := certification; current.SupersededBy != "" && !seen[current.SupersededBy]; {
+		next, err := s.getCertification(ctx, current.SupersededBy)
+		if err != nil {
+			return nil, err
+		}
+		seen[next.CertificationID] = true
+		chain = append(chain, next)
+		current = next
+	}
+
+	return chain, nil
+}
+
+// GetCertificationRenewalChain retrieves the renewal history of a
+// certification: the certifications it renewed, followed back through
+// PreviousCertificationID, oldest first and ending with the certification
+// itself. Unlike GetCertificationChain it fails on a renewal cycle rather than
+// stopping at it, since a cycle means the lineage on the ledger is corrupt.
+func (s *SupplyChainContract) GetCertificationRenewalChain(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) ([]*CertificationAsset, error) {
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{certification.CertificationID: true}
+	chain := []*CertificationAsset{certification}
+	for current := certification; current.PreviousCertificationID != ""; {
+		if seen[current.PreviousCertificationID] {
+			return nil, fmt.Errorf("renewal chain of certification %s loops back to %s", certificationID, current.PreviousCertificationID)
+		}
+		previous, err := s.getCertification(ctx, current.PreviousCertificationID)
+		if err != nil {
+			return nil, err
+		}
+		seen[previous.CertificationID] = true
+		chain = append(chain, previous)
+		current = previous
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return chain, nil
+}
+
+// ApproveCertification approves a PENDING certification (Regulator only).
+// This is the second step of the review path enabled by RequireCertificationReview.
+func (s *SupplyChainContract) ApproveCertification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) (*CertificationAsset, error) {
+	// Authorization check (Regulator only)
+	certification, err := s.authorizeAndLoadCertification(ctx, RegulatorOrgMSP, certificationID)
+	if err != nil {
+		return nil, err
+	}
+	if certification.Status != "PENDING" {
+		return nil, fmt.Errorf("certification %s is %s, only PENDING certifications can be approved", certificationID, certification.Status)
+	}
+
+	return s.UpdateCertificationStatus(ctx, certificationID, "APPROVED")
+}
+
+// UpdateCertificationStatus updates certification status (Regulator only).
+// Suspension, reinstatement, revocation and supersession go through their
+// dedicated functions instead. An expiry is followed by the certifications
+// derived from this one.
+func (s *SupplyChainContract) UpdateCertificationStatus(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+	newStatus string,
+) (*CertificationAsset, error) {
+	// Authorization check (Regulator only)
+	certification, err := s.authorizeAndLoadCertification(ctx, RegulatorOrgMSP, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate transition
+	if err := validateCertificationTransition(certification.Status, newStatus); err != nil {
+		return nil, err
+	}
+	if function, ok := certificationTransitionFunctions[certification.Status+"->"+newStatus]; ok {
+		return nil, fmt.Errorf("transition from %s to %s must be made with %s", certification.Status, newStatus, function)
+	}
+
+	oldStatus := certification.Status
+	if _, err := s.moveCertification(ctx, certification, newStatus, ""); err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := s.certificationEventPayload(ctx, certification)
+	eventPayload["previous_status"] = oldStatus
+	s.emitCertificationEvent(ctx, "CertificationStatusChanged", eventPayload, certification)
+
+	return certification, nil
+}
+
+// GetCertification retrieves a certification by ID together with its consumer
+// verification count and last verification time. It is redacted for callers
+// outside the Regulator and Admin MSPs.
+func (s *SupplyChainContract) GetCertification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) (*CertificationAsset, error) {
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	counter, err := s.getCertificationVerificationCounter(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+	certification.VerificationCount = counter.VerificationCount
+	certification.LastVerifiedAt = counter.LastVerifiedAt
+
+	visible, err := s.certificationsForCaller(ctx, []*CertificationAsset{certification})
+	if err != nil {
+		return nil, err
+	}
+	return visible[0], nil
+}
+
+// GetCertificationStatusHistory returns every status a certification has
+// held, oldest first, with who changed it, why and when. Certifications
+// stored before the history was kept have an empty history.
+func (s *SupplyChainContract) GetCertificationStatusHistory(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) ([]CertificationStatusChange, error) {
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+	if certification.StatusHistory == nil {
+		return []CertificationStatusChange{}, nil
+	}
+
+	return certification.StatusHistory, nil
+}
+
+// setCertificationStatus moves a certification to newStatus and appends the
+// change to its status history, returning the acting identity. The caller
+// validates the transition and stores the certification.
+func (s *SupplyChainContract) setCertificationStatus(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+	newStatus string,
+	reason string,
+) (string, error) {
+	actor, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get client identity: %v", err)
+	}
+
+	certification.StatusHistory = append(certification.StatusHistory, CertificationStatusChange{
+		OldStatus: certification.Status,
+		NewStatus: newStatus,
+		Actor:     actor,
+		Reason:    reason,
+		ChangedAt: s.GetTxTimestamp(ctx),
+		TxID:      ctx.GetStub().GetTxID(),
+	})
+	certification.Status = newStatus
+	certification.UpdatedAt = s.GetTxTimestamp(ctx)
+	return actor, nil
+}
+
+// RecordCertificationVerification counts a consumer check of a certification
+// through channel (for example "qr_scan" or "web"). Any MSP may call it, the
+// public API gateway included. The count lives in a separate counter key, not
+// on the certification document.
+func (s *SupplyChainContract) RecordCertificationVerification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+	channel string,
+) (*CertificationVerificationCounter, error) {
+	// Authorization check (any MSP)
+	if err := s.AuthorizeMSP(ctx, "ANY"); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(channel, "channel"); err != nil {
+		return nil, err
+	}
+	if _, err := s.getCertification(ctx, certificationID); err != nil {
+		return nil, err
+	}
+
+	counter, err := s.getCertificationVerificationCounter(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+	counter.VerificationCount++
+	counter.ChannelCounts[channel]++
+	counter.LastVerifiedAt = s.GetTxTimestamp(ctx)
+	counter.LastChannel = channel
+
+	counterBytes, err := json.Marshal(counter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verification counter: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(fmt.Sprintf("cert_verification~%s", certificationID), counterBytes); err != nil {
+		return nil, fmt.Errorf("failed to save verification counter: %v", err)
+	}
+
+	return counter, nil
+}
+
+// getCertificationVerificationCounter reads a certification's verification
+// counter, returning a zero counter when it has never been verified
+func (s *SupplyChainContract) getCertificationVerificationCounter(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) (*CertificationVerificationCounter, error) {
+	counter := &CertificationVerificationCounter{
+		DocType:         "CertificationVerificationCounter",
+		CertificationID: certificationID,
+	}
+
+	counterBytes, err := ctx.GetStub().GetState(fmt.Sprintf("cert_verification~%s", certificationID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verification counter: %v", err)
+	}
+	if counterBytes != nil {
+		if err := json.Unmarshal(counterBytes, counter); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal verification counter: %v", err)
+		}
+	}
+	if counter.ChannelCounts == nil {
+		counter.ChannelCounts = map[string]int{}
+	}
+	return counter, nil
+}
+
+// getCertification reads a certification as stored, without its verification
+// counter. Paths that write the certification back must use it.
+func (s *SupplyChainContract) getCertification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) (*CertificationAsset, error) {
+	if err := s.ValidateNonEmptyString(certificationID, "certificationID"); err != nil {
+		return nil, err
+	}
+
+	certBytes, err := ctx.GetStub().GetState(certificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certification: %v", err)
+	}
+	if certBytes == nil {
+		return nil, fmt.Errorf("certification %s not found", certificationID)
+	}
+
+	var certification CertificationAsset
+	certErr := json.Unmarshal(certBytes, &certification)
+	if certErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal certification: %v", certErr)
+	}
+
+	return &certification, nil
+}
+
+// GetCertificationChainForBatch retrieves every certification of a batch,
+// batch-scoped or on one of its processing records, each resolved to its processing record (with approved
+// amendments applied), the batch summary and the product name
+func (s *SupplyChainContract) GetCertificationChainForBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*CertificationChainLink, error) {
+	summary, err := s.GetBatchSummary(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	productName := ""
+	if product, found, err := s.TryGetProduct(ctx, summary.Batch.ProductID); err != nil {
+		return nil, err
+	} else if found {
+		productName = product.Name
+	}
+
+	records, err := s.GetProcessingRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	processingByID := map[string]*ProcessingAsset{}
+	for _, record := range records {
+		processingByID[record.ProcessingID] = record
+	}
+
+	certifications, err := s.getBatchCertifications(ctx, batchID, records)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []*CertificationChainLink{}
+	for _, certification := range certifications {
+		link := &CertificationChainLink{
+			Certification: certification,
+			Batch:         summary,
+			ProductName:   productName,
+		}
+		if certification.ProcessingID == "" {
+			chain = append(chain, link)
+			continue
+		}
+		if record, ok := processingByID[certification.ProcessingID]; ok {
+			link.Processing, err = s.effectiveProcessingRecord(ctx, record)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			link.ProcessingMissing = true
+		}
+		chain = append(chain, link)
+	}
+
+	return chain, nil
+}
+
+// GetCertificationsByProcessing retrieves certifications for a processing record
+func (s *SupplyChainContract) GetCertificationsByProcessing(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+) ([]*CertificationAsset, error) {
+	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+		return nil, err
+	}
+
+	certifications, err := queryAssets[CertificationAsset](ctx, map[string]interface{}{
+		"docType":       "CertificationAsset",
+		"processing_id": processingID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(certifications, func(i, j int) bool {
+		return certifications[i].IssuedDate < certifications[j].IssuedDate
+	})
+
+	return certifications, nil
+}
+
+// GetCertificationsByBatch retrieves a batch's certifications: those issued
+// against the batch itself and those on any of its processing records. They
+// are redacted for callers outside the Regulator and Admin MSPs.
+func (s *SupplyChainContract) GetCertificationsByBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*CertificationAsset, error) {
+	certifications, err := s.getAllBatchCertifications(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return s.certificationsForCaller(ctx, certifications)
+}
+
+// getAllBatchCertifications is GetCertificationsByBatch without redaction
+func (s *SupplyChainContract) getAllBatchCertifications(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*CertificationAsset, error) {
+	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+		return nil, err
+	}
+
+	records, err := s.GetProcessingRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getBatchCertifications(ctx, batchID, records)
+}
+
+// GetCertificationsByIssuer retrieves every certification attributed to an
+// issuer, including those issued on the issuer's behalf, oldest first
+func (s *SupplyChainContract) GetCertificationsByIssuer(
+	ctx contractapi.TransactionContextInterface,
+	issuerID string,
+) ([]*CertificationAsset, error) {
+	if err := s.ValidateNonEmptyString(issuerID, "issuerID"); err != nil {
+		return nil, err
+	}
+
+	certifications, err := queryAssets[CertificationAsset](ctx, map[string]interface{}{
+		"docType":   "CertificationAsset",
+		"issuer_id": issuerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(certifications, func(i, j int) bool {
+		return certifications[i].IssuedDate < certifications[j].IssuedDate
+	})
+
+	return certifications, nil
+}
+
+// GetMissingCertificationsForBatch lists the required certification types
+// (see getRequiredCertificationTypes) the batch does not currently hold. A
+// type is held when a valid certification of it is batch-scoped or on any of
+// the batch's processing records. Validity is checked as in
+// IsCertificationValid.
+func (s *SupplyChainContract) GetMissingCertificationsForBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]string, error) {
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	requiredTypes, err := s.getRequiredCertificationTypes(ctx, batch.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	missing := []string{}
+	if len(requiredTypes) == 0 {
+		return missing, nil
+	}
+
+	held, err := s.getValidBatchCertificationTypes(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	for _, certType := range requiredTypes {
+		if !held[certType] {
+			missing = append(missing, certType)
+		}
+	}
+
+	return missing, nil
+}
+
+// VerifyExportReadiness checks a batch holds a currently valid certification,
+// batch-scoped or on one of its processing records, of every type
+// SetExportRequirements lists for countryCode. A country without stored
+// requirements reports RequirementsConfigured false and is not ready, so an
+// unconfigured destination is never waved through.
+func (s *SupplyChainContract) VerifyExportReadiness(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	countryCode string,
+) (*ExportReadiness, error) {
+	countryCode, err := validateCountryCode(countryCode)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetBatch(ctx, batchID); err != nil {
+		return nil, err
+	}
+
+	readiness := &ExportReadiness{
+		BatchID:           batchID,
+		CountryCode:       countryCode,
+		RequiredCertTypes: []string{},
+		MissingCertTypes:  []string{},
+	}
+	requirements, err := s.getExportRequirements(ctx, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	if requirements == nil {
+		return readiness, nil
+	}
+	readiness.RequirementsConfigured = true
+	readiness.RequiredCertTypes = append(readiness.RequiredCertTypes, requirements.RequiredCertTypes...)
+
+	held, err := s.getValidBatchCertificationTypes(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	for _, certType := range readiness.RequiredCertTypes {
+		if !held[certType] {
+			readiness.MissingCertTypes = append(readiness.MissingCertTypes, certType)
+		}
+	}
+	readiness.Ready = len(readiness.MissingCertTypes) == 0
+
+	return readiness, nil
+}
+
+// VerifyBatchCertifications answers whether a batch is fully certified. For
+// each required type (see getRequiredCertificationTypes) it reports the valid
+// certification satisfying it, batch-scoped, derived from a split or merge,
+// or on one of the batch's processing records, or why the requirement fails:
+// MISSING, EXPIRED, SUSPENDED, REVOKED or NOT_VALID (pending, superseded or
+// not yet in force), judged from the most recently issued certification of
+// the type. Certified is set when every requirement is satisfied; with no
+// required types the batch must hold at least one valid certification.
+// Validity is checked as in IsCertificationValid.
+func (s *SupplyChainContract) VerifyBatchCertifications(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (*BatchCertificationVerdict, error) {
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	requiredTypes, err := s.getRequiredCertificationTypes(ctx, batch.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// certifications are ordered by issued date, so later ones replace earlier
+	certifications, err := s.getAllBatchCertifications(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	bySubject := map[string][]*CertificationAsset{}
+	for _, certification := range certifications {
+		subject := certificationSubject(certification)
+		bySubject[subject] = append(bySubject[subject], certification)
+	}
+	valid := map[string]*CertificationAsset{}
+	latest := map[string]*CertificationValidity{}
+	for _, certification := range certifications {
+		certType := normalizeCertificationType(certification.CertType)
+		validity := certificationValidity(certification, bySubject[certificationSubject(certification)], now)
+		if validity.Valid {
+			valid[certType] = certification
+		}
+		latest[certType] = validity
+	}
+
+	verdict := &BatchCertificationVerdict{
+		BatchID:      batchID,
+		ProductID:    batch.ProductID,
+		Certified:    len(requiredTypes) > 0 || len(valid) > 0,
+		Requirements: []*CertificationRequirementVerdict{},
+	}
+	for _, certType := range requiredTypes {
+		requirement := &CertificationRequirementVerdict{CertType: certType, Reasons: []string{}}
+		if certification, ok := valid[certType]; ok {
+			requirement.Satisfied = true
+			requirement.CertificationID = certification.CertificationID
+			requirement.Derived = len(certification.SourceCertificationIDs) > 0
+		} else if validity, ok := latest[certType]; ok {
+			requirement.CertificationID = validity.CertificationID
+			requirement.Failure = certificationFailure(validity)
+			requirement.Reasons = validity.Reasons
+		} else {
+			requirement.Failure = "MISSING"
+		}
+		if !requirement.Satisfied {
+			verdict.Certified = false
+		}
+		verdict.Requirements = append(verdict.Requirements, requirement)
+	}
+
+	return verdict, nil
+}
+
+// certificationFailure classifies why a certification is not valid, in
+// order of precedence: REVOKED, SUSPENDED, EXPIRED, then NOT_VALID
+func certificationFailure(validity *CertificationValidity) string {
+	failure := "NOT_VALID"
+	for _, code := range validity.Failures {
+		switch {
+		case code == "REVOKED":
+			return "REVOKED"
+		case code == "SUSPENDED":
+			failure = "SUSPENDED"
+		case code == "EXPIRED" && failure != "SUSPENDED":
+			failure = "EXPIRED"
+		}
+	}
+	return failure
+}
+
+// getRequiredCertificationTypes returns the certification types batches of
+// productID must hold: the system-wide list set with
+// SetRequiredCertifications followed by the product's own list
+func (s *SupplyChainContract) getRequiredCertificationTypes(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+) ([]string, error) {
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product, found, err := s.TryGetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	required := append([]string{}, config.RequiredCertTypes...)
+	if found {
+		seen := map[string]bool{}
+		for _, certType := range required {
+			seen[certType] = true
+		}
+		for _, certType := range product.RequiredCertTypes {
+			if !seen[certType] {
+				seen[certType] = true
+				required = append(required, certType)
+			}
+		}
+	}
+	return required, nil
+}
+
+// getValidBatchCertificationTypes returns the normalized types of the batch's
+// currently valid certifications, batch-scoped or on its processing records
+func (s *SupplyChainContract) getValidBatchCertificationTypes(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (map[string]bool, error) {
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	certifications, err := s.getAllBatchCertifications(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	bySubject := map[string][]*CertificationAsset{}
+	for _, certification := range certifications {
+		subject := certificationSubject(certification)
+		bySubject[subject] = append(bySubject[subject], certification)
+	}
+
+	held := map[string]bool{}
+	for _, certification := range certifications {
+		if certificationValidity(certification, bySubject[certificationSubject(certification)], now).Valid {
+			held[normalizeCertificationType(certification.CertType)] = true
+		}
+	}
+	return held, nil
+}
+
+// getBatchCertifications merges a batch's batch-scoped certifications with
+// those on the given processing records, ordered by issued date
+func (s *SupplyChainContract) getBatchCertifications(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	records []*ProcessingAsset,
+) ([]*CertificationAsset, error) {
+	certifications, err := s.getBatchScopedCertifications(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > 0 {
+		processingIDs := make([]string, 0, len(records))
+		for _, record := range records {
+			processingIDs = append(processingIDs, record.ProcessingID)
+		}
+		processingCertifications, err := queryAssets[CertificationAsset](ctx, map[string]interface{}{
+			"docType":       "CertificationAsset",
+			"processing_id": map[string]interface{}{"$in": processingIDs},
+		})
+		if err != nil {
+			return nil, err
+		}
+		certifications = append(certifications, processingCertifications...)
+	}
+
+	sort.SliceStable(certifications, func(i, j int) bool {
+		return certifications[i].IssuedDate < certifications[j].IssuedDate
+	})
+
+	return certifications, nil
+}
+
+// getBatchScopedCertifications retrieves the certifications issued against
+// a batch itself, ordered by issued date
+func (s *SupplyChainContract) getBatchScopedCertifications(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*CertificationAsset, error) {
+	certifications, err := queryAssets[CertificationAsset](ctx, map[string]interface{}{
+		"docType":  "CertificationAsset",
+		"batch_id": batchID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(certifications, func(i, j int) bool {
+		return certifications[i].IssuedDate < certifications[j].IssuedDate
+	})
+
+	return certifications, nil
+}
+
+// getDerivableCertifications returns the batch-scoped certifications that
+// SplitBatch and MergeBatches carry to new batches: the most recently issued
+// valid certification of each type, ordered by type
+func (s *SupplyChainContract) getDerivableCertifications(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*CertificationAsset, error) {
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	certifications, err := s.getBatchScopedCertifications(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// certifications are ordered by issued date, so later ones replace earlier
+	byType := map[string]*CertificationAsset{}
+	for _, certification := range certifications {
+		if certificationValidity(certification, certifications, now).Valid {
+			byType[normalizeCertificationType(certification.CertType)] = certification
+		}
+	}
+
+	certTypes := make([]string, 0, len(byType))
+	for certType := range byType {
+		certTypes = append(certTypes, certType)
+	}
+	sort.Strings(certTypes)
+
+	derivable := make([]*CertificationAsset, 0, len(certTypes))
+	for _, certType := range certTypes {
+		derivable = append(derivable, byType[certType])
+	}
+	return derivable, nil
+}
+
+// deriveCertification stores an APPROVED batch-scoped certification for
+// batchID standing in for sources, which share a certification type. Its ID
+// is "<batchID>~<type>". It keeps the issuer and document of the source that
+// expires first, the latest issued date and the earliest expiry date.
+func (s *SupplyChainContract) deriveCertification(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	sources []*CertificationAsset,
+) (*CertificationAsset, error) {
+	basis := sources[0]
+	issuedDate := basis.IssuedDate
+	sourceIDs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if source.ExpiryDate != "" && (basis.ExpiryDate == "" || source.ExpiryDate < basis.ExpiryDate) {
+			basis = source
+		}
+		if source.IssuedDate > issuedDate {
+			issuedDate = source.IssuedDate
+		}
+		sourceIDs = append(sourceIDs, source.CertificationID)
+	}
+	certType := normalizeCertificationType(basis.CertType)
+
+	certification := CertificationAsset{
+		DocType:                "CertificationAsset",
+		CertificationID:        fmt.Sprintf("%s~%s", batchID, certType),
+		BatchID:                batchID,
+		CertType:               certType,
+		IssuedDate:             issuedDate,
+		ExpiryDate:             basis.ExpiryDate,
+		IssuerID:               basis.IssuerID,
+		IssuerMSP:              basis.IssuerMSP,
+		IssuerIdentity:         basis.IssuerIdentity,
+		IssuedOnBehalfBy:       basis.IssuedOnBehalfBy,
+		Notes:                  fmt.Sprintf("derived from %s", strings.Join(sourceIDs, ", ")),
+		DocumentSHA256:         basis.DocumentSHA256,
+		DocumentURI:            basis.DocumentURI,
+		SourceCertificationIDs: sourceIDs,
+		CreatedAt:              s.GetTxTimestamp(ctx),
+	}
+	if _, err := s.setCertificationStatus(ctx, &certification, "APPROVED", certification.Notes); err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "CertificationAsset", certification.CertificationID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("certification %s already exists", certification.CertificationID)
+	}
+
+	certBytes, err := json.Marshal(certification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certification: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(certification.CertificationID, certBytes); err != nil {
+		return nil, fmt.Errorf("failed to save certification: %v", err)
+	}
+
+	return &certification, nil
+}
+
+// getSubjectCertifications retrieves the certifications of whichever of
+// processingID and batchID is set
+func (s *SupplyChainContract) getSubjectCertifications(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+	batchID string,
+) ([]*CertificationAsset, error) {
+	if processingID == "" {
+		return s.getBatchScopedCertifications(ctx, batchID)
+	}
+	return s.GetCertificationsByProcessing(ctx, processingID)
+}
+
+// checkCertificationSubject requires exactly one of processingID and batchID
+// and checks that the processing record or batch exists
+func (s *SupplyChainContract) checkCertificationSubject(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+	batchID string,
+) error {
+	if (processingID == "") == (batchID == "") {
+		return fmt.Errorf("exactly one of processingID and batchID must be given")
+	}
+	if batchID != "" {
+		if _, err := s.GetBatch(ctx, batchID); err != nil {
+			return fmt.Errorf("batch does not exist: %v", err)
+		}
+		return nil
+	}
+	if _, err := s.getProcessingRecord(ctx, processingID); err != nil {
+		return fmt.Errorf("processing record does not exist: %v", err)
+	}
+	return nil
+}
+
+// checkCertificationQualityScore rejects certifying a processing record whose
+// quality score, with approved amendments applied, is below the
+// MinQualityScoreForCertification threshold. Batch-scoped certifications and
+// an unset threshold skip the check. The Regulator may pass override to issue
+// anyway, which is reported so the certification can record it.
+func (s *SupplyChainContract) checkCertificationQualityScore(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+	override bool,
+) (bool, error) {
+	if processingID == "" {
+		return false, nil
+	}
+	minScore, set, err := s.getThreshold(ctx, ThresholdMinCertQualityScore)
+	if err != nil || !set {
+		return false, err
+	}
+
+	original, err := s.getProcessingRecord(ctx, processingID)
+	if err != nil {
+		return false, err
+	}
+	record, err := s.effectiveProcessingRecord(ctx, original)
+	if err != nil {
+		return false, err
+	}
+	if record.QualityScore >= minScore {
+		return false, nil
+	}
+
+	if !override {
+		return false, fmt.Errorf("processing record %s has quality score %.1f, below the %.1f required for certification", processingID, record.QualityScore, minScore)
+	}
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return false, fmt.Errorf("quality score override: %v", err)
+	}
+	return true, nil
+}
+
+// certificationSubject describes what a certification is issued against
+func certificationSubject(certification *CertificationAsset) string {
+	if certification.ProcessingID == "" {
+		return "batch " + certification.BatchID
+	}
+	return "processing record " + certification.ProcessingID
+}
+
+// IsCertificationValid reports whether a certification is in force at the
+// transaction timestamp: APPROVED, within its issued and expiry dates, and
+// not superseded by a later APPROVED certification of the same type for the
+// same processing record or batch that is itself issued by then. Parallel
+// certifications (see IssueCertification) never supersede or get superseded.
+// An empty expiry date never expires. When invalid, Reasons lists every
+// failed check.
+func (s *SupplyChainContract) IsCertificationValid(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+) (*CertificationValidity, error) {
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	siblings, err := s.getSubjectCertifications(ctx, certification.ProcessingID, certification.BatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	return certificationValidity(certification, siblings, now), nil
+}
+
+// certificationValidity evaluates a certification at now. siblings are the
+// certifications of the same processing record or batch and must include every
+// APPROVED one for the supersession check.
+func certificationValidity(certification *CertificationAsset, siblings []*CertificationAsset, now time.Time) *CertificationValidity {
+	validity := &CertificationValidity{CertificationID: certification.CertificationID, Reasons: []string{}, Failures: []string{}}
+
+	switch certification.Status {
+	case "APPROVED":
+	case "REVOKED":
+		validity.fail("REVOKED", fmt.Sprintf("revoked: %s", certification.RevokedReason))
+	case "SUPERSEDED":
+		validity.fail("SUPERSEDED", fmt.Sprintf("superseded by %s", certification.SupersededBy))
+	case "SUSPENDED":
+		validity.fail("SUSPENDED", fmt.Sprintf("suspended: %s", certification.SuspendedReason))
+	case "EXPIRED":
+		validity.fail("EXPIRED", "marked expired")
+	default:
+		validity.fail("NOT_APPROVED", fmt.Sprintf("status is %s, not APPROVED", certification.Status))
+	}
+
+	if certification.IssuedDate != "" {
+		issued, err := parseLedgerDate(certification.IssuedDate)
+		if err != nil {
+			validity.fail("INVALID_DATE", fmt.Sprintf("invalid issued date %q", certification.IssuedDate))
+		} else if now.Before(issued) {
+			validity.fail("NOT_YET_VALID", fmt.Sprintf("not valid until %s", certification.IssuedDate))
+		}
+	}
+	if certification.ExpiryDate != "" {
+		expiry, err := parseLedgerDeadline(certification.ExpiryDate)
+		if err != nil {
+			validity.fail("INVALID_DATE", fmt.Sprintf("invalid expiry date %q", certification.ExpiryDate))
+		} else if now.After(expiry) {
+			validity.fail("EXPIRED", fmt.Sprintf("expired on %s", certification.ExpiryDate))
+		}
+	}
+
+	for _, sibling := range siblings {
+		if sibling.CertificationID == certification.CertificationID || sibling.Status != "APPROVED" {
+			continue
+		}
+		if !strings.EqualFold(sibling.CertType, certification.CertType) {
+			continue
+		}
+		if sibling.ParallelTo != "" || certification.ParallelTo != "" {
+			continue
+		}
+		// A successor that is not yet in force does not supersede
+		if sibling.IssuedDate != "" {
+			issued, err := parseLedgerDate(sibling.IssuedDate)
+			if err != nil || now.Before(issued) {
+				continue
+			}
+		}
+		if sibling.IssuedDate > certification.IssuedDate ||
+			(sibling.IssuedDate == certification.IssuedDate && sibling.CreatedAt > certification.CreatedAt) {
+			validity.fail("SUPERSEDED", fmt.Sprintf("superseded by %s", sibling.CertificationID))
+			break
+		}
+	}
+
+	validity.Valid = len(validity.Reasons) == 0
+	return validity
+}
+
+// findActiveCertification returns the active certification of certType for
+// the processing record or batch, or nil when there is none. PENDING,
+// APPROVED and SUSPENDED certifications are active until they expire; expiry
+// is evaluated against the transaction timestamp.
+func (s *SupplyChainContract) findActiveCertification(
+	ctx contractapi.TransactionContextInterface,
+	processingID string,
+	batchID string,
+	certType string,
+) (*CertificationAsset, error) {
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	certifications, err := s.getSubjectCertifications(ctx, processingID, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, certification := range certifications {
+		if !strings.EqualFold(certification.CertType, certType) {
+			continue
+		}
+		if certification.Status != "APPROVED" && certification.Status != "PENDING" && certification.Status != "SUSPENDED" {
+			continue
+		}
+		if certification.ExpiryDate != "" {
+			expiry, err := parseLedgerDeadline(certification.ExpiryDate)
+			if err != nil || now.After(expiry) {
+				continue
+			}
+		}
+		return certification, nil
+	}
+
+	return nil, nil
+}
+
+// RevokeCertification pulls an issued certification, moving it to the
+// terminal REVOKED status with the reason and revoking identity (Regulator
+// only). Revoked certifications count as absent wherever certifications are
+// checked. Certifications derived from it by batch splits and merges are
+// revoked with it.
+func (s *SupplyChainContract) RevokeCertification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+	reason string,
+) (*CertificationAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
+		return nil, err
+	}
+
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.revokeCertification(ctx, certification, reason); err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := s.certificationEventPayload(ctx, certification)
+	eventPayload["reason"] = reason
+	eventPayload["revoked_by"] = certification.RevokedBy
+	s.emitEvent(ctx, "CertificationRevoked", eventPayload, certification)
+
+	return certification, nil
+}
+
+// revokeCertification moves a PENDING, APPROVED or SUSPENDED certification
+// to the terminal REVOKED status, recording the reason and the revoking
+// identity, and revokes the certifications derived from it that are still
+// revocable
+func (s *SupplyChainContract) revokeCertification(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+	reason string,
+) error {
+	if certification.Status == "REVOKED" {
+		return fmt.Errorf("certification %s is already revoked", certification.CertificationID)
+	}
+	_, err := s.moveCertification(ctx, certification, "REVOKED", reason)
+	return err
+}
+
+// sourceInvalidatingStatuses are the statuses that end a certification's
+// validity; certifications derived from it by SplitBatch or MergeBatches
+// follow it into them
+var sourceInvalidatingStatuses = []string{"REVOKED", "SUSPENDED", "EXPIRED", "SUPERSEDED"}
+
+// moveCertification validates and applies a status change, recording the
+// revocation or suspension details, and stores the certification. A change
+// into one of sourceInvalidatingStatuses is applied to the certifications
+// derived from it that can make the same transition, recursively, with
+// StatusFromSource naming the certification they followed; derived
+// certifications follow a renewal with the renewal as their SupersededBy.
+// The acting identity is returned.
+func (s *SupplyChainContract) moveCertification(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+	newStatus string,
+	reason string,
+) (string, error) {
+	return s.moveCertificationOnce(ctx, certification, newStatus, reason, map[string]*CertificationAsset{})
+}
+
+// moveCertificationOnce is moveCertification recording every certification
+// it moves in moved, keyed by ID. Queries do not see the transaction's own
+// writes, so a derived certification reached twice, through two merged
+// sources, is only moved the first time.
+func (s *SupplyChainContract) moveCertificationOnce(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+	newStatus string,
+	reason string,
+	moved map[string]*CertificationAsset,
+) (string, error) {
+	if err := validateCertificationTransition(certification.Status, newStatus); err != nil {
+		return "", err
+	}
+
+	actor, err := s.setCertificationStatus(ctx, certification, newStatus, reason)
+	if err != nil {
+		return "", err
+	}
+	switch newStatus {
+	case "REVOKED":
+		certification.RevokedReason = reason
+		certification.RevokedBy = actor
+		certification.RevokedAt = s.GetTxTimestamp(ctx)
+	case "SUSPENDED":
+		certification.SuspendedReason = reason
+		certification.SuspendedBy = actor
+		certification.SuspendedAt = s.GetTxTimestamp(ctx)
+	}
+
+	if err := s.putCertification(ctx, certification); err != nil {
+		return "", err
+	}
+	moved[certification.CertificationID] = certification
+
+	invalidating := false
+	for _, status := range sourceInvalidatingStatuses {
+		if status == newStatus {
+			invalidating = true
+		}
+	}
+	if !invalidating {
+		return actor, nil
+	}
+
+	derived, err := s.getDerivedCertifications(ctx, certification.CertificationID)
+	if err != nil {
+		return "", err
+	}
+	for _, derivedCertification := range derived {
+		if _, ok := moved[derivedCertification.CertificationID]; ok {
+			continue
+		}
+		if validateCertificationTransition(derivedCertification.Status, newStatus) != nil {
+			continue
+		}
+		derivedCertification.StatusFromSource = certification.CertificationID
+		if newStatus == "SUPERSEDED" {
+			derivedCertification.SupersededBy = certification.SupersededBy
+		}
+		derivedReason := fmt.Sprintf("source certification %s %s: %s", certification.CertificationID, strings.ToLower(newStatus), reason)
+		if _, err := s.moveCertificationOnce(ctx, derivedCertification, newStatus, derivedReason, moved); err != nil {
+			return "", err
+		}
+	}
+	return actor, nil
+}
+
+// getDerivedCertifications returns the certifications SplitBatch or
+// MergeBatches derived from sourceID that are not yet REVOKED or SUPERSEDED
+func (s *SupplyChainContract) getDerivedCertifications(
+	ctx contractapi.TransactionContextInterface,
+	sourceID string,
+) ([]*CertificationAsset, error) {
+	return queryAssets[CertificationAsset](ctx, map[string]interface{}{
+		"docType":                  "CertificationAsset",
+		"source_certification_ids": map[string]interface{}{"$elemMatch": map[string]interface{}{"$eq": sourceID}},
+		"status":                   map[string]interface{}{"$in": []string{"PENDING", "APPROVED", "SUSPENDED", "EXPIRED"}},
+	})
+}
+
+// SuspendCertification temporarily suspends an APPROVED certification for
+// non-compliance, recording the reason and the suspending identity (Regulator
+// only). Suspended certifications are not valid until reinstated.
+// Certifications derived from it by batch splits and merges are suspended
+// with it.
+func (s *SupplyChainContract) SuspendCertification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+	reason string,
+) (*CertificationAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
+		return nil, err
+	}
+
+	certification, err := s.getCertification(ctx, certificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	suspendedBy, err := s.moveCertification(ctx, certification, "SUSPENDED", reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := s.certificationEventPayload(ctx, certification)
+	eventPayload["reason"] = reason
+	eventPayload["suspended_by"] = suspendedBy
+	s.emitEvent(ctx, "CertificationSuspended", eventPayload, certification)
+
+	return certification, nil
+}
+
+// ReinstateCertification returns a SUSPENDED certification to APPROVED,
+// recording the notes and the reinstating identity (Regulator only). The
+// suspension details are kept for the record. Derived certifications that
+// were suspended with it are reinstated too once all their sources are
+// APPROVED again.
+func (s *SupplyChainContract) ReinstateCertification(
+	ctx contractapi.TransactionContextInterface,
+	certificationID string,
+	notes string,
+) (*CertificationAsset, error) {
+	// Authorization check (Regulator only)
+	certification, err := s.authorizeAndLoadCertification(ctx, RegulatorOrgMSP, certificationID)
+	if err != nil {
+		return nil, err
+	}
+	if certification.Status != "SUSPENDED" {
+		return nil, fmt.Errorf("certification %s is %s, only SUSPENDED certifications can be reinstated", certificationID, certification.Status)
+	}
+
+	reinstatedBy, err := s.reinstateCertification(ctx, certification, notes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit event
+	eventPayload := s.certificationEventPayload(ctx, certification)
+	eventPayload["reinstated_by"] = reinstatedBy
+	s.emitEvent(ctx, "CertificationReinstated", eventPayload, certification)
+
+	return certification, nil
+}
+
+// reinstateCertification returns a SUSPENDED certification to APPROVED and
+// stores it, then reinstates the derived certifications suspended because of
+// it. A derived certification with another source that is not APPROVED stays
+// SUSPENDED, following that source instead. The acting identity is returned.
+func (s *SupplyChainContract) reinstateCertification(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+	notes string,
+) (string, error) {
+	reinstatedBy, err := s.setCertificationStatus(ctx, certification, "APPROVED", notes)
+	if err != nil {
+		return "", err
+	}
+	certification.StatusFromSource = ""
+	certification.ReinstatedNotes = notes
+	certification.ReinstatedBy = reinstatedBy
+	certification.ReinstatedAt = s.GetTxTimestamp(ctx)
+
+	if err := s.putCertification(ctx, certification); err != nil {
+		return "", err
+	}
+
+	derived, err := s.getDerivedCertifications(ctx, certification.CertificationID)
+	if err != nil {
+		return "", err
+	}
+	for _, derivedCertification := range derived {
+		if derivedCertification.Status != "SUSPENDED" || derivedCertification.StatusFromSource != certification.CertificationID {
+			continue
+		}
+
+		blockingSource := ""
+		for _, sourceID := range derivedCertification.SourceCertificationIDs {
+			if sourceID == certification.CertificationID {
+				continue
+			}
+			source, err := s.getCertification(ctx, sourceID)
+			if err != nil {
+				return "", err
+			}
+			if source.Status != "APPROVED" {
+				blockingSource = sourceID
+				break
+			}
+		}
+		if blockingSource != "" {
+			derivedCertification.StatusFromSource = blockingSource
+			if err := s.putCertification(ctx, derivedCertification); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		derivedNotes := fmt.Sprintf("source certification %s reinstated: %s", certification.CertificationID, notes)
+		if _, err := s.reinstateCertification(ctx, derivedCertification, derivedNotes); err != nil {
+			return "", err
+		}
+	}
+	return reinstatedBy, nil
+}
+
+// putCertification stores a certification under its ID
+func (s *SupplyChainContract) putCertification(
+	ctx contractapi.TransactionContextInterface,
+	certification *CertificationAsset,
+) error {
+	certBytes, err := json.Marshal(certification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal certification: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(certification.CertificationID, certBytes); err != nil {
+		return fmt.Errorf("failed to update certification: %v", err)
+	}
+	return nil
+}
+
+// BatchExpireAndNotify moves APPROVED certifications whose expiry date has
+// passed at the transaction timestamp to EXPIRED, at most limit per call to
+// bound the transaction (Regulator only), and returns how many were expired.
+// A single CertificationsExpiredBatch event lists the expired certification
+// IDs with their batch IDs, so notifications can fan out from one message;
+// its has_more flag tells the caller to run it again. No event is emitted
+// when nothing expires.
+func (s *SupplyChainContract) BatchExpireAndNotify(
+	ctx contractapi.TransactionContextInterface,
+	limit int,
+) (int, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return 0, err
+	}
+
+	sweep, err := s.expireCertifications(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	// Emit event, only when something expired
+	if sweep.ExpiredCount > 0 {
+		actorMSP, _ := ctx.GetClientIdentity().GetMSPID()
+		eventPayload := map[string]interface{}{
+			"as_of_date":     s.GetTxTimestamp(ctx),
+			"expired_count":  sweep.ExpiredCount,
+			"certifications": sweep.Certifications,
+			"has_more":       sweep.HasMore,
+			"actor_msp":      actorMSP,
+		}
+		s.emitEvent(ctx, "CertificationsExpiredBatch", eventPayload, sweep.Certifications)
+	}
+
+	return sweep.ExpiredCount, nil
+}
+
+// CheckAndExpireCertifications is the maintenance sweep that moves APPROVED
+// certifications whose expiry date has passed at the transaction timestamp to
+// EXPIRED, at most limit per call to bound the transaction (Regulator only;
+// scheduled jobs use a Regulator identity). HasMore tells the caller to run
+// it again. Expired certifications can only move on through renewal.
+//
+// Fabric keeps one event per transaction, so instead of one event per
+// certification a single CertificationExpired event lists all of them.
+func (s *SupplyChainContract) CheckAndExpireCertifications(
+	ctx contractapi.TransactionContextInterface,
+	limit int,
+) (*CertificationExpirySweep, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	sweep, err := s.expireCertifications(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit event, only when something expired
+	if sweep.ExpiredCount > 0 {
+		actorMSP, _ := ctx.GetClientIdentity().GetMSPID()
+		eventPayload := map[string]interface{}{
+			"expired_count":  sweep.ExpiredCount,
+			"certifications": sweep.Certifications,
+			"has_more":       sweep.HasMore,
+			"actor_msp":      actorMSP,
+		}
+		s.emitEvent(ctx, "CertificationExpired", eventPayload, sweep.Certifications)
+	}
+
+	return sweep, nil
+}
+
+// expireCertifications is the expiry sweep shared by
+// CheckAndExpireCertifications and BatchExpireAndNotify. It moves APPROVED
+// certifications whose expiry date has passed at the transaction timestamp to
+// EXPIRED through moveCertificationOnce, in certification ID order and at
+// most limit of them; HasMore reports whether more were due. Certifications
+// derived from an expired one expire with it. Each expired certification is
+// returned with its batch ID.
+func (s *SupplyChainContract) expireCertifications(
+	ctx contractapi.TransactionContextInterface,
+	limit int,
+) (*CertificationExpirySweep, error) {
+	if err := s.ValidatePageSize(limit); err != nil {
+		return nil, err
+	}
+	cutoff, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	approved, err := queryAssets[CertificationAsset](ctx, map[string]interface{}{
+		"docType":     "CertificationAsset",
+		"status":      "APPROVED",
+		"expiry_date": expiryBefore(cutoff),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(approved, func(i, j int) bool {
+		return approved[i].CertificationID < approved[j].CertificationID
+	})
+
+	batchByProcessing := map[string]string{}
+	moved := map[string]*CertificationAsset{}
+	listed := map[string]bool{}
+	expired := []*ExpiredCertification{}
+	dueCount := 0
+	for _, certification := range approved {
+		if _, ok := moved[certification.CertificationID]; ok {
+			continue
+		}
+		if certification.ExpiryDate == "" {
+			continue
+		}
+		expiry, err := parseLedgerDeadline(certification.ExpiryDate)
+		if err != nil || !expiry.Before(cutoff) {
+			continue
+		}
+		if dueCount == limit {
+			return &CertificationExpirySweep{ExpiredCount: len(expired), Certifications: expired, HasMore: true}, nil
+		}
+		dueCount++
+
+		if _, err := s.moveCertificationOnce(ctx, certification, "EXPIRED", "expired on "+certification.ExpiryDate, moved); err != nil {
+			return nil, err
+		}
+
+		// The certification itself, then the derived certifications that
+		// expired with it, in ID order
+		cascaded := []*CertificationAsset{}
+		for id, movedCertification := range moved {
+			if id != certification.CertificationID && !listed[id] {
+				cascaded = append(cascaded, movedCertification)
+			}
+		}
+		sort.Slice(cascaded, func(i, j int) bool {
+			return cascaded[i].CertificationID < cascaded[j].CertificationID
+		})
+		for _, expiredCertification := range append([]*CertificationAsset{certification}, cascaded...) {
+			listed[expiredCertification.CertificationID] = true
+			batchID := expiredCertification.BatchID
+			if expiredCertification.ProcessingID != "" {
+				if cached, ok := batchByProcessing[expiredCertification.ProcessingID]; ok {
+					batchID = cached
+				} else if processing, err := s.getProcessingRecord(ctx, expiredCertification.ProcessingID); err == nil {
+					batchID = processing.BatchID
+					batchByProcessing[expiredCertification.ProcessingID] = batchID
+				}
+			}
+
+			expired = append(expired, &ExpiredCertification{
+				CertificationID: expiredCertification.CertificationID,
+				CertType:        expiredCertification.CertType,
+				ProcessingID:    expiredCertification.ProcessingID,
+				BatchID:         batchID,
+				IssuedDate:      expiredCertification.IssuedDate,
+				ExpiryDate:      expiredCertification.ExpiryDate,
+			})
+		}
+	}
+
+	return &CertificationExpirySweep{ExpiredCount: len(expired), Certifications: expired}, nil
+}
+
+// GetUncertifiedProcessingOlderThan retrieves processing records without a
+// currently valid certification whose processing date is more than days
+// before asOfDate, oldest first. This surfaces overdue certifications for
+// follow-up. Validity is checked as in IsCertificationValid.
+func (s *SupplyChainContract) GetUncertifiedProcessingOlderThan(
+	ctx contractapi.TransactionContextInterface,
+	asOfDate string,
+	days int,
+) ([]*ProcessingAsset, error) {
+	asOf, err := parseLedgerDate(asOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid asOfDate %q: %v", asOfDate, err)
+	}
+	if days < 0 {
+		return nil, fmt.Errorf("days must be non-negative, got %d", days)
+	}
+	cutoff := asOf.AddDate(0, 0, -days)
+
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	approved, err := queryAssets[CertificationAsset](ctx, map[string]interface{}{
+		"docType": "CertificationAsset",
+		"status":  "APPROVED",
+	})
+	if err != nil {
+		return nil, err
+	}
+	approvedByProcessing := map[string][]*CertificationAsset{}
+	for _, certification := range approved {
+		if certification.ProcessingID == "" {
+			continue
+		}
+		approvedByProcessing[certification.ProcessingID] = append(approvedByProcessing[certification.ProcessingID], certification)
+	}
+	certified := map[string]bool{}
+	for processingID, certifications := range approvedByProcessing {
+		for _, certification := range certifications {
+			if certificationValidity(certification, certifications, now).Valid {
+				certified[processingID] = true
+				break
+			}
+		}
+	}
+
+	records, err := queryAssets[ProcessingAsset](ctx, map[string]interface{}{
+		"docType": "ProcessingAsset",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	overdue := []*ProcessingAsset{}
+	for _, record := range records {
+		if certified[record.ProcessingID] {
+			continue
+		}
+		processDate, err := parseLedgerDate(record.ProcessDate)
+		if err != nil || !processDate.Before(cutoff) {
+			continue
+		}
+		overdue = append(overdue, record)
+	}
+
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return overdue[i].ProcessDate < overdue[j].ProcessDate
+	})
+
+	return overdue, nil
+}
+
+// ============================================================================
+// RECALL FUNCTIONS
+// ============================================================================
+
+// InitiateRecall opens a recall against a batch (Regulator only)
+func (s *SupplyChainContract) InitiateRecall(
+	ctx contractapi.TransactionContextInterface,
+	recallID string,
+	batchID string,
+	reason string,
+) (*RecallAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if err := s.ValidateNonEmptyString(recallID, "recallID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
+		return nil, err
+	}
+
+	// Check batch exists
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch does not exist: %v", err)
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "RecallAsset", recallID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("recall %s already exists", recallID)
+	}
+
+	recall := RecallAsset{
+		DocType:   "RecallAsset",
+		RecallID:  recallID,
+		BatchID:   batchID,
+		ProductID: batch.ProductID,
+		Reason:    reason,
+		Status:    "OPEN",
+		CreatedAt: s.GetTxTimestamp(ctx),
+		UpdatedAt: s.GetTxTimestamp(ctx),
+	}
+
+	recallBytes, err := json.Marshal(recall)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recall: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(recallID, recallBytes); err != nil {
+		return nil, fmt.Errorf("failed to save recall: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"recall_id": recallID, "batch_id": batchID, "reason": reason}
+	s.emitEvent(ctx, "RecallInitiated", eventPayload, &recall)
+
+	return &recall, nil
+}
+
+// CloseRecall closes an OPEN recall with a resolution (Regulator only)
+func (s *SupplyChainContract) CloseRecall(
+	ctx contractapi.TransactionContextInterface,
+	recallID string,
+	resolution string,
+) (*RecallAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(resolution, "resolution"); err != nil {
+		return nil, err
+	}
+
+	recall, err := s.GetRecall(ctx, recallID)
+	if err != nil {
+		return nil, err
+	}
+	if recall.Status != "OPEN" {
+		return nil, fmt.Errorf("recall %s is %s, only OPEN recalls can be closed", recallID, recall.Status)
+	}
+
+	recall.Status = "CLOSED"
+	recall.Resolution = resolution
+	recall.ClosedAt = s.GetTxTimestamp(ctx)
+	recall.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	recallBytes, err := json.Marshal(recall)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recall: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(recallID, recallBytes); err != nil {
+		return nil, fmt.Errorf("failed to update recall: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{"recall_id": recallID, "batch_id": recall.BatchID, "resolution": resolution}
+	s.emitEvent(ctx, "RecallClosed", eventPayload, recall)
+
+	return recall, nil
+}
+
+// GetRecall retrieves a recall by ID
+func (s *SupplyChainContract) GetRecall(
+	ctx contractapi.TransactionContextInterface,
+	recallID string,
+) (*RecallAsset, error) {
+	if err := s.ValidateNonEmptyString(recallID, "recallID"); err != nil {
+		return nil, err
+	}
+
+	recallBytes, err := ctx.GetStub().GetState(recallID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recall: %v", err)
+	}
+	if recallBytes == nil {
+		return nil, fmt.Errorf("recall %s not found", recallID)
+	}
+
+	var recall RecallAsset
+	recallErr := json.Unmarshal(recallBytes, &recall)
+	if recallErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal recall: %v", recallErr)
+	}
+
+	return &recall, nil
+}
+
+// GetAllRecallsForProduct retrieves every recall, open or closed, raised
+// against any batch of a product, newest first
+func (s *SupplyChainContract) GetAllRecallsForProduct(
+	ctx contractapi.TransactionContextInterface,
+	productID string,
+) ([]*RecallAsset, error) {
+	if err := s.ValidateNonEmptyString(productID, "productID"); err != nil {
+		return nil, err
+	}
+
+	batches, err := queryAssets[BatchAsset](ctx, map[string]interface{}{
+		"docType":    "BatchAsset",
+		"product_id": productID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) == 0 {
+		return []*RecallAsset{}, nil
+	}
+
+	batchIDs := make([]string, 0, len(batches))
+	for _, batch := range batches {
+		batchIDs = append(batchIDs, batch.BatchID)
+	}
+
+	recalls, err := queryAssets[RecallAsset](ctx, map[string]interface{}{
+		"docType":  "RecallAsset",
+		"batch_id": map[string]interface{}{"$in": batchIDs},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(recalls, func(i, j int) bool {
+		return recalls[i].CreatedAt > recalls[j].CreatedAt
+	})
+
+	return recalls, nil
+}
+
+// ============================================================================
+// REGULATORY FUNCTIONS
+// ============================================================================
+
+// CreateRegulatoryRecord creates a regulatory record (Regulator only).
+// regulatorID is bound to the caller as the certification issuer is: it
+// defaults to the caller's enrollment ID and must match it, except that an
+// admin must name the regulator it acts for and is recorded as doing so.
+// Stop-sales and export permits have their own creation calls, IssueStopSale
+// and CreateExportPermit. details is capped at MaxRegulatoryDetailsLength
+// bytes and, for a record type with a schema set by
+// SetRegulatoryRecordDetailSchema, must be a JSON object holding every
+// required key.
+func (s *SupplyChainContract) CreateRegulatoryRecord(
+	ctx contractapi.TransactionContextInterface,
+	regulatoryID string,
+	batchID string,
+	recordType string,
+	issuedDate string,
+	expiryDate string,
+	regulatorID string,
+	details string,
+	auditFlags string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateNonEmptyString(recordType, "recordType"); err != nil {
+		return nil, err
+	}
+	switch normalizeRegulatoryRecordType(recordType) {
+	case StopSaleRecordType:
+		return nil, fmt.Errorf("%s records are issued with IssueStopSale", StopSaleRecordType)
+	case ExportPermitRecordType:
+		return nil, fmt.Errorf("%s records are created with CreateExportPermit", ExportPermitRecordType)
+	}
+
+	return s.createRegulatoryRecord(ctx, regulatoryID, batchID, recordType, issuedDate, expiryDate, regulatorID, details, auditFlags, nil)
+}
+
+// CreateExportPermit creates a PENDING EXPORT_PERMIT regulatory record for
+// export of a batch to countryCode, an ISO 3166-1 alpha-2 code (Regulator
+// only). The importer and port of exit are required, and the certification
+// types SetExportRequirements lists for the country are copied onto the
+// permit. regulatorID is bound to the caller as in CreateRegulatoryRecord.
+func (s *SupplyChainContract) CreateExportPermit(
+	ctx contractapi.TransactionContextInterface,
+	regulatoryID string,
+	batchID string,
+	issuedDate string,
+	expiryDate string,
+	regulatorID string,
+	countryCode string,
+	importerName string,
+	importerID string,
+	portOfExit string,
+	details string,
+	auditFlags string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	countryCode, err := validateCountryCode(countryCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(importerName, "importerName"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(importerID, "importerID"); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNonEmptyString(portOfExit, "portOfExit"); err != nil {
+		return nil, err
+	}
+
+	requirements, err := s.getExportRequirements(ctx, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	requiredTypes := []string{}
+	if requirements != nil {
+		requiredTypes = append(requiredTypes, requirements.RequiredCertTypes...)
+	}
+
+	return s.createRegulatoryRecord(ctx, regulatoryID, batchID, ExportPermitRecordType, issuedDate, expiryDate, regulatorID, details, auditFlags, &ExportPermitDetails{
+		DestinationCountry: countryCode,
+		ImporterName:       strings.TrimSpace(importerName),
+		ImporterID:         strings.TrimSpace(importerID),
+		PortOfExit:         strings.TrimSpace(portOfExit),
+		RequiredCertTypes:  requiredTypes,
+	})
+}
+
+// createRegulatoryRecord validates and saves a new PENDING regulatory record
+// for CreateRegulatoryRecord and CreateExportPermit
+func (s *SupplyChainContract) createRegulatoryRecord(
+	ctx contractapi.TransactionContextInterface,
+	regulatoryID string,
+	batchID string,
+	recordType string,
+	issuedDate string,
+	expiryDate string,
+	regulatorID string,
+	details string,
+	auditFlags string,
+	exportPermit *ExportPermitDetails,
+) (*RegulatoryAsset, error) {
+	recordType, err := s.validateRegulatoryRecordType(ctx, recordType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateRegulatoryDetails(ctx, recordType, details); err != nil {
+		return nil, err
+	}
+	flags, err := parseAuditFlags(auditFlags)
+	if err != nil {
+		return nil, err
+	}
+	regulator, err := s.resolveActingOfficer(ctx, regulatorID, "regulatorID", "regulator")
+	if err != nil {
+		return nil, err
+	}
+
+	regulatory := RegulatoryAsset{
+		DocType:            "RegulatoryAsset",
+		RegulatoryID:       regulatoryID,
+		BatchID:            batchID,
+		RecordType:         recordType,
+		Status:             "PENDING",
+		IssuedDate:         issuedDate,
+		ExpiryDate:         expiryDate,
+		RegulatorID:        regulator.OfficerID,
+		RegulatorMSP:       regulator.OfficerMSP,
+		RegulatorIdentity:  regulator.OfficerIdentity,
+		RecordedOnBehalfBy: regulator.OnBehalfBy,
+		AssignedTo:         regulator.OfficerID,
+		Details:            details,
+		ExportPermit:       exportPermit,
+		AuditFlags:         flags,
+		CreatedAt:          s.GetTxTimestamp(ctx),
+		UpdatedAt:          s.GetTxTimestamp(ctx),
+	}
+
+	// Validation
+	if err := validationError(validateRegulatoryAsset(&regulatory)); err != nil {
+		return nil, err
+	}
+
+	// Check batch exists
+	_, err = s.GetBatch(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch does not exist: %v", err)
+	}
+
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "RegulatoryAsset", regulatoryID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("regulatory record %s already exists", regulatoryID)
+	}
+
+	for _, flag := range regulatory.AuditFlags {
+		if err := s.recordAuditFlagChange(ctx, &regulatory, flag, "ADDED", "raised at creation"); err != nil {
+			return nil, err
+		}
+	}
+
+	regBytes, err := json.Marshal(regulatory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(regulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to save regulatory record: %v", err)
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"regulatory_id": regulatoryID,
+		"batch_id":      batchID,
+		"status":        "PENDING",
+		"regulator_id":  regulatory.RegulatorID,
+	}
+	if regulatory.RecordedOnBehalfBy != "" {
+		eventPayload["on_behalf_by"] = regulatory.RecordedOnBehalfBy
+	}
+	s.emitEvent(ctx, "RegulatoryRecordUpdated", eventPayload, &regulatory)
+
+	return &regulatory, nil
+}
+
+// UpdateRegulatoryStatus updates regulatory record status (Regulator only).
+// Moving to REJECTED or NEEDS_INFO requires a rejectionReason; moving back to
+// PENDING on resubmission clears it. regulatorID is bound to the caller as in
+// CreateRegulatoryRecord, and the deciding regulator becomes the record's
+// regulator; earlier decisions stay in the decision history. Approving a
+// record whose type is set by SetRegulatorySampleRequirement needs at least
+// one passing regulatory sample.
+func (s *SupplyChainContract) UpdateRegulatoryStatus(
+	ctx contractapi.TransactionContextInterface,
+	regulatoryID string,
+	newStatus string,
+	rejectionReason string,
+	regulatorID string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	regulatory, err := s.authorizeAndLoadRegulatoryRecord(ctx, RegulatorOrgMSP, regulatoryID)
+	if err != nil {
+		return nil, err
+	}
+	if regulatory.RecordType == StopSaleRecordType {
+		return nil, fmt.Errorf("regulatory record %s is a stop-sale; use LiftStopSale", regulatoryID)
+	}
+	if newStatus == "EXPIRED" {
+		return nil, fmt.Errorf("regulatory records expire through CheckAndExpireRegulatoryRecords")
+	}
+
+	// Validate transition
+	if err := validateRegulatoryTransition(regulatory.Status, newStatus); err != nil {
+		return nil, err
+	}
+	if err := validateRegulatoryReason(newStatus, rejectionReason); err != nil {
+		return nil, err
+	}
+	if newStatus == "APPROVED" {
+		if err := s.checkRegulatoryApproval(ctx, regulatory); err != nil {
+			return nil, err
+		}
+	}
+	regulator, err := s.resolveActingOfficer(ctx, regulatorID, "regulatorID", "regulator")
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
 
-// ValidatePositiveInt validates that an integer is positive
-func (s *SupplyChainContract) ValidatePositiveInt(value int, fieldName string) error {
-	if value <= 0 {
-		return fmt.Errorf("%s must be positive, got %d", fieldName, value)
+	if err := s.recordRegulatoryDecision(ctx, regulatory, RegulatoryDecision{
+		Action:      "STATUS_CHANGE",
+		NewStatus:   newStatus,
+		RegulatorID: regulator.OfficerID,
+		OnBehalfBy:  regulator.OnBehalfBy,
+		Reason:      strings.TrimSpace(rejectionReason),
+	}); err != nil {
+		return nil, err
+	}
+	regulatory.Status = newStatus
+	regulatory.RegulatorID = regulator.OfficerID
+	regulatory.RegulatorMSP = regulator.OfficerMSP
+	regulatory.RegulatorIdentity = regulator.OfficerIdentity
+	regulatory.RecordedOnBehalfBy = regulator.OnBehalfBy
+	switch newStatus {
+	case "REJECTED", "NEEDS_INFO":
+		regulatory.RejectionReason = strings.TrimSpace(rejectionReason)
+	case "PENDING":
+		regulatory.RejectionReason = ""
 	}
-	return nil
-}
 
-// ValidatePositiveFloat validates that a float is positive
-func (s *SupplyChainContract) ValidatePositiveFloat(value float64, fieldName string) error {
-	if value < 0 {
-		return fmt.Errorf("%s must be non-negative, got %f", fieldName, value)
+	regBytes, err := json.Marshal(regulatory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
 	}
-	return nil
-}
 
-// ============================================================================
-// PRODUCT FUNCTIONS
-// ============================================================================
+	if err := ctx.GetStub().PutState(regulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to update regulatory record: %v", err)
+	}
 
-// CreateProduct creates a new product type (Admin or Regulator)
-func (s *SupplyChainContract) CreateProduct(
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"regulatory_id": regulatoryID,
+		"status":        newStatus,
+		"regulator_id":  regulatory.RegulatorID,
+	}
+	if regulatory.RejectionReason != "" {
+		eventPayload["rejection_reason"] = regulatory.RejectionReason
+	}
+	if regulatory.RecordedOnBehalfBy != "" {
+		eventPayload["on_behalf_by"] = regulatory.RecordedOnBehalfBy
+	}
+	s.emitEvent(ctx, "RegulatoryRecordUpdated", eventPayload, regulatory)
+
+	return regulatory, nil
+}
+
+// ReopenRegulatoryRecord moves a REJECTED regulatory record back to PENDING
+// for reconsideration, for example after new evidence (Regulator only). The
+// rejection reason is cleared and note is kept in the decision history. For
+// a free-text record type note is also appended to the details, which must
+// stay within MaxRegulatoryDetailsLength; the details of a type with a schema
+// are left as they are and can be edited with UpdateRegulatoryDetails once
+// the record is PENDING.
+func (s *SupplyChainContract) ReopenRegulatoryRecord(
 	ctx contractapi.TransactionContextInterface,
-	productID string,
-	name string,
-	description string,
-) (*ProductAsset, error) {
-	// Authorization check
+	regulatoryID string,
+	note string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
 	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
 		return nil, err
 	}
 
-	// Validation
-	if err := s.ValidateNonEmptyString(productID, "productID"); err != nil {
-		return nil, err
-	}
-	if err := s.ValidateNonEmptyString(name, "name"); err != nil {
+	if err := s.ValidateNonEmptyString(note, "note"); err != nil {
 		return nil, err
 	}
 
-	// Check uniqueness
-	exists, err := s.AssetExists(ctx, "ProductAsset", productID)
+	regulatory, err := s.GetRegulatoryRecord(ctx, regulatoryID)
 	if err != nil {
 		return nil, err
 	}
-	if exists {
-		return nil, fmt.Errorf("product %s already exists", productID)
+	if regulatory.Status != "REJECTED" {
+		return nil, fmt.Errorf("regulatory record %s is %s, only REJECTED records can be reopened", regulatoryID, regulatory.Status)
 	}
 
-	product := ProductAsset{
-		DocType:   "ProductAsset",
-		ProductID: productID,
-		Name:      name,
-		Desc:      description,
-		IsActive:  true,
-		CreatedAt: s.GetTxTimestamp(ctx),
+	// Validate transition
+	if err := validateRegulatoryTransition(regulatory.Status, "PENDING"); err != nil {
+		return nil, err
 	}
 
-	productBytes, err := json.Marshal(product)
+	if err := s.recordRegulatoryDecision(ctx, regulatory, RegulatoryDecision{
+		Action:    "REOPENED",
+		NewStatus: "PENDING",
+		Reason:    note,
+	}); err != nil {
+		return nil, err
+	}
+	regulatory.Status = "PENDING"
+	regulatory.RejectionReason = ""
+	detailKeys, err := s.getRegulatoryDetailKeys(ctx, regulatory.RecordType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal product: %v", err)
+		return nil, err
+	}
+	if len(detailKeys) == 0 {
+		if regulatory.Details == "" {
+			regulatory.Details = "Reopened: " + note
+		} else {
+			regulatory.Details += "\nReopened: " + note
+		}
+		if err := s.validateRegulatoryDetails(ctx, regulatory.RecordType, regulatory.Details); err != nil {
+			return nil, err
+		}
 	}
 
-	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
-		return nil, fmt.Errorf("failed to save product: %v", err)
+	regBytes, err := json.Marshal(regulatory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(regulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to update regulatory record: %v", err)
 	}
 
 	// Emit event
-	eventPayload := map[string]string{"product_id": productID}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("ProductCreated", eventBytes)
+	eventPayload := map[string]interface{}{
+		"regulatory_id": regulatoryID,
+		"status":        regulatory.Status,
+		"reopened":      true,
+		"note":          note,
+	}
+	s.emitEvent(ctx, "RegulatoryRecordUpdated", eventPayload, regulatory)
 
-	return &product, nil
+	return regulatory, nil
 }
 
-// GetProduct retrieves a product by ID
-func (s *SupplyChainContract) GetProduct(
+// RenewRegulatoryRecord opens the successor of an APPROVED or EXPIRED
+// regulatory record for the same batch and type, running from the transaction
+// timestamp until newExpiry (Regulator only). The renewal is PENDING and is
+// reviewed and approved with UpdateRegulatoryStatus like any other record.
+// It links back to the previous one, which records the renewal in
+// SupersededBy and keeps its status: an APPROVED predecessor stays valid until
+// it expires. A record can be renewed once. regulatorID is bound to the caller
+// as in CreateRegulatoryRecord.
+func (s *SupplyChainContract) RenewRegulatoryRecord(
 	ctx contractapi.TransactionContextInterface,
-	productID string,
-) (*ProductAsset, error) {
-	if err := s.ValidateNonEmptyString(productID, "productID"); err != nil {
+	newRegulatoryID string,
+	previousRegulatoryID string,
+	newExpiry string,
+	regulatorID string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	previous, err := s.authorizeAndLoadRegulatoryRecord(ctx, RegulatorOrgMSP, previousRegulatoryID)
+	if err != nil {
 		return nil, err
 	}
 
-	productBytes, err := ctx.GetStub().GetState(productID)
+	// Validation
+	if err := s.ValidateNonEmptyString(newRegulatoryID, "newRegulatoryID"); err != nil {
+		return nil, err
+	}
+	if previous.RecordType == StopSaleRecordType {
+		return nil, fmt.Errorf("regulatory record %s is a stop-sale and cannot be renewed", previousRegulatoryID)
+	}
+	if previous.Status != "APPROVED" && previous.Status != "EXPIRED" {
+		return nil, fmt.Errorf("regulatory record %s is %s, only APPROVED or EXPIRED records can be renewed", previousRegulatoryID, previous.Status)
+	}
+	if previous.SupersededBy != "" {
+		return nil, fmt.Errorf("regulatory record %s was already renewed by %s", previousRegulatoryID, previous.SupersededBy)
+	}
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := parseLedgerDeadline(newExpiry)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read product: %v", err)
+		return nil, fmt.Errorf("invalid newExpiry %q: must be RFC3339 or YYYY-MM-DD", newExpiry)
 	}
-	if productBytes == nil {
-		return nil, fmt.Errorf("product %s not found", productID)
+	if !expiry.After(now) {
+		return nil, fmt.Errorf("newExpiry %s must be after the transaction time", newExpiry)
 	}
 
-	var product ProductAsset
-	marshalErr := json.Unmarshal(productBytes, &product)
-	if marshalErr != nil {
-		return nil, fmt.Errorf("failed to unmarshal product: %v", marshalErr)
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "RegulatoryAsset", newRegulatoryID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("regulatory record %s already exists", newRegulatoryID)
 	}
 
-	return &product, nil
+	regulator, err := s.resolveActingOfficer(ctx, regulatorID, "regulatorID", "regulator")
+	if err != nil {
+		return nil, err
+	}
+
+	renewal := RegulatoryAsset{
+		DocType:            "RegulatoryAsset",
+		RegulatoryID:       newRegulatoryID,
+		BatchID:            previous.BatchID,
+		RecordType:         previous.RecordType,
+		Status:             "PENDING",
+		IssuedDate:         s.GetTxTimestamp(ctx),
+		ExpiryDate:         newExpiry,
+		RegulatorID:        regulator.OfficerID,
+		RegulatorMSP:       regulator.OfficerMSP,
+		RegulatorIdentity:  regulator.OfficerIdentity,
+		RecordedOnBehalfBy: regulator.OnBehalfBy,
+		AssignedTo:         regulator.OfficerID,
+		Details:            previous.Details,
+		ExportPermit:       previous.ExportPermit,
+		PreviousID:         previousRegulatoryID,
+		CreatedAt:          s.GetTxTimestamp(ctx),
+		UpdatedAt:          s.GetTxTimestamp(ctx),
+	}
+
+	previous.SupersededBy = newRegulatoryID
+	previous.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	for _, record := range []*RegulatoryAsset{previous, &renewal} {
+		regBytes, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
+		}
+		if err := ctx.GetStub().PutState(record.RegulatoryID, regBytes); err != nil {
+			return nil, fmt.Errorf("failed to save regulatory record: %v", err)
+		}
+	}
+
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"regulatory_id":          newRegulatoryID,
+		"batch_id":               renewal.BatchID,
+		"status":                 renewal.Status,
+		"regulator_id":           renewal.RegulatorID,
+		"previous_regulatory_id": previousRegulatoryID,
+		"expiry_date":            newExpiry,
+	}
+	s.emitEvent(ctx, "RegulatoryRecordUpdated", eventPayload, &renewal)
+
+	return &renewal, nil
 }
 
-// DeactivateProduct deactivates a product
-func (s *SupplyChainContract) DeactivateProduct(
+// UpdateRegulatoryDetails replaces a regulatory record's details (Regulator
+// only). The edit and its reason go into the decision history along with the
+// SHA-256 of the details they replace. Only PENDING and NEEDS_INFO records
+// are under review and can be edited; a REJECTED record is reopened with
+// ReopenRegulatoryRecord first.
+// The new details are validated as in CreateRegulatoryRecord.
+func (s *SupplyChainContract) UpdateRegulatoryDetails(
 	ctx contractapi.TransactionContextInterface,
-	productID string,
-) (*ProductAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+	regulatoryID string,
+	details string,
+	reason string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	regulatory, err := s.authorizeAndLoadRegulatoryRecord(ctx, RegulatorOrgMSP, regulatoryID)
+	if err != nil {
 		return nil, err
 	}
 
-	product, err := s.GetProduct(ctx, productID)
-	if err != nil {
+	// Validation
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
+		return nil, err
+	}
+	if regulatory.Status != "PENDING" && regulatory.Status != "NEEDS_INFO" {
+		return nil, fmt.Errorf("regulatory record %s is %s; only PENDING or NEEDS_INFO records can be edited", regulatoryID, regulatory.Status)
+	}
+	if details == regulatory.Details {
+		return nil, fmt.Errorf("details of regulatory record %s are unchanged", regulatoryID)
+	}
+	if err := s.validateRegulatoryDetails(ctx, regulatory.RecordType, details); err != nil {
 		return nil, err
 	}
 
-	product.IsActive = false
-	productBytes, err := json.Marshal(product)
+	if err := s.recordRegulatoryDecision(ctx, regulatory, RegulatoryDecision{
+		Action:                "DETAILS_EDIT",
+		NewStatus:             regulatory.Status,
+		Reason:                strings.TrimSpace(reason),
+		PreviousDetailsSHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(regulatory.Details))),
+	}); err != nil {
+		return nil, err
+	}
+	regulatory.Details = details
+
+	regBytes, err := json.Marshal(regulatory)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal product: %v", err)
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
 	}
 
-	if err = ctx.GetStub().PutState(productID, productBytes); err != nil {
-		return nil, fmt.Errorf("failed to update product: %v", err)
+	if err := ctx.GetStub().PutState(regulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to update regulatory record: %v", err)
 	}
 
-	return product, nil
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"regulatory_id":  regulatoryID,
+		"status":         regulatory.Status,
+		"details_edited": true,
+		"reason":         strings.TrimSpace(reason),
+	}
+	s.emitEvent(ctx, "RegulatoryRecordUpdated", eventPayload, regulatory)
+
+	return regulatory, nil
 }
 
-// ============================================================================
-// BATCH FUNCTIONS
-// ============================================================================
+// GetRegulatoryDecisionHistory returns a regulatory record's status changes
+// and details edits, oldest first. Only the latest
+// MaxRegulatoryDecisionHistory are kept, and records stored before the
+// history was kept have an empty history.
+func (s *SupplyChainContract) GetRegulatoryDecisionHistory(
+	ctx contractapi.TransactionContextInterface,
+	regulatoryID string,
+) ([]RegulatoryDecision, error) {
+	regulatory, err := s.GetRegulatoryRecord(ctx, regulatoryID)
+	if err != nil {
+		return nil, err
+	}
+	if regulatory.DecisionHistory == nil {
+		return []RegulatoryDecision{}, nil
+	}
 
-// CreateBatch creates a new batch (Farmer)
-func (s *SupplyChainContract) CreateBatch(
+	return regulatory.DecisionHistory, nil
+}
+
+// AttachRegulatorySample attaches a sampling or residue test result to a
+// regulatory record (Regulator or Lab). A failed sample emits
+// RegulatorySampleFailed, suggesting a stop-sale on the record's batch; the
+// stop-sale itself is left to the Regulator's IssueStopSale.
+func (s *SupplyChainContract) AttachRegulatorySample(
 	ctx contractapi.TransactionContextInterface,
-	batchID string,
-	productID string,
-	farmerID string,
-	batchNumber string,
-	quantity int,
-	startDate string,
-	expectedEndDate string,
-	location string,
-	qrCode string,
-	notes string,
-) (*BatchAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	sampleID string,
+	regulatoryID string,
+	sampleType string,
+	collectedAt string,
+	labID string,
+	resultSummary string,
+	passed bool,
+	reportHash string,
+) (*RegulatorySampleAsset, error) {
+	// Authorization check (Regulator or Lab)
+	if err := s.authorizeAnyMSP(ctx, RegulatorOrgMSP, LabOrgMSP); err != nil {
 		return nil, err
 	}
 
 	// Validation
-	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+	if err := s.ValidateNonEmptyString(sampleID, "sampleID"); err != nil {
 		return nil, err
 	}
-	if err := s.ValidateNonEmptyString(batchNumber, "batchNumber"); err != nil {
+	if err := s.ValidateNonEmptyString(sampleType, "sampleType"); err != nil {
 		return nil, err
 	}
-	if err := s.ValidatePositiveInt(quantity, "quantity"); err != nil {
+	if err := s.ValidateNonEmptyString(labID, "labID"); err != nil {
 		return nil, err
 	}
+	if err := s.ValidateNonEmptyString(resultSummary, "resultSummary"); err != nil {
+		return nil, err
+	}
+	if _, err := parseLedgerDate(collectedAt); err != nil {
+		return nil, fmt.Errorf("invalid collectedAt %q: %v", collectedAt, err)
+	}
+	if reportHash != "" {
+		if err := s.ValidateSHA256Hex(reportHash, "reportHash"); err != nil {
+			return nil, err
+		}
+	}
 
-	// Check product exists
-	_, err := s.GetProduct(ctx, productID)
+	// Check regulatory record exists
+	regulatory, err := s.GetRegulatoryRecord(ctx, regulatoryID)
 	if err != nil {
-		return nil, fmt.Errorf("product %s does not exist", productID)
+		return nil, err
 	}
 
-	// Check batch ID uniqueness
-	var exists bool
-	exists, err = s.AssetExists(ctx, "BatchAsset", batchID)
+	// Check uniqueness
+	exists, err := s.AssetExists(ctx, "RegulatorySampleAsset", sampleID)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("batch %s already exists", batchID)
-	}
-
-	// For batch_number uniqueness, create a secondary index key
-	// In production, use CouchDB rich queries; for now, check a composite key
-	batchNumberKey := fmt.Sprintf("batch_number~%s", batchNumber)
-	existingBatchNum, _ := ctx.GetStub().GetState(batchNumberKey)
-	if existingBatchNum != nil {
-		return nil, fmt.Errorf("batch number %s already exists", batchNumber)
-	}
-
-	batch := BatchAsset{
-		DocType:         "BatchAsset",
-		BatchID:         batchID,
-		ProductID:       productID,
-		FarmerID:        farmerID,
-		BatchNumber:     batchNumber,
-		Status:          "CREATED",
-		Quantity:        quantity,
-		StartDate:       startDate,
-		ExpectedEndDate: expectedEndDate,
-		Location:        location,
-		QRCode:          qrCode,
-		Notes:           notes,
-		CreatedAt:       s.GetTxTimestamp(ctx),
-		UpdatedAt:       s.GetTxTimestamp(ctx),
+		return nil, fmt.Errorf("regulatory sample %s already exists", sampleID)
 	}
 
-	batchBytes, err := json.Marshal(batch)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	sample := RegulatorySampleAsset{
+		DocType:       "RegulatorySampleAsset",
+		SampleID:      sampleID,
+		RegulatoryID:  regulatoryID,
+		BatchID:       regulatory.BatchID,
+		SampleType:    strings.ToUpper(strings.TrimSpace(sampleType)),
+		CollectedAt:   collectedAt,
+		LabID:         labID,
+		ResultSummary: resultSummary,
+		Passed:        passed,
+		ReportHash:    strings.ToLower(reportHash),
+		CreatedAt:     s.GetTxTimestamp(ctx),
 	}
 
-	putErr := ctx.GetStub().PutState(batchID, batchBytes)
-	if putErr != nil {
-		return nil, fmt.Errorf("failed to save batch: %v", putErr)
+	sampleBytes, err := json.Marshal(sample)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal regulatory sample: %v", err)
 	}
 
-	// Store batch number index for uniqueness checking
-	if err = ctx.GetStub().PutState(batchNumberKey, []byte(batchID)); err != nil {
-		return nil, fmt.Errorf("failed to save batch number index: %v", err)
+	if err := ctx.GetStub().PutState(sampleID, sampleBytes); err != nil {
+		return nil, fmt.Errorf("failed to save regulatory sample: %v", err)
 	}
 
 	// Emit event
-	eventPayload := map[string]string{"batch_id": batchID, "farmer_id": farmerID}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("BatchCreated", eventBytes)
+	eventPayload := map[string]interface{}{
+		"sample_id":     sampleID,
+		"regulatory_id": regulatoryID,
+		"batch_id":      regulatory.BatchID,
+		"sample_type":   sample.SampleType,
+	}
+	if passed {
+		s.emitEvent(ctx, "RegulatorySampleAttached", eventPayload, &sample)
+		return &sample, nil
+	}
+	eventPayload["result_summary"] = resultSummary
+	eventPayload["suggested_action"] = "STOP_SALE"
+	s.emitEvent(ctx, "RegulatorySampleFailed", eventPayload, &sample)
 
-	return &batch, nil
+	return &sample, nil
 }
 
-// GetBatch retrieves a batch by ID
-func (s *SupplyChainContract) GetBatch(
+// GetRegulatorySamples retrieves the samples attached to a regulatory record,
+// ordered by collection time
+func (s *SupplyChainContract) GetRegulatorySamples(
 	ctx contractapi.TransactionContextInterface,
-	batchID string,
-) (*BatchAsset, error) {
-	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+	regulatoryID string,
+) ([]*RegulatorySampleAsset, error) {
+	if err := s.ValidateNonEmptyString(regulatoryID, "regulatoryID"); err != nil {
 		return nil, err
 	}
 
-	batchBytes, err := ctx.GetStub().GetState(batchID)
+	samples, err := queryAssets[RegulatorySampleAsset](ctx, map[string]interface{}{
+		"docType":       "RegulatorySampleAsset",
+		"regulatory_id": regulatoryID,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read batch: %v", err)
+		return nil, err
 	}
-	if batchBytes == nil {
-		return nil, fmt.Errorf("batch %s not found", batchID)
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].CollectedAt < samples[j].CollectedAt
+	})
+
+	return samples, nil
+}
+
+// checkRegulatoryApproval refuses to approve regulatory until the rules for
+// its type are met: a type set by SetRegulatorySampleRequirement needs a
+// passing sample attached to the record. Every path that moves a record to
+// APPROVED or creates it APPROVED checks it.
+func (s *SupplyChainContract) checkRegulatoryApproval(
+	ctx contractapi.TransactionContextInterface,
+	regulatory *RegulatoryAsset,
+) error {
+	config, err := s.getSystemConfig(ctx)
+	if err != nil {
+		return err
+	}
+	recordType := normalizeRegulatoryRecordType(regulatory.RecordType)
+	required := false
+	for _, known := range config.SampleRequiredRecordTypes {
+		if known == recordType {
+			required = true
+			break
+		}
+	}
+	if !required {
+		return nil
 	}
 
-	var batch BatchAsset
-	marshalErr := json.Unmarshal(batchBytes, &batch)
-	if marshalErr != nil {
-		return nil, fmt.Errorf("failed to unmarshal batch: %v", marshalErr)
+	passing, err := queryAssets[RegulatorySampleAsset](ctx, map[string]interface{}{
+		"docType":       "RegulatorySampleAsset",
+		"regulatory_id": regulatory.RegulatoryID,
+		"passed":        true,
+	})
+	if err != nil {
+		return err
 	}
+	if len(passing) == 0 {
+		return fmt.Errorf("%s records need a passing sample before approval; attach one with AttachRegulatorySample", recordType)
+	}
+	return nil
+}
 
-	return &batch, nil
+// recordRegulatoryDecision appends a decision by the caller, taken from the
+// record's current status, to its history and sets UpdatedAt. The caller
+// applies the change itself and stores the record.
+func (s *SupplyChainContract) recordRegulatoryDecision(
+	ctx contractapi.TransactionContextInterface,
+	regulatory *RegulatoryAsset,
+	decision RegulatoryDecision,
+) error {
+	actor, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+	actorMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP: %v", err)
+	}
+
+	decision.OldStatus = regulatory.Status
+	decision.Actor = actor
+	decision.ActorMSP = actorMSP
+	decision.ChangedAt = s.GetTxTimestamp(ctx)
+	decision.TxID = ctx.GetStub().GetTxID()
+	regulatory.DecisionHistory = append(regulatory.DecisionHistory, decision)
+	if excess := len(regulatory.DecisionHistory) - MaxRegulatoryDecisionHistory; excess > 0 {
+		regulatory.DecisionHistory = regulatory.DecisionHistory[excess:]
+	}
+	regulatory.UpdatedAt = s.GetTxTimestamp(ctx)
+	return nil
 }
 
-// UpdateBatchStatus updates batch status with validation
-func (s *SupplyChainContract) UpdateBatchStatus(
+// AddRegulatoryAuditFlag raises one of regulatoryAuditFlags on a regulatory
+// record (Regulator only). A flag already on the record is refused.
+func (s *SupplyChainContract) AddRegulatoryAuditFlag(
 	ctx contractapi.TransactionContextInterface,
-	batchID string,
-	newStatus string,
-) (*BatchAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	regulatoryID string,
+	flag string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	regulatory, err := s.authorizeAndLoadRegulatoryRecord(ctx, RegulatorOrgMSP, regulatoryID)
+	if err != nil {
 		return nil, err
 	}
 
-	batch, err := s.GetBatch(ctx, batchID)
+	flag, err = normalizeAuditFlag(flag)
 	if err != nil {
 		return nil, err
 	}
+	for _, existing := range regulatory.AuditFlags {
+		if strings.EqualFold(existing, flag) {
+			return nil, fmt.Errorf("regulatory record %s already has audit flag %s", regulatoryID, flag)
+		}
+	}
 
-	// Validate transition
-	if err := s.ValidateStatusTransition(batch.Status, newStatus); err != nil {
+	regulatory.AuditFlags = append(regulatory.AuditFlags, flag)
+	if err := s.recordAuditFlagChange(ctx, regulatory, flag, "ADDED", ""); err != nil {
 		return nil, err
 	}
 
-	batch.Status = newStatus
-	batch.UpdatedAt = s.GetTxTimestamp(ctx)
-
-	batchBytes, err := json.Marshal(batch)
+	regBytes, err := json.Marshal(regulatory)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
 	}
 
-	if err := ctx.GetStub().PutState(batchID, batchBytes); err != nil {
-		return nil, fmt.Errorf("failed to update batch: %v", err)
+	if err := ctx.GetStub().PutState(regulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to update regulatory record: %v", err)
 	}
 
-	return batch, nil
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"regulatory_id":    regulatoryID,
+		"status":           regulatory.Status,
+		"audit_flag_added": flag,
+	}
+	s.emitEvent(ctx, "RegulatoryRecordUpdated", eventPayload, regulatory)
+
+	return regulatory, nil
 }
 
-// CompleteBatch completes a batch
-func (s *SupplyChainContract) CompleteBatch(
+// RemoveRegulatoryAuditFlag clears an audit flag from a regulatory record
+// (Regulator only). The remover and reason are kept in the record's audit flag
+// history. Flags carried over from the legacy string need not be in
+// regulatoryAuditFlags to be removed.
+func (s *SupplyChainContract) RemoveRegulatoryAuditFlag(
 	ctx contractapi.TransactionContextInterface,
-	batchID string,
-	actualEndDate string,
-) (*BatchAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	regulatoryID string,
+	flag string,
+	reason string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	regulatory, err := s.authorizeAndLoadRegulatoryRecord(ctx, RegulatorOrgMSP, regulatoryID)
+	if err != nil {
 		return nil, err
 	}
 
-	batch, err := s.GetBatch(ctx, batchID)
-	if err != nil {
+	if err := s.ValidateNonEmptyString(flag, "flag"); err != nil {
 		return nil, err
 	}
-
-	// Validate transition to COMPLETED
-	if err := s.ValidateStatusTransition(batch.Status, "COMPLETED"); err != nil {
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
 		return nil, err
 	}
 
-	batch.Status = "COMPLETED"
-	batch.ActualEndDate = actualEndDate
-	batch.UpdatedAt = s.GetTxTimestamp(ctx)
+	removed := ""
+	remaining := AuditFlagList{}
+	for _, existing := range regulatory.AuditFlags {
+		if removed == "" && strings.EqualFold(existing, strings.TrimSpace(flag)) {
+			removed = existing
+			continue
+		}
+		remaining = append(remaining, existing)
+	}
+	if removed == "" {
+		return nil, fmt.Errorf("regulatory record %s has no audit flag %s", regulatoryID, flag)
+	}
 
-	batchBytes, err := json.Marshal(batch)
+	regulatory.AuditFlags = remaining
+	if err := s.recordAuditFlagChange(ctx, regulatory, removed, "REMOVED", reason); err != nil {
+		return nil, err
+	}
+
+	regBytes, err := json.Marshal(regulatory)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
 	}
 
-	if err := ctx.GetStub().PutState(batchID, batchBytes); err != nil {
-		return nil, fmt.Errorf("failed to complete batch: %v", err)
+	if err := ctx.GetStub().PutState(regulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to update regulatory record: %v", err)
 	}
 
-	return batch, nil
+	// Emit event
+	eventPayload := map[string]interface{}{
+		"regulatory_id":      regulatoryID,
+		"status":             regulatory.Status,
+		"audit_flag_removed": removed,
+		"reason":             reason,
+	}
+	s.emitEvent(ctx, "RegulatoryRecordUpdated", eventPayload, regulatory)
+
+	return regulatory, nil
 }
 
-// GetBatchesByFarmer retrieves all batches for a farmer
-func (s *SupplyChainContract) GetBatchesByFarmer(
+// recordAuditFlagChange appends an audit flag change by the caller to the
+// record's history and sets UpdatedAt
+func (s *SupplyChainContract) recordAuditFlagChange(
 	ctx contractapi.TransactionContextInterface,
-	farmerID string,
-) ([]*BatchAsset, error) {
-	if err := s.ValidateNonEmptyString(farmerID, "farmerID"); err != nil {
-		return nil, err
+	regulatory *RegulatoryAsset,
+	flag string,
+	action string,
+	reason string,
+) error {
+	actor, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
 	}
 
-	// Note: In production, use CouchDB rich queries via GetQueryResultsForQueryString
-	// For now, return empty list (full implementation requires RichQuery support)
-	return []*BatchAsset{}, nil
+	regulatory.AuditFlagHistory = append(regulatory.AuditFlagHistory, AuditFlagChange{
+		Flag:      flag,
+		Action:    action,
+		Actor:     actor,
+		Reason:    reason,
+		ChangedAt: s.GetTxTimestamp(ctx),
+		TxID:      ctx.GetStub().GetTxID(),
+	})
+	regulatory.UpdatedAt = s.GetTxTimestamp(ctx)
+	return nil
 }
 
-// ============================================================================
-// LIFECYCLE EVENT FUNCTIONS
-// ============================================================================
-
-// RecordLifecycleEvent records a lifecycle event (append-only)
-func (s *SupplyChainContract) RecordLifecycleEvent(
+// GetRegulatoryRecord retrieves a regulatory record by ID
+func (s *SupplyChainContract) GetRegulatoryRecord(
 	ctx contractapi.TransactionContextInterface,
-	eventID string,
-	batchID string,
-	eventType string,
-	description string,
-	recordedBy string,
-	eventDate string,
-	quantityAffected int,
-	metadata string,
-) (*LifecycleEventAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	regulatoryID string,
+) (*RegulatoryAsset, error) {
+	if err := s.ValidateNonEmptyString(regulatoryID, "regulatoryID"); err != nil {
 		return nil, err
 	}
 
-	// Validation
-	if err := s.ValidateNonEmptyString(eventID, "eventID"); err != nil {
-		return nil, err
+	regBytes, err := ctx.GetStub().GetState(regulatoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read regulatory record: %v", err)
+	}
+	if regBytes == nil {
+		return nil, fmt.Errorf("regulatory record %s not found", regulatoryID)
 	}
+
+	var regulatory RegulatoryAsset
+	regErr := json.Unmarshal(regBytes, &regulatory)
+	if regErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal regulatory record: %v", regErr)
+	}
+
+	return &regulatory, nil
+}
+
+// GetRegulatoryRecordsByBatch retrieves regulatory records for a batch
+func (s *SupplyChainContract) GetRegulatoryRecordsByBatch(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) ([]*RegulatoryAsset, error) {
 	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
 		return nil, err
 	}
 
-	// Check batch exists
-	_, err := s.GetBatch(ctx, batchID)
+	records, err := queryAssets[RegulatoryAsset](ctx, map[string]interface{}{
+		"docType":  "RegulatoryAsset",
+		"batch_id": batchID,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("batch does not exist: %v", err)
+		return nil, err
 	}
 
-	// Check event uniqueness
-	exists, err := s.AssetExists(ctx, "LifecycleEventAsset", eventID)
-	if err != nil {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt < records[j].CreatedAt
+	})
+
+	return records, nil
+}
+
+// GetRegulatoryRecordsByType retrieves the regulatory records of a type,
+// oldest first, optionally only those in status. recordType is matched on its
+// normalized spelling, as in GetRegulatoryRecordsByStatus, and need not be
+// registered any more, so records of a retired type can still be read.
+func (s *SupplyChainContract) GetRegulatoryRecordsByType(
+	ctx contractapi.TransactionContextInterface,
+	recordType string,
+	status string,
+) ([]*RegulatoryAsset, error) {
+	if err := s.ValidateNonEmptyString(recordType, "recordType"); err != nil {
 		return nil, err
 	}
-	if exists {
-		return nil, fmt.Errorf("event %s already exists", eventID)
-	}
 
-	event := LifecycleEventAsset{
-		DocType:          "LifecycleEventAsset",
-		EventID:          eventID,
-		BatchID:          batchID,
-		EventType:        eventType,
-		Description:      description,
-		RecordedBy:       recordedBy,
-		EventDate:        eventDate,
-		QuantityAffected: quantityAffected,
-		Metadata:         metadata,
-		CreatedAt:        s.GetTxTimestamp(ctx),
+	selector := map[string]interface{}{
+		"docType":     "RegulatoryAsset",
+		"record_type": normalizeRegulatoryRecordType(recordType),
 	}
-
-	eventBytes, err := json.Marshal(event)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal event: %v", err)
+	if status != "" {
+		if _, known := regulatoryStatusTransitions[status]; !known {
+			return nil, fmt.Errorf("unknown regulatory status: %s", status)
+		}
+		selector["status"] = status
 	}
 
-	if err := ctx.GetStub().PutState(eventID, eventBytes); err != nil {
-		return nil, fmt.Errorf("failed to save event: %v", err)
+	records, err := queryAssets[RegulatoryAsset](ctx, selector)
+	if err != nil {
+		return nil, err
 	}
 
-	// Emit event
-	eventPayload := map[string]string{
-		"event_id":   eventID,
-		"batch_id":   batchID,
-		"event_type": eventType,
-	}
-	eventPayloadBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("LifecycleEventRecorded", eventPayloadBytes)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt < records[j].CreatedAt
+	})
 
-	return &event, nil
+	return records, nil
 }
 
-// GetBatchLifecycleEvents retrieves all lifecycle events for a batch
-func (s *SupplyChainContract) GetBatchLifecycleEvents(
+// GetRegulatoryRecordsByStatus retrieves one page of regulatory records across
+// all batches (Regulator only). status and recordType are optional filters;
+// status must be a state of the regulatory status machine. recordType is
+// matched on its normalized spelling, so records stored under a variant
+// spelling before types were validated are not returned. Records are sorted
+// oldest first in the query itself, so the pages follow on from each other.
+func (s *SupplyChainContract) GetRegulatoryRecordsByStatus(
 	ctx contractapi.TransactionContextInterface,
-	batchID string,
-) ([]*LifecycleEventAsset, error) {
-	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+	status string,
+	recordType string,
+	pageSize int,
+	bookmark string,
+) (*RegulatoryRecordPage, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
 		return nil, err
 	}
 
-	// Note: In production, use CouchDB rich queries via GetQueryResultsForQueryString
-	// For now, return empty list (full implementation requires RichQuery support)
-	return []*LifecycleEventAsset{}, nil
-}
-
-// ============================================================================
-// TRANSPORT FUNCTIONS
-// ============================================================================
+	// Validation
+	if err := s.ValidatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+	selector := map[string]interface{}{"docType": "RegulatoryAsset"}
+	if status != "" {
+		if _, known := regulatoryStatusTransitions[status]; !known {
+			return nil, fmt.Errorf("unknown regulatory status: %s", status)
+		}
+		selector["status"] = status
+	}
+	if strings.TrimSpace(recordType) != "" {
+		selector["record_type"] = normalizeRegulatoryRecordType(recordType)
+	}
 
-// CreateTransportManifest creates a transport manifest
-func (s *SupplyChainContract) CreateTransportManifest(
-	ctx contractapi.TransactionContextInterface,
-	transportID string,
-	batchID string,
-	fromPartyID string,
-	toPartyID string,
-	vehicleID string,
-	driverName string,
-	departureTime string,
-	originLocation string,
-	destinationLocation string,
-	temperatureMonitored bool,
-	notes string,
-) (*TransportAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	records, nextBookmark, err := querySortedAssetsWithPagination[RegulatoryAsset](ctx, selector, []map[string]string{{"created_at": "asc"}}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegulatoryRecordPage{
+		Records:  records,
+		Bookmark: nextBookmark,
+		Count:    len(records),
+	}, nil
+}
+
+// ReassignRegulatoryRecord hands a regulatory record to another reviewer, for
+// example when the assigned officer goes on leave mid-review (Regulator or
+// Admin). Records created before assignment existed are treated as assigned
+// to their regulator. WITHDRAWN and EXPIRED records are closed and cannot be
+// reassigned. Each reassignment is kept in the decision history.
+func (s *SupplyChainContract) ReassignRegulatoryRecord(
+	ctx contractapi.TransactionContextInterface,
+	regulatoryID string,
+	newReviewerID string,
+	reason string,
+) (*RegulatoryAsset, error) {
+	// Authorization check (Regulator or Admin)
+	regulatory, err := s.authorizeAndLoadRegulatoryRecord(ctx, RegulatorOrgMSP, regulatoryID)
+	if err != nil {
 		return nil, err
 	}
 
 	// Validation
-	if err := s.ValidateNonEmptyString(transportID, "transportID"); err != nil {
+	if err := s.ValidateNonEmptyString(newReviewerID, "newReviewerID"); err != nil {
 		return nil, err
 	}
-	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
 		return nil, err
 	}
-
-	// Check batch exists
-	_, err := s.GetBatch(ctx, batchID)
-	if err != nil {
-		return nil, fmt.Errorf("batch does not exist: %v", err)
+	if len(regulatoryStatusTransitions[regulatory.Status]) == 0 {
+		return nil, fmt.Errorf("regulatory record %s is %s and cannot be reassigned", regulatoryID, regulatory.Status)
 	}
-
-	// Check uniqueness
-	exists, err := s.AssetExists(ctx, "TransportAsset", transportID)
-	if err != nil {
-		return nil, err
+	previousAssignee := regulatory.AssignedTo
+	if previousAssignee == "" {
+		previousAssignee = regulatory.RegulatorID
 	}
-	if exists {
-		return nil, fmt.Errorf("transport %s already exists", transportID)
+	if newReviewerID == previousAssignee {
+		return nil, fmt.Errorf("regulatory record %s is already assigned to %s", regulatoryID, newReviewerID)
 	}
 
-	transport := TransportAsset{
-		DocType:             "TransportAsset",
-		TransportID:         transportID,
-		BatchID:             batchID,
-		FromPartyID:         fromPartyID,
-		ToPartyID:           toPartyID,
-		VehicleID:           vehicleID,
-		DriverName:          driverName,
-		DepartureTime:       departureTime,
-		OriginLocation:      originLocation,
-		DestinationLocation: destinationLocation,
-		TemperatureMonitored: temperatureMonitored,
-		Status:              "INITIATED",
-		Notes:               notes,
-		CreatedAt:           s.GetTxTimestamp(ctx),
-		UpdatedAt:           s.GetTxTimestamp(ctx),
+	if err := s.recordRegulatoryDecision(ctx, regulatory, RegulatoryDecision{
+		Action:           "REASSIGNMENT",
+		NewStatus:        regulatory.Status,
+		Reason:           strings.TrimSpace(reason),
+		PreviousAssignee: previousAssignee,
+		AssignedTo:       newReviewerID,
+	}); err != nil {
+		return nil, err
 	}
+	regulatory.AssignedTo = newReviewerID
 
-	transportBytes, err := json.Marshal(transport)
+	regBytes, err := json.Marshal(regulatory)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal transport: %v", err)
+		return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
 	}
 
-	if err := ctx.GetStub().PutState(transportID, transportBytes); err != nil {
-		return nil, fmt.Errorf("failed to save transport: %v", err)
+	if err := ctx.GetStub().PutState(regulatoryID, regBytes); err != nil {
+		return nil, fmt.Errorf("failed to update regulatory record: %v", err)
 	}
 
 	// Emit event
-	eventPayload := map[string]string{"transport_id": transportID, "batch_id": batchID}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("TransportCreated", eventBytes)
+	eventPayload := map[string]interface{}{
+		"regulatory_id":     regulatoryID,
+		"status":            regulatory.Status,
+		"previous_assignee": previousAssignee,
+		"assigned_to":       newReviewerID,
+		"reason":            strings.TrimSpace(reason),
+	}
+	s.emitEvent(ctx, "RegulatoryRecordReassigned", eventPayload, regulatory)
 
-	return &transport, nil
+	return regulatory, nil
 }
 
-// UpdateTransportStatus updates transport status
-func (s *SupplyChainContract) UpdateTransportStatus(
+// GetRegulatoryRecordsByAssignee retrieves the regulatory records assigned to
+// a reviewer, least recently updated first, as their work queue (Regulator
+// only). As in ReassignRegulatoryRecord, a record with no assignee is
+// assigned to its regulator. An empty status returns records in every status.
+func (s *SupplyChainContract) GetRegulatoryRecordsByAssignee(
 	ctx contractapi.TransactionContextInterface,
-	transportID string,
-	newStatus string,
-	arrivalTime string,
-) (*TransportAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	reviewerID string,
+	status string,
+) ([]*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
 		return nil, err
 	}
 
-	transport, err := s.GetTransport(ctx, transportID)
-	if err != nil {
+	// Validation
+	if err := s.ValidateNonEmptyString(reviewerID, "reviewerID"); err != nil {
 		return nil, err
 	}
-
-	// Validate transition
-	if err := s.ValidateStatusTransition(transport.Status, newStatus); err != nil {
-		return nil, err
+	selector := map[string]interface{}{
+		"docType":     "RegulatoryAsset",
+		"assigned_to": reviewerID,
 	}
-
-	transport.Status = newStatus
-	if newStatus == "COMPLETED" {
-		transport.ArrivalTime = arrivalTime
+	unassignedSelector := map[string]interface{}{
+		"docType":      "RegulatoryAsset",
+		"regulator_id": reviewerID,
+	}
+	if status != "" {
+		if _, known := regulatoryStatusTransitions[status]; !known {
+			return nil, fmt.Errorf("unknown regulatory status: %s", status)
+		}
+		selector["status"] = status
+		unassignedSelector["status"] = status
 	}
-	transport.UpdatedAt = s.GetTxTimestamp(ctx)
 
-	transportBytes, err := json.Marshal(transport)
+	records, err := queryAssets[RegulatoryAsset](ctx, selector)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal transport: %v", err)
+		return nil, err
 	}
-
-	if err := ctx.GetStub().PutState(transportID, transportBytes); err != nil {
-		return nil, fmt.Errorf("failed to update transport: %v", err)
+	// Records created before assignment existed have no assigned_to at all,
+	// so they are found by regulator and filtered here
+	byRegulator, err := queryAssets[RegulatoryAsset](ctx, unassignedSelector)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range byRegulator {
+		if record.AssignedTo == "" {
+			records = append(records, record)
+		}
 	}
 
-	return transport, nil
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].UpdatedAt < records[j].UpdatedAt
+	})
+
+	return records, nil
 }
 
-// GetTransport retrieves a transport by ID
-func (s *SupplyChainContract) GetTransport(
+// GetRegulatoryRecordsByRegulator retrieves the regulatory records whose
+// regulator, the creator or latest decider, is regulatorID and that were last
+// updated within [fromDate, toDate], oldest first (Regulator only). The range
+// may cover at most MaxStatsRangeDays.
+func (s *SupplyChainContract) GetRegulatoryRecordsByRegulator(
 	ctx contractapi.TransactionContextInterface,
-	transportID string,
-) (*TransportAsset, error) {
-	if err := s.ValidateNonEmptyString(transportID, "transportID"); err != nil {
+	regulatorID string,
+	fromDate string,
+	toDate string,
+) ([]*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
 		return nil, err
 	}
 
-	transportBytes, err := ctx.GetStub().GetState(transportID)
+	// Validation
+	if err := s.ValidateNonEmptyString(regulatorID, "regulatorID"); err != nil {
+		return nil, err
+	}
+	from, to, err := parseDateRange(fromDate, toDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read transport: %v", err)
+		return nil, err
 	}
-	if transportBytes == nil {
-		return nil, fmt.Errorf("transport %s not found", transportID)
+	if to.Sub(from) > time.Duration(MaxStatsRangeDays)*24*time.Hour {
+		return nil, fmt.Errorf("date range %s to %s exceeds %d days", fromDate, toDate, MaxStatsRangeDays)
+	}
+	dateRange, err := dateRangeSelector(fromDate, toDate)
+	if err != nil {
+		return nil, err
 	}
 
-	var transport TransportAsset
-	transportErr := json.Unmarshal(transportBytes, &transport)
-	if transportErr != nil {
-		return nil, fmt.Errorf("failed to unmarshal transport: %v", transportErr)
+	records, err := queryAssets[RegulatoryAsset](ctx, map[string]interface{}{
+		"docType":      "RegulatoryAsset",
+		"regulator_id": regulatorID,
+		"updated_at":   dateRange,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &transport, nil
+	matching := []*RegulatoryAsset{}
+	for _, record := range records {
+		if inDateRange(record.UpdatedAt, from, to) {
+			matching = append(matching, record)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].UpdatedAt < matching[j].UpdatedAt
+	})
+
+	return matching, nil
 }
 
-// AddTemperatureLog adds a temperature reading
-func (s *SupplyChainContract) AddTemperatureLog(
+// GetRegulatoryRecordsByAuditFlag retrieves the regulatory records carrying
+// an audit flag, oldest first, as a work queue (Regulator only). Records whose
+// flags are still a legacy string are matched only once their flags have been
+// changed.
+func (s *SupplyChainContract) GetRegulatoryRecordsByAuditFlag(
 	ctx contractapi.TransactionContextInterface,
-	logID string,
-	transportID string,
-	temperature float64,
-	timestamp string,
-	location string,
-) (*TemperatureLogAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	flag string,
+) ([]*RegulatoryAsset, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
 		return nil, err
 	}
 
-	// Validation
-	if err := s.ValidateNonEmptyString(logID, "logID"); err != nil {
-		return nil, err
-	}
-	if err := s.ValidatePositiveFloat(temperature, "temperature"); err != nil {
+	flag, err := normalizeAuditFlag(flag)
+	if err != nil {
 		return nil, err
 	}
 
-	// Check transport exists
-	_, err := s.GetTransport(ctx, transportID)
+	records, err := queryAssets[RegulatoryAsset](ctx, map[string]interface{}{
+		"docType":     "RegulatoryAsset",
+		"audit_flags": map[string]interface{}{"$elemMatch": map[string]interface{}{"$eq": flag}},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("transport does not exist: %v", err)
+		return nil, err
 	}
 
-	// Detect temperature violation
-	isViolation := temperature < TemperatureMinSafe || temperature > TemperatureMaxSafe
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt < records[j].CreatedAt
+	})
 
-	tempLog := TemperatureLogAsset{
-		DocType:     "TemperatureLogAsset",
-		LogID:       logID,
-		TransportID: transportID,
-		Temperature: temperature,
-		Timestamp:   timestamp,
-		Location:    location,
-		IsViolation: isViolation,
-		CreatedAt:   s.GetTxTimestamp(ctx),
-	}
+	return records, nil
+}
 
-	logBytes, err := json.Marshal(tempLog)
+// GetRegulatoryApprovalRate counts the regulatory records approved and
+// rejected within [fromDate, toDate] and the share approved. A record's
+// decision time is that of the decision history entry that gave it its
+// current status, so later edits do not move it. Stop-sale holds are not
+// reviews and are left out. The range may span at most MaxStatsRangeDays.
+func (s *SupplyChainContract) GetRegulatoryApprovalRate(
+	ctx contractapi.TransactionContextInterface,
+	fromDate string,
+	toDate string,
+) (*RegulatoryApprovalRate, error) {
+	from, to, err := parseDateRange(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	if to.Sub(from) > time.Duration(MaxStatsRangeDays)*24*time.Hour {
+		return nil, fmt.Errorf("date range %s to %s exceeds %d days", fromDate, toDate, MaxStatsRangeDays)
+	}
+	dateRange, err := dateRangeSelector(fromDate, toDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal temperature log: %v", err)
+		return nil, err
 	}
 
-	if err := ctx.GetStub().PutState(logID, logBytes); err != nil {
-		return nil, fmt.Errorf("failed to save temperature log: %v", err)
+	// A record is updated at or after its decision, so updated_at bounds
+	// the decision time from below
+	records, err := queryAssets[RegulatoryAsset](ctx, map[string]interface{}{
+		"docType":     "RegulatoryAsset",
+		"status":      map[string]interface{}{"$in": []string{"APPROVED", "REJECTED"}},
+		"record_type": map[string]interface{}{"$ne": StopSaleRecordType},
+		"updated_at":  map[string]interface{}{"$gte": dateRange["$gte"]},
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Emit violation event if detected
-	if isViolation {
-		eventPayload := map[string]interface{}{
-			"transport_id": transportID,
-			"temperature":  temperature,
-			"threshold":    fmt.Sprintf("%.1f-%.1f°C", TemperatureMinSafe, TemperatureMaxSafe),
+	rate := &RegulatoryApprovalRate{
+		FromDate: fromDate,
+		ToDate:   toDate,
+	}
+	for _, record := range records {
+		if !inDateRange(regulatoryDecisionTime(record), from, to) {
+			continue
 		}
-		eventBytes, _ := json.Marshal(eventPayload)
-		ctx.GetStub().SetEvent("TemperatureViolationDetected", eventBytes)
+		if record.Status == "APPROVED" {
+			rate.ApprovedCount++
+		} else {
+			rate.RejectedCount++
+		}
+	}
+	rate.DecidedCount = rate.ApprovedCount + rate.RejectedCount
+	if rate.DecidedCount > 0 {
+		rate.ApprovalRatePercent = float64(rate.ApprovedCount) / float64(rate.DecidedCount) * 100
 	}
 
-	return &tempLog, nil
+	return rate, nil
 }
 
-// GetTransportTemperatureLogs retrieves all temperature logs for a transport
-func (s *SupplyChainContract) GetTransportTemperatureLogs(
-	ctx contractapi.TransactionContextInterface,
-	transportID string,
-) ([]*TemperatureLogAsset, error) {
-	if err := s.ValidateNonEmptyString(transportID, "transportID"); err != nil {
-		return nil, err
+// regulatoryDecisionTime returns when a regulatory record took its current
+// status: the latest decision history entry moving it there, or UpdatedAt for
+// records whose history does not show it
+func regulatoryDecisionTime(record *RegulatoryAsset) string {
+	for i := len(record.DecisionHistory) - 1; i >= 0; i-- {
+		decision := record.DecisionHistory[i]
+		if decision.NewStatus == record.Status && decision.OldStatus != decision.NewStatus {
+			return decision.ChangedAt
+		}
 	}
-
-	// Note: In production, use CouchDB rich queries via GetQueryResultsForQueryString
-	// For now, return empty list (full implementation requires RichQuery support)
-	return []*TemperatureLogAsset{}, nil
+	return record.UpdatedAt
 }
 
-// GetTransportsByBatch retrieves all transports for a batch
-func (s *SupplyChainContract) GetTransportsByBatch(
+// GetExpiringRegulatoryRecords retrieves up to limit APPROVED regulatory
+// records whose expiry date is within withinDays of the transaction
+// timestamp, soonest first. Records already past expiry but not yet swept by
+// CheckAndExpireRegulatoryRecords are included.
+func (s *SupplyChainContract) GetExpiringRegulatoryRecords(
 	ctx contractapi.TransactionContextInterface,
-	batchID string,
-) ([]*TransportAsset, error) {
-	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+	withinDays int,
+	limit int,
+) ([]*RegulatoryAsset, error) {
+	if withinDays < 0 {
+		return nil, fmt.Errorf("withinDays must be non-negative, got %d", withinDays)
+	}
+	if err := s.ValidatePageSize(limit); err != nil {
 		return nil, err
 	}
-
-	// Note: In production, use CouchDB rich queries via GetQueryResultsForQueryString
-	// For now, return empty list (full implementation requires RichQuery support)
-	return []*TransportAsset{}, nil
-}
-
-// ============================================================================
-// PROCESSING FUNCTIONS
-// ============================================================================
-
-// RecordProcessing records processing facility output
-func (s *SupplyChainContract) RecordProcessing(
-	ctx contractapi.TransactionContextInterface,
-	processingID string,
-	batchID string,
-	processDate string,
-	facilityName string,
-	slaughterCount int,
-	yieldKg float64,
-	qualityScore float64,
-	notes string,
-) (*ProcessingAsset, error) {
-	// Authorization check
-	if err := s.AuthorizeMSP(ctx, MinFarmOrgMSP); err != nil {
+	now, err := s.txTime(ctx)
+	if err != nil {
 		return nil, err
 	}
+	cutoff := now.AddDate(0, 0, withinDays)
 
-	// Validation
-	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
+	records, err := queryAssets[RegulatoryAsset](ctx, map[string]interface{}{
+		"docType":     "RegulatoryAsset",
+		"status":      "APPROVED",
+		"expiry_date": expiryBefore(cutoff),
+	})
+	if err != nil {
 		return nil, err
 	}
-	if err := s.ValidatePositiveFloat(yieldKg, "yieldKg"); err != nil {
+
+	expiring := []*RegulatoryAsset{}
+	for _, record := range records {
+		if record.ExpiryDate == "" {
+			continue
+		}
+		expiry, err := parseLedgerDeadline(record.ExpiryDate)
+		if err != nil || expiry.After(cutoff) {
+			continue
+		}
+		expiring = append(expiring, record)
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiryDate < expiring[j].ExpiryDate
+	})
+	if len(expiring) > limit {
+		expiring = expiring[:limit]
+	}
+
+	return expiring, nil
+}
+
+// CheckAndExpireRegulatoryRecords is the maintenance sweep that moves APPROVED
+// regulatory records whose expiry date has passed at the transaction
+// timestamp to EXPIRED, at most limit per call (Regulator only), as
+// CheckAndExpireCertifications does for certifications. A single
+// RegulatoryRecordExpired event lists every record expired.
+func (s *SupplyChainContract) CheckAndExpireRegulatoryRecords(
+	ctx contractapi.TransactionContextInterface,
+	limit int,
+) (*RegulatoryExpirySweep, error) {
+	// Authorization check (Regulator only)
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
 		return nil, err
 	}
-	if err := s.ValidatePositiveFloat(qualityScore, "qualityScore"); err != nil {
+
+	if err := s.ValidatePageSize(limit); err != nil {
 		return nil, err
 	}
 
-	// Check batch exists
-	_, err := s.GetBatch(ctx, batchID)
+	now, err := s.txTime(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("batch does not exist: %v", err)
+		return nil, err
 	}
 
-	// Check uniqueness
-	exists, err := s.AssetExists(ctx, "ProcessingAsset", processingID)
+	approved, err := queryAssets[RegulatoryAsset](ctx, map[string]interface{}{
+		"docType":     "RegulatoryAsset",
+		"status":      "APPROVED",
+		"expiry_date": expiryBefore(now),
+	})
 	if err != nil {
 		return nil, err
 	}
-	if exists {
-		return nil, fmt.Errorf("processing record %s already exists", processingID)
+	sort.SliceStable(approved, func(i, j int) bool {
+		return approved[i].RegulatoryID < approved[j].RegulatoryID
+	})
+
+	sweep := &RegulatoryExpirySweep{Records: []*ExpiredRegulatoryRecord{}}
+	for _, record := range approved {
+		if record.ExpiryDate == "" || regulatoryRecordActive(record, now) {
+			continue
+		}
+		if len(sweep.Records) == limit {
+			sweep.HasMore = true
+			break
+		}
+
+		if err := s.recordRegulatoryDecision(ctx, record, RegulatoryDecision{
+			Action:    "EXPIRED",
+			NewStatus: "EXPIRED",
+			Reason:    "expired on " + record.ExpiryDate,
+		}); err != nil {
+			return nil, err
+		}
+		record.Status = "EXPIRED"
+
+		regBytes, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal regulatory record: %v", err)
+		}
+
+		if err := ctx.GetStub().PutState(record.RegulatoryID, regBytes); err != nil {
+			return nil, fmt.Errorf("failed to update regulatory record: %v", err)
+		}
+
+		sweep.Records = append(sweep.Records, &ExpiredRegulatoryRecord{
+			RegulatoryID: record.RegulatoryID,
+			BatchID:      record.BatchID,
+			RecordType:   record.RecordType,
+			ExpiryDate:   record.ExpiryDate,
+		})
 	}
+	sweep.ExpiredCount = len(sweep.Records)
 
-	processing := ProcessingAsset{
-		DocType:      "ProcessingAsset",
-		ProcessingID: processingID,
-		BatchID:      batchID,
-		ProcessDate:  processDate,
-		FacilityName: facilityName,
-		SlaughterCnt: slaughterCount,
-		YieldKg:      yieldKg,
-		QualityScore: qualityScore,
-		Notes:        notes,
-		CreatedAt:    s.GetTxTimestamp(ctx),
-		UpdatedAt:    s.GetTxTimestamp(ctx),
+	// Emit event, only when something expired
+	if sweep.ExpiredCount > 0 {
+		actorMSP, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get client MSP: %v", err)
+		}
+		eventPayload := map[string]interface{}{
+			"expired_count": sweep.ExpiredCount,
+			"records":       sweep.Records,
+			"has_more":      sweep.HasMore,
+			"actor_msp":     actorMSP,
+		}
+		s.emitEvent(ctx, "RegulatoryRecordExpired", eventPayload, sweep.Records)
 	}
 
-	processingBytes, err := json.Marshal(processing)
+	return sweep, nil
+}
+
+// findActiveRegulatoryRecord returns an APPROVED, unexpired regulatory record
+// of recordType for the batch, or nil when there is none. Expiry is evaluated
+// against the transaction timestamp; records without an expiry never expire.
+func (s *SupplyChainContract) findActiveRegulatoryRecord(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+	recordType string,
+) (*RegulatoryAsset, error) {
+	now, err := s.txTime(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal processing: %v", err)
+		return nil, err
 	}
 
-	if err := ctx.GetStub().PutState(processingID, processingBytes); err != nil {
-		return nil, fmt.Errorf("failed to save processing: %v", err)
+	records, err := s.GetRegulatoryRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Emit event
-	eventPayload := map[string]string{
-		"processing_id": processingID,
-		"batch_id":      batchID,
+	for _, record := range records {
+		if normalizeRegulatoryRecordType(record.RecordType) == normalizeRegulatoryRecordType(recordType) && regulatoryRecordActive(record, now) {
+			return record, nil
+		}
 	}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("ProcessingRecorded", eventBytes)
 
-	return &processing, nil
+	return nil, nil
 }
 
-// GetProcessingRecord retrieves a processing record by ID
-func (s *SupplyChainContract) GetProcessingRecord(
+// regulatoryRecordActive reports whether a regulatory record is APPROVED and
+// unexpired at now. Records without an expiry never expire.
+func regulatoryRecordActive(record *RegulatoryAsset, now time.Time) bool {
+	if record.Status != "APPROVED" {
+		return false
+	}
+	if record.ExpiryDate != "" {
+		expiry, err := parseLedgerDeadline(record.ExpiryDate)
+		if err != nil || now.After(expiry) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRegulatoryApprovalForShipment rejects shipping a batch flagged
+// RequiresRegulatoryApproval until it holds an APPROVED, unexpired regulatory
+// record of any type. A stop-sale is a hold, not an approval, and does not count.
+func (s *SupplyChainContract) checkRegulatoryApprovalForShipment(
 	ctx contractapi.TransactionContextInterface,
-	processingID string,
-) (*ProcessingAsset, error) {
-	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
-		return nil, err
+	batch *BatchAsset,
+) error {
+	if !batch.RequiresRegulatoryApproval {
+		return nil
 	}
 
-	processingBytes, err := ctx.GetStub().GetState(processingID)
+	approved, err := s.hasRegulatoryApproval(ctx, batch.BatchID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read processing: %v", err)
+		return err
 	}
-	if processingBytes == nil {
-		return nil, fmt.Errorf("processing record %s not found", processingID)
+	if !approved {
+		return fmt.Errorf("batch %s requires an approved regulatory record before it can ship", batch.BatchID)
 	}
+	return nil
+}
 
-	var processing ProcessingAsset
-	processingErr := json.Unmarshal(processingBytes, &processing)
-	if processingErr != nil {
-		return nil, fmt.Errorf("failed to unmarshal processing: %v", processingErr)
+// hasRegulatoryApproval reports whether the batch holds an APPROVED,
+// unexpired regulatory record other than a stop-sale
+func (s *SupplyChainContract) hasRegulatoryApproval(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) (bool, error) {
+	now, err := s.txTime(ctx)
+	if err != nil {
+		return false, err
 	}
-
-	return &processing, nil
+	records, err := s.GetRegulatoryRecordsByBatch(ctx, batchID)
+	if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if record.RecordType != StopSaleRecordType && regulatoryRecordActive(record, now) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-// ============================================================================
-// CERTIFICATION FUNCTIONS
-// ============================================================================
-
-// IssueCertification issues a certification (Regulator only)
-func (s *SupplyChainContract) IssueCertification(
+// IssueClearanceOverride issues a single-use emergency override letting a
+// batch be processed without its required clearance (Regulator only). The
+// override ID is passed to RecordProcessing and recorded on the processing record.
+func (s *SupplyChainContract) IssueClearanceOverride(
 	ctx contractapi.TransactionContextInterface,
-	certificationID string,
-	processingID string,
-	certType string,
-	issuedDate string,
-	expiryDate string,
-	issuerID string,
-	notes string,
-) (*CertificationAsset, error) {
+	overrideID string,
+	batchID string,
+	reason string,
+) (*ClearanceOverrideAsset, error) {
 	// Authorization check (Regulator only)
 	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
 		return nil, err
 	}
 
 	// Validation
-	if err := s.ValidateNonEmptyString(certificationID, "certificationID"); err != nil {
+	if err := s.ValidateNonEmptyString(overrideID, "overrideID"); err != nil {
 		return nil, err
 	}
-	if err := s.ValidateNonEmptyString(certType, "certType"); err != nil {
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
 		return nil, err
 	}
 
-	// Check processing record exists
-	_, err := s.GetProcessingRecord(ctx, processingID)
-	if err != nil {
-		return nil, fmt.Errorf("processing record does not exist: %v", err)
+	// Check batch exists
+	if _, err := s.GetBatch(ctx, batchID); err != nil {
+		return nil, fmt.Errorf("batch does not exist: %v", err)
 	}
 
 	// Check uniqueness
-	exists, err := s.AssetExists(ctx, "CertificationAsset", certificationID)
+	exists, err := s.AssetExists(ctx, "ClearanceOverrideAsset", overrideID)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("certification %s already exists", certificationID)
+		return nil, fmt.Errorf("clearance override %s already exists", overrideID)
 	}
 
-	certification := CertificationAsset{
-		DocType:         "CertificationAsset",
-		CertificationID: certificationID,
-		ProcessingID:    processingID,
-		CertType:        certType,
-		Status:          "APPROVED",
-		IssuedDate:      issuedDate,
-		ExpiryDate:      expiryDate,
-		IssuerID:        issuerID,
-		Notes:           notes,
-		CreatedAt:       s.GetTxTimestamp(ctx),
-		UpdatedAt:       s.GetTxTimestamp(ctx),
+	clientMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP: %v", err)
 	}
 
-	certBytes, err := json.Marshal(certification)
+	override := ClearanceOverrideAsset{
+		DocType:    "ClearanceOverrideAsset",
+		OverrideID: overrideID,
+		BatchID:    batchID,
+		Reason:     reason,
+		IssuedBy:   clientMSP,
+		CreatedAt:  s.GetTxTimestamp(ctx),
+		UpdatedAt:  s.GetTxTimestamp(ctx),
+	}
+
+	overrideBytes, err := json.Marshal(override)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal certification: %v", err)
+		return nil, fmt.Errorf("failed to marshal clearance override: %v", err)
 	}
 
-	if err := ctx.GetStub().PutState(certificationID, certBytes); err != nil {
-		return nil, fmt.Errorf("failed to save certification: %v", err)
+	if err := ctx.GetStub().PutState(overrideID, overrideBytes); err != nil {
+		return nil, fmt.Errorf("failed to save clearance override: %v", err)
 	}
 
 	// Emit event
-	eventPayload := map[string]string{
-		"certification_id": certificationID,
-		"processing_id":    processingID,
-		"status":           "APPROVED",
-	}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("CertificationUpdated", eventBytes)
+	eventPayload := map[string]interface{}{"override_id": overrideID, "batch_id": batchID, "reason": reason}
+	s.emitEvent(ctx, "ClearanceOverrideIssued", eventPayload, &override)
 
-	return &certification, nil
+	return &override, nil
 }
 
-// UpdateCertificationStatus updates certification status (Regulator only)
-func (s *SupplyChainContract) UpdateCertificationStatus(
+// checkProcessingClearance enforces the product's processing clearance for a
+// batch. It returns the override to consume when one was used in its place.
+func (s *SupplyChainContract) checkProcessingClearance(
 	ctx contractapi.TransactionContextInterface,
-	certificationID string,
-	newStatus string,
-) (*CertificationAsset, error) {
-	// Authorization check (Regulator only)
-	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+	batch *BatchAsset,
+	overrideID string,
+) (*ClearanceOverrideAsset, error) {
+	product, err := s.GetProduct(ctx, batch.ProductID)
+	if err != nil {
 		return nil, err
 	}
+	if product.ProcessingClearance == "" {
+		return nil, nil
+	}
 
-	certification, err := s.GetCertification(ctx, certificationID)
+	clearance, err := s.findActiveRegulatoryRecord(ctx, batch.BatchID, product.ProcessingClearance)
 	if err != nil {
 		return nil, err
 	}
-
-	// Validate transition
-	if err := s.ValidateStatusTransition(certification.Status, newStatus); err != nil {
-		return nil, err
+	if clearance != nil {
+		return nil, nil
 	}
 
-	certification.Status = newStatus
-	certification.UpdatedAt = s.GetTxTimestamp(ctx)
+	if overrideID == "" {
+		return nil, fmt.Errorf("batch %s has no approved, unexpired %s regulatory record", batch.BatchID, product.ProcessingClearance)
+	}
 
-	certBytes, err := json.Marshal(certification)
+	overrideBytes, err := ctx.GetStub().GetState(overrideID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal certification: %v", err)
+		return nil, fmt.Errorf("failed to read clearance override: %v", err)
 	}
-
-	if err := ctx.GetStub().PutState(certificationID, certBytes); err != nil {
-		return nil, fmt.Errorf("failed to update certification: %v", err)
+	if overrideBytes == nil {
+		return nil, fmt.Errorf("clearance override %s not found", overrideID)
 	}
-
-	// Emit event
-	eventPayload := map[string]string{
-		"certification_id": certificationID,
-		"status":           newStatus,
+	var override ClearanceOverrideAsset
+	if err := json.Unmarshal(overrideBytes, &override); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal clearance override: %v", err)
+	}
+	if override.BatchID != batch.BatchID {
+		return nil, fmt.Errorf("clearance override %s was issued for batch %s", overrideID, override.BatchID)
+	}
+	if override.UsedBy != "" {
+		return nil, fmt.Errorf("clearance override %s was already used by processing record %s", overrideID, override.UsedBy)
 	}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("CertificationUpdated", eventBytes)
 
-	return certification, nil
+	return &override, nil
 }
 
-// GetCertification retrieves a certification by ID
-func (s *SupplyChainContract) GetCertification(
+// checkCompletionClearance enforces the product's completion clearance for a
+// batch. Without the clearance, a Regulator's overrideReason lets completion
+// go ahead and is recorded on the batch.
+func (s *SupplyChainContract) checkCompletionClearance(
 	ctx contractapi.TransactionContextInterface,
-	certificationID string,
-) (*CertificationAsset, error) {
-	if err := s.ValidateNonEmptyString(certificationID, "certificationID"); err != nil {
-		return nil, err
+	batch *BatchAsset,
+	overrideReason string,
+) error {
+	product, err := s.GetProduct(ctx, batch.ProductID)
+	if err != nil {
+		return err
+	}
+	if product.CompletionClearance == "" {
+		return nil
 	}
 
-	certBytes, err := ctx.GetStub().GetState(certificationID)
+	clearance, err := s.findActiveRegulatoryRecord(ctx, batch.BatchID, product.CompletionClearance)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read certification: %v", err)
+		return err
 	}
-	if certBytes == nil {
-		return nil, fmt.Errorf("certification %s not found", certificationID)
+	if clearance != nil {
+		return nil
 	}
 
-	var certification CertificationAsset
-	certErr := json.Unmarshal(certBytes, &certification)
-	if certErr != nil {
-		return nil, fmt.Errorf("failed to unmarshal certification: %v", certErr)
+	if strings.TrimSpace(overrideReason) == "" {
+		return fmt.Errorf("batch %s has no approved, unexpired %s regulatory record", batch.BatchID, product.CompletionClearance)
+	}
+	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
+		return fmt.Errorf("completion clearance override: %v", err)
+	}
+	overrideBy, err := s.getCallerEnrollmentID(ctx)
+	if err != nil {
+		return err
 	}
 
-	return &certification, nil
+	batch.CompletionOverride = strings.TrimSpace(overrideReason)
+	batch.CompletionOverrideBy = overrideBy
+	return nil
 }
 
-// GetCertificationsByProcessing retrieves certifications for a processing record
-func (s *SupplyChainContract) GetCertificationsByProcessing(
+// consumeClearanceOverride marks an override as used by a processing record
+func (s *SupplyChainContract) consumeClearanceOverride(
 	ctx contractapi.TransactionContextInterface,
+	override *ClearanceOverrideAsset,
 	processingID string,
-) ([]*CertificationAsset, error) {
-	if err := s.ValidateNonEmptyString(processingID, "processingID"); err != nil {
-		return nil, err
+) error {
+	override.UsedBy = processingID
+	override.UpdatedAt = s.GetTxTimestamp(ctx)
+
+	overrideBytes, err := json.Marshal(override)
+	if err != nil {
+		return fmt.Errorf("failed to marshal clearance override: %v", err)
 	}
 
-	// Note: In production, use CouchDB rich queries via GetQueryResultsForQueryString
-	// For now, return empty list (full implementation requires RichQuery support)
-	return []*CertificationAsset{}, nil
+	if err := ctx.GetStub().PutState(override.OverrideID, overrideBytes); err != nil {
+		return fmt.Errorf("failed to update clearance override: %v", err)
+	}
+	return nil
 }
 
-// ============================================================================
-// REGULATORY FUNCTIONS
-// ============================================================================
-
-// CreateRegulatoryRecord creates a regulatory record (Regulator only)
-func (s *SupplyChainContract) CreateRegulatoryRecord(
+// IssueStopSale freezes a batch pending investigation (Regulator only). It
+// records an APPROVED STOP_SALE regulatory record; while it is active the
+// batch cannot be transported, processed or sold. LiftStopSale releases it.
+// regulatorID is bound to the caller as in CreateRegulatoryRecord.
+func (s *SupplyChainContract) IssueStopSale(
 	ctx contractapi.TransactionContextInterface,
 	regulatoryID string,
 	batchID string,
-	recordType string,
-	issuedDate string,
-	expiryDate string,
+	reason string,
 	regulatorID string,
-	details string,
-	auditFlags string,
 ) (*RegulatoryAsset, error) {
 	// Authorization check (Regulator only)
 	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
@@ -1187,16 +13929,24 @@ func (s *SupplyChainContract) CreateRegulatoryRecord(
 	if err := s.ValidateNonEmptyString(regulatoryID, "regulatoryID"); err != nil {
 		return nil, err
 	}
-	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+	if err := s.ValidateNonEmptyString(reason, "reason"); err != nil {
 		return nil, err
 	}
 
 	// Check batch exists
-	_, err := s.GetBatch(ctx, batchID)
-	if err != nil {
+	if _, err := s.GetBatch(ctx, batchID); err != nil {
 		return nil, fmt.Errorf("batch does not exist: %v", err)
 	}
 
+	// One stop-sale at a time per batch
+	active, err := s.findActiveRegulatoryRecord(ctx, batchID, StopSaleRecordType)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, fmt.Errorf("batch %s is already under stop-sale %s", batchID, active.RegulatoryID)
+	}
+
 	// Check uniqueness
 	exists, err := s.AssetExists(ctx, "RegulatoryAsset", regulatoryID)
 	if err != nil {
@@ -1206,19 +13956,26 @@ func (s *SupplyChainContract) CreateRegulatoryRecord(
 		return nil, fmt.Errorf("regulatory record %s already exists", regulatoryID)
 	}
 
+	regulator, err := s.resolveActingOfficer(ctx, regulatorID, "regulatorID", "regulator")
+	if err != nil {
+		return nil, err
+	}
+
 	regulatory := RegulatoryAsset{
-		DocType:       "RegulatoryAsset",
-		RegulatoryID:  regulatoryID,
-		BatchID:       batchID,
-		RecordType:    recordType,
-		Status:        "PENDING",
-		IssuedDate:    issuedDate,
-		ExpiryDate:    expiryDate,
-		RegulatorID:   regulatorID,
-		Details:       details,
-		AuditFlags:    auditFlags,
-		CreatedAt:     s.GetTxTimestamp(ctx),
-		UpdatedAt:     s.GetTxTimestamp(ctx),
+		DocType:            "RegulatoryAsset",
+		RegulatoryID:       regulatoryID,
+		BatchID:            batchID,
+		RecordType:         StopSaleRecordType,
+		Status:             "APPROVED",
+		IssuedDate:         s.GetTxTimestamp(ctx),
+		RegulatorID:        regulator.OfficerID,
+		RegulatorMSP:       regulator.OfficerMSP,
+		RegulatorIdentity:  regulator.OfficerIdentity,
+		RecordedOnBehalfBy: regulator.OnBehalfBy,
+		AssignedTo:         regulator.OfficerID,
+		Details:            strings.TrimSpace(reason),
+		CreatedAt:          s.GetTxTimestamp(ctx),
+		UpdatedAt:          s.GetTxTimestamp(ctx),
 	}
 
 	regBytes, err := json.Marshal(regulatory)
@@ -1230,45 +13987,60 @@ func (s *SupplyChainContract) CreateRegulatoryRecord(
 		return nil, fmt.Errorf("failed to save regulatory record: %v", err)
 	}
 
+	timelineEvent, err := s.recordStopSaleLifecycleEvent(ctx, &regulatory, "STOP_SALE", "Stop-sale issued: "+regulatory.Details)
+	if err != nil {
+		return nil, err
+	}
+
 	// Emit event
-	eventPayload := map[string]string{
-		"regulatory_id": regulatoryID,
-		"batch_id":      batchID,
-		"status":        "PENDING",
+	eventPayload := map[string]interface{}{
+		"regulatory_id":      regulatoryID,
+		"batch_id":           batchID,
+		"reason":             regulatory.Details,
+		"regulator_id":       regulatory.RegulatorID,
+		"lifecycle_event_id": timelineEvent.EventID,
+	}
+	if regulatory.RecordedOnBehalfBy != "" {
+		eventPayload["on_behalf_by"] = regulatory.RecordedOnBehalfBy
 	}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("RegulatoryRecordUpdated", eventBytes)
+	s.emitEvent(ctx, "StopSaleIssued", eventPayload, &regulatory)
 
 	return &regulatory, nil
 }
 
-// UpdateRegulatoryStatus updates regulatory record status (Regulator only)
-func (s *SupplyChainContract) UpdateRegulatoryStatus(
+// LiftStopSale releases an active stop-sale (Regulator only). The record
+// moves to WITHDRAWN with the resolution kept on it.
+func (s *SupplyChainContract) LiftStopSale(
 	ctx contractapi.TransactionContextInterface,
 	regulatoryID string,
-	newStatus string,
-	rejectionReason string,
+	resolution string,
 ) (*RegulatoryAsset, error) {
 	// Authorization check (Regulator only)
-	if err := s.AuthorizeMSP(ctx, RegulatorOrgMSP); err != nil {
-		return nil, err
-	}
-
-	regulatory, err := s.GetRegulatoryRecord(ctx, regulatoryID)
+	regulatory, err := s.authorizeAndLoadRegulatoryRecord(ctx, RegulatorOrgMSP, regulatoryID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate transition
-	if err := s.ValidateStatusTransition(regulatory.Status, newStatus); err != nil {
+	// Validation
+	if err := s.ValidateNonEmptyString(resolution, "resolution"); err != nil {
 		return nil, err
 	}
+	if regulatory.RecordType != StopSaleRecordType {
+		return nil, fmt.Errorf("regulatory record %s is %s, not a stop-sale", regulatoryID, regulatory.RecordType)
+	}
+	if regulatory.Status != "APPROVED" {
+		return nil, fmt.Errorf("stop-sale %s is %s, not active", regulatoryID, regulatory.Status)
+	}
 
-	regulatory.Status = newStatus
-	if newStatus == "REJECTED" {
-		regulatory.RejectionReason = rejectionReason
+	if err := s.recordRegulatoryDecision(ctx, regulatory, RegulatoryDecision{
+		Action:    "STOP_SALE_LIFTED",
+		NewStatus: "WITHDRAWN",
+		Reason:    strings.TrimSpace(resolution),
+	}); err != nil {
+		return nil, err
 	}
-	regulatory.UpdatedAt = s.GetTxTimestamp(ctx)
+	regulatory.Status = "WITHDRAWN"
+	regulatory.Resolution = strings.TrimSpace(resolution)
 
 	regBytes, err := json.Marshal(regulatory)
 	if err != nil {
@@ -1279,55 +14051,164 @@ func (s *SupplyChainContract) UpdateRegulatoryStatus(
 		return nil, fmt.Errorf("failed to update regulatory record: %v", err)
 	}
 
+	timelineEvent, err := s.recordStopSaleLifecycleEvent(ctx, regulatory, "STOP_SALE_LIFTED", "Stop-sale lifted: "+regulatory.Resolution)
+	if err != nil {
+		return nil, err
+	}
+
 	// Emit event
-	eventPayload := map[string]string{
-		"regulatory_id": regulatoryID,
-		"status":        newStatus,
+	eventPayload := map[string]interface{}{
+		"regulatory_id":      regulatoryID,
+		"batch_id":           regulatory.BatchID,
+		"resolution":         regulatory.Resolution,
+		"lifecycle_event_id": timelineEvent.EventID,
 	}
-	eventBytes, _ := json.Marshal(eventPayload)
-	ctx.GetStub().SetEvent("RegulatoryRecordUpdated", eventBytes)
+	s.emitEvent(ctx, "StopSaleLifted", eventPayload, regulatory)
 
 	return regulatory, nil
 }
 
-// GetRegulatoryRecord retrieves a regulatory record by ID
-func (s *SupplyChainContract) GetRegulatoryRecord(
+// IsBatchSellable reports whether a batch may be sold: it must not be under
+// an active stop-sale or on hold, and a batch held for regulatory approval
+// must hold an APPROVED, unexpired regulatory record
+func (s *SupplyChainContract) IsBatchSellable(
 	ctx contractapi.TransactionContextInterface,
-	regulatoryID string,
-) (*RegulatoryAsset, error) {
-	if err := s.ValidateNonEmptyString(regulatoryID, "regulatoryID"); err != nil {
+	batchID string,
+) (*BatchSellability, error) {
+	batch, err := s.GetBatch(ctx, batchID)
+	if err != nil {
 		return nil, err
 	}
 
-	regBytes, err := ctx.GetStub().GetState(regulatoryID)
+	sellability := &BatchSellability{BatchID: batchID, Sellable: true}
+
+	stopSale, err := s.findActiveRegulatoryRecord(ctx, batchID, StopSaleRecordType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read regulatory record: %v", err)
+		return nil, err
 	}
-	if regBytes == nil {
-		return nil, fmt.Errorf("regulatory record %s not found", regulatoryID)
+	switch {
+	case stopSale != nil:
+		sellability.Sellable = false
+		sellability.StopSaleID = stopSale.RegulatoryID
+		sellability.Reason = fmt.Sprintf("under stop-sale %s: %s", stopSale.RegulatoryID, stopSale.Details)
+	case batch.Status == "ON_HOLD":
+		sellability.Sellable = false
+		sellability.Reason = "on hold: " + batch.HoldReason
+	case batch.RequiresRegulatoryApproval:
+		approved, err := s.hasRegulatoryApproval(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+		if !approved {
+			sellability.Sellable = false
+			sellability.Reason = "awaiting an approved regulatory record"
+		}
 	}
 
-	var regulatory RegulatoryAsset
-	regErr := json.Unmarshal(regBytes, &regulatory)
-	if regErr != nil {
-		return nil, fmt.Errorf("failed to unmarshal regulatory record: %v", regErr)
+	return sellability, nil
+}
+
+// checkNoStopSale rejects an operation on a batch under an active stop-sale
+func (s *SupplyChainContract) checkNoStopSale(
+	ctx contractapi.TransactionContextInterface,
+	batchID string,
+) error {
+	stopSale, err := s.findActiveRegulatoryRecord(ctx, batchID, StopSaleRecordType)
+	if err != nil {
+		return err
 	}
+	if stopSale != nil {
+		return fmt.Errorf("batch %s is under stop-sale %s: %s", batchID, stopSale.RegulatoryID, stopSale.Details)
+	}
+	return nil
+}
 
-	return &regulatory, nil
+// recordStopSaleLifecycleEvent adds a stop-sale's issue or lift to the batch
+// timeline as a lifecycle event of eventType
+func (s *SupplyChainContract) recordStopSaleLifecycleEvent(
+	ctx contractapi.TransactionContextInterface,
+	regulatory *RegulatoryAsset,
+	eventType string,
+	description string,
+) (*LifecycleEventAsset, error) {
+	eventID := regulatory.RegulatoryID + "~" + eventType
+	exists, err := s.AssetExists(ctx, "LifecycleEventAsset", eventID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("event %s already exists", eventID)
+	}
+
+	metadata, err := json.Marshal(map[string]string{"regulatory_id": regulatory.RegulatoryID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stop-sale metadata: %v", err)
+	}
+
+	event := LifecycleEventAsset{
+		DocType:     "LifecycleEventAsset",
+		EventID:     eventID,
+		BatchID:     regulatory.BatchID,
+		EventType:   eventType,
+		Description: description,
+		RecordedBy:  regulatory.RegulatorID,
+		EventDate:   regulatory.UpdatedAt,
+		Metadata:    string(metadata),
+		CreatedAt:   s.GetTxTimestamp(ctx),
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(eventID, eventBytes); err != nil {
+		return nil, fmt.Errorf("failed to save event: %v", err)
+	}
+
+	return &event, nil
 }
 
-// GetRegulatoryRecordsByBatch retrieves regulatory records for a batch
-func (s *SupplyChainContract) GetRegulatoryRecordsByBatch(
+// ============================================================================
+// ADMIN FUNCTIONS
+// ============================================================================
+
+// CountAssetsByType returns the number of assets per docType across the whole
+// world state (Admin only). This is a maintenance call for capacity planning:
+// it scans every key on the channel, so it must not be used on a hot path.
+func (s *SupplyChainContract) CountAssetsByType(
 	ctx contractapi.TransactionContextInterface,
-	batchID string,
-) ([]*RegulatoryAsset, error) {
-	if err := s.ValidateNonEmptyString(batchID, "batchID"); err != nil {
+) (map[string]int, error) {
+	// Authorization check (Admin only)
+	if err := s.AuthorizeMSP(ctx, AdminOrgMSP); err != nil {
 		return nil, err
 	}
 
-	// Note: In production, use CouchDB rich queries via GetQueryResultsForQueryString
-	// For now, return empty list (full implementation requires RichQuery support)
-	return []*RegulatoryAsset{}, nil
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan ledger: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	counts := map[string]int{}
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate ledger: %v", err)
+		}
+
+		// Index entries (e.g. batch_number~) hold raw IDs rather than JSON
+		// documents, so anything without a docType is not counted.
+		var doc struct {
+			DocType string `json:"docType"`
+		}
+		if err := json.Unmarshal(kv.Value, &doc); err != nil || doc.DocType == "" {
+			continue
+		}
+		counts[doc.DocType]++
+	}
+
+	return counts, nil
 }
 
 // ============================================================================
@@ -1,7 +1,21 @@
 package main
 
 import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // TestChaincodeCompiles validates that the chaincode package compiles without errors
@@ -23,3 +37,5414 @@ func TestChaincodeCompiles(t *testing.T) {
 // ./network.sh up createChannel -c mychannel -ca
 // ./network.sh deployCC -ccn supplychain -ccp ../fabric-chaincode/chaincode -ccl go
 // peer chaincode invoke -C mychannel -n supplychain -c '{"Args":["CreateProduct","prod-001","Poultry","Chicken"]}'
+
+// fakeClientIdentity is a client identity with a fixed MSP ID
+type fakeClientIdentity struct {
+	mspID string
+}
+
+func (f *fakeClientIdentity) GetID() (string, error)    { return "x509::CN=test", nil }
+func (f *fakeClientIdentity) GetMSPID() (string, error) { return f.mspID, nil }
+func (f *fakeClientIdentity) GetAttributeValue(string) (string, bool, error) {
+	return "", false, nil
+}
+func (f *fakeClientIdentity) AssertAttributeValue(string, string) error { return nil }
+func (f *fakeClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return nil, nil
+}
+
+// certificationSecrets are the values of sensitiveCertification's fields
+// that consumer-level callers must never see
+var certificationSecrets = []string{
+	"internal-regulator-note",
+	"issuer-contact-42",
+	"x509::CN=issuer,O=Regulator",
+	"admin-delegate-7",
+	"revocation-details",
+	"x509::CN=revoker",
+	"suspension-details",
+	"x509::CN=suspender",
+	"reinstatement-details",
+	"x509::CN=history-actor",
+}
+
+func sensitiveCertification() *CertificationAsset {
+	return &CertificationAsset{
+		DocType:          "CertificationAsset",
+		CertificationID:  "VN-HAL-2025-000123",
+		ProcessingID:     "proc-1",
+		CertType:         "HALAL",
+		Status:           "APPROVED",
+		IssuedDate:       "2025-01-10",
+		ExpiryDate:       "2026-01-10",
+		IssuerID:         "issuer-contact-42",
+		IssuerMSP:        RegulatorOrgMSP,
+		IssuerIdentity:   "x509::CN=issuer,O=Regulator",
+		IssuedOnBehalfBy: "admin-delegate-7",
+		Notes:            "internal-regulator-note",
+		DocumentSHA256:   strings.Repeat("ab", 32),
+		DocumentURI:      "https://example.org/cert.pdf",
+		RevokedReason:    "revocation-details",
+		RevokedBy:        "x509::CN=revoker",
+		SuspendedReason:  "suspension-details",
+		SuspendedBy:      "x509::CN=suspender",
+		ReinstatedNotes:  "reinstatement-details",
+		StatusHistory: []CertificationStatusChange{
+			{NewStatus: "APPROVED", Actor: "x509::CN=history-actor", Reason: "issued"},
+		},
+	}
+}
+
+func callerContext(mspID string) *contractapi.TransactionContext {
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetClientIdentity(&fakeClientIdentity{mspID: mspID})
+	return ctx
+}
+
+func marshalCertifications(t *testing.T, certifications []*CertificationAsset) string {
+	t.Helper()
+	certBytes, err := json.Marshal(certifications)
+	if err != nil {
+		t.Fatalf("failed to marshal certifications: %v", err)
+	}
+	return string(certBytes)
+}
+
+// TestRedactCertificationKeepsPublicFields checks the consumer view keeps the
+// certificate number, type, status, dates and document hash
+func TestRedactCertificationKeepsPublicFields(t *testing.T) {
+	full := sensitiveCertification()
+	redacted := redactCertification(full)
+
+	if redacted.CertificationID != full.CertificationID ||
+		redacted.CertType != full.CertType ||
+		redacted.Status != full.Status ||
+		redacted.IssuedDate != full.IssuedDate ||
+		redacted.ExpiryDate != full.ExpiryDate ||
+		redacted.DocumentSHA256 != full.DocumentSHA256 {
+		t.Fatalf("redacted certification lost a public field: %+v", redacted)
+	}
+
+	output := marshalCertifications(t, []*CertificationAsset{redacted})
+	for _, secret := range certificationSecrets {
+		if strings.Contains(output, secret) {
+			t.Errorf("redacted certification contains %q: %s", secret, output)
+		}
+	}
+	if full.Notes != "internal-regulator-note" {
+		t.Errorf("redaction modified the original certification")
+	}
+}
+
+// TestCertificationsForCallerRedactsConsumers checks no sensitive field
+// reaches callers outside the Regulator and Admin MSPs
+func TestCertificationsForCallerRedactsConsumers(t *testing.T) {
+	s := &SupplyChainContract{}
+	for _, mspID := range []string{MinFarmOrgMSP, ProcessorOrgMSP, LabOrgMSP, "CertifierOrgMSP", "ConsumerMSP"} {
+		t.Run(mspID, func(t *testing.T) {
+			certifications, err := s.certificationsForCaller(callerContext(mspID), []*CertificationAsset{sensitiveCertification()})
+			if err != nil {
+				t.Fatalf("certificationsForCaller failed: %v", err)
+			}
+			if len(certifications) != 1 {
+				t.Fatalf("expected 1 certification, got %d", len(certifications))
+			}
+
+			output := marshalCertifications(t, certifications)
+			for _, secret := range certificationSecrets {
+				if strings.Contains(output, secret) {
+					t.Errorf("%s caller sees %q: %s", mspID, secret, output)
+				}
+			}
+		})
+	}
+}
+
+// TestCertificationsForCallerShowsRegulatorsEverything checks the Regulator
+// and Admin MSPs get certifications unredacted
+func TestCertificationsForCallerShowsRegulatorsEverything(t *testing.T) {
+	s := &SupplyChainContract{}
+	for _, mspID := range []string{RegulatorOrgMSP, AdminOrgMSP} {
+		t.Run(mspID, func(t *testing.T) {
+			certifications, err := s.certificationsForCaller(callerContext(mspID), []*CertificationAsset{sensitiveCertification()})
+			if err != nil {
+				t.Fatalf("certificationsForCaller failed: %v", err)
+			}
+
+			output := marshalCertifications(t, certifications)
+			for _, secret := range certificationSecrets {
+				if !strings.Contains(output, secret) {
+					t.Errorf("%s caller does not see %q: %s", mspID, secret, output)
+				}
+			}
+		})
+	}
+}
+
+// memStub is an in-memory ledger covering the stub calls made by functions
+// that read, write and delete assets by key or scan a key range, plus rich
+// queries, paginated or not, whose selectors match fields, top-level or
+// dotted, or alternatives of them under $or, by equality or the operators
+// memCondition supports, and private data collections fed from a transient
+// map. Calls it does not implement panic on the embedded nil interface.
+type memStub struct {
+	shim.ChaincodeStubInterface
+	state       map[string][]byte
+	privateData map[string]map[string][]byte
+	transient   map[string][]byte
+	eventName   string
+	event       map[string]interface{}
+}
+
+func newMemStub() *memStub {
+	return &memStub{state: map[string][]byte{}, privateData: map[string]map[string][]byte{}}
+}
+
+func (m *memStub) GetState(key string) ([]byte, error) { return m.state[key], nil }
+func (m *memStub) PutState(key string, value []byte) error {
+	m.state[key] = value
+	return nil
+}
+func (m *memStub) DelState(key string) error {
+	delete(m.state, key)
+	return nil
+}
+func (m *memStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return m.privateData[collection][key], nil
+}
+func (m *memStub) PutPrivateData(collection, key string, value []byte) error {
+	if m.privateData[collection] == nil {
+		m.privateData[collection] = map[string][]byte{}
+	}
+	m.privateData[collection][key] = value
+	return nil
+}
+func (m *memStub) GetTransient() (map[string][]byte, error) { return m.transient, nil }
+func (m *memStub) GetTxID() string                          { return "tx-1" }
+func (m *memStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)), nil
+}
+func (m *memStub) SetEvent(name string, payload []byte) error {
+	m.eventName = name
+	m.event = nil
+	return json.Unmarshal(payload, &m.event)
+}
+
+// GetQueryResult returns the stored documents whose fields match every
+// condition in the query's selector, in key order. A dotted field name
+// selects a nested field and $or combines selectors, as in CouchDB.
+func (m *memStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(m.state))
+	for key := range m.state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	results := &memIterator{}
+	for _, key := range keys {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(m.state[key], &doc); err != nil {
+			continue
+		}
+		matched, err := memSelector(doc, parsed.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			results.kvs = append(results.kvs, &queryresult.KV{Key: key, Value: m.state[key]})
+		}
+	}
+	return results, nil
+}
+
+// GetStateByRange returns the stored keys in [startKey, endKey), in key
+// order; an empty bound is open
+func (m *memStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	keys := make([]string, 0, len(m.state))
+	for key := range m.state {
+		if (startKey == "" || key >= startKey) && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	results := &memIterator{}
+	for _, key := range keys {
+		results.kvs = append(results.kvs, &queryresult.KV{Key: key, Value: m.state[key]})
+	}
+	return results, nil
+}
+
+// GetQueryResultWithPagination runs GetQueryResult, orders the matches by
+// the query's ascending sort fields and serves pageSize of them; the bookmark
+// is the number of matches already served
+func (m *memStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	var parsed struct {
+		Sort []map[string]string `json:"sort"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, nil, err
+	}
+	iterator, err := m.GetQueryResult(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	kvs := iterator.(*memIterator).kvs
+	for i := len(parsed.Sort) - 1; i >= 0; i-- {
+		for field := range parsed.Sort[i] {
+			sort.SliceStable(kvs, func(a, b int) bool {
+				var docA, docB map[string]interface{}
+				_ = json.Unmarshal(kvs[a].Value, &docA)
+				_ = json.Unmarshal(kvs[b].Value, &docB)
+				return fmt.Sprint(docA[field]) < fmt.Sprint(docB[field])
+			})
+		}
+	}
+
+	offset := 0
+	if bookmark != "" {
+		if _, err := fmt.Sscan(bookmark, &offset); err != nil {
+			return nil, nil, err
+		}
+	}
+	end := offset + int(pageSize)
+	if end > len(kvs) {
+		end = len(kvs)
+	}
+	if offset > end {
+		offset = end
+	}
+	return &memIterator{kvs: kvs[offset:end]}, &peer.QueryResponseMetadata{
+		FetchedRecordsCount: int32(end - offset),
+		Bookmark:            fmt.Sprint(end),
+	}, nil
+}
+
+// memSelector reports whether doc matches every field condition of selector.
+// A top-level $or matches when any of its selectors does.
+func memSelector(doc map[string]interface{}, selector map[string]interface{}) (bool, error) {
+	for field, want := range selector {
+		if field == "$or" {
+			alternatives, _ := want.([]interface{})
+			matched := false
+			for _, alternative := range alternatives {
+				alternativeSelector, _ := alternative.(map[string]interface{})
+				ok, err := memSelector(doc, alternativeSelector)
+				if err != nil {
+					return false, err
+				}
+				matched = matched || ok
+			}
+			if !matched {
+				return false, nil
+			}
+			continue
+		}
+		ok, err := memCondition(memField(doc, field), want)
+		if err != nil {
+			return false, fmt.Errorf("memStub selector on %s: %v", field, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// memField returns the value at a dotted field path of doc, or nil
+func memField(doc map[string]interface{}, field string) interface{} {
+	var value interface{} = doc
+	for _, name := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// memCondition reports whether a document value matches a selector
+// condition: a plain value by equality, or an object of $eq, $ne, $in, $lt,
+// $lte, $gt, $gte, $regex and $elemMatch operators, all of which must hold.
+// Ordered operators compare strings with strings and numbers with numbers.
+func memCondition(value, condition interface{}) (bool, error) {
+	operators, isOperator := condition.(map[string]interface{})
+	if !isOperator {
+		return value == condition, nil
+	}
+	for operator, operand := range operators {
+		var ok bool
+		switch operator {
+		case "$eq":
+			ok = value == operand
+		case "$ne":
+			ok = value != operand
+		case "$in":
+			for _, candidate := range operand.([]interface{}) {
+				if value == candidate {
+					ok = true
+				}
+			}
+		case "$lt", "$lte", "$gt", "$gte":
+			var order int
+			switch want := operand.(type) {
+			case string:
+				got, isString := value.(string)
+				if !isString {
+					return false, nil
+				}
+				order = strings.Compare(got, want)
+			case float64:
+				got, isNumber := value.(float64)
+				if !isNumber {
+					return false, nil
+				}
+				switch {
+				case got < want:
+					order = -1
+				case got > want:
+					order = 1
+				}
+			default:
+				return false, fmt.Errorf("unsupported %s operand %v", operator, operand)
+			}
+			ok = map[string]bool{"$lt": order < 0, "$lte": order <= 0, "$gt": order > 0, "$gte": order >= 0}[operator]
+		case "$regex":
+			pattern, err := regexp.Compile(fmt.Sprint(operand))
+			if err != nil {
+				return false, err
+			}
+			got, isString := value.(string)
+			ok = isString && pattern.MatchString(got)
+		case "$elemMatch":
+			elements, _ := value.([]interface{})
+			for _, element := range elements {
+				if matched, err := memCondition(element, operand); err != nil {
+					return false, err
+				} else if matched {
+					ok = true
+				}
+			}
+		default:
+			return false, fmt.Errorf("unsupported operator %s", operator)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// memIterator iterates over memStub query results
+type memIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (it *memIterator) HasNext() bool { return len(it.kvs) > 0 }
+func (it *memIterator) Close() error  { return nil }
+func (it *memIterator) Next() (*queryresult.KV, error) {
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+func ledgerContext(mspID string, stub *memStub) *contractapi.TransactionContext {
+	ctx := callerContext(mspID)
+	ctx.SetStub(stub)
+	return ctx
+}
+
+func putRegulatoryRecord(t *testing.T, stub *memStub, status string) {
+	t.Helper()
+	regBytes, err := json.Marshal(RegulatoryAsset{
+		DocType:      "RegulatoryAsset",
+		RegulatoryID: "reg-1",
+		BatchID:      "batch-1",
+		RecordType:   "EXPORT",
+		Status:       status,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal regulatory record: %v", err)
+	}
+	stub.state["reg-1"] = regBytes
+}
+
+// putAsset stores asset under key in the stub's state
+func putAsset(t *testing.T, stub *memStub, key string, asset interface{}) {
+	t.Helper()
+	assetBytes, err := json.Marshal(asset)
+	if err != nil {
+		t.Fatalf("failed to marshal %s: %v", key, err)
+	}
+	stub.state[key] = assetBytes
+}
+
+// TestCountAssetsByType checks assets are counted per docType, index entries
+// are skipped and only the Admin may scan the ledger
+func TestCountAssetsByType(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1"})
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1"})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", ProductID: "prod-1"})
+	stub.state["batch_number~B-1"] = []byte("batch-1")
+
+	if _, err := s.CountAssetsByType(ledgerContext(RegulatorOrgMSP, stub)); err == nil {
+		t.Errorf("a regulator scanned the ledger")
+	}
+	counts, err := s.CountAssetsByType(ledgerContext(AdminOrgMSP, stub))
+	if err != nil {
+		t.Fatalf("CountAssetsByType failed: %v", err)
+	}
+	if len(counts) != 2 || counts["BatchAsset"] != 2 || counts["ProductAsset"] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+}
+
+// TestGradeForQualityScore checks scores map onto the highest band they reach
+// and that bands must be set in strictly descending order
+func TestGradeForQualityScore(t *testing.T) {
+	for score, want := range map[float64]string{100: "A", 85: "A", 84.9: "B", 70: "B", 50: "C", 49.9: QualityGradeReject} {
+		if got := gradeForQualityScore(defaultQualityGradeBands, score); got != want {
+			t.Errorf("score %v: expected grade %s, got %s", score, want, got)
+		}
+	}
+
+	s := &SupplyChainContract{}
+	regulator := ledgerContext(RegulatorOrgMSP, newMemStub())
+	for _, bands := range []string{`[]`, `[{"grade": "A", "min_score": 60}, {"grade": "B", "min_score": 60}]`, `[{"grade": "REJECT", "min_score": 10}]`, `[{"grade": "A", "min_score": 101}]`} {
+		if _, err := s.SetQualityGradeBands(regulator, bands); err == nil {
+			t.Errorf("bands %s were accepted", bands)
+		}
+	}
+}
+
+// processingStub returns a ledger with the COMPLETED batch batch-1 of 100
+// units and the licensed facility fac-1
+func processingStub(t *testing.T) *memStub {
+	t.Helper()
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true})
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", FarmerID: "farm-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "fac-1", FacilityAsset{DocType: "FacilityAsset", FacilityID: "fac-1", Name: "Plant 1", LicenseNumber: "LIC-1", LicenseExpiry: "2026-12-31", IsActive: true})
+	return stub
+}
+
+// recordSlaughter records a SLAUGHTER processing record on processingStub's batch
+func recordSlaughter(s *SupplyChainContract, ctx contractapi.TransactionContextInterface, processingID string, slaughterCount int, yieldKg float64, clearanceOverrideID string) (*ProcessingAsset, error) {
+	return s.RecordProcessing(ctx, processingID, "batch-1", "SLAUGHTER", "2025-03-01",
+		"2025-03-01T08:00:00Z", "2025-03-01T10:00:00Z", "fac-1", slaughterCount, yieldKg, 90, nil, clearanceOverrideID, "")
+}
+
+// TestRecordProcessingGradesQualityScore checks scores outside 0-100 are
+// refused and each record is graded against the bands in force when written
+func TestRecordProcessingGradesQualityScore(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.RecordProcessing(farm, "proc-0", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 101, nil, "", ""); err == nil {
+		t.Errorf("a quality score above 100 was accepted")
+	}
+	processing, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", "")
+	if err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if processing.QualityGrade != "B" {
+		t.Errorf("expected grade B from the default bands, got %s", processing.QualityGrade)
+	}
+
+	if _, err := s.SetQualityGradeBands(farm, `[{"grade": "PREMIUM", "min_score": 75}]`); err == nil {
+		t.Errorf("a farm changed the grade bands")
+	}
+	if _, err := s.SetQualityGradeBands(ledgerContext(RegulatorOrgMSP, stub), `[{"grade": "PREMIUM", "min_score": 75}]`); err != nil {
+		t.Fatalf("SetQualityGradeBands failed: %v", err)
+	}
+	processing, err = s.RecordProcessing(farm, "proc-2", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", "")
+	if err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if processing.QualityGrade != "PREMIUM" {
+		t.Errorf("expected grade PREMIUM from the stored bands, got %s", processing.QualityGrade)
+	}
+	stored, err := s.GetProcessingRecord(farm, "proc-1")
+	if err != nil {
+		t.Fatalf("GetProcessingRecord failed: %v", err)
+	}
+	if stored.QualityGrade != "B" {
+		t.Errorf("changing the bands regraded an existing record: %s", stored.QualityGrade)
+	}
+}
+
+// TestGetBatchByBatchNumber checks the lookup resolves through the batch
+// number index
+func TestGetBatchByBatchNumber(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", BatchNumber: "B-1", Quantity: 100, Status: "COMPLETED"})
+	stub.state["batch_number~B-1"] = []byte("batch-2")
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	batch, err := s.GetBatchByBatchNumber(ctx, "B-1")
+	if err != nil {
+		t.Fatalf("GetBatchByBatchNumber failed: %v", err)
+	}
+	if batch.BatchID != "batch-2" {
+		t.Errorf("expected batch-2, got %s", batch.BatchID)
+	}
+	if _, err := s.GetBatchByBatchNumber(ctx, "B-404"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected an unknown batch number to be not found, got %v", err)
+	}
+}
+
+// TestGetBatchProcessingPipeline checks records are grouped by stage and that
+// skipped and out-of-order stages are reported as warnings
+func TestGetBatchProcessingPipeline(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.RecordProcessing(farm, "proc-0", "batch-1", "smoking", "2025-03-01", "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", ""); err == nil || !strings.Contains(err.Error(), "invalid stage") {
+		t.Errorf("expected an unknown stage to be refused, got %v", err)
+	}
+	for _, record := range []struct{ id, stage, date string }{
+		{"proc-1", "slaughter", "2025-03-02"},
+		{"proc-2", "SLAUGHTER", "2025-03-01"},
+		{"proc-3", "PACKAGING", "2025-03-01"},
+	} {
+		if _, err := s.RecordProcessing(farm, record.id, "batch-1", record.stage, record.date, "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", ""); err != nil {
+			t.Fatalf("RecordProcessing %s failed: %v", record.id, err)
+		}
+	}
+
+	pipeline, err := s.GetBatchProcessingPipeline(farm, "batch-1")
+	if err != nil {
+		t.Fatalf("GetBatchProcessingPipeline failed: %v", err)
+	}
+	var groups []string
+	for _, group := range pipeline.Stages {
+		var ids []string
+		for _, record := range group.Records {
+			ids = append(ids, record.ProcessingID)
+		}
+		groups = append(groups, group.Stage+":"+strings.Join(ids, "+"))
+	}
+	if strings.Join(groups, ",") != "SLAUGHTER:proc-2+proc-1,PACKAGING:proc-3" {
+		t.Errorf("unexpected stages %v", groups)
+	}
+	if len(pipeline.Warnings) != 2 || !strings.Contains(pipeline.Warnings[0], "stage CUTTING has no records") || !strings.Contains(pipeline.Warnings[1], "PACKAGING record proc-3 (2025-03-01) is dated before SLAUGHTER record proc-1") {
+		t.Errorf("unexpected warnings %q", pipeline.Warnings)
+	}
+}
+
+// TestGetTransportComplianceScore checks the score is the share of readings
+// within the safe range and a transport without readings reports NoData
+func TestGetTransportComplianceScore(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "IN_TRANSIT"})
+	putAsset(t, stub, "tr-2", TransportAsset{DocType: "TransportAsset", TransportID: "tr-2", BatchID: "batch-1", Status: "IN_TRANSIT"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	for i, temperature := range []float64{4, 11.5, 6, 3} {
+		if _, err := s.AddTemperatureLog(farm, fmt.Sprintf("log-%d", i+1), "tr-1", temperature, fmt.Sprintf("2025-03-01T0%d:00:00Z", i+8), "A1"); err != nil {
+			t.Fatalf("AddTemperatureLog failed: %v", err)
+		}
+	}
+
+	score, err := s.GetTransportComplianceScore(farm, "tr-1")
+	if err != nil {
+		t.Fatalf("GetTransportComplianceScore failed: %v", err)
+	}
+	if score.NoData || score.TotalReadings != 4 || score.CompliantReadings != 3 || score.CompliancePercent != 75 {
+		t.Errorf("unexpected compliance score %+v", score)
+	}
+	if score, err := s.GetTransportComplianceScore(farm, "tr-2"); err != nil || !score.NoData || score.CompliancePercent != 0 {
+		t.Errorf("expected no data for a transport without readings, got %+v, %v", score, err)
+	}
+	if _, err := s.GetTransportComplianceScore(farm, "tr-404"); err == nil {
+		t.Errorf("an unknown transport was scored")
+	}
+}
+
+// TestTraceByLotNumber checks kg lots are reconciled against the processing
+// yield, lot numbers are unique and a lot traces back to its batch history
+func TestTraceByLotNumber(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true})
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", Stage: "PACKAGING", YieldKg: 100})
+	putAsset(t, stub, "event-1", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "event-1", BatchID: "batch-1", EventType: "FEEDING", EventDate: "2025-01-10"})
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "COMPLETED"})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-1", CertType: "HALAL", Status: "APPROVED"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.RecordProcessingOutput(farm, "out-1", "proc-1", "LOT-1", "prod-1", 60, "kg", "2025-03-01", "2025-03-10"); err != nil {
+		t.Fatalf("RecordProcessingOutput failed: %v", err)
+	}
+	if _, err := s.RecordProcessingOutput(farm, "out-2", "proc-1", "LOT-2", "prod-1", 50, "KG", "2025-03-01", "2025-03-10"); err == nil || !strings.Contains(err.Error(), "would exceed processing yield") {
+		t.Errorf("expected output above the yield to be refused, got %v", err)
+	}
+	if _, err := s.RecordProcessingOutput(farm, "out-2", "proc-1", "LOT-1", "prod-1", 20, "PIECES", "2025-03-01", "2025-03-10"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected a reused lot number to be refused, got %v", err)
+	}
+	if _, err := s.RecordProcessingOutput(farm, "out-2", "proc-1", "LOT-2", "prod-1", 200, "PIECES", "2025-03-01", "2025-03-10"); err != nil {
+		t.Fatalf("RecordProcessingOutput in pieces failed: %v", err)
+	}
+
+	trace, err := s.TraceByLotNumber(farm, "LOT-1")
+	if err != nil {
+		t.Fatalf("TraceByLotNumber failed: %v", err)
+	}
+	if trace.Lot.OutputID != "out-1" || trace.Lot.Unit != "KG" || trace.Processing.ProcessingID != "proc-1" || trace.Batch.BatchID != "batch-1" || trace.Product.ProductID != "prod-1" {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+	if len(trace.LifecycleEvents) != 1 || len(trace.Transports) != 1 || len(trace.Certifications) != 1 {
+		t.Errorf("trace is missing batch history: %d events, %d transports, %d certifications", len(trace.LifecycleEvents), len(trace.Transports), len(trace.Certifications))
+	}
+	if _, err := s.TraceByLotNumber(farm, "LOT-404"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected an unknown lot to be not found, got %v", err)
+	}
+}
+
+// issueCertification issues certType for a processing record, valid from
+// 2025-03-01 to 2026-03-01 UTC
+func issueCertification(s *SupplyChainContract, ctx contractapi.TransactionContextInterface, certificationID, processingID, certType string) (*CertificationAsset, error) {
+	return s.IssueCertification(ctx, certificationID, processingID, "", certType, "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "")
+}
+
+// TestCertificationReviewStep checks issuance is direct by default and goes
+// through ApproveCertification once RequireCertificationReview is on
+func TestCertificationReviewStep(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", Stage: "SLAUGHTER", YieldKg: 100, QualityScore: 90})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	direct, err := issueCertification(s, regulator, "cert-1", "proc-1", "HALAL")
+	if err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if direct.Status != "APPROVED" {
+		t.Errorf("expected direct issuance to be APPROVED, got %s", direct.Status)
+	}
+	if _, err := s.ApproveCertification(regulator, "cert-1"); err == nil {
+		t.Errorf("an APPROVED certification was approved again")
+	}
+
+	if _, err := s.SetFeatureFlag(regulator, FeatureRequireCertificationReview, true); err == nil {
+		t.Errorf("a regulator changed a feature flag")
+	}
+	admin := ledgerContext(AdminOrgMSP, stub)
+	if _, err := s.SetFeatureFlag(admin, "NoSuchFlag", true); err == nil {
+		t.Errorf("an unknown feature flag was accepted")
+	}
+	if _, err := s.SetFeatureFlag(admin, FeatureRequireCertificationReview, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+
+	pending, err := issueCertification(s, regulator, "cert-2", "proc-1", "ORGANIC")
+	if err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if pending.Status != "PENDING" {
+		t.Errorf("expected a reviewed issuance to be PENDING, got %s", pending.Status)
+	}
+	approved, err := s.ApproveCertification(regulator, "cert-2")
+	if err != nil {
+		t.Fatalf("ApproveCertification failed: %v", err)
+	}
+	if approved.Status != "APPROVED" {
+		t.Errorf("expected APPROVED after review, got %s", approved.Status)
+	}
+}
+
+// TestProcessingWasteAndFacilitySummary checks waste entries are capped by
+// the estimated input mass and summed per facility over the date range
+func TestProcessingWasteAndFacilitySummary(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", ProcessDate: "2025-03-01", FacilityName: "Plant 1", SlaughterCnt: 10, YieldKg: 12})
+	putAsset(t, stub, "proc-2", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-2", BatchID: "batch-1", ProcessDate: "2025-04-01", FacilityName: "Plant 1", SlaughterCnt: 10, YieldKg: 12})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetThreshold(regulator, ThresholdLiveWeightKgPerUnit, 2); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	if _, err := s.RecordProcessingWaste(farm, "proc-1", 4, 1, "RENDERING"); err != nil {
+		t.Fatalf("RecordProcessingWaste failed: %v", err)
+	}
+	if _, err := s.RecordProcessingWaste(farm, "proc-1", 3, 1, "COMPOST"); err == nil || !strings.Contains(err.Error(), "exceeds estimated input mass of 20.00 kg") {
+		t.Errorf("expected waste above the input mass to be refused, got %v", err)
+	}
+	if _, err := s.RecordProcessingWaste(farm, "proc-1", 2, 1, "COMPOST"); err != nil {
+		t.Fatalf("RecordProcessingWaste failed: %v", err)
+	}
+	if _, err := s.RecordProcessingWaste(farm, "proc-2", 5, 0, "RENDERING"); err != nil {
+		t.Fatalf("RecordProcessingWaste failed: %v", err)
+	}
+
+	if _, err := s.GetFacilityWasteSummary(farm, "Plant 1", "2025-03-01", "2025-03-31"); err == nil {
+		t.Errorf("a farm read the facility waste summary")
+	}
+	summary, err := s.GetFacilityWasteSummary(regulator, "Plant 1", "2025-03-01", "2025-03-31")
+	if err != nil {
+		t.Fatalf("GetFacilityWasteSummary failed: %v", err)
+	}
+	if summary.RecordCount != 1 || summary.TotalWasteKg != 6 || summary.TotalByproductKg != 2 || summary.ByDisposalMethod["RENDERING"] != 5 || summary.ByDisposalMethod["COMPOST"] != 3 {
+		t.Errorf("unexpected waste summary %+v", summary)
+	}
+}
+
+// TestGetTransportsInTransitForParty checks only transports in transit to
+// the party are listed, soonest expected arrival first and unknown last
+func TestGetTransportsInTransitForParty(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", ToPartyID: "plant-1", Status: "IN_TRANSIT", ExpectedArrivalTime: "2025-03-01T12:00:00Z"})
+	putAsset(t, stub, "tr-2", TransportAsset{DocType: "TransportAsset", TransportID: "tr-2", BatchID: "batch-2", ToPartyID: "plant-1", Status: "IN_TRANSIT"})
+	putAsset(t, stub, "tr-3", TransportAsset{DocType: "TransportAsset", TransportID: "tr-3", BatchID: "batch-3", ToPartyID: "plant-1", Status: "IN_TRANSIT", ExpectedArrivalTime: "2025-03-01T09:00:00Z"})
+	putAsset(t, stub, "tr-4", TransportAsset{DocType: "TransportAsset", TransportID: "tr-4", BatchID: "batch-4", ToPartyID: "plant-1", Status: "COMPLETED", ExpectedArrivalTime: "2025-02-01T09:00:00Z"})
+	putAsset(t, stub, "tr-5", TransportAsset{DocType: "TransportAsset", TransportID: "tr-5", BatchID: "batch-5", ToPartyID: "plant-2", Status: "IN_TRANSIT", ExpectedArrivalTime: "2025-03-01T08:00:00Z"})
+
+	arriving, err := s.GetTransportsInTransitForParty(ledgerContext(MinFarmOrgMSP, stub), "plant-1")
+	if err != nil {
+		t.Fatalf("GetTransportsInTransitForParty failed: %v", err)
+	}
+	var ids []string
+	for _, transport := range arriving {
+		ids = append(ids, transport.TransportID)
+	}
+	if strings.Join(ids, ",") != "tr-3,tr-1,tr-2" {
+		t.Errorf("unexpected transports in transit %v", ids)
+	}
+}
+
+// TestRecordProcessingRequiresLicensedFacility checks processing is refused
+// at an inactive facility or one whose license lapsed before the processing
+// date, and that expiring licenses are reported
+func TestRecordProcessingRequiresLicensedFacility(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.RegisterFacility(farm, "fac-2", "Plant 2", "Road 2", "LIC-2", "2025-03-15"); err == nil {
+		t.Errorf("a farm registered a facility")
+	}
+	if _, err := s.RegisterFacility(regulator, "fac-2", "Plant 2", "Road 2", "LIC-2", "2025-03-15"); err != nil {
+		t.Fatalf("RegisterFacility failed: %v", err)
+	}
+	if _, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-16", "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-2", 10, 20, 80, nil, "", ""); err == nil || !strings.Contains(err.Error(), "expired on 2025-03-15") {
+		t.Errorf("expected processing after the license expiry to be refused, got %v", err)
+	}
+	processing, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-15", "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-2", 10, 20, 80, nil, "", "")
+	if err != nil {
+		t.Fatalf("RecordProcessing on the expiry date failed: %v", err)
+	}
+	if processing.FacilityID != "fac-2" || processing.FacilityName != "Plant 2" {
+		t.Errorf("facility not copied onto the record: %s %s", processing.FacilityID, processing.FacilityName)
+	}
+	if _, err := s.UpdateFacility(regulator, "fac-1", "Plant 1", "Road 1", "LIC-1", "2026-12-31", false); err != nil {
+		t.Fatalf("UpdateFacility failed: %v", err)
+	}
+	if _, err := s.RecordProcessing(farm, "proc-2", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T06:00:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", ""); err == nil || !strings.Contains(err.Error(), "is not active") {
+		t.Errorf("expected processing at an inactive facility to be refused, got %v", err)
+	}
+
+	records, err := s.GetProcessingByFacility(farm, "fac-2")
+	if err != nil || len(records) != 1 || records[0].ProcessingID != "proc-1" {
+		t.Errorf("unexpected processing by facility %v, %v", records, err)
+	}
+
+	putAsset(t, stub, "fac-3", FacilityAsset{DocType: "FacilityAsset", FacilityID: "fac-3", Name: "Plant 3", LicenseNumber: "LIC-3", LicenseExpiry: "2025-04-30", IsActive: true})
+	if _, err := s.GetExpiringFacilityLicenses(regulator, "2025-03-01", -1); err == nil {
+		t.Errorf("expected a negative window to be refused")
+	}
+	expiring, err := s.GetExpiringFacilityLicenses(regulator, "2025-03-01", 30)
+	if err != nil {
+		t.Fatalf("GetExpiringFacilityLicenses failed: %v", err)
+	}
+	if len(expiring) != 1 || expiring[0].FacilityID != "fac-2" {
+		t.Errorf("unexpected expiring licenses %v", expiring)
+	}
+}
+
+// TestRecordProcessingFlagsQuantityMismatch checks a slaughter count above
+// the batch's live quantity is flagged in the ProcessingRecorded event
+func TestRecordProcessingFlagsQuantityMismatch(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := recordSlaughter(s, farm, "proc-1", 120, 150, ""); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if stub.eventName != "ProcessingRecorded" || stub.event["quantity_mismatch"] != true || stub.event["available_quantity"] != float64(100) {
+		t.Errorf("expected a flagged ProcessingRecorded event, got %s %v", stub.eventName, stub.event)
+	}
+
+	if _, err := recordSlaughter(s, farm, "proc-2", 50, 60, ""); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if _, flagged := stub.event["quantity_mismatch"]; flagged {
+		t.Errorf("quantity mismatch flagged within the live quantity: %v", stub.event)
+	}
+}
+
+// TestRecordProcessingTimesAgainstDelivery checks the processing window must
+// be ordered and may only start before delivery within the configured slack
+func TestRecordProcessingTimesAgainstDelivery(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "COMPLETED", ArrivalTime: "2025-03-01T06:00:00Z"})
+	putAsset(t, stub, "tr-2", TransportAsset{DocType: "TransportAsset", TransportID: "tr-2", BatchID: "batch-1", Status: "IN_TRANSIT", ArrivalTime: "2025-02-01T06:00:00Z"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T08:00:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", ""); err == nil || !strings.Contains(err.Error(), "must be after") {
+		t.Errorf("expected an empty processing window to be refused, got %v", err)
+	}
+	if _, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", ""); err == nil || !strings.Contains(err.Error(), "RFC3339") {
+		t.Errorf("expected a date-only start time to be refused, got %v", err)
+	}
+	if _, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T05:30:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", ""); err == nil || !strings.Contains(err.Error(), "before batch delivery by transport tr-1") {
+		t.Errorf("expected a start before delivery to be refused, got %v", err)
+	}
+
+	if _, err := s.SetThreshold(regulator, ThresholdProcessingStartTolerance, 45); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	processing, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T05:30:00Z", "2025-03-01T08:00:00Z", "fac-1", 10, 20, 80, nil, "", "")
+	if err != nil {
+		t.Fatalf("RecordProcessing within the tolerance failed: %v", err)
+	}
+	if processing.DurationMins != 150 {
+		t.Errorf("expected a duration of 150 minutes, got %d", processing.DurationMins)
+	}
+}
+
+// TestFinalizeProcessingReconcilesQuantity checks a batch moves to PROCESSED
+// only once its live quantity is covered by slaughter records
+func TestFinalizeProcessingReconcilesQuantity(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.FinalizeProcessing(farm, "batch-1"); err == nil || !strings.Contains(err.Error(), "no processing records") {
+		t.Fatalf("expected finalizing an unprocessed batch to be refused, got %v", err)
+	}
+	if _, err := recordSlaughter(s, farm, "proc-1", 95, 120, ""); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if _, err := s.FinalizeProcessing(farm, "batch-1"); err == nil || !strings.Contains(err.Error(), "5 of 100 units unprocessed") {
+		t.Fatalf("expected the unprocessed remainder to be refused, got %v", err)
+	}
+	if _, err := s.SetThreshold(regulator, ThresholdUnprocessedRemainderPct, 5); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	batch, err := s.FinalizeProcessing(farm, "batch-1")
+	if err != nil {
+		t.Fatalf("FinalizeProcessing within the tolerance failed: %v", err)
+	}
+	if batch.Status != "PROCESSED" || stub.eventName != "BatchStatusChanged" || stub.event["old_status"] != "COMPLETED" {
+		t.Errorf("unexpected finalized batch %s, event %s %v", batch.Status, stub.eventName, stub.event)
+	}
+	if _, err := s.FinalizeProcessing(farm, "batch-1"); err == nil {
+		t.Errorf("expected finalizing a PROCESSED batch again to be refused")
+	}
+}
+
+// TestSetTransitionRuleOverridesWorkflow checks a rule stored on the ledger
+// replaces the compiled-in transitions for its status only
+func TestSetTransitionRuleOverridesWorkflow(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+
+	if _, err := s.SetTransitionRule(ledgerContext(RegulatorOrgMSP, stub), "CREATED", `["IN_PROGRESS"]`); err == nil {
+		t.Errorf("a regulator changed the transition rules")
+	}
+	admin := ledgerContext(AdminOrgMSP, stub)
+	if _, err := s.SetTransitionRule(admin, "CREATED", `"IN_PROGRESS"`); err == nil {
+		t.Errorf("expected a non-array rule to be refused")
+	}
+	if _, err := s.SetTransitionRule(admin, "CREATED", `["IN_PROGRESS"]`); err != nil {
+		t.Fatalf("SetTransitionRule failed: %v", err)
+	}
+	if err := s.ValidateStatusTransition(admin, "CREATED", "CANCELLED"); err == nil {
+		t.Errorf("a transition removed by the stored rule was allowed")
+	}
+	if err := s.ValidateStatusTransition(admin, "IN_PROGRESS", "CANCELLED"); err != nil {
+		t.Errorf("a compiled-in transition was lost: %v", err)
+	}
+	if _, err := s.SetTransitionRule(admin, "FAILED", `[]`); err != nil {
+		t.Fatalf("SetTransitionRule failed: %v", err)
+	}
+	if err := s.ValidateStatusTransition(admin, "FAILED", "IN_PROGRESS"); err == nil {
+		t.Errorf("an empty rule did not make the status terminal")
+	}
+}
+
+// TestHACCPCheckpointLog checks out-of-limit readings need a corrective
+// action and that the flag makes HACCP certifications require a log
+func TestHACCPCheckpointLog(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", Stage: "SLAUGHTER", YieldKg: 100, QualityScore: 90})
+	processor := ledgerContext(ProcessorOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetFeatureFlag(ledgerContext(AdminOrgMSP, stub), FeatureRequireHACCPLog, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HACCP"); err == nil || !strings.Contains(err.Error(), "prerequisites not met: HACCP checkpoint log") {
+		t.Errorf("expected a HACCP certification without checkpoints to be refused, got %v", err)
+	}
+
+	if _, err := s.RecordHACCPCheckpoint(ledgerContext(MinFarmOrgMSP, stub), "ccp-1", "proc-1", "Chilling", 3, "C", true, ""); err == nil {
+		t.Errorf("a farm recorded a HACCP checkpoint")
+	}
+	if _, err := s.RecordHACCPCheckpoint(processor, "ccp-1", "proc-1", "Chilling", 9, "C", false, " "); err == nil || !strings.Contains(err.Error(), "correctiveAction is required") {
+		t.Errorf("expected an out-of-limit reading without corrective action to be refused, got %v", err)
+	}
+	checkpoint, err := s.RecordHACCPCheckpoint(processor, "ccp-1", "proc-1", "Chilling", 9, "C", false, "re-chilled")
+	if err != nil {
+		t.Fatalf("RecordHACCPCheckpoint failed: %v", err)
+	}
+	if checkpoint.RecordedBy != ProcessorOrgMSP || checkpoint.CreatedAt != "2025-03-01T12:00:00.000000000Z" {
+		t.Errorf("unexpected checkpoint provenance %s at %s", checkpoint.RecordedBy, checkpoint.CreatedAt)
+	}
+	if _, err := s.RecordHACCPCheckpoint(regulator, "ccp-1", "proc-1", "Chilling", 3, "C", true, ""); err == nil {
+		t.Errorf("a checkpoint was overwritten")
+	}
+
+	log, err := s.GetProcessingHACCPLog(regulator, "proc-1")
+	if err != nil || len(log) != 1 {
+		t.Fatalf("unexpected HACCP log %v, %v", log, err)
+	}
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HACCP"); err != nil {
+		t.Errorf("IssueCertification with a checkpoint log failed: %v", err)
+	}
+}
+
+// TestGetBatchesByDateAndStatus checks the status and start date filters,
+// the inclusive end date and paging through the bookmark
+func TestGetBatchesByDateAndStatus(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", StartDate: "2025-01-01", Status: "COMPLETED"})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", StartDate: "2025-01-31T18:00:00Z", Status: "COMPLETED"})
+	putAsset(t, stub, "batch-3", BatchAsset{DocType: "BatchAsset", BatchID: "batch-3", StartDate: "2025-01-15", Status: "IN_PROGRESS"})
+	putAsset(t, stub, "batch-4", BatchAsset{DocType: "BatchAsset", BatchID: "batch-4", StartDate: "2025-02-01", Status: "COMPLETED"})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.GetBatchesByDateAndStatus(ctx, "DONE", "2025-01-01", "2025-01-31", 10, ""); err == nil {
+		t.Errorf("expected an unknown status to be refused")
+	}
+	if _, err := s.GetBatchesByDateAndStatus(ctx, "COMPLETED", "2025-01-01", "2025-01-31", MaxPageSize+1, ""); err == nil {
+		t.Errorf("expected an oversized page to be refused")
+	}
+
+	first, err := s.GetBatchesByDateAndStatus(ctx, "COMPLETED", "2025-01-01", "2025-01-31", 1, "")
+	if err != nil {
+		t.Fatalf("GetBatchesByDateAndStatus failed: %v", err)
+	}
+	if first.Count != 1 || first.Batches[0].BatchID != "batch-1" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+	second, err := s.GetBatchesByDateAndStatus(ctx, "COMPLETED", "2025-01-01", "2025-01-31", 1, first.Bookmark)
+	if err != nil {
+		t.Fatalf("GetBatchesByDateAndStatus failed: %v", err)
+	}
+	if second.Count != 1 || second.Batches[0].BatchID != "batch-2" {
+		t.Errorf("expected the end date to include later that day, got %+v", second)
+	}
+	last, err := s.GetBatchesByDateAndStatus(ctx, "COMPLETED", "2025-01-01", "2025-01-31", 1, second.Bookmark)
+	if err != nil || last.Count != 0 {
+		t.Errorf("expected an empty last page, got %+v, %v", last, err)
+	}
+}
+
+// TestLabTestsGateCertificationAndHoldBatch checks a failing result can hold
+// the batch until released and that required tests gate certification
+func TestLabTestsGateCertificationAndHoldBatch(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", Stage: "SLAUGHTER", YieldKg: 100, QualityScore: 90})
+	lab := ledgerContext(LabOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetRequiredLabTests(lab, "HALAL", `["salmonella"]`); err == nil {
+		t.Errorf("a lab configured required lab tests")
+	}
+	if _, err := s.SetRequiredLabTests(regulator, "halal", `["salmonella"]`); err != nil {
+		t.Fatalf("SetRequiredLabTests failed: %v", err)
+	}
+	if _, err := s.RecordLabTest(lab, "lab-1", "proc-1", "SALMONELLA", 1, "cfu", false, "lab-a", "2025-03-01", "abc", false); err == nil {
+		t.Errorf("expected a malformed report hash to be refused")
+	}
+
+	failed, err := s.RecordLabTest(lab, "lab-1", "proc-1", "salmonella", 1, "cfu", false, "lab-a", "2025-03-01", strings.Repeat("A", 64), true)
+	if err != nil {
+		t.Fatalf("RecordLabTest failed: %v", err)
+	}
+	if failed.TestType != "SALMONELLA" || failed.BatchID != "batch-1" || failed.ReportHash != strings.Repeat("a", 64) {
+		t.Errorf("unexpected normalized lab test %+v", failed)
+	}
+	if stub.eventName != "LabTestFailed" || stub.event["batch_on_hold"] != true {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+	batch, err := s.GetBatch(regulator, "batch-1")
+	if err != nil || batch.Status != "ON_HOLD" || batch.StatusBeforeHold != "COMPLETED" {
+		t.Fatalf("expected the batch ON_HOLD from COMPLETED, got %+v, %v", batch, err)
+	}
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HALAL"); err == nil || !strings.Contains(err.Error(), "prerequisites not met: passing SALMONELLA lab test") {
+		t.Errorf("expected certification without a passing test to be refused, got %v", err)
+	}
+
+	released, err := s.ReleaseBatchHold(regulator, "batch-1", "retested")
+	if err != nil {
+		t.Fatalf("ReleaseBatchHold failed: %v", err)
+	}
+	if released.Status != "COMPLETED" || released.HoldReason != "" {
+		t.Errorf("unexpected released batch %+v", released)
+	}
+	if _, err := s.ReleaseBatchHold(regulator, "batch-1", "retested"); err == nil {
+		t.Errorf("a batch not ON_HOLD was released")
+	}
+
+	if _, err := s.RecordLabTest(lab, "lab-2", "proc-1", "SALMONELLA", 0, "cfu", true, "lab-a", "2025-03-02", "", false); err != nil {
+		t.Fatalf("RecordLabTest failed: %v", err)
+	}
+	tests, err := s.GetLabTestsByBatch(regulator, "batch-1")
+	if err != nil || len(tests) != 2 || tests[0].TestID != "lab-1" {
+		t.Errorf("unexpected lab tests by batch %v, %v", tests, err)
+	}
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HALAL"); err != nil {
+		t.Errorf("IssueCertification after a passing test failed: %v", err)
+	}
+}
+
+// TestGetUncertifiedProcessingOlderThan checks processing records past the
+// age limit without an APPROVED certification are listed oldest first
+func TestGetUncertifiedProcessingOlderThan(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", ProcessDate: "2025-01-20"})
+	putAsset(t, stub, "proc-2", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-2", BatchID: "batch-1", ProcessDate: "2025-01-05"})
+	putAsset(t, stub, "proc-3", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-3", BatchID: "batch-1", ProcessDate: "2025-01-10"})
+	putAsset(t, stub, "proc-4", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-4", BatchID: "batch-1", ProcessDate: "2025-02-25"})
+	putAsset(t, stub, "cert-3", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-3", ProcessingID: "proc-3", CertType: "HACCP", Status: "APPROVED", IssuedDate: "2025-01-11", ExpiryDate: "2026-01-11"})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-1", CertType: "HACCP", Status: "PENDING", IssuedDate: "2025-01-21", ExpiryDate: "2026-01-21"})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.GetUncertifiedProcessingOlderThan(ctx, "2025-03-01", -1); err == nil {
+		t.Errorf("expected a negative age to be refused")
+	}
+	overdue, err := s.GetUncertifiedProcessingOlderThan(ctx, "2025-03-01", 14)
+	if err != nil {
+		t.Fatalf("GetUncertifiedProcessingOlderThan failed: %v", err)
+	}
+	var ids []string
+	for _, record := range overdue {
+		ids = append(ids, record.ProcessingID)
+	}
+	if strings.Join(ids, ",") != "proc-2,proc-1" {
+		t.Errorf("unexpected uncertified processing %v", ids)
+	}
+}
+
+// TestProcessingClearanceAndOverride checks a product's clearance must be
+// APPROVED and unexpired, and that an override lets one record through
+func TestProcessingClearanceAndOverride(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetProductProcessingClearance(farm, "prod-1", "SANITARY_INSPECTION"); err == nil {
+		t.Errorf("a farm set a processing clearance")
+	}
+	if _, err := s.SetProductProcessingClearance(regulator, "prod-1", "SANITARY_INSPECTION"); err != nil {
+		t.Fatalf("SetProductProcessingClearance failed: %v", err)
+	}
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "APPROVED", ExpiryDate: "2025-02-28", CreatedAt: "2025-01-01"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "PENDING", CreatedAt: "2025-02-01"})
+
+	if _, err := recordSlaughter(s, farm, "proc-1", 40, 60, ""); err == nil || !strings.Contains(err.Error(), "no approved, unexpired SANITARY_INSPECTION") {
+		t.Fatalf("expected processing with an expired clearance to be refused, got %v", err)
+	}
+	if _, err := s.IssueClearanceOverride(farm, "ovr-1", "batch-1", "lab backlog"); err == nil {
+		t.Errorf("a farm issued a clearance override")
+	}
+	if _, err := s.IssueClearanceOverride(regulator, "ovr-1", "batch-1", "lab backlog"); err != nil {
+		t.Fatalf("IssueClearanceOverride failed: %v", err)
+	}
+	processing, err := recordSlaughter(s, farm, "proc-1", 40, 60, "ovr-1")
+	if err != nil {
+		t.Fatalf("RecordProcessing with override failed: %v", err)
+	}
+	if processing.OverrideID != "ovr-1" {
+		t.Errorf("override not recorded on the processing record: %q", processing.OverrideID)
+	}
+	if _, err := recordSlaughter(s, farm, "proc-2", 40, 60, "ovr-1"); err == nil || !strings.Contains(err.Error(), "already used by processing record proc-1") {
+		t.Errorf("expected the used override to be refused, got %v", err)
+	}
+
+	putAsset(t, stub, "reg-3", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-3", BatchID: "batch-1", RecordType: "sanitary_inspection", Status: "APPROVED", ExpiryDate: "2025-03-01", CreatedAt: "2025-02-15"})
+	if _, err := recordSlaughter(s, farm, "proc-2", 40, 60, ""); err != nil {
+		t.Errorf("RecordProcessing with a clearance valid through today failed: %v", err)
+	}
+}
+
+// TestTransportManifestQuantity checks manifests default to the whole batch
+// and may not ship more than the live quantity across non-cancelled manifests
+func TestTransportManifestQuantity(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "evt-1", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "evt-1", BatchID: "batch-1", EventType: "MORTALITY", EventDate: "2025-02-01", QuantityAffected: 10})
+	putAsset(t, stub, "tr-0", TransportAsset{DocType: "TransportAsset", TransportID: "tr-0", BatchID: "batch-1", Quantity: 50, Status: "CANCELLED"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	createTransport := func(transportID string, quantity int) (*TransportAsset, error) {
+		return s.CreateTransportManifest(farm, transportID, "batch-1", quantity, "farm-1", "plant-1", "truck-1", "driver",
+			"2025-03-02T08:00:00Z", "2025-03-02T12:00:00Z", "Farm", "Plant", false, "")
+	}
+
+	if _, err := createTransport("tr-1", -1); err == nil {
+		t.Errorf("expected a negative quantity to be refused")
+	}
+	first, err := createTransport("tr-1", 60)
+	if err != nil {
+		t.Fatalf("CreateTransportManifest failed: %v", err)
+	}
+	if first.Quantity != 60 || stub.event["quantity"] != float64(60) {
+		t.Errorf("unexpected manifest quantity %d, event %v", first.Quantity, stub.event)
+	}
+	if _, err := createTransport("tr-2", 31); err == nil || !strings.Contains(err.Error(), "remaining quantity 30 (60 of 90 already on manifests)") {
+		t.Errorf("expected shipping past the live quantity to be refused, got %v", err)
+	}
+	if _, err := createTransport("tr-2", 30); err != nil {
+		t.Fatalf("CreateTransportManifest for the remainder failed: %v", err)
+	}
+	if _, err := createTransport("tr-3", 0); err == nil {
+		t.Errorf("expected a whole-batch manifest on a fully shipped batch to be refused")
+	}
+}
+
+// TestTransportManifestDefaultsToWholeBatch checks an omitted quantity ships
+// the live quantity and that legacy manifests count as the whole batch
+func TestTransportManifestDefaultsToWholeBatch(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	transport, err := s.CreateTransportManifest(farm, "tr-1", "batch-1", 0, "farm-1", "plant-1", "truck-1", "driver",
+		"2025-03-02T08:00:00Z", "2025-03-02T12:00:00Z", "Farm", "Plant", false, "")
+	if err != nil {
+		t.Fatalf("CreateTransportManifest failed: %v", err)
+	}
+	if transport.Quantity != 100 {
+		t.Errorf("expected the whole batch of 100, got %d", transport.Quantity)
+	}
+
+	legacy := processingStub(t)
+	putAsset(t, legacy, "tr-0", TransportAsset{DocType: "TransportAsset", TransportID: "tr-0", BatchID: "batch-1", Status: "COMPLETED"})
+	if _, err := s.CreateTransportManifest(ledgerContext(MinFarmOrgMSP, legacy), "tr-1", "batch-1", 1, "farm-1", "plant-1", "truck-1", "driver",
+		"2025-03-02T08:00:00Z", "2025-03-02T12:00:00Z", "Farm", "Plant", false, ""); err == nil {
+		t.Errorf("expected a legacy manifest to count as the whole batch")
+	}
+}
+
+// TestProcessingEquipment checks equipment must be at the processing
+// facility, stale sanitation is flagged and records are found by equipment
+func TestProcessingEquipment(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "fac-2", FacilityAsset{DocType: "FacilityAsset", FacilityID: "fac-2", Name: "Plant 2", LicenseNumber: "LIC-2", LicenseExpiry: "2026-12-31", IsActive: true})
+	processor := ledgerContext(ProcessorOrgMSP, stub)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	record := func(processingID string, equipmentIDs []string) (*ProcessingAsset, error) {
+		return s.RecordProcessing(farm, processingID, "batch-1", "SLAUGHTER", "2025-03-01",
+			"2025-03-01T08:00:00Z", "2025-03-01T10:00:00Z", "fac-1", 10, 20, 90, equipmentIDs, "", "")
+	}
+
+	if _, err := s.RegisterEquipment(farm, "eq-1", "fac-1", "LINE", "2025-02-20"); err == nil {
+		t.Errorf("a farm registered equipment")
+	}
+	for _, equipment := range []struct{ id, facility, sanitized string }{
+		{"eq-1", "fac-1", "2025-02-20"}, {"eq-2", "fac-1", "2025-02-28"}, {"eq-3", "fac-2", "2025-02-28"},
+	} {
+		if _, err := s.RegisterEquipment(processor, equipment.id, equipment.facility, "LINE", equipment.sanitized); err != nil {
+			t.Fatalf("RegisterEquipment %s failed: %v", equipment.id, err)
+		}
+	}
+	if _, err := s.RecordEquipmentSanitation(processor, "eq-1", "2025-02-19"); err == nil {
+		t.Errorf("expected a sanitation before the last one to be refused")
+	}
+
+	if _, err := record("proc-1", []string{"eq-1", "eq-3"}); err == nil || !strings.Contains(err.Error(), "belongs to facility fac-2") {
+		t.Errorf("expected equipment from another facility to be refused, got %v", err)
+	}
+	if _, err := record("proc-1", []string{"eq-1", "eq-1"}); err == nil {
+		t.Errorf("expected duplicate equipment to be refused")
+	}
+	if _, err := s.SetThreshold(ledgerContext(RegulatorOrgMSP, stub), ThresholdSanitationMaxAgeDays, 7); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	processing, err := record("proc-1", []string{"eq-1", "eq-2"})
+	if err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if strings.Join(processing.SanitationOverdue, ",") != "eq-1" {
+		t.Errorf("expected eq-1 sanitation overdue, got %v", processing.SanitationOverdue)
+	}
+	if _, err := record("proc-2", []string{"eq-2"}); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+
+	records, err := s.GetProcessingByEquipment(farm, "eq-1", "2025-03-01", "2025-03-01")
+	if err != nil || len(records) != 1 || records[0].ProcessingID != "proc-1" {
+		t.Errorf("unexpected processing by equipment %v, %v", records, err)
+	}
+}
+
+// TestGetBatchShippedQuantity checks cancelled manifests are ignored, the
+// remainder is taken from the live quantity and never goes below zero
+func TestGetBatchShippedQuantity(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "event-1", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "event-1", BatchID: "batch-1", EventType: "MORTALITY", QuantityAffected: 10})
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Quantity: 30, Status: "IN_TRANSIT"})
+	putAsset(t, stub, "tr-2", TransportAsset{DocType: "TransportAsset", TransportID: "tr-2", BatchID: "batch-1", Quantity: 50, Status: "CANCELLED"})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	shipped, err := s.GetBatchShippedQuantity(ctx, "batch-1")
+	if err != nil {
+		t.Fatalf("GetBatchShippedQuantity failed: %v", err)
+	}
+	if shipped.TotalQuantity != 90 || shipped.ShippedQuantity != 30 || shipped.RemainingQuantity != 60 {
+		t.Errorf("unexpected shipped quantity %+v", shipped)
+	}
+
+	putAsset(t, stub, "event-2", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "event-2", BatchID: "batch-1", EventType: "MORTALITY", QuantityAffected: 70})
+	shipped, err = s.GetBatchShippedQuantity(ctx, "batch-1")
+	if err != nil {
+		t.Fatalf("GetBatchShippedQuantity failed: %v", err)
+	}
+	if shipped.TotalQuantity != 20 || shipped.ShippedQuantity != 30 || shipped.RemainingQuantity != 0 {
+		t.Errorf("expected the remainder floored at zero, got %+v", shipped)
+	}
+}
+
+// TestColdStorageAssignments checks stays may not overlap, history is
+// ordered by entry and storage readings raise violations like transport ones
+func TestColdStorageAssignments(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", Stage: "SLAUGHTER"})
+	processor := ledgerContext(ProcessorOrgMSP, stub)
+
+	if _, err := s.AssignColdStorage(ledgerContext(RegulatorOrgMSP, stub), "cs-1", "proc-1", "room-a", "2025-03-01T10:00:00Z"); err == nil {
+		t.Errorf("a regulator assigned cold storage")
+	}
+	if _, err := s.AssignColdStorage(processor, "cs-1", "proc-1", "room-a", "2025-03-01T10:00:00Z"); err != nil {
+		t.Fatalf("AssignColdStorage failed: %v", err)
+	}
+	if _, err := s.AssignColdStorage(processor, "cs-2", "proc-1", "room-b", "2025-03-02T10:00:00Z"); err == nil || !strings.Contains(err.Error(), "still in cold storage under assignment cs-1") {
+		t.Errorf("expected a second open stay to be refused, got %v", err)
+	}
+	if _, err := s.ReleaseColdStorage(processor, "cs-1", "2025-03-01T09:00:00Z"); err == nil {
+		t.Errorf("expected a release before entry to be refused")
+	}
+	released, err := s.ReleaseColdStorage(processor, "cs-1", "2025-03-02T10:00:00Z")
+	if err != nil {
+		t.Fatalf("ReleaseColdStorage failed: %v", err)
+	}
+	if released.Status != "RELEASED" {
+		t.Errorf("expected RELEASED, got %s", released.Status)
+	}
+	if _, err := s.AssignColdStorage(processor, "cs-2", "proc-1", "room-b", "2025-03-02T09:00:00Z"); err == nil || !strings.Contains(err.Error(), "overlaps cold storage assignment cs-1") {
+		t.Errorf("expected an overlapping stay to be refused, got %v", err)
+	}
+	if _, err := s.AssignColdStorage(processor, "cs-2", "proc-1", "room-b", "2025-03-02T10:00:00Z"); err != nil {
+		t.Fatalf("AssignColdStorage after release failed: %v", err)
+	}
+
+	history, err := s.GetColdStorageHistory(processor, "proc-1")
+	if err != nil || len(history) != 2 || history[0].AssignmentID != "cs-1" || history[1].BatchID != "batch-1" {
+		t.Errorf("unexpected cold storage history %v, %v", history, err)
+	}
+
+	if _, err := s.AddStorageTemperatureLog(processor, "log-1", "cs-2", -1, "2025-03-02T11:00:00Z"); err == nil || !strings.Contains(err.Error(), "temperature must be non-negative") {
+		t.Errorf("expected a negative reading to be refused, got %v", err)
+	}
+	if _, err := s.AddStorageTemperatureLog(processor, "log-1", "cs-2", 9.5, "02/03/2025 11:00"); err == nil || !strings.Contains(err.Error(), "invalid timestamp") {
+		t.Errorf("expected an unparseable timestamp to be refused, got %v", err)
+	}
+	tempLog, err := s.AddStorageTemperatureLog(processor, "log-1", "cs-2", 9.5, "2025-03-02T11:00:00Z")
+	if err != nil {
+		t.Fatalf("AddStorageTemperatureLog failed: %v", err)
+	}
+	if !tempLog.IsViolation || tempLog.Location != "room-b" {
+		t.Errorf("unexpected storage temperature log %+v", tempLog)
+	}
+	if stub.eventName != "TemperatureViolationDetected" || stub.event["storage_assignment_id"] != "cs-2" || stub.event["transport_id"] != nil {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+	logs, err := s.GetStorageTemperatureLogs(processor, "cs-2")
+	if err != nil || len(logs) != 1 {
+		t.Errorf("unexpected storage temperature logs %v, %v", logs, err)
+	}
+}
+
+// TestTryGetReportsMissingAssets checks the TryGet variants report an absent
+// asset without an error and keep errors for undecodable state
+func TestTryGetReportsMissingAssets(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	stub.state["tr-bad"] = []byte("{")
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	if batch, found, err := s.TryGetBatch(ctx, "batch-1"); err != nil || !found || batch.Quantity != 100 {
+		t.Errorf("TryGetBatch on a stored batch returned %v, %v, %v", batch, found, err)
+	}
+	if batch, found, err := s.TryGetBatch(ctx, "batch-2"); err != nil || found || batch != nil {
+		t.Errorf("TryGetBatch on a missing batch returned %v, %v, %v", batch, found, err)
+	}
+	if _, found, err := s.TryGetProduct(ctx, "prod-2"); err != nil || found {
+		t.Errorf("TryGetProduct on a missing product returned %v, %v", found, err)
+	}
+	if _, _, err := s.TryGetTransport(ctx, "tr-bad"); err == nil {
+		t.Errorf("expected an undecodable transport to return an error")
+	}
+	if _, _, err := s.TryGetTransport(ctx, " "); err == nil {
+		t.Errorf("expected a blank transport ID to be refused")
+	}
+	if _, err := s.GetBatch(ctx, "batch-2"); err == nil || err.Error() != "batch batch-2 not found" {
+		t.Errorf("expected GetBatch to keep its not found error, got %v", err)
+	}
+
+	ignored := map[string]bool{}
+	for _, name := range s.GetIgnoredFunctions() {
+		ignored[name] = true
+	}
+	for _, name := range []string{"TryGetBatch", "TryGetProduct", "TryGetTransport"} {
+		if !ignored[name] {
+			t.Errorf("%s is not excluded from the contract's transactions", name)
+		}
+	}
+}
+
+// TestBatchProcessingAggregateAndSummary checks the aggregate weights quality
+// by yield, ignores records without a yield and feeds the batch summary
+func TestBatchProcessingAggregateAndSummary(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", Quantity: 50, Status: "PROCESSED"})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", ProductID: "prod-1", Quantity: 50, Status: "PROCESSED"})
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", SlaughterCnt: 10, YieldKg: 100, QualityScore: 90, WasteEntries: []WasteEntry{{WasteKg: 5, ByproductKg: 2}}})
+	putAsset(t, stub, "proc-2", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-2", BatchID: "batch-1", SlaughterCnt: 30, YieldKg: 300, QualityScore: 70})
+	putAsset(t, stub, "proc-3", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-3", BatchID: "batch-1", QualityScore: 10})
+	putAsset(t, stub, "proc-4", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-4", BatchID: "batch-2", QualityScore: 60})
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Quantity: 20, Status: "IN_TRANSIT"})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	aggregate, err := s.GetBatchProcessingAggregate(ctx, "batch-1")
+	if err != nil {
+		t.Fatalf("GetBatchProcessingAggregate failed: %v", err)
+	}
+	if aggregate.RecordCount != 3 || aggregate.TotalSlaughterCount != 40 || aggregate.TotalYieldKg != 400 || aggregate.AverageQualityScore != 75 || aggregate.TotalWasteKg != 5 || aggregate.TotalByproductKg != 2 {
+		t.Errorf("unexpected aggregate %+v", aggregate)
+	}
+	unweighted, err := s.GetBatchProcessingAggregate(ctx, "batch-2")
+	if err != nil || unweighted.AverageQualityScore != 0 {
+		t.Errorf("expected a zero average without yield, got %+v, %v", unweighted, err)
+	}
+
+	summary, err := s.GetBatchSummary(ctx, "batch-1")
+	if err != nil {
+		t.Fatalf("GetBatchSummary failed: %v", err)
+	}
+	if summary.CurrentQuantity != 50 || summary.Shipping.RemainingQuantity != 30 || summary.Processing.TotalYieldKg != 400 {
+		t.Errorf("unexpected batch summary %+v", summary)
+	}
+}
+
+// TestEmitFullStateFlag checks event payloads carry the written asset only
+// while the EmitFullState flag is on
+func TestEmitFullStateFlag(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.IssueClearanceOverride(regulator, "ovr-1", "batch-1", "lab backlog"); err != nil {
+		t.Fatalf("IssueClearanceOverride failed: %v", err)
+	}
+	if _, hasState := stub.event["state"]; hasState || stub.event["override_id"] != "ovr-1" {
+		t.Errorf("expected a plain payload with the flag off, got %v", stub.event)
+	}
+
+	if _, err := s.SetFeatureFlag(ledgerContext(AdminOrgMSP, stub), FeatureEmitFullState, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+	if _, err := s.IssueClearanceOverride(regulator, "ovr-2", "batch-1", "lab backlog"); err != nil {
+		t.Fatalf("IssueClearanceOverride failed: %v", err)
+	}
+	state, _ := stub.event["state"].(map[string]interface{})
+	if stub.eventName != "ClearanceOverrideIssued" || state["override_id"] != "ovr-2" || state["issued_by"] != RegulatorOrgMSP {
+		t.Errorf("expected the full override in the payload, got %s %v", stub.eventName, stub.event)
+	}
+}
+
+// TestProcessingEventPayload checks processing events carry the versioned
+// payload, on record and on a waste amendment
+func TestProcessingEventPayload(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := recordSlaughter(s, farm, "proc-1", 40, 60, ""); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"payload_version": float64(ProcessingEventVersion),
+		"processing_id":   "proc-1",
+		"batch_id":        "batch-1",
+		"processing_date": "2025-03-01",
+		"facility_id":     "fac-1",
+		"slaughter_count": float64(40),
+		"yield_kg":        float64(60),
+		"quality_score":   float64(90),
+		"quality_grade":   "A",
+		"tx_timestamp":    "2025-03-01T12:00:00.000000000Z",
+	}
+	if stub.eventName != "ProcessingRecorded" {
+		t.Fatalf("expected ProcessingRecorded, got %s", stub.eventName)
+	}
+	for field, value := range want {
+		if stub.event[field] != value {
+			t.Errorf("ProcessingRecorded %s = %v, want %v", field, stub.event[field], value)
+		}
+	}
+
+	if _, err := s.RecordProcessingWaste(farm, "proc-1", 5, 2, "RENDERING"); err != nil {
+		t.Fatalf("RecordProcessingWaste failed: %v", err)
+	}
+	if stub.eventName != "ProcessingUpdated" || stub.event["waste_entry_count"] != float64(1) || stub.event["payload_version"] != float64(ProcessingEventVersion) {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+
+	if _, err := recordSlaughter(s, farm, "proc-2", 120, 60, ""); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if stub.eventName != "ProcessingRecorded" || stub.event["quantity_mismatch"] != true || stub.event["yield_kg"] != float64(60) || stub.event["available_quantity"] != float64(100) {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+}
+
+// TestIssuedBeforeExpiry checks regulatory records refuse an expiry not
+// after the issue date while either date may be left empty
+func TestIssuedBeforeExpiry(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	for _, dates := range [][2]string{
+		{"2025-03-01T00:00:00Z", "2025-03-01T00:00:00Z"},
+		{"2025-03-01T00:00:00Z", "2025-02-01T00:00:00Z"},
+		{"2026-03-01", "2025-03-01"},
+	} {
+		if _, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "SANITARY_INSPECTION", dates[0], dates[1], "", "", ""); err == nil {
+			t.Errorf("CreateRegulatoryRecord accepted issued %s, expiry %s", dates[0], dates[1])
+		}
+	}
+
+	if _, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "SANITARY_INSPECTION", "", "2026-03-01", "", "", ""); err != nil {
+		t.Errorf("CreateRegulatoryRecord without an issue date failed: %v", err)
+	}
+}
+
+// TestValidateCertificationDates checks a certification needs both dates,
+// an expiry after issue, an issue date not in the future and a validity
+// period within its type's cap
+func TestValidateCertificationDates(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetCertificationMaxValidity(regulator, "halal", 365); err != nil {
+		t.Fatalf("SetCertificationMaxValidity failed: %v", err)
+	}
+	for _, c := range []struct{ issued, expiry, message string }{
+		{"2025-03-01", "", "invalid expiryDate"},
+		{"", "2026-03-01", "invalid issuedDate"},
+		{"2025-03-01", "2025-03-01", "must be after issuedDate"},
+		{"2025-03-01T12:06:00Z", "2026-03-01", "more than 5 minutes in the future"},
+		{"2025-03-01", "2026-03-02", "exceeds the 365 day maximum for HALAL"},
+	} {
+		if _, err := s.IssueCertification(regulator, "cert-1", "proc-1", "", "HALAL", c.issued, c.expiry, "", ""); err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("issued %q, expiry %q: expected %q, got %v", c.issued, c.expiry, c.message, err)
+		}
+	}
+	if _, err := s.IssueCertification(regulator, "cert-1", "proc-1", "", "HALAL", "2025-03-01T12:04:00Z", "2026-03-01", "", ""); err != nil {
+		t.Errorf("IssueCertification within the tolerance failed: %v", err)
+	}
+}
+
+// TestProcessingAmendments checks corrections leave the original record
+// alone, large yield changes wait for the Regulator and approved ones apply
+func TestProcessingAmendments(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", Stage: "SLAUGHTER", ProcessDate: "2025-03-01", SlaughterCnt: 40, YieldKg: 100, QualityScore: 90, QualityGrade: "A"})
+	processor := ledgerContext(ProcessorOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.AmendProcessingRecord(ledgerContext(MinFarmOrgMSP, stub), "amd-1", "proc-1", `{"notes": "typo"}`, "typo"); err == nil {
+		t.Errorf("a farm amended a processing record")
+	}
+	if _, err := s.AmendProcessingRecord(processor, "amd-1", "proc-1", `{"facility_id": "fac-2"}`, "moved"); err == nil || !strings.Contains(err.Error(), "facility_id cannot be amended") {
+		t.Errorf("expected an uncorrectable field to be refused, got %v", err)
+	}
+	if _, err := s.AmendProcessingRecord(processor, "amd-1", "proc-1", `{"quality_score": 101}`, "rescored"); err == nil {
+		t.Errorf("expected an out-of-range quality score to be refused")
+	}
+
+	if _, err := s.SetThreshold(regulator, ThresholdAmendmentCoSignPct, 10); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	small, err := s.AmendProcessingRecord(processor, "amd-1", "proc-1", `{"yield_kg": 105, "quality_score": 70}`, "scale recalibrated")
+	if err != nil {
+		t.Fatalf("AmendProcessingRecord failed: %v", err)
+	}
+	if small.Status != "APPROVED" || small.RequiresCoSign || small.QualityGrade != "B" {
+		t.Errorf("unexpected small amendment %+v", small)
+	}
+	large, err := s.AmendProcessingRecord(processor, "amd-2", "proc-1", `{"yield_kg": 150}`, "missed a pallet")
+	if err != nil {
+		t.Fatalf("AmendProcessingRecord failed: %v", err)
+	}
+	if large.Status != "PENDING" || !large.RequiresCoSign {
+		t.Errorf("expected a co-sign for a 43%% change, got %+v", large)
+	}
+
+	effective, err := s.GetEffectiveProcessingRecord(regulator, "proc-1")
+	if err != nil || effective.YieldKg != 105 || effective.QualityGrade != "B" {
+		t.Fatalf("expected only the approved amendment applied, got %+v, %v", effective, err)
+	}
+	if _, err := s.RejectProcessingAmendment(regulator, "amd-2", ""); err == nil {
+		t.Errorf("expected a rejection without a comment to be refused")
+	}
+	if _, err := s.ApproveProcessingAmendment(processor, "amd-2", "ok"); err == nil {
+		t.Errorf("a processor co-signed an amendment")
+	}
+	if _, err := s.ApproveProcessingAmendment(regulator, "amd-2", "checked the pallet"); err != nil {
+		t.Fatalf("ApproveProcessingAmendment failed: %v", err)
+	}
+	if _, err := s.ApproveProcessingAmendment(regulator, "amd-2", "again"); err == nil {
+		t.Errorf("an approved amendment was reviewed again")
+	}
+
+	aggregate, err := s.GetBatchProcessingAggregate(regulator, "batch-1")
+	if err != nil || aggregate.TotalYieldKg != 150 || aggregate.AverageQualityScore != 70 {
+		t.Errorf("expected the aggregate to use amended values, got %+v, %v", aggregate, err)
+	}
+	original, err := s.GetProcessingRecord(regulator, "proc-1")
+	if err != nil || original.YieldKg != 100 || len(original.Amendments) != 2 {
+		t.Errorf("expected the original record with both amendments listed, got %+v, %v", original, err)
+	}
+}
+
+// TestGetBatchProcessingYieldTrend checks processed batches are listed by
+// start date with amended yields and unprocessed batches are left out
+func TestGetBatchProcessingYieldTrend(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", BatchNumber: "B-1", StartDate: "2025-02-01", Status: "PROCESSED"})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", ProductID: "prod-1", BatchNumber: "B-2", StartDate: "2025-01-01", Status: "PROCESSED"})
+	putAsset(t, stub, "batch-3", BatchAsset{DocType: "BatchAsset", BatchID: "batch-3", ProductID: "prod-1", BatchNumber: "B-3", StartDate: "2024-12-01", Status: "IN_PROGRESS"})
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", SlaughterCnt: 10, YieldKg: 100, QualityScore: 90})
+	putAsset(t, stub, "proc-2", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-2", BatchID: "batch-1", SlaughterCnt: 30, YieldKg: 300, QualityScore: 70})
+	putAsset(t, stub, "proc-3", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-3", BatchID: "batch-2", SlaughterCnt: 20, YieldKg: 150, QualityScore: 80})
+	putAsset(t, stub, "amd-1", ProcessingAmendmentAsset{DocType: "ProcessingAmendmentAsset", AmendmentID: "amd-1", ProcessingID: "proc-3", Status: "APPROVED", Corrections: ProcessingCorrection{Fields: []string{"yield_kg"}, YieldKg: 160}})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	trend, err := s.GetBatchProcessingYieldTrend(ctx, "prod-1")
+	if err != nil {
+		t.Fatalf("GetBatchProcessingYieldTrend failed: %v", err)
+	}
+	var points []string
+	for _, point := range trend {
+		points = append(points, fmt.Sprintf("%s:%.0f", point.BatchNumber, point.TotalYieldKg))
+	}
+	if strings.Join(points, ",") != "B-2:160,B-1:400" {
+		t.Errorf("unexpected yield trend %v", points)
+	}
+
+	empty, err := s.GetBatchProcessingYieldTrend(ctx, "prod-2")
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("expected an empty trend for an unprocessed product, got %v, %v", empty, err)
+	}
+}
+
+// TestGetFacilityProcessingStats checks the stats cover the facility's
+// records in range, count flagged records and failed lab tests
+func TestGetFacilityProcessingStats(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01", SlaughterCnt: 10, YieldKg: 100, QualityScore: 90, QualityGrade: "A"})
+	putAsset(t, stub, "proc-2", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-2", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-10", SlaughterCnt: 20, YieldKg: 300, QualityScore: 30, QualityGrade: QualityGradeReject})
+	putAsset(t, stub, "proc-3", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-3", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-15", SlaughterCnt: 5, YieldKg: 50, QualityScore: 90, QualityGrade: "A", SanitationOverdue: []string{"eq-1"}})
+	putAsset(t, stub, "proc-4", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-4", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-04-01", SlaughterCnt: 50, YieldKg: 500, QualityScore: 90})
+	putAsset(t, stub, "proc-5", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-5", BatchID: "batch-1", FacilityID: "fac-2", ProcessDate: "2025-03-05", SlaughterCnt: 50, YieldKg: 500, QualityScore: 90})
+	putAsset(t, stub, "lab-1", LabTestAsset{DocType: "LabTestAsset", TestID: "lab-1", ProcessingID: "proc-2", Passed: false})
+	putAsset(t, stub, "lab-2", LabTestAsset{DocType: "LabTestAsset", TestID: "lab-2", ProcessingID: "proc-1", Passed: true})
+	putAsset(t, stub, "lab-3", LabTestAsset{DocType: "LabTestAsset", TestID: "lab-3", ProcessingID: "proc-5", Passed: false})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.GetFacilityProcessingStats(ledgerContext(MinFarmOrgMSP, stub), "fac-1", "2025-03-01", "2025-03-31"); err == nil {
+		t.Errorf("a farm read facility stats")
+	}
+	if _, err := s.GetFacilityProcessingStats(regulator, "fac-1", "2024-01-01", "2025-03-31"); err == nil || !strings.Contains(err.Error(), "exceeds 366 days") {
+		t.Errorf("expected an overlong range to be refused, got %v", err)
+	}
+
+	stats, err := s.GetFacilityProcessingStats(regulator, "fac-1", "2025-03-01", "2025-03-31")
+	if err != nil {
+		t.Fatalf("GetFacilityProcessingStats failed: %v", err)
+	}
+	if stats.RecordCount != 3 || stats.TotalSlaughterCount != 35 || stats.TotalYieldKg != 450 || stats.AverageQualityScore != 50 {
+		t.Errorf("unexpected totals %+v", stats)
+	}
+	if stats.FlaggedRecordCount != 2 || stats.FailedLabTestCount != 1 {
+		t.Errorf("unexpected flagged %d and failed lab tests %d", stats.FlaggedRecordCount, stats.FailedLabTestCount)
+	}
+}
+
+// TestIssueCertificationOptions checks IssueCertification's optional settings
+// are read from its options JSON, and that a second active certification of
+// a type is refused unless it replaces the first
+func TestIssueCertificationOptions(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	if _, err := recordSlaughter(s, ledgerContext(MinFarmOrgMSP, stub), "proc-1", 10, 20, ""); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	issue := func(certificationID, optionsJSON string) (*CertificationAsset, error) {
+		return s.IssueCertification(regulator, certificationID, "proc-1", "", "HALAL", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", optionsJSON)
+	}
+
+	if _, err := issue("cert-1", "{notes"); err == nil || !strings.Contains(err.Error(), "invalid options JSON") {
+		t.Errorf("expected malformed options to be refused, got %v", err)
+	}
+	certification, err := issue("cert-1", `{"notes": "slaughter audit passed"}`)
+	if err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if certification.Notes != "slaughter audit passed" {
+		t.Errorf("notes not taken from the options: %q", certification.Notes)
+	}
+
+	if _, err := s.IssueCertification(regulator, "cert-2", "proc-1", "", "halal", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", ""); err == nil || !strings.Contains(err.Error(), "cert-1") {
+		t.Errorf("expected a second active HALAL certification to be refused, got %v", err)
+	}
+	if _, err := issue("cert-2", `{"replace_existing": true, "force_parallel": true}`); err == nil {
+		t.Errorf("accepted replace_existing with force_parallel")
+	}
+	if _, err := issue("cert-2", `{"replace_existing": true}`); err != nil {
+		t.Fatalf("replacing the certification failed: %v", err)
+	}
+	if stub.event["replaced_certification_id"] != "cert-1" {
+		t.Errorf("event does not name the replaced certification: %v", stub.event)
+	}
+	var replaced CertificationAsset
+	if err := json.Unmarshal(stub.state["cert-1"], &replaced); err != nil {
+		t.Fatalf("failed to unmarshal certification: %v", err)
+	}
+	if replaced.Status != "REVOKED" {
+		t.Errorf("replaced certification is %s", replaced.Status)
+	}
+}
+
+// TestRevokeCertification checks only the Regulator revokes, that the reason
+// and identity are recorded and that a revoked certification stays revoked
+func TestRevokeCertification(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-1", CertType: "HALAL", Status: "APPROVED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.RevokeCertification(ledgerContext(MinFarmOrgMSP, stub), "cert-1", "fraudulent audit"); err == nil {
+		t.Errorf("a farm revoked a certification")
+	}
+	if _, err := s.RevokeCertification(regulator, "cert-1", ""); err == nil {
+		t.Errorf("revoked a certification without a reason")
+	}
+	revoked, err := s.RevokeCertification(regulator, "cert-1", "fraudulent audit")
+	if err != nil {
+		t.Fatalf("RevokeCertification failed: %v", err)
+	}
+	if revoked.Status != "REVOKED" || revoked.RevokedReason != "fraudulent audit" || revoked.RevokedBy != "x509::CN=test" || revoked.RevokedAt == "" {
+		t.Errorf("revocation not recorded: %+v", revoked)
+	}
+	if stub.eventName != "CertificationRevoked" || stub.event["reason"] != "fraudulent audit" {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+	if _, err := s.RevokeCertification(regulator, "cert-1", "again"); err == nil || !strings.Contains(err.Error(), "already revoked") {
+		t.Errorf("expected a second revocation to be refused, got %v", err)
+	}
+}
+
+// TestGetCertificationChainForBatch checks a batch's certifications are
+// returned oldest first, each resolved to its processing record and product
+func TestGetCertificationChainForBatch(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01", QualityScore: 90})
+	putAsset(t, stub, "proc-2", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-2", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-02", QualityScore: 80})
+	putAsset(t, stub, "proc-9", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-9", BatchID: "batch-9", FacilityID: "fac-1", ProcessDate: "2025-03-02"})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-2", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-03-05T00:00:00Z"})
+	putAsset(t, stub, "cert-2", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-2", ProcessingID: "proc-1", CertType: "HACCP", Status: "APPROVED", IssuedDate: "2025-03-02T00:00:00Z"})
+	putAsset(t, stub, "cert-9", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-9", ProcessingID: "proc-9", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-03-01T00:00:00Z"})
+
+	chain, err := s.GetCertificationChainForBatch(ledgerContext(MinFarmOrgMSP, stub), "batch-1")
+	if err != nil {
+		t.Fatalf("GetCertificationChainForBatch failed: %v", err)
+	}
+	if len(chain) != 2 || chain[0].Certification.CertificationID != "cert-2" || chain[1].Certification.CertificationID != "cert-1" {
+		t.Fatalf("unexpected chain %v", chain)
+	}
+	link := chain[1]
+	if link.ProcessingMissing || link.Processing.ProcessingID != "proc-2" || link.ProductName != "Broiler" || link.Batch.Batch.BatchID != "batch-1" {
+		t.Errorf("link not resolved: %+v", link)
+	}
+}
+
+// TestIsCertificationValid checks validity reports every failed check:
+// status, the issued to expiry window and supersession
+func TestIsCertificationValid(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	putAsset(t, stub, "cert-2", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-2", ProcessingID: "proc-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-02-01"})
+	putAsset(t, stub, "cert-3", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-3", ProcessingID: "proc-2", CertType: "HACCP", Status: "REVOKED", RevokedReason: "fraudulent audit", IssuedDate: "2025-01-01", ExpiryDate: "2025-02-01"})
+	putAsset(t, stub, "cert-4", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-4", ProcessingID: "proc-3", CertType: "HACCP", Status: "APPROVED", IssuedDate: "2025-04-01"})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	for _, c := range []struct {
+		certificationID string
+		reasons         string
+	}{
+		{"cert-2", ""},
+		{"cert-1", "superseded by cert-2"},
+		{"cert-3", "revoked: fraudulent audit;expired on 2025-02-01"},
+		{"cert-4", "not valid until 2025-04-01"},
+	} {
+		validity, err := s.IsCertificationValid(ctx, c.certificationID)
+		if err != nil {
+			t.Fatalf("IsCertificationValid %s failed: %v", c.certificationID, err)
+		}
+		if strings.Join(validity.Reasons, ";") != c.reasons || validity.Valid != (c.reasons == "") {
+			t.Errorf("%s: got valid %v reasons %v, want %q", c.certificationID, validity.Valid, validity.Reasons, c.reasons)
+		}
+	}
+}
+
+// TestDeactivateAndReactivateProduct checks deactivation needs a reason and
+// records it, and that reactivation clears it
+func TestDeactivateAndReactivateProduct(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.ReactivateProduct(regulator, "prod-1"); err == nil || !strings.Contains(err.Error(), "already active") {
+		t.Errorf("expected reactivating an active product to be refused, got %v", err)
+	}
+	if _, err := s.DeactivateProduct(regulator, "prod-1", ""); err == nil {
+		t.Errorf("deactivated a product without a reason")
+	}
+	product, err := s.DeactivateProduct(regulator, "prod-1", "recipe withdrawn")
+	if err != nil {
+		t.Fatalf("DeactivateProduct failed: %v", err)
+	}
+	if product.IsActive || product.DeactivationReason != "recipe withdrawn" || product.DeactivatedAt == "" {
+		t.Errorf("deactivation not recorded: %+v", product)
+	}
+	if stub.eventName != "ProductDeactivated" || stub.event["reason"] != "recipe withdrawn" {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+	if _, err := s.DeactivateProduct(regulator, "prod-1", "again"); err == nil || !strings.Contains(err.Error(), "already inactive") {
+		t.Errorf("expected deactivating an inactive product to be refused, got %v", err)
+	}
+
+	product, err = s.ReactivateProduct(regulator, "prod-1")
+	if err != nil {
+		t.Fatalf("ReactivateProduct failed: %v", err)
+	}
+	if !product.IsActive || product.DeactivationReason != "" || product.DeactivatedAt != "" || stub.eventName != "ProductReactivated" {
+		t.Errorf("reactivation not recorded: %+v", product)
+	}
+}
+
+// TestRenewCertificationLinksChain checks a renewal supersedes its
+// predecessor, links back to it and cannot be repeated
+func TestRenewCertificationLinksChain(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2024-03-01T00:00:00Z", ExpiryDate: "2025-03-01T00:00:00Z", IssuerID: "regulator-1"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	renewal, err := s.RenewCertification(regulator, "cert-2", "cert-1", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "annual renewal", "", "")
+	if err != nil {
+		t.Fatalf("RenewCertification failed: %v", err)
+	}
+	if renewal.PreviousCertificationID != "cert-1" || renewal.ProcessingID != "proc-1" || renewal.CertType != "HALAL" || renewal.IssuerID != "x509::CN=test" {
+		t.Errorf("unexpected renewal %+v", renewal)
+	}
+	var previous CertificationAsset
+	if err := json.Unmarshal(stub.state["cert-1"], &previous); err != nil {
+		t.Fatalf("failed to unmarshal certification: %v", err)
+	}
+	if previous.Status != "SUPERSEDED" || previous.SupersededBy != "cert-2" {
+		t.Errorf("predecessor not superseded: %s by %q", previous.Status, previous.SupersededBy)
+	}
+	if _, err := s.RenewCertification(regulator, "cert-3", "cert-1", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "", ""); err == nil || !strings.Contains(err.Error(), "already renewed") {
+		t.Errorf("expected a second renewal to be refused, got %v", err)
+	}
+
+	for _, certificationID := range []string{"cert-1", "cert-2"} {
+		chain, err := s.GetCertificationChain(regulator, certificationID)
+		if err != nil {
+			t.Fatalf("GetCertificationChain failed: %v", err)
+		}
+		if len(chain) != 2 || chain[0].CertificationID != "cert-1" || chain[1].CertificationID != "cert-2" {
+			t.Errorf("unexpected chain from %s: %v", certificationID, chain)
+		}
+	}
+	validity, err := s.IsCertificationValid(regulator, "cert-1")
+	if err != nil || validity.Valid || !strings.Contains(strings.Join(validity.Reasons, ";"), "superseded by cert-2") {
+		t.Errorf("superseded certification reported as %+v, %v", validity, err)
+	}
+}
+
+// TestRecordProcessingRequiresCompletedBatch checks a batch still in
+// production is refused unless early processing is enabled, and that an
+// admin bypass is marked on the record
+func TestRecordProcessingRequiresCompletedBatch(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", FarmerID: "farm-1", Quantity: 100, Status: "IN_PROGRESS"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := recordSlaughter(s, farm, "proc-1", 40, 60, ""); err == nil || !strings.Contains(err.Error(), "only COMPLETED batches can be processed") {
+		t.Fatalf("expected the IN_PROGRESS batch to be refused, got %v", err)
+	}
+	processing, err := recordSlaughter(s, ledgerContext(AdminOrgMSP, stub), "proc-1", 40, 60, "")
+	if err != nil {
+		t.Fatalf("admin RecordProcessing failed: %v", err)
+	}
+	if !processing.ReadinessOverride {
+		t.Errorf("admin processing of an unready batch was not marked as an override")
+	}
+
+	if _, err := s.SetFeatureFlag(ledgerContext(AdminOrgMSP, stub), FeatureAllowEarlyProcessing, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+	processing, err = recordSlaughter(s, farm, "proc-2", 10, 15, "")
+	if err != nil {
+		t.Fatalf("RecordProcessing with early processing enabled failed: %v", err)
+	}
+	if processing.ReadinessOverride {
+		t.Errorf("early processing was marked as an override")
+	}
+}
+
+// TestCertificationTypeRegistry checks certification types are normalized,
+// unknown ones are refused and the Admin can add new ones
+func TestCertificationTypeRegistry(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	admin := ledgerContext(AdminOrgMSP, stub)
+
+	certification, err := issueCertification(s, regulator, "cert-1", "proc-1", "Cold chain-compliant")
+	if err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if certification.CertType != "COLD_CHAIN_COMPLIANT" {
+		t.Errorf("type not normalized: %s", certification.CertType)
+	}
+	if _, err := issueCertification(s, regulator, "cert-2", "proc-1", "KOSHER"); err == nil || !strings.Contains(err.Error(), "allowed: HALAL") {
+		t.Errorf("expected an unknown type to be refused listing the allowed ones, got %v", err)
+	}
+
+	if _, err := s.AddCertificationType(regulator, "kosher"); err == nil {
+		t.Errorf("a regulator added a certification type")
+	}
+	if _, err := s.AddCertificationType(admin, "kosher"); err != nil {
+		t.Fatalf("AddCertificationType failed: %v", err)
+	}
+	if _, err := s.AddCertificationType(admin, "KOSHER"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected a duplicate type to be refused, got %v", err)
+	}
+	if _, err := issueCertification(s, regulator, "cert-2", "proc-1", "KOSHER"); err != nil {
+		t.Errorf("IssueCertification of an added type failed: %v", err)
+	}
+}
+
+// TestProductRecalls checks the recall lifecycle and that a product's
+// recalls span all its batches, newest first
+func TestProductRecalls(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", ProductID: "prod-1", Quantity: 50, Status: "COMPLETED"})
+	putAsset(t, stub, "recall-1", RecallAsset{DocType: "RecallAsset", RecallID: "recall-1", BatchID: "batch-2", ProductID: "prod-1", Status: "CLOSED", CreatedAt: "2025-01-05T00:00:00Z"})
+	putAsset(t, stub, "recall-9", RecallAsset{DocType: "RecallAsset", RecallID: "recall-9", BatchID: "batch-9", ProductID: "prod-9", Status: "OPEN", CreatedAt: "2025-02-07T00:00:00Z"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.InitiateRecall(ledgerContext(MinFarmOrgMSP, stub), "recall-2", "batch-1", "listeria"); err == nil {
+		t.Errorf("a farm initiated a recall")
+	}
+	recall, err := s.InitiateRecall(regulator, "recall-2", "batch-1", "listeria")
+	if err != nil {
+		t.Fatalf("InitiateRecall failed: %v", err)
+	}
+	if recall.Status != "OPEN" || recall.ProductID != "prod-1" {
+		t.Errorf("unexpected recall %+v", recall)
+	}
+
+	recalls, err := s.GetAllRecallsForProduct(regulator, "prod-1")
+	if err != nil {
+		t.Fatalf("GetAllRecallsForProduct failed: %v", err)
+	}
+	if len(recalls) != 2 || recalls[0].RecallID != "recall-2" || recalls[1].RecallID != "recall-1" {
+		t.Errorf("unexpected recalls %v", recalls)
+	}
+	if recalls, err := s.GetAllRecallsForProduct(regulator, "prod-unknown"); err != nil || len(recalls) != 0 {
+		t.Errorf("expected no recalls for an unknown product, got %v, %v", recalls, err)
+	}
+
+	closed, err := s.CloseRecall(regulator, "recall-2", "stock destroyed")
+	if err != nil {
+		t.Fatalf("CloseRecall failed: %v", err)
+	}
+	if closed.Status != "CLOSED" || closed.Resolution != "stock destroyed" || closed.ClosedAt == "" {
+		t.Errorf("closure not recorded: %+v", closed)
+	}
+	if _, err := s.CloseRecall(regulator, "recall-2", "again"); err == nil || !strings.Contains(err.Error(), "only OPEN recalls") {
+		t.Errorf("expected closing a closed recall to be refused, got %v", err)
+	}
+}
+
+// TestCertificationDocumentAnchor checks a document digest is anchored at
+// issuance, verified later and shown only as a flag in the public view
+func TestCertificationDocumentAnchor(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	digest := strings.Repeat("ab", 32)
+	issue := func(certificationID, optionsJSON string) (*CertificationAsset, error) {
+		return s.IssueCertification(regulator, certificationID, "proc-1", "", "HALAL", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", optionsJSON)
+	}
+
+	if _, err := issue("cert-1", `{"document_uri": "https://certs.example/1.pdf"}`); err == nil || !strings.Contains(err.Error(), "documentURI requires documentSHA256") {
+		t.Errorf("expected a URI without a digest to be refused, got %v", err)
+	}
+	if _, err := issue("cert-1", `{"document_sha256": "not-a-digest"}`); err == nil {
+		t.Errorf("accepted a malformed digest")
+	}
+	certification, err := issue("cert-1", `{"document_sha256": "`+strings.ToUpper(digest)+`", "document_uri": "https://certs.example/1.pdf"}`)
+	if err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if certification.DocumentSHA256 != digest || certification.DocumentURI != "https://certs.example/1.pdf" {
+		t.Errorf("document not anchored: %q %q", certification.DocumentSHA256, certification.DocumentURI)
+	}
+
+	check, err := s.VerifyCertificationDocument(regulator, "cert-1", digest)
+	if err != nil {
+		t.Fatalf("VerifyCertificationDocument failed: %v", err)
+	}
+	if !check.Anchored || !check.Match || !check.Validity.Valid {
+		t.Errorf("unexpected document check %+v", check)
+	}
+	check, err = s.VerifyCertificationDocument(regulator, "cert-1", strings.Repeat("cd", 32))
+	if err != nil || check.Match {
+		t.Errorf("a different digest matched: %+v, %v", check, err)
+	}
+
+	redacted := redactCertification(certification)
+	if redacted.DocumentSHA256 != digest || redacted.DocumentURI != "https://certs.example/1.pdf" {
+		t.Errorf("unexpected redacted view %+v", redacted)
+	}
+}
+
+// TestInspectionSchedules checks inspections of each target type are listed
+// earliest first, that completion records the outcome as requested and holds
+// a failed batch, and that open inspections can be cancelled or closed as no-shows
+func TestInspectionSchedules(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.ScheduleInspection(ledgerContext(MinFarmOrgMSP, stub), "insp-1", "BATCH", "batch-1", "2025-03-10", "x509::CN=test"); err == nil {
+		t.Errorf("a farm scheduled an inspection")
+	}
+	if _, err := s.ScheduleInspection(regulator, "insp-x", "WAREHOUSE", "batch-1", "2025-03-10", "x509::CN=test"); err == nil || !strings.Contains(err.Error(), "invalid targetType") {
+		t.Errorf("expected an unknown target type to be refused, got %v", err)
+	}
+	for _, schedule := range []struct{ id, targetType, targetID, due string }{
+		{"insp-1", "BATCH", "batch-1", "2025-03-10"},
+		{"insp-2", "batch", "batch-1", "2025-03-05"},
+		{"insp-3", "FACILITY", "fac-1", "2025-04-01"},
+		{"insp-4", "FARM", "farm-1", "2025-03-08"},
+	} {
+		if _, err := s.ScheduleInspection(regulator, schedule.id, schedule.targetType, schedule.targetID, schedule.due, "x509::CN=test"); err != nil {
+			t.Fatalf("ScheduleInspection %s failed: %v", schedule.id, err)
+		}
+	}
+
+	due, err := s.GetDueInspections(regulator, "2025-03-10")
+	if err != nil {
+		t.Fatalf("GetDueInspections failed: %v", err)
+	}
+	if len(due) != 3 || due[0].ScheduleID != "insp-2" || due[1].ScheduleID != "insp-4" || due[2].ScheduleID != "insp-1" {
+		t.Errorf("unexpected due inspections %v", due)
+	}
+	if due[0].TargetType != "BATCH" || due[0].BatchID != "batch-1" {
+		t.Errorf("batch target not normalised: %+v", due[0])
+	}
+
+	if _, err := s.CompleteInspection(regulator, "insp-2", "FAIL", "dirty", "", false); err == nil || !strings.Contains(err.Error(), "findings must be") {
+		t.Errorf("expected short findings on a failure to be refused, got %v", err)
+	}
+	if _, err := s.CompleteInspection(regulator, "insp-4", "PASS", "", "REGULATORY_RECORD", false); err == nil || !strings.Contains(err.Error(), "only batch inspections") {
+		t.Errorf("expected a farm inspection to refuse a regulatory record, got %v", err)
+	}
+	schedule, err := s.CompleteInspection(regulator, "insp-2", "PASS", "", "REGULATORY_RECORD", false)
+	if err != nil {
+		t.Fatalf("CompleteInspection failed: %v", err)
+	}
+	if schedule.Status != "COMPLETED" || schedule.Outcome != "PASS" || schedule.RegulatoryID != "insp-2~SANITARY_INSPECTION" {
+		t.Errorf("completion not recorded: %+v", schedule)
+	}
+	record, ok := stub.event["regulatory_record"].(map[string]interface{})
+	if !ok || record["regulatory_id"] != "insp-2~SANITARY_INSPECTION" || record["status"] != "APPROVED" {
+		t.Errorf("regulatory record not announced: %v", stub.event)
+	}
+
+	schedule, err = s.CompleteInspection(regulator, "insp-1", "FAIL", "Carcass contamination found", "LIFECYCLE_EVENT", true)
+	if err != nil {
+		t.Fatalf("CompleteInspection failed: %v", err)
+	}
+	if schedule.LifecycleEventID != "insp-1~INSPECTION" || !schedule.BatchOnHold {
+		t.Errorf("failed inspection not recorded: %+v", schedule)
+	}
+	if batch, err := s.GetBatch(regulator, "batch-1"); err != nil || batch.Status != "ON_HOLD" {
+		t.Errorf("failed inspection did not hold the batch: %+v, %v", batch, err)
+	}
+
+	if _, err := s.CancelInspection(regulator, "insp-3", ""); err == nil {
+		t.Errorf("expected a cancellation without a reason to be refused")
+	}
+	if schedule, err := s.CancelInspection(regulator, "insp-3", "facility closed for refit"); err != nil || schedule.Status != "CANCELLED" {
+		t.Errorf("CancelInspection failed: %+v, %v", schedule, err)
+	}
+	if schedule, err := s.RecordInspectionNoShow(regulator, "insp-4", "nobody on site"); err != nil || schedule.Status != "NO_SHOW" {
+		t.Errorf("RecordInspectionNoShow failed: %+v, %v", schedule, err)
+	}
+	if _, err := s.RecordInspectionNoShow(regulator, "insp-1", "nobody on site"); err == nil {
+		t.Errorf("a completed inspection was closed as a no-show")
+	}
+
+	if _, err := s.ScheduleInspection(regulator, "insp-5", "FACILITY", "fac-1", "2025-05-01", "x509::CN=test"); err != nil {
+		t.Fatalf("ScheduleInspection failed: %v", err)
+	}
+	upcoming, err := s.GetUpcomingInspections(regulator, "x509::CN=test")
+	if err != nil || len(upcoming) != 1 || upcoming[0].ScheduleID != "insp-5" {
+		t.Errorf("unexpected upcoming inspections %v, %v", upcoming, err)
+	}
+}
+
+// TestBatchScopedCertifications checks a certification is issued against
+// exactly one of a processing record and a batch, and that a batch holds the
+// required types through certifications of either scope
+func TestBatchScopedCertifications(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	issue := func(certificationID, processingID, batchID, certType string) (*CertificationAsset, error) {
+		return s.IssueCertification(regulator, certificationID, processingID, batchID, certType, "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "")
+	}
+
+	if _, err := issue("cert-1", "proc-1", "batch-1", "ORGANIC"); err == nil || !strings.Contains(err.Error(), "exactly one of processingID and batchID") {
+		t.Errorf("expected both scopes to be refused, got %v", err)
+	}
+	if _, err := issue("cert-1", "", "", "ORGANIC"); err == nil {
+		t.Errorf("issued a certification with no scope")
+	}
+
+	if _, err := s.SetRequiredCertifications(regulator, `["organic", "HALAL", "HACCP"]`); err != nil {
+		t.Fatalf("SetRequiredCertifications failed: %v", err)
+	}
+	if _, err := issue("cert-1", "", "batch-1", "ORGANIC"); err != nil {
+		t.Fatalf("batch-scoped IssueCertification failed: %v", err)
+	}
+	if _, err := issue("cert-2", "", "batch-1", "organic"); err == nil || !strings.Contains(err.Error(), "batch batch-1 already has active ORGANIC certification cert-1") {
+		t.Errorf("expected a second batch ORGANIC certification to be refused, got %v", err)
+	}
+	if _, err := issue("cert-2", "proc-1", "", "HALAL"); err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+
+	certifications, err := s.GetCertificationsByBatch(regulator, "batch-1")
+	if err != nil {
+		t.Fatalf("GetCertificationsByBatch failed: %v", err)
+	}
+	if len(certifications) != 2 {
+		t.Errorf("expected both scopes in the batch's certifications, got %v", certifications)
+	}
+	missing, err := s.GetMissingCertificationsForBatch(regulator, "batch-1")
+	if err != nil {
+		t.Fatalf("GetMissingCertificationsForBatch failed: %v", err)
+	}
+	if strings.Join(missing, ",") != "HACCP" {
+		t.Errorf("unexpected missing certifications %v", missing)
+	}
+}
+
+// TestGetLifecycleEventMetadata checks metadata keys are looked up as strings
+// or JSON, and that metadata which is not an object is an error
+func TestGetLifecycleEventMetadata(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "evt-1", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "evt-1", BatchID: "batch-1", EventType: "FEEDING", Metadata: `{"feed": "grower mash", "kg": 12.5}`})
+	putAsset(t, stub, "evt-2", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "evt-2", BatchID: "batch-1", EventType: "FEEDING"})
+	putAsset(t, stub, "evt-3", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "evt-3", BatchID: "batch-1", EventType: "FEEDING", Metadata: `["feed"]`})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	for _, c := range []struct {
+		eventID, key, value string
+		found               bool
+	}{
+		{"evt-1", "feed", "grower mash", true},
+		{"evt-1", "kg", "12.5", true},
+		{"evt-1", "water", "", false},
+		{"evt-2", "feed", "", false},
+	} {
+		result, err := s.GetLifecycleEventMetadata(ctx, c.eventID, c.key)
+		if err != nil {
+			t.Fatalf("GetLifecycleEventMetadata %s %s failed: %v", c.eventID, c.key, err)
+		}
+		if result.Value != c.value || result.Found != c.found {
+			t.Errorf("%s %s: got %q found %v", c.eventID, c.key, result.Value, result.Found)
+		}
+	}
+	if _, err := s.GetLifecycleEventMetadata(ctx, "evt-3", "feed"); err == nil || !strings.Contains(err.Error(), "invalid metadata JSON") {
+		t.Errorf("expected non-object metadata to be an error, got %v", err)
+	}
+	if _, err := s.GetLifecycleEventMetadata(ctx, "evt-9", "feed"); err == nil {
+		t.Errorf("looked up metadata of a missing event")
+	}
+}
+
+// TestCertificationSuspension checks the certification status machine:
+// suspension invalidates until reinstatement, and transitions with a
+// dedicated function are refused by UpdateCertificationStatus
+func TestCertificationSuspension(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-01-01"})
+	putAsset(t, stub, "cert-2", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-2", ProcessingID: "proc-2", CertType: "HALAL", Status: "REJECTED", IssuedDate: "2025-01-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.UpdateCertificationStatus(regulator, "cert-1", "SUSPENDED"); err == nil || !strings.Contains(err.Error(), "must be made with SuspendCertification") {
+		t.Errorf("expected suspension through UpdateCertificationStatus to be refused, got %v", err)
+	}
+	if _, err := s.UpdateCertificationStatus(regulator, "cert-2", "APPROVED"); err == nil || !strings.Contains(err.Error(), "invalid certification transition from REJECTED to APPROVED") {
+		t.Errorf("expected REJECTED to APPROVED to be refused, got %v", err)
+	}
+	if _, err := s.UpdateCertificationStatus(regulator, "cert-2", "PENDING"); err != nil {
+		t.Errorf("resubmitting a rejected certification failed: %v", err)
+	}
+
+	if _, err := s.ReinstateCertification(regulator, "cert-1", "cleared"); err == nil {
+		t.Errorf("reinstated an APPROVED certification")
+	}
+	suspended, err := s.SuspendCertification(regulator, "cert-1", "audit pending")
+	if err != nil {
+		t.Fatalf("SuspendCertification failed: %v", err)
+	}
+	if suspended.Status != "SUSPENDED" || suspended.SuspendedReason != "audit pending" || suspended.SuspendedBy != "x509::CN=test" {
+		t.Errorf("suspension not recorded: %+v", suspended)
+	}
+	validity, err := s.IsCertificationValid(regulator, "cert-1")
+	if err != nil || validity.Valid || strings.Join(validity.Reasons, ";") != "suspended: audit pending" {
+		t.Errorf("suspended certification reported as %+v, %v", validity, err)
+	}
+
+	reinstated, err := s.ReinstateCertification(regulator, "cert-1", "audit passed")
+	if err != nil {
+		t.Fatalf("ReinstateCertification failed: %v", err)
+	}
+	if reinstated.Status != "APPROVED" || reinstated.ReinstatedNotes != "audit passed" || reinstated.SuspendedReason != "audit pending" {
+		t.Errorf("reinstatement not recorded: %+v", reinstated)
+	}
+	if validity, err := s.IsCertificationValid(regulator, "cert-1"); err != nil || !validity.Valid {
+		t.Errorf("reinstated certification reported as %+v, %v", validity, err)
+	}
+}
+
+// TestGetTransportDurationStats checks only completed transports on the
+// route with a usable departure and arrival are summarized
+func TestGetTransportDurationStats(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	route := func(id, status, departure, arrival string) {
+		putAsset(t, stub, id, TransportAsset{DocType: "TransportAsset", TransportID: id, BatchID: "batch-1", OriginLocation: "Farm A", DestinationLocation: "Plant 1", Status: status, DepartureTime: departure, ArrivalTime: arrival})
+	}
+	route("tr-1", "COMPLETED", "2025-03-01T08:00:00Z", "2025-03-01T10:00:00Z")
+	route("tr-2", "COMPLETED", "2025-03-02T08:00:00Z", "2025-03-02T12:00:00Z")
+	route("tr-3", "COMPLETED", "2025-03-03T08:00:00Z", "2025-03-03T07:00:00Z")
+	route("tr-4", "IN_TRANSIT", "2025-03-04T08:00:00Z", "")
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	stats, err := s.GetTransportDurationStats(ctx, "Farm A", "Plant 1")
+	if err != nil {
+		t.Fatalf("GetTransportDurationStats failed: %v", err)
+	}
+	if stats.NoData || stats.SampleCount != 2 || stats.MinDurationSeconds != 7200 || stats.MaxDurationSeconds != 14400 || stats.AvgDurationSeconds != 10800 {
+		t.Errorf("unexpected duration stats %+v", stats)
+	}
+	if stats, err := s.GetTransportDurationStats(ctx, "Plant 1", "Farm A"); err != nil || !stats.NoData {
+		t.Errorf("expected no data for the reverse route, got %+v, %v", stats, err)
+	}
+}
+
+// TestRecordProcessingChecksYieldPerUnit checks a yield above the per-unit
+// maximum is refused, or stored and flagged when the feature flag is on
+func TestRecordProcessingChecksYieldPerUnit(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.SetThreshold(ledgerContext(RegulatorOrgMSP, stub), ThresholdMaxYieldPerUnit, 2); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	if _, err := recordSlaughter(s, farm, "proc-1", 50, 250, ""); err == nil || !strings.Contains(err.Error(), "2.50 kg per unit of batch batch-1") {
+		t.Fatalf("expected the implausible yield to be refused, got %v", err)
+	}
+	if _, err := recordSlaughter(s, farm, "proc-1", 50, 200, ""); err != nil {
+		t.Fatalf("RecordProcessing at the maximum failed: %v", err)
+	}
+
+	if _, err := s.SetFeatureFlag(ledgerContext(AdminOrgMSP, stub), FeatureFlagImplausibleYield, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+	processing, err := recordSlaughter(s, farm, "proc-2", 10, 250, "")
+	if err != nil {
+		t.Fatalf("RecordProcessing with flagging on failed: %v", err)
+	}
+	if !processing.YieldImplausible || stub.eventName != "ProcessingRecorded" || stub.event["yield_implausible"] != true {
+		t.Errorf("implausible yield not flagged: %v, event %s", processing.YieldImplausible, stub.eventName)
+	}
+	if _, err := recordSlaughter(s, farm, "proc-3", 120, 250, ""); err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if stub.eventName != "ProcessingRecorded" || stub.event["quantity_mismatch"] != true || stub.event["yield_implausible"] != true {
+		t.Errorf("expected ProcessingRecorded carrying both flags, got %s %v", stub.eventName, stub.event)
+	}
+}
+
+// TestCertificationIssuerBinding checks a regulator issues as themselves and
+// an admin only on behalf of a named issuer
+func TestCertificationIssuerBinding(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	admin := ledgerContext(AdminOrgMSP, stub)
+	issue := func(ctx contractapi.TransactionContextInterface, certificationID, certType, issuerID string) (*CertificationAsset, error) {
+		return s.IssueCertification(ctx, certificationID, "proc-1", "", certType, "2025-03-01", "2026-03-01", issuerID, "")
+	}
+
+	if _, err := issue(regulator, "cert-1", "HALAL", "regulator-9"); err == nil || !strings.Contains(err.Error(), "does not match the caller's enrollment ID") {
+		t.Errorf("expected a foreign issuerID to be refused, got %v", err)
+	}
+	certification, err := issue(regulator, "cert-1", "HALAL", "")
+	if err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if certification.IssuerID != "x509::CN=test" || certification.IssuerMSP != RegulatorOrgMSP || certification.IssuedOnBehalfBy != "" {
+		t.Errorf("issuer not bound to the caller: %+v", certification)
+	}
+
+	if _, err := issue(admin, "cert-2", "HACCP", ""); err == nil || !strings.Contains(err.Error(), "must name the issuer") {
+		t.Errorf("expected an admin without an issuer to be refused, got %v", err)
+	}
+	delegated, err := issue(admin, "cert-2", "HACCP", "regulator-9")
+	if err != nil {
+		t.Fatalf("admin IssueCertification failed: %v", err)
+	}
+	if delegated.IssuerID != "regulator-9" || delegated.IssuedOnBehalfBy != "x509::CN=test" || delegated.IssuerMSP != AdminOrgMSP {
+		t.Errorf("delegation not recorded: %+v", delegated)
+	}
+
+	byIssuer, err := s.GetCertificationsByIssuer(regulator, "regulator-9")
+	if err != nil || len(byIssuer) != 1 || byIssuer[0].CertificationID != "cert-2" {
+		t.Errorf("unexpected certifications by issuer %v, %v", byIssuer, err)
+	}
+}
+
+// TestGetBatchPassport checks the passport resolves a QR code to the public
+// view, with cold chain compliance and certification validity summarized,
+// and only for the batch's verification token
+func TestGetBatchPassport(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", FarmerID: "farm-1", QRCode: "QR-1", Location: "Valley Farm", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "COMPLETED"})
+	putAsset(t, stub, "csa-1", ColdStorageAssignmentAsset{DocType: "ColdStorageAssignmentAsset", AssignmentID: "csa-1", BatchID: "batch-1"})
+	putAsset(t, stub, "log-1", TemperatureLogAsset{DocType: "TemperatureLogAsset", LogID: "log-1", TransportID: "tr-1", Temperature: 3})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", BatchID: "batch-1", CertType: "ORGANIC", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	stub.privateData[BatchTokenSecretCollection] = map[string][]byte{BatchTokenSecretKey: []byte(strings.Repeat("k", MinBatchTokenSecretLength))}
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	token, err := s.GetBatchLabelToken(farm, "batch-1")
+	if err != nil {
+		t.Fatalf("GetBatchLabelToken failed: %v", err)
+	}
+	if _, err := s.GetBatchPassport(farm, "QR-1", strings.Repeat("0", len(token))); err == nil || !strings.Contains(err.Error(), "token") {
+		t.Errorf("expected a forged token to be refused, got %v", err)
+	}
+	if _, err := s.SetFarmerDisplayName(ledgerContext(AdminOrgMSP, stub), "farm-1", "Valley Farm Co-op"); err != nil {
+		t.Fatalf("SetFarmerDisplayName failed: %v", err)
+	}
+	passport, err := s.GetBatchPassport(farm, "QR-1", token)
+	if err != nil {
+		t.Fatalf("GetBatchPassport failed: %v", err)
+	}
+	want := BatchPassport{ProductName: "Broiler", Origin: "Valley Farm", Farmer: "Valley Farm Co-op", ProductionStatus: "COMPLETED", ColdChainCompliant: true, CertificationsValid: true}
+	if *passport != want {
+		t.Errorf("unexpected passport %+v", passport)
+	}
+
+	putAsset(t, stub, "log-2", TemperatureLogAsset{DocType: "TemperatureLogAsset", LogID: "log-2", StorageAssignmentID: "csa-1", Temperature: 12, IsViolation: true})
+	if _, err := s.SetRequiredCertifications(ledgerContext(RegulatorOrgMSP, stub), `["HALAL"]`); err != nil {
+		t.Fatalf("SetRequiredCertifications failed: %v", err)
+	}
+	passport, err = s.GetBatchPassport(farm, "QR-1", token)
+	if err != nil {
+		t.Fatalf("GetBatchPassport failed: %v", err)
+	}
+	if passport.ColdChainCompliant || passport.CertificationsValid {
+		t.Errorf("expected the cold storage violation and missing HALAL to show, got %+v", passport)
+	}
+	if _, err := s.GetBatchPassport(farm, "QR-unknown", token); err == nil {
+		t.Errorf("resolved an unknown QR code")
+	}
+}
+
+// TestParallelCertifications checks force_parallel issues alongside an
+// active certification without revoking or superseding it
+func TestParallelCertifications(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", BatchID: "batch-1", CertType: "ORGANIC", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	issue := func(certificationID, optionsJSON string) (*CertificationAsset, error) {
+		return s.IssueCertification(regulator, certificationID, "", "batch-1", "ORGANIC", "2025-03-01", "2026-03-01", "", optionsJSON)
+	}
+
+	if _, err := issue("cert-2", ""); err == nil || !strings.Contains(err.Error(), "use RenewCertification to extend it") {
+		t.Errorf("expected a duplicate to point to RenewCertification, got %v", err)
+	}
+	parallel, err := issue("cert-2", `{"force_parallel": true}`)
+	if err != nil {
+		t.Fatalf("parallel IssueCertification failed: %v", err)
+	}
+	if parallel.ParallelTo != "cert-1" || stub.event["parallel_to_certification_id"] != "cert-1" {
+		t.Errorf("parallel link not recorded: %q, event %v", parallel.ParallelTo, stub.event)
+	}
+	for _, certificationID := range []string{"cert-1", "cert-2"} {
+		validity, err := s.IsCertificationValid(regulator, certificationID)
+		if err != nil || !validity.Valid {
+			t.Errorf("%s reported as %+v, %v", certificationID, validity, err)
+		}
+	}
+}
+
+// TestGetStaleBatches checks overdue batches in production are listed longest
+// overdue first, with the grace period applied
+func TestGetStaleBatches(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ExpectedEndDate: "2025-02-20", Status: "IN_PROGRESS"})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", ExpectedEndDate: "2025-02-01", Status: "ON_HOLD"})
+	putAsset(t, stub, "batch-3", BatchAsset{DocType: "BatchAsset", BatchID: "batch-3", ExpectedEndDate: "2025-02-25", Status: "CREATED"})
+	putAsset(t, stub, "batch-4", BatchAsset{DocType: "BatchAsset", BatchID: "batch-4", ExpectedEndDate: "2025-01-01", Status: "COMPLETED"})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	stale, err := s.GetStaleBatches(ctx, "2025-03-01", 5)
+	if err != nil {
+		t.Fatalf("GetStaleBatches failed: %v", err)
+	}
+	var ids []string
+	for _, batch := range stale {
+		ids = append(ids, batch.BatchID)
+	}
+	if strings.Join(ids, ",") != "batch-2,batch-1" {
+		t.Errorf("unexpected stale batches %v", ids)
+	}
+	if _, err := s.GetStaleBatches(ctx, "2025-03-01", -1); err == nil {
+		t.Errorf("a negative grace period was accepted")
+	}
+}
+
+// TestCertificationPrerequisites checks issuance lists every unmet
+// prerequisite of the type and succeeds once all are in place
+func TestCertificationPrerequisites(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetCertificationPrerequisites(ledgerContext(MinFarmOrgMSP, stub), "HALAL", `{}`); err == nil {
+		t.Errorf("a farm set certification prerequisites")
+	}
+	if _, err := s.SetCertificationPrerequisites(regulator, "HALAL", `{"required_certifications": ["halal"]}`); err == nil || !strings.Contains(err.Error(), "cannot require itself") {
+		t.Errorf("expected a self-requirement to be refused, got %v", err)
+	}
+	prerequisites, err := s.SetCertificationPrerequisites(regulator, "halal", `{"required_lab_tests": ["listeria"], "required_haccp_checkpoints": ["Chilling"], "required_certifications": ["organic"]}`)
+	if err != nil {
+		t.Fatalf("SetCertificationPrerequisites failed: %v", err)
+	}
+	if prerequisites.CertType != "HALAL" || prerequisites.RequiredLabTests[0] != "LISTERIA" || prerequisites.RequiredCertifications[0] != "ORGANIC" || stub.eventName != "CertificationPrerequisitesUpdated" {
+		t.Errorf("prerequisites not normalized: %+v", prerequisites)
+	}
+
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HALAL"); err == nil || !strings.Contains(err.Error(), "passing LISTERIA lab test, HACCP checkpoint Chilling, valid ORGANIC certification") {
+		t.Errorf("expected every unmet prerequisite to be listed, got %v", err)
+	}
+
+	putAsset(t, stub, "lab-1", LabTestAsset{DocType: "LabTestAsset", TestID: "lab-1", ProcessingID: "proc-1", BatchID: "batch-1", TestType: "LISTERIA", Passed: true})
+	if _, err := s.RecordHACCPCheckpoint(ledgerContext(ProcessorOrgMSP, stub), "ccp-1", "proc-1", "Chilling", 3, "C", true, ""); err != nil {
+		t.Fatalf("RecordHACCPCheckpoint failed: %v", err)
+	}
+	putAsset(t, stub, "cert-organic", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-organic", BatchID: "batch-1", CertType: "ORGANIC", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HALAL"); err != nil {
+		t.Errorf("IssueCertification with prerequisites met failed: %v", err)
+	}
+
+	all, err := s.GetAllCertificationPrerequisites(regulator)
+	if err != nil || len(all) != 1 || all[0].CertType != "HALAL" {
+		t.Errorf("unexpected prerequisites %v, %v", all, err)
+	}
+}
+
+// TestBatchExpireAndNotify checks certifications expire against the
+// transaction timestamp, at most limit per call
+func TestBatchExpireAndNotify(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for id, expiryDate := range map[string]string{
+		"cert-1": "2025-02-01",
+		"cert-2": "2025-02-15T00:00:00Z",
+		"cert-3": "2099-01-01",
+	} {
+		putAsset(t, stub, id, CertificationAsset{DocType: "CertificationAsset", CertificationID: id, CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: expiryDate})
+	}
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.BatchExpireAndNotify(ledgerContext(MinFarmOrgMSP, stub), 10); err == nil {
+		t.Errorf("farm caller ran the expiry batch")
+	}
+	if _, err := s.BatchExpireAndNotify(regulator, 0); err == nil {
+		t.Errorf("accepted a zero limit")
+	}
+
+	count, err := s.BatchExpireAndNotify(regulator, 1)
+	if err != nil {
+		t.Fatalf("BatchExpireAndNotify failed: %v", err)
+	}
+	if count != 1 || stub.eventName != "CertificationsExpiredBatch" || stub.event["has_more"] != true {
+		t.Errorf("expected one expiry with more due, got %d and %v", count, stub.event)
+	}
+	if count, err = s.BatchExpireAndNotify(regulator, 10); err != nil || count != 1 {
+		t.Fatalf("expected the second certification to expire, got %d, %v", count, err)
+	}
+
+	var future CertificationAsset
+	if err := json.Unmarshal(stub.state["cert-3"], &future); err != nil {
+		t.Fatalf("failed to unmarshal certification: %v", err)
+	}
+	if future.Status != "APPROVED" {
+		t.Errorf("unexpired certification moved to %s", future.Status)
+	}
+}
+
+// TestCertificationVerifications checks verifications are counted per
+// channel under their own key and surfaced by GetCertification
+func TestCertificationVerifications(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	consumer := ledgerContext("ConsumerMSP", stub)
+
+	for _, channel := range []string{"qr_scan", "qr_scan", "web"} {
+		if _, err := s.RecordCertificationVerification(consumer, "cert-1", channel); err != nil {
+			t.Fatalf("RecordCertificationVerification failed: %v", err)
+		}
+	}
+	if _, err := s.RecordCertificationVerification(consumer, "cert-missing", "web"); err == nil {
+		t.Errorf("a verification of an unknown certification was counted")
+	}
+	if _, err := s.RecordCertificationVerification(consumer, "cert-1", ""); err == nil {
+		t.Errorf("a verification without a channel was counted")
+	}
+
+	counter, err := s.RecordCertificationVerification(consumer, "cert-1", "web")
+	if err != nil {
+		t.Fatalf("RecordCertificationVerification failed: %v", err)
+	}
+	if counter.VerificationCount != 4 || counter.ChannelCounts["qr_scan"] != 2 || counter.ChannelCounts["web"] != 2 || counter.LastChannel != "web" {
+		t.Errorf("unexpected verification counter %+v", counter)
+	}
+
+	certification, err := s.GetCertification(consumer, "cert-1")
+	if err != nil {
+		t.Fatalf("GetCertification failed: %v", err)
+	}
+	if certification.VerificationCount != 4 || certification.LastVerifiedAt == "" {
+		t.Errorf("verification count not surfaced: %d %q", certification.VerificationCount, certification.LastVerifiedAt)
+	}
+	var stored CertificationAsset
+	if err := json.Unmarshal(stub.state["cert-1"], &stored); err != nil {
+		t.Fatalf("failed to unmarshal certification: %v", err)
+	}
+	if stored.VerificationCount != 0 {
+		t.Errorf("verification count written onto the certification document")
+	}
+}
+
+// TestGetProductWithBatchSummary checks the batch rollup counts every
+// status and totals the quantity of the product's batches only
+func TestGetProductWithBatchSummary(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", ProductID: "prod-1", Quantity: 50, Status: "CANCELLED"})
+	putAsset(t, stub, "batch-9", BatchAsset{DocType: "BatchAsset", BatchID: "batch-9", ProductID: "prod-9", Quantity: 70, Status: "COMPLETED"})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	summary, err := s.GetProductWithBatchSummary(ctx, "prod-1")
+	if err != nil {
+		t.Fatalf("GetProductWithBatchSummary failed: %v", err)
+	}
+	if summary.Product.ProductID != "prod-1" || summary.BatchCount != 2 || summary.TotalQuantity != 150 {
+		t.Errorf("unexpected product summary %+v", summary)
+	}
+	if summary.BatchesByStatus["COMPLETED"] != 1 || summary.BatchesByStatus["CANCELLED"] != 1 || len(summary.BatchesByStatus) != len(batchStatuses) {
+		t.Errorf("unexpected batch status counts %v", summary.BatchesByStatus)
+	}
+	if _, err := s.GetProductWithBatchSummary(ctx, "prod-unknown"); err == nil {
+		t.Errorf("expected an unknown product to be refused")
+	}
+}
+
+// TestCheckAndExpireCertifications checks the sweep expires past-due
+// certifications in ID order, at most limit per call, and that an expired
+// certification is reported invalid yet can still be renewed
+func TestCheckAndExpireCertifications(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-01-01"})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", ProcessingID: "proc-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2024-02-01", ExpiryDate: "2025-02-01"})
+	putAsset(t, stub, "cert-2", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-2", BatchID: "batch-1", CertType: "ORGANIC", Status: "APPROVED", IssuedDate: "2024-02-15", ExpiryDate: "2025-02-15"})
+	putAsset(t, stub, "cert-3", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-3", BatchID: "batch-1", CertType: "GAP", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2099-01-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.CheckAndExpireCertifications(ledgerContext(MinFarmOrgMSP, stub), 10); err == nil {
+		t.Errorf("a farm ran the expiry sweep")
+	}
+	if _, err := s.CheckAndExpireCertifications(regulator, MaxPageSize+1); err == nil {
+		t.Errorf("accepted a limit above the page size")
+	}
+
+	sweep, err := s.CheckAndExpireCertifications(regulator, 1)
+	if err != nil {
+		t.Fatalf("CheckAndExpireCertifications failed: %v", err)
+	}
+	if sweep.ExpiredCount != 1 || !sweep.HasMore || sweep.Certifications[0].CertificationID != "cert-1" || sweep.Certifications[0].BatchID != "batch-1" {
+		t.Errorf("unexpected first sweep %+v", sweep)
+	}
+	if stub.eventName != "CertificationExpired" {
+		t.Errorf("expected a CertificationExpired event, got %s", stub.eventName)
+	}
+	sweep, err = s.CheckAndExpireCertifications(regulator, 10)
+	if err != nil || sweep.ExpiredCount != 1 || sweep.HasMore || sweep.Certifications[0].CertificationID != "cert-2" {
+		t.Errorf("unexpected second sweep %+v, %v", sweep, err)
+	}
+
+	validity, err := s.IsCertificationValid(regulator, "cert-1")
+	if err != nil || validity.Valid || !strings.Contains(strings.Join(validity.Reasons, ";"), "marked expired") {
+		t.Errorf("expected the expired status to be reported, got %+v, %v", validity, err)
+	}
+	if _, err := s.RenewCertification(regulator, "cert-1b", "cert-1", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "", ""); err != nil {
+		t.Errorf("renewing an expired certification failed: %v", err)
+	}
+}
+
+// TestRequireMonotonicTempLogs checks out-of-order readings are refused only
+// with the flag on, and that equal timestamps are allowed
+func TestRequireMonotonicTempLogs(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "IN_TRANSIT"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.AddTemperatureLog(farm, "log-1", "tr-1", 4, "2025-03-01T10:00:00Z", "A1"); err != nil {
+		t.Fatalf("AddTemperatureLog failed: %v", err)
+	}
+	if _, err := s.AddTemperatureLog(farm, "log-2", "tr-1", 4, "2025-03-01T09:00:00Z", "A1"); err != nil {
+		t.Errorf("an earlier reading was refused with the flag off: %v", err)
+	}
+
+	if _, err := s.SetFeatureFlag(ledgerContext(AdminOrgMSP, stub), FeatureRequireMonotonicTempLogs, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+	if _, err := s.AddTemperatureLog(farm, "log-3", "tr-1", 4, "2025-03-01T09:30:00Z", "A1"); err == nil || !strings.HasPrefix(err.Error(), "timestamp out of order") || !strings.Contains(err.Error(), "log-1") {
+		t.Errorf("expected an out-of-order reading to be refused, got %v", err)
+	}
+	if _, err := s.AddTemperatureLog(farm, "log-3", "tr-1", 4, "not a time", "A1"); err == nil {
+		t.Errorf("an unparseable timestamp was accepted")
+	}
+	if _, err := s.AddTemperatureLog(farm, "log-3", "tr-1", 4, "2025-03-01T10:00:00Z", "A1"); err != nil {
+		t.Errorf("a reading at the latest timestamp was refused: %v", err)
+	}
+}
+
+// TestCertifierAccreditation checks an accredited certifier MSP may issue and
+// renew its types only, and that an empty list withdraws the accreditation
+func TestCertifierAccreditation(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	certifier := ledgerContext("HalalBodyMSP", stub)
+
+	if _, err := s.SetCertifierAccreditation(certifier, "HalalBodyMSP", `["HALAL"]`); err == nil {
+		t.Errorf("a certifier accredited itself")
+	}
+	if _, err := s.SetCertifierAccreditation(regulator, AdminOrgMSP, `["HALAL"]`); err == nil {
+		t.Errorf("accredited the Admin MSP")
+	}
+	if _, err := s.SetCertifierAccreditation(regulator, "HalalBodyMSP", `["NO_SUCH_TYPE"]`); err == nil {
+		t.Errorf("accredited an unknown certification type")
+	}
+	if _, err := issueCertification(s, certifier, "cert-1", "proc-1", "HALAL"); err == nil || !strings.Contains(err.Error(), "or an accredited certifier") {
+		t.Errorf("expected an unaccredited MSP to be refused, got %v", err)
+	}
+
+	config, err := s.SetCertifierAccreditation(regulator, "HalalBodyMSP", `["halal", "HALAL"]`)
+	if err != nil {
+		t.Fatalf("SetCertifierAccreditation failed: %v", err)
+	}
+	if accredited := config.CertifierAccreditations["HalalBodyMSP"]; len(accredited) != 1 || accredited[0] != "HALAL" {
+		t.Errorf("unexpected accreditation %v", accredited)
+	}
+	if _, err := issueCertification(s, certifier, "cert-1", "proc-1", "HALAL"); err != nil {
+		t.Fatalf("accredited IssueCertification failed: %v", err)
+	}
+	if _, err := issueCertification(s, certifier, "cert-2", "proc-1", "ORGANIC"); err == nil || !strings.Contains(err.Error(), "not accredited to issue ORGANIC certifications (accredited for: HALAL)") {
+		t.Errorf("expected issuance outside the accreditation to be refused, got %v", err)
+	}
+	if _, err := s.RevokeCertification(certifier, "cert-1", "withdrawn"); err == nil {
+		t.Errorf("a certifier revoked a certification")
+	}
+
+	if _, err := s.SetCertifierAccreditation(regulator, "HalalBodyMSP", `[]`); err != nil {
+		t.Fatalf("SetCertifierAccreditation failed: %v", err)
+	}
+	if _, err := s.RenewCertification(certifier, "cert-1b", "cert-1", "2026-03-01T00:00:00Z", "2027-03-01T00:00:00Z", "", "", ""); err == nil {
+		t.Errorf("a withdrawn certifier renewed a certification")
+	}
+}
+
+// TestGetBatchAlerts checks every kind of warning is reported, critical
+// alerts first
+func TestGetBatchAlerts(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, SystemConfigKey, SystemConfigAsset{DocType: "SystemConfigAsset", RequiredCertTypes: []string{"ORGANIC", "HALAL"}})
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true})
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", Quantity: 100, ExpectedEndDate: "2025-02-01", Status: "IN_PROGRESS"})
+	putAsset(t, stub, "recall-1", RecallAsset{DocType: "RecallAsset", RecallID: "recall-1", BatchID: "batch-1", Reason: "salmonella", Status: "OPEN"})
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "HEALTH_CERTIFICATE", Status: "REJECTED", RejectionReason: "missing vet signature"})
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "COMPLETED"})
+	putAsset(t, stub, "log-1", TemperatureLogAsset{DocType: "TemperatureLogAsset", LogID: "log-1", TransportID: "tr-1", Temperature: 12, IsViolation: true})
+	putAsset(t, stub, "log-2", TemperatureLogAsset{DocType: "TemperatureLogAsset", LogID: "log-2", TransportID: "tr-1", Temperature: 4})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", BatchID: "batch-1", CertType: "ORGANIC", Status: "APPROVED", IssuedDate: "2024-01-01", ExpiryDate: "2025-01-01"})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	alerts, err := s.GetBatchAlerts(ctx, "batch-1", "2025-03-01")
+	if err != nil {
+		t.Fatalf("GetBatchAlerts failed: %v", err)
+	}
+	var codes []string
+	for _, alert := range alerts {
+		codes = append(codes, alert.Severity+":"+alert.Code)
+	}
+	want := "CRITICAL:RECALL_OPEN,CRITICAL:REGULATORY_REJECTED,WARNING:TEMPERATURE_VIOLATION,WARNING:CERTIFICATION_EXPIRED,WARNING:CERTIFICATION_MISSING,WARNING:COMPLETION_OVERDUE"
+	if strings.Join(codes, ",") != want {
+		t.Errorf("unexpected alerts %v", codes)
+	}
+
+	alerts, err = s.GetBatchAlerts(ctx, "batch-1", "2024-06-01")
+	if err != nil {
+		t.Fatalf("GetBatchAlerts failed: %v", err)
+	}
+	for _, alert := range alerts {
+		if alert.Code == "CERTIFICATION_EXPIRED" || alert.Code == "COMPLETION_OVERDUE" {
+			t.Errorf("unexpected %s alert before the dates passed", alert.Code)
+		}
+	}
+}
+
+// derivationStub holds two COMPLETED batches of one product, each with an
+// APPROVED ORGANIC certification, and a batch already using QR code QR-used
+func derivationStub(t *testing.T) *memStub {
+	stub := newMemStub()
+	for _, id := range []string{"batch-1", "batch-2"} {
+		putAsset(t, stub, id, BatchAsset{DocType: "BatchAsset", BatchID: id, ProductID: "prod-1", BatchNumber: "B-" + id, Quantity: 100, Status: "COMPLETED"})
+		putAsset(t, stub, "cert-"+id, CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-" + id, BatchID: id, CertType: "ORGANIC", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	}
+	putAsset(t, stub, "batch-x", BatchAsset{DocType: "BatchAsset", BatchID: "batch-x", ProductID: "prod-1", BatchNumber: "B-x", QRCode: "QR-used", Quantity: 10, Status: "CANCELLED"})
+	return stub
+}
+
+func storedCertification(t *testing.T, stub *memStub, certificationID string) CertificationAsset {
+	t.Helper()
+	var certification CertificationAsset
+	if err := json.Unmarshal(stub.state[certificationID], &certification); err != nil {
+		t.Fatalf("failed to unmarshal certification %s: %v", certificationID, err)
+	}
+	return certification
+}
+
+// TestSplitBatchCarriesCertifications checks a split must account for the
+// whole quantity, retires the parent and copies its certifications to every
+// child, and that revoking the source revokes the copies
+func TestSplitBatchCarriesCertifications(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := derivationStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	split := func(quantityB int) ([]*BatchAsset, error) {
+		return s.SplitBatch(farm, "batch-1", fmt.Sprintf(`[{"batch_id": "batch-1a", "batch_number": "B-1A", "quantity": 60, "qr_code": "QR-1a"}, {"batch_id": "batch-1b", "batch_number": "B-1B", "quantity": %d, "qr_code": "QR-1b"}]`, quantityB))
+	}
+
+	if _, err := s.SplitBatch(farm, "batch-1", `[{"batch_id": "batch-1a", "batch_number": "B-1A", "quantity": 100}]`); err == nil || !strings.Contains(err.Error(), "at least 2 parts") {
+		t.Errorf("expected a single part to be refused, got %v", err)
+	}
+	if _, err := split(30); err == nil || !strings.Contains(err.Error(), "parts total 90 units but batch batch-1 has 100") {
+		t.Errorf("expected a short split to be refused, got %v", err)
+	}
+	children, err := split(40)
+	if err != nil {
+		t.Fatalf("SplitBatch failed: %v", err)
+	}
+	if len(children) != 2 || children[0].Quantity != 60 || children[1].Quantity != 40 || children[0].ParentBatchIDs[0] != "batch-1" || children[1].Status != "COMPLETED" {
+		t.Fatalf("unexpected children: %+v", children)
+	}
+	parent, err := s.GetBatch(farm, "batch-1")
+	if err != nil || parent.Status != "SPLIT" {
+		t.Errorf("parent not retired: %+v, %v", parent, err)
+	}
+	for _, id := range []string{"batch-1a~ORGANIC", "batch-1b~ORGANIC"} {
+		if derived := storedCertification(t, stub, id); derived.Status != "APPROVED" || len(derived.SourceCertificationIDs) != 1 || derived.SourceCertificationIDs[0] != "cert-batch-1" {
+			t.Errorf("certification %s not carried: %+v", id, derived)
+		}
+	}
+	if _, err := split(40); err == nil {
+		t.Errorf("a SPLIT batch was split again")
+	}
+
+	if _, err := s.RevokeCertification(regulator, "cert-batch-1", "fraudulent audit"); err != nil {
+		t.Fatalf("RevokeCertification failed: %v", err)
+	}
+	if derived := storedCertification(t, stub, "batch-1b~ORGANIC"); derived.Status != "REVOKED" {
+		t.Errorf("derived certification did not follow the revocation: %s", derived.Status)
+	}
+}
+
+// TestMergeBatchesCarriesSharedCertifications checks a merge of one
+// product's batches carries only the types every source holds
+func TestMergeBatchesCarriesSharedCertifications(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := derivationStub(t)
+	putAsset(t, stub, "cert-halal-2", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-halal-2", BatchID: "batch-2", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	putAsset(t, stub, "batch-3", BatchAsset{DocType: "BatchAsset", BatchID: "batch-3", ProductID: "prod-2", BatchNumber: "B-3", Quantity: 10, Status: "COMPLETED"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.MergeBatches(farm, "batch-m", "B-M", `["batch-1", "batch-3"]`, "QR-m"); err == nil || !strings.Contains(err.Error(), "only batches of one product can be merged") {
+		t.Errorf("expected batches of different products to be refused, got %v", err)
+	}
+	target, err := s.MergeBatches(farm, "batch-m", "B-M", `["batch-1", "batch-2"]`, "QR-m")
+	if err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+	if target.Quantity != 200 || target.ProductID != "prod-1" || len(target.ParentBatchIDs) != 2 {
+		t.Errorf("unexpected merge target: %+v", target)
+	}
+	if derived := storedCertification(t, stub, "batch-m~ORGANIC"); derived.Status != "APPROVED" || len(derived.SourceCertificationIDs) != 2 {
+		t.Errorf("certification not carried: %+v", derived)
+	}
+	if _, ok := stub.state["batch-m~HALAL"]; ok {
+		t.Errorf("a type held by one source was carried")
+	}
+	if notCarried, _ := stub.event["not_carried_cert_types"].([]interface{}); len(notCarried) != 1 || notCarried[0] != "HALAL" {
+		t.Errorf("unexpected not carried types %v", stub.event["not_carried_cert_types"])
+	}
+	for _, id := range []string{"batch-1", "batch-2"} {
+		if source, err := s.GetBatch(farm, id); err != nil || source.Status != "MERGED" {
+			t.Errorf("source %s not retired: %+v, %v", id, source, err)
+		}
+	}
+}
+
+// TestPurgeCancelledBatch checks only CANCELLED batches without processing
+// or certifications are purged, together with their events, transports,
+// temperature logs and batch number
+func TestPurgeCancelledBatch(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", BatchNumber: "B-1", Quantity: 100, Status: "CANCELLED"})
+	putAsset(t, stub, "batch_number~B-1", map[string]string{"batch_id": "batch-1"})
+	putAsset(t, stub, "event-1", LifecycleEventAsset{DocType: "LifecycleEventAsset", EventID: "event-1", BatchID: "batch-1", EventType: "FEEDING", EventDate: "2025-01-10"})
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "COMPLETED"})
+	putAsset(t, stub, "log-1", TemperatureLogAsset{DocType: "TemperatureLogAsset", LogID: "log-1", TransportID: "tr-1", Temperature: 4})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", ProductID: "prod-1", BatchNumber: "B-2", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "batch-3", BatchAsset{DocType: "BatchAsset", BatchID: "batch-3", ProductID: "prod-1", BatchNumber: "B-3", Quantity: 100, Status: "CANCELLED"})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", BatchID: "batch-3", CertType: "ORGANIC", Status: "APPROVED"})
+	admin := ledgerContext(AdminOrgMSP, stub)
+
+	if err := s.PurgeCancelledBatch(ledgerContext(RegulatorOrgMSP, stub), "batch-1"); err == nil {
+		t.Errorf("a regulator purged a batch")
+	}
+	if err := s.PurgeCancelledBatch(admin, "batch-2"); err == nil || !strings.Contains(err.Error(), "only CANCELLED batches") {
+		t.Errorf("expected a COMPLETED batch to be refused, got %v", err)
+	}
+	if err := s.PurgeCancelledBatch(admin, "batch-3"); err == nil || !strings.Contains(err.Error(), "certifications and cannot be purged") {
+		t.Errorf("expected a certified batch to be refused, got %v", err)
+	}
+
+	if err := s.PurgeCancelledBatch(admin, "batch-1"); err != nil {
+		t.Fatalf("PurgeCancelledBatch failed: %v", err)
+	}
+	for _, key := range []string{"batch-1", "batch_number~B-1", "event-1", "tr-1", "log-1"} {
+		if _, ok := stub.state[key]; ok {
+			t.Errorf("%s was not deleted", key)
+		}
+	}
+	if stub.eventName != "BatchPurged" || stub.event["temperature_logs_deleted"] != float64(1) {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+}
+
+// TestCertificationStatusHistory checks each status change is appended to
+// the history with its actor, reason and transaction
+func TestCertificationStatusHistory(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := derivationStub(t)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SuspendCertification(regulator, "cert-batch-1", "audit pending"); err != nil {
+		t.Fatalf("SuspendCertification failed: %v", err)
+	}
+	if _, err := s.ReinstateCertification(regulator, "cert-batch-1", "audit passed"); err != nil {
+		t.Fatalf("ReinstateCertification failed: %v", err)
+	}
+	if _, err := s.RevokeCertification(regulator, "cert-batch-1", "fraudulent feed records"); err != nil {
+		t.Fatalf("RevokeCertification failed: %v", err)
+	}
+
+	history, err := s.GetCertificationStatusHistory(regulator, "cert-batch-1")
+	if err != nil {
+		t.Fatalf("GetCertificationStatusHistory failed: %v", err)
+	}
+	var changes []string
+	for _, change := range history {
+		changes = append(changes, change.OldStatus+">"+change.NewStatus+":"+change.Reason)
+	}
+	if strings.Join(changes, ",") != "APPROVED>SUSPENDED:audit pending,SUSPENDED>APPROVED:audit passed,APPROVED>REVOKED:fraudulent feed records" {
+		t.Errorf("unexpected status history %v", changes)
+	}
+	if last := history[len(history)-1]; last.TxID != "tx-1" || last.Actor == "" || last.ChangedAt == "" {
+		t.Errorf("change not attributed: %+v", last)
+	}
+	if history, err := s.GetCertificationStatusHistory(regulator, "cert-batch-2"); err != nil || len(history) != 0 {
+		t.Errorf("expected an empty history for an unchanged certification, got %v, %v", history, err)
+	}
+}
+
+// TestGetTopFarmersByVolume checks farmers are ranked by total quantity,
+// skipping split and merged batches, with display names attached
+func TestGetTopFarmersByVolume(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", FarmerID: "farmer-1", Quantity: 50, Status: "COMPLETED"})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", FarmerID: "farmer-1", Quantity: 40, Status: "IN_PROGRESS"})
+	putAsset(t, stub, "batch-3", BatchAsset{DocType: "BatchAsset", BatchID: "batch-3", FarmerID: "farmer-2", Quantity: 500, Status: "SPLIT"})
+	putAsset(t, stub, "batch-4", BatchAsset{DocType: "BatchAsset", BatchID: "batch-4", FarmerID: "farmer-2", Quantity: 60, Status: "COMPLETED"})
+	putAsset(t, stub, "batch-5", BatchAsset{DocType: "BatchAsset", BatchID: "batch-5", FarmerID: "farmer-3", Quantity: 10, Status: "COMPLETED"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	if _, err := s.SetFarmerDisplayName(farm, "x509::CN=test", "Green Acres"); err != nil {
+		t.Fatalf("SetFarmerDisplayName failed: %v", err)
+	}
+	putAsset(t, stub, "batch-6", BatchAsset{DocType: "BatchAsset", BatchID: "batch-6", FarmerID: "x509::CN=test", Quantity: 70, Status: "COMPLETED"})
+
+	ranked, err := s.GetTopFarmersByVolume(farm, 3)
+	if err != nil {
+		t.Fatalf("GetTopFarmersByVolume failed: %v", err)
+	}
+	var leaderboard []string
+	for _, volume := range ranked {
+		leaderboard = append(leaderboard, fmt.Sprintf("%s:%d:%d", volume.FarmerID, volume.TotalQuantity, volume.BatchCount))
+	}
+	if strings.Join(leaderboard, ",") != "farmer-1:90:2,x509::CN=test:70:1,farmer-2:60:1" {
+		t.Errorf("unexpected leaderboard %v", leaderboard)
+	}
+	if ranked[1].DisplayName != "Green Acres" {
+		t.Errorf("expected the display name to be attached, got %q", ranked[1].DisplayName)
+	}
+	if _, err := s.GetTopFarmersByVolume(farm, 0); err == nil {
+		t.Errorf("a zero limit was accepted")
+	}
+}
+
+// TestNextCertificateNumber checks numbers run per type and year and skip
+// numbers already taken
+func TestNextCertificateNumber(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "CERT-HAL-2025-000001", CertificationAsset{DocType: "CertificationAsset", CertificationID: "CERT-HAL-2025-000001"})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	var numbers []string
+	for _, next := range []struct{ certType, issuedDate string }{
+		{"HALAL", "2025-03-01"},
+		{"HALAL", "2025-06-01T09:00:00Z"},
+		{"HALAL", "2026-01-02"},
+		{"COLD_CHAIN_COMPLIANT", "2025-03-01"},
+	} {
+		number, err := s.nextCertificateNumber(ctx, next.certType, next.issuedDate)
+		if err != nil {
+			t.Fatalf("nextCertificateNumber failed: %v", err)
+		}
+		numbers = append(numbers, number)
+	}
+	if strings.Join(numbers, ",") != "CERT-HAL-2025-000002,CERT-HAL-2025-000003,CERT-HAL-2026-000001,CERT-COL-2025-000001" {
+		t.Errorf("unexpected certificate numbers %v", numbers)
+	}
+}
+
+// TestIssueCertificationGeneratesNumber checks an empty ID is replaced by the
+// next number under the configured prefix and a given ID is validated
+func TestIssueCertificationGeneratesNumber(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetCertificateNumberPrefix(regulator, "V-N"); err == nil {
+		t.Errorf("a prefix with punctuation was accepted")
+	}
+	if _, err := s.SetCertificateNumberPrefix(regulator, "vn"); err != nil {
+		t.Fatalf("SetCertificateNumberPrefix failed: %v", err)
+	}
+	if _, err := issueCertification(s, regulator, "-cert", "proc-1", "HALAL"); err == nil {
+		t.Errorf("an ID starting with '-' was accepted")
+	}
+	certification, err := issueCertification(s, regulator, "", "proc-1", "HALAL")
+	if err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if certification.CertificationID != "VN-HAL-2025-000001" {
+		t.Errorf("unexpected generated ID %s", certification.CertificationID)
+	}
+	if _, ok := stub.state["VN-HAL-2025-000001"]; !ok {
+		t.Errorf("certification not stored under the generated ID")
+	}
+}
+
+// TestSearchProducts checks the search is a case-insensitive substring match
+// sorted by name, treats the query literally and needs two characters
+func TestSearchProducts(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Free-range Broiler", IsActive: true})
+	putAsset(t, stub, "prod-2", ProductAsset{DocType: "ProductAsset", ProductID: "prod-2", Name: "broiler (frozen)", IsActive: false})
+	putAsset(t, stub, "prod-3", ProductAsset{DocType: "ProductAsset", ProductID: "prod-3", Name: "Layer hen", IsActive: true})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	products, err := s.SearchProducts(ctx, "BROIL")
+	if err != nil {
+		t.Fatalf("SearchProducts failed: %v", err)
+	}
+	if len(products) != 2 || products[0].ProductID != "prod-2" || products[1].ProductID != "prod-1" {
+		t.Errorf("unexpected search results %+v", products)
+	}
+	if products, err := s.SearchProducts(ctx, "(frozen"); err != nil || len(products) != 1 {
+		t.Errorf("expected the query to be matched literally, got %d results, %v", len(products), err)
+	}
+	if _, err := s.SearchProducts(ctx, " b "); err == nil {
+		t.Errorf("a one-character query was accepted")
+	}
+}
+
+// TestVerifyBatchCertifications checks each required type is reported with
+// the certification satisfying it or the reason it is not
+func TestVerifyBatchCertifications(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := derivationStub(t)
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true, RequiredCertTypes: []string{"ORGANIC", "HALAL", "HACCP"}})
+	putAsset(t, stub, "cert-halal", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-halal", BatchID: "batch-1", CertType: "HALAL", Status: "SUSPENDED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	verdict, err := s.VerifyBatchCertifications(ctx, "batch-1")
+	if err != nil {
+		t.Fatalf("VerifyBatchCertifications failed: %v", err)
+	}
+	var requirements []string
+	for _, requirement := range verdict.Requirements {
+		requirements = append(requirements, fmt.Sprintf("%s:%t:%s:%s", requirement.CertType, requirement.Satisfied, requirement.CertificationID, requirement.Failure))
+	}
+	if verdict.Certified || strings.Join(requirements, ",") != "ORGANIC:true:cert-batch-1:,HALAL:false:cert-halal:SUSPENDED,HACCP:false::MISSING" {
+		t.Errorf("unexpected verdict %t %v", verdict.Certified, requirements)
+	}
+
+	if _, err := s.SetProductRequiredCertifications(ledgerContext(MinFarmOrgMSP, stub), "prod-1", `[]`); err == nil {
+		t.Errorf("a farm set the product's required certifications")
+	}
+	product, err := s.SetProductRequiredCertifications(ctx, "prod-1", `["organic", "ORGANIC"]`)
+	if err != nil {
+		t.Fatalf("SetProductRequiredCertifications failed: %v", err)
+	}
+	if len(product.RequiredCertTypes) != 1 || product.RequiredCertTypes[0] != "ORGANIC" {
+		t.Errorf("unexpected required types %v", product.RequiredCertTypes)
+	}
+	if verdict, err := s.VerifyBatchCertifications(ctx, "batch-1"); err != nil || !verdict.Certified {
+		t.Errorf("expected the batch to be certified once only ORGANIC is required, got %+v, %v", verdict, err)
+	}
+}
+
+// TestReopenRegulatoryRecord checks only rejected records are reopened and
+// that the note is appended to the details
+func TestReopenRegulatoryRecord(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "REJECTED", RejectionReason: "missing lab report", Details: "original findings"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "APPROVED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.ReopenRegulatoryRecord(ledgerContext(MinFarmOrgMSP, stub), "reg-1", "lab report received"); err == nil {
+		t.Errorf("a farm reopened a regulatory record")
+	}
+	if _, err := s.ReopenRegulatoryRecord(regulator, "reg-2", "second look"); err == nil {
+		t.Errorf("an APPROVED record was reopened")
+	}
+
+	regulatory, err := s.ReopenRegulatoryRecord(regulator, "reg-1", "lab report received")
+	if err != nil {
+		t.Fatalf("ReopenRegulatoryRecord failed: %v", err)
+	}
+	if regulatory.Status != "PENDING" || regulatory.RejectionReason != "" || !strings.HasSuffix(regulatory.Details, "Reopened: lab report received") || !strings.HasPrefix(regulatory.Details, "original findings") {
+		t.Errorf("unexpected reopened record: %s, %q, %q", regulatory.Status, regulatory.Details, regulatory.RejectionReason)
+	}
+	if stub.eventName != "RegulatoryRecordUpdated" || stub.event["reopened"] != true {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+}
+
+// TestCreateBatchReportsEveryValidationError checks every field and date
+// problem of a new batch is reported in one error
+func TestCreateBatchReportsEveryValidationError(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	_, err := s.CreateBatch(farm, "batch-2", "", "farm-1", "", 0, "2025-03-10", "2025-03-01", "Barn 2", "QR-2", "")
+	if err == nil {
+		t.Fatalf("an invalid batch was accepted")
+	}
+	for _, want := range []string{"4 validation errors", "productID cannot be empty", "batchNumber cannot be empty", "quantity must be positive", "expectedEndDate 2025-03-01 cannot be before startDate 2025-03-10"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+	if _, err := s.CreateBatch(farm, "batch-2", "prod-1", "farm-1", "B-2", 10, "10/03/2025", "", "Barn 2", "QR-2", ""); err == nil || !strings.Contains(err.Error(), `invalid startDate "10/03/2025"`) {
+		t.Errorf("expected an unparseable start date to be refused, got %v", err)
+	}
+	if _, err := s.CreateBatch(farm, "batch-2", "prod-1", "farm-1", "B-2", 10, "2025-03-01", "2025-03-01", "Barn 2", "QR-2", ""); err != nil {
+		t.Errorf("a batch ending on its start date was refused: %v", err)
+	}
+}
+
+// TestValidateRegulatoryAsset checks a new regulatory record's problems are
+// all returned together
+func TestValidateRegulatoryAsset(t *testing.T) {
+	errs := validateRegulatoryAsset(&RegulatoryAsset{IssuedDate: "2025-03-01", ExpiryDate: "2025-02-01"})
+	if err := validationError(errs); err == nil || !strings.HasPrefix(err.Error(), "3 validation errors: regulatoryID cannot be empty; batchID cannot be empty; ") {
+		t.Errorf("unexpected validation error %v", err)
+	}
+	if err := validationError(validateRegulatoryAsset(&RegulatoryAsset{RegulatoryID: "reg-1", BatchID: "batch-1"})); err != nil {
+		t.Errorf("a valid record was refused: %v", err)
+	}
+}
+
+// TestRecordProcessingReportsEveryValidationError checks the field problems
+// of a new processing record are reported in one error before it is stored
+func TestRecordProcessingReportsEveryValidationError(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	_, err := s.RecordProcessing(farm, "proc-1", "batch-1", "SLAUGHTER", "2025-03-01", "2025-03-01T08:00:00Z", "2025-03-01T10:00:00Z", "fac-1", -1, -5, 120, nil, "", "")
+	if err == nil {
+		t.Fatalf("an invalid processing record was accepted")
+	}
+	for _, want := range []string{"3 validation errors", "slaughterCount must be non-negative", "yieldKg must be non-negative", "qualityScore must be between"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+	if _, ok := stub.state["proc-1"]; ok {
+		t.Errorf("the invalid processing record was stored")
+	}
+}
+
+// TestValidateCertificationAsset checks a new certification's problems are
+// all returned together
+func TestValidateCertificationAsset(t *testing.T) {
+	errs := validateCertificationAsset(&CertificationAsset{ProcessingID: "proc-1", BatchID: "batch-1", IssuedDate: "2025-03-01", ExpiryDate: "2025-02-01", DocumentURI: "https://certs.example/1.pdf"})
+	if err := validationError(errs); err == nil || !strings.HasPrefix(err.Error(), "5 validation errors: certificationID cannot be empty; certType cannot be empty; exactly one of processingID and batchID must be given; ") {
+		t.Errorf("unexpected validation error %v", err)
+	}
+	if err := validationError(validateCertificationAsset(&CertificationAsset{CertificationID: "cert-1", BatchID: "batch-1", CertType: "ORGANIC", IssuedDate: "2025-03-01", ExpiryDate: "2026-03-01"})); err != nil {
+		t.Errorf("a valid certification was refused: %v", err)
+	}
+}
+
+// TestSplitCertificationEvents checks issuance goes out as the deprecated
+// CertificationUpdated naming its replacement until SplitCertificationEvents
+// is on, and that status changes carry the previous status
+func TestSplitCertificationEvents(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HALAL"); err != nil {
+		t.Fatalf("IssueCertification failed: %v", err)
+	}
+	if stub.eventName != "CertificationUpdated" || stub.event["event_type"] != "CertificationIssued" || stub.event["deprecated"] == nil {
+		t.Errorf("unexpected deprecated event %s %v", stub.eventName, stub.event)
+	}
+	if stub.event["cert_type"] != "HALAL" || stub.event["processing_id"] != "proc-1" || stub.event["actor_msp"] != RegulatorOrgMSP {
+		t.Errorf("event missing certification fields: %v", stub.event)
+	}
+
+	if _, err := s.SetFeatureFlag(ledgerContext(AdminOrgMSP, stub), FeatureSplitCertificationEvents, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+	if _, err := s.UpdateCertificationStatus(regulator, "cert-1", "EXPIRED"); err != nil {
+		t.Fatalf("UpdateCertificationStatus failed: %v", err)
+	}
+	if stub.eventName != "CertificationStatusChanged" || stub.event["previous_status"] != "APPROVED" || stub.event["event_type"] != nil {
+		t.Errorf("unexpected status event %s %v", stub.eventName, stub.event)
+	}
+}
+
+// TestGetTransportTemperatureExtremes checks the coldest and hottest readings
+// are picked, the earliest winning a tie, and an empty log is flagged
+func TestGetTransportTemperatureExtremes(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-1", Status: "IN_TRANSIT"})
+	putAsset(t, stub, "tr-2", TransportAsset{DocType: "TransportAsset", TransportID: "tr-2", BatchID: "batch-2", Status: "IN_TRANSIT"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	for i, reading := range []struct {
+		temperature float64
+		timestamp   string
+	}{{4, "2025-03-01T08:00:00Z"}, {11.5, "2025-03-01T09:00:00Z"}, {3, "2025-03-01T10:00:00Z"}, {3, "2025-03-01T11:00:00Z"}} {
+		if _, err := s.AddTemperatureLog(farm, fmt.Sprintf("log-%d", i+1), "tr-1", reading.temperature, reading.timestamp, fmt.Sprintf("km %d", i*10)); err != nil {
+			t.Fatalf("AddTemperatureLog %d failed: %v", i+1, err)
+		}
+	}
+
+	extremes, err := s.GetTransportTemperatureExtremes(farm, "tr-1")
+	if err != nil {
+		t.Fatalf("GetTransportTemperatureExtremes failed: %v", err)
+	}
+	if extremes.NoData || extremes.ReadingCount != 4 || extremes.Min.LogID != "log-3" || extremes.Max.LogID != "log-2" || extremes.Max.Location != "km 10" {
+		t.Errorf("unexpected extremes %+v %+v %+v", extremes, extremes.Min, extremes.Max)
+	}
+	empty, err := s.GetTransportTemperatureExtremes(farm, "tr-2")
+	if err != nil || !empty.NoData || empty.Min != nil || empty.Max != nil {
+		t.Errorf("expected no data for a transport without readings, got %+v, %v", empty, err)
+	}
+	if _, err := s.GetTransportTemperatureExtremes(farm, "tr-missing"); err == nil {
+		t.Errorf("expected an unknown transport to be refused")
+	}
+}
+
+// TestUpdateRegulatoryStatusTransitions runs every status pair through
+// UpdateRegulatoryStatus and checks only the regulatory machine's
+// transitions are accepted
+func TestUpdateRegulatoryStatusTransitions(t *testing.T) {
+	allowed := map[string]bool{
+		"PENDING->APPROVED":   true,
+		"PENDING->REJECTED":   true,
+		"PENDING->NEEDS_INFO": true,
+		"REJECTED->PENDING":   true,
+		"NEEDS_INFO->PENDING": true,
+		"APPROVED->WITHDRAWN": true,
+	}
+	statuses := []string{"PENDING", "APPROVED", "REJECTED", "NEEDS_INFO", "WITHDRAWN"}
+
+	s := &SupplyChainContract{}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			transition := from + "->" + to
+			t.Run(transition, func(t *testing.T) {
+				stub := newMemStub()
+				putRegulatoryRecord(t, stub, from)
+
+				regulatory, err := s.UpdateRegulatoryStatus(ledgerContext(RegulatorOrgMSP, stub), "reg-1", to, "missing export health certificate", "")
+				if !allowed[transition] {
+					if err == nil {
+						t.Fatalf("transition %s was accepted", transition)
+					}
+					if stub.eventName != "" {
+						t.Errorf("rejected transition emitted %s", stub.eventName)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("transition %s failed: %v", transition, err)
+				}
+				if regulatory.Status != to {
+					t.Errorf("expected status %s, got %s", to, regulatory.Status)
+				}
+
+				var stored RegulatoryAsset
+				if err := json.Unmarshal(stub.state["reg-1"], &stored); err != nil {
+					t.Fatalf("failed to unmarshal stored record: %v", err)
+				}
+				if stored.Status != to {
+					t.Errorf("expected stored status %s, got %s", to, stored.Status)
+				}
+				if stub.eventName != "RegulatoryRecordUpdated" || stub.event["status"] != to || stub.event["regulatory_id"] != "reg-1" {
+					t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+				}
+			})
+		}
+	}
+}
+
+// TestUpdateRegulatoryStatusRequiresReason checks REJECTED and NEEDS_INFO
+// refuse an empty, blank or too-short rejectionReason and leave the record as is
+func TestUpdateRegulatoryStatusRequiresReason(t *testing.T) {
+	s := &SupplyChainContract{}
+	for _, to := range []string{"REJECTED", "NEEDS_INFO"} {
+		for _, reason := range []string{"", "   ", "too short"} {
+			t.Run(to+"/"+reason, func(t *testing.T) {
+				stub := newMemStub()
+				putRegulatoryRecord(t, stub, "PENDING")
+				before := string(stub.state["reg-1"])
+
+				if _, err := s.UpdateRegulatoryStatus(ledgerContext(RegulatorOrgMSP, stub), "reg-1", to, reason, ""); err == nil {
+					t.Fatalf("%s accepted rejectionReason %q", to, reason)
+				}
+				if string(stub.state["reg-1"]) != before {
+					t.Errorf("record changed after a refused update")
+				}
+			})
+		}
+	}
+}
+
+// TestUpdateRegulatoryStatusRejectionReason checks the reason is stored and
+// sent in the event, and cleared when the record is resubmitted
+func TestUpdateRegulatoryStatusRejectionReason(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putRegulatoryRecord(t, stub, "PENDING")
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	regulatory, err := s.UpdateRegulatoryStatus(ctx, "reg-1", "REJECTED", "  residue test above limit  ", "")
+	if err != nil {
+		t.Fatalf("rejection failed: %v", err)
+	}
+	if regulatory.RejectionReason != "residue test above limit" {
+		t.Errorf("unexpected rejection reason %q", regulatory.RejectionReason)
+	}
+	if stub.event["rejection_reason"] != "residue test above limit" {
+		t.Errorf("event does not carry the rejection reason: %v", stub.event)
+	}
+
+	regulatory, err = s.UpdateRegulatoryStatus(ctx, "reg-1", "PENDING", "", "")
+	if err != nil {
+		t.Fatalf("resubmission failed: %v", err)
+	}
+	if regulatory.RejectionReason != "" {
+		t.Errorf("resubmission kept rejection reason %q", regulatory.RejectionReason)
+	}
+	if _, ok := stub.event["rejection_reason"]; ok {
+		t.Errorf("resubmission event carries a rejection reason: %v", stub.event)
+	}
+}
+
+// TestAuthorizeAndLoadChecksCallerFirst checks an unauthorized caller is
+// refused before the asset is read, so it learns nothing about the ID
+func TestAuthorizeAndLoadChecksCallerFirst(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.authorizeAndLoadRegulatoryRecord(farm, RegulatorOrgMSP, "reg-missing"); err == nil || !strings.HasPrefix(err.Error(), "unauthorized") {
+		t.Errorf("expected the caller to be refused before the load, got %v", err)
+	}
+	if _, err := s.authorizeAndLoadProduct(ledgerContext(RegulatorOrgMSP, stub), RegulatorOrgMSP, "prod-missing"); err == nil || strings.HasPrefix(err.Error(), "unauthorized") {
+		t.Errorf("expected a missing product to be reported, got %v", err)
+	}
+	batch, err := s.authorizeAndLoadBatch(farm, MinFarmOrgMSP, "batch-1")
+	if err != nil || batch.BatchID != "batch-1" {
+		t.Errorf("unexpected batch %+v, %v", batch, err)
+	}
+}
+
+// TestRegulatoryAuditFlags checks legacy string flags are read as a list,
+// that flags are added and removed one at a time with their history, and
+// that records are found by flag
+func TestRegulatoryAuditFlags(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", map[string]interface{}{"docType": "RegulatoryAsset", "regulatory_id": "reg-1", "batch_id": "batch-1", "record_type": "EXPORT", "status": "PENDING", "audit_flags": "SAMPLING_REQUIRED, document_missing", "created_at": "2025-01-01"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-2", RecordType: "EXPORT", Status: "PENDING", CreatedAt: "2025-02-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	legacy, err := s.GetRegulatoryRecord(regulator, "reg-1")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecord failed: %v", err)
+	}
+	if len(legacy.AuditFlags) != 2 || legacy.AuditFlags[0] != "SAMPLING_REQUIRED" {
+		t.Errorf("legacy flags not split: %v", legacy.AuditFlags)
+	}
+
+	if _, err := s.AddRegulatoryAuditFlag(ledgerContext(MinFarmOrgMSP, stub), "reg-2", "REPEAT_OFFENDER"); err == nil {
+		t.Errorf("a farm raised an audit flag")
+	}
+	if _, err := s.AddRegulatoryAuditFlag(regulator, "reg-2", "NOT_A_FLAG"); err == nil || !strings.Contains(err.Error(), "invalid audit flag") {
+		t.Errorf("expected an unknown flag to be refused, got %v", err)
+	}
+	if _, err := s.AddRegulatoryAuditFlag(regulator, "reg-2", "repeat_offender"); err != nil {
+		t.Fatalf("AddRegulatoryAuditFlag failed: %v", err)
+	}
+	if _, err := s.AddRegulatoryAuditFlag(regulator, "reg-2", "REPEAT_OFFENDER"); err == nil || !strings.Contains(err.Error(), "already has audit flag") {
+		t.Errorf("expected a duplicate flag to be refused, got %v", err)
+	}
+	if _, err := s.AddRegulatoryAuditFlag(regulator, "reg-1", "REPEAT_OFFENDER"); err != nil {
+		t.Fatalf("AddRegulatoryAuditFlag failed: %v", err)
+	}
+
+	flagged, err := s.GetRegulatoryRecordsByAuditFlag(regulator, "REPEAT_OFFENDER")
+	if err != nil || len(flagged) != 2 || flagged[0].RegulatoryID != "reg-1" || flagged[1].RegulatoryID != "reg-2" {
+		t.Errorf("unexpected records by flag %v, %v", flagged, err)
+	}
+
+	if _, err := s.RemoveRegulatoryAuditFlag(regulator, "reg-2", "REPEAT_OFFENDER", ""); err == nil {
+		t.Errorf("a flag was removed without a reason")
+	}
+	regulatory, err := s.RemoveRegulatoryAuditFlag(regulator, "reg-2", "REPEAT_OFFENDER", "raised in error")
+	if err != nil {
+		t.Fatalf("RemoveRegulatoryAuditFlag failed: %v", err)
+	}
+	if len(regulatory.AuditFlags) != 0 || len(regulatory.AuditFlagHistory) != 2 {
+		t.Fatalf("unexpected flags %v and history %v", regulatory.AuditFlags, regulatory.AuditFlagHistory)
+	}
+	if removal := regulatory.AuditFlagHistory[1]; removal.Action != "REMOVED" || removal.Reason != "raised in error" || removal.TxID != "tx-1" {
+		t.Errorf("removal not recorded: %+v", removal)
+	}
+	if stub.event["audit_flag_removed"] != "REPEAT_OFFENDER" {
+		t.Errorf("unexpected event %v", stub.event)
+	}
+}
+
+// TestGetRegulatoryApprovalRate checks decisions within the range are counted
+// into the approval percentage and oversized ranges are refused
+func TestGetRegulatoryApprovalRate(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for id, record := range map[string]struct{ status, updatedAt string }{
+		"reg-1": {"APPROVED", "2025-02-10T00:00:00Z"},
+		"reg-2": {"APPROVED", "2025-02-20T00:00:00Z"},
+		"reg-3": {"REJECTED", "2025-02-28T18:00:00Z"},
+		"reg-4": {"APPROVED", "2025-02-25T00:00:00Z"},
+		"reg-5": {"APPROVED", "2025-03-05T00:00:00Z"},
+		"reg-6": {"PENDING", "2025-02-10T00:00:00Z"},
+	} {
+		putAsset(t, stub, id, RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: id, RecordType: "SANITARY_INSPECTION", Status: record.status, UpdatedAt: record.updatedAt})
+	}
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	rate, err := s.GetRegulatoryApprovalRate(ctx, "2025-02-01", "2025-02-28")
+	if err != nil {
+		t.Fatalf("GetRegulatoryApprovalRate failed: %v", err)
+	}
+	if rate.DecidedCount != 4 || rate.ApprovedCount != 3 || rate.RejectedCount != 1 || rate.ApprovalRatePercent != 75 {
+		t.Errorf("unexpected approval rate %+v", rate)
+	}
+	if empty, err := s.GetRegulatoryApprovalRate(ctx, "2024-01-01", "2024-01-31"); err != nil || empty.DecidedCount != 0 || empty.ApprovalRatePercent != 0 {
+		t.Errorf("expected an empty range to report zero, got %+v, %v", empty, err)
+	}
+	if _, err := s.GetRegulatoryApprovalRate(ctx, "2020-01-01", "2025-02-28"); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("expected an oversized range to be refused, got %v", err)
+	}
+}
+
+// TestRegulatoryRecordTypes checks record types are validated against the
+// managed list and that records are found by normalized type and status
+func TestRegulatoryRecordTypes(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "reg-old", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-old", BatchID: "batch-1", RecordType: "MOVEMENT_PERMIT", Status: "APPROVED", CreatedAt: "2024-12-01"})
+	admin := ledgerContext(AdminOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "VET_VISIT", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "", ""); err == nil || !strings.Contains(err.Error(), "allowed: SLAUGHTER_CLEARANCE") {
+		t.Errorf("expected an unknown record type to be refused, got %v", err)
+	}
+	if _, err := s.AddRegulatoryRecordType(regulator, "VET_VISIT"); err == nil {
+		t.Errorf("a regulator added a record type")
+	}
+	if _, err := s.AddRegulatoryRecordType(admin, "vet visit"); err != nil {
+		t.Fatalf("AddRegulatoryRecordType failed: %v", err)
+	}
+	if _, err := s.AddRegulatoryRecordType(admin, "VET_VISIT"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected a duplicate record type to be refused, got %v", err)
+	}
+	if _, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "Vet Visit", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "", ""); err != nil {
+		t.Fatalf("CreateRegulatoryRecord failed: %v", err)
+	}
+	if _, err := s.CreateRegulatoryRecord(regulator, "reg-2", "batch-1", "MOVEMENT_PERMIT", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "", ""); err != nil {
+		t.Fatalf("CreateRegulatoryRecord failed: %v", err)
+	}
+
+	records, err := s.GetRegulatoryRecordsByType(regulator, "movement_permit", "")
+	if err != nil || len(records) != 2 || records[0].RegulatoryID != "reg-old" || records[1].RegulatoryID != "reg-2" {
+		t.Errorf("unexpected records by type %v, %v", records, err)
+	}
+	if records, err := s.GetRegulatoryRecordsByType(regulator, "MOVEMENT_PERMIT", "APPROVED"); err != nil || len(records) != 1 || records[0].RegulatoryID != "reg-old" {
+		t.Errorf("unexpected approved records by type %v, %v", records, err)
+	}
+	if _, err := s.GetRegulatoryRecordsByType(regulator, "MOVEMENT_PERMIT", "DONE"); err == nil {
+		t.Errorf("an unknown status was accepted")
+	}
+
+	config, err := s.RemoveRegulatoryRecordType(admin, "VET_VISIT")
+	if err != nil {
+		t.Fatalf("RemoveRegulatoryRecordType failed: %v", err)
+	}
+	if len(config.RecordTypes) != 5 {
+		t.Errorf("unexpected record types %v", config.RecordTypes)
+	}
+}
+
+// TestMergeBatchesRecordsCertificationTransfer checks the target gets a
+// CERT_TRANSFERRED event listing carried types and conflicting ones
+func TestMergeBatchesRecordsCertificationTransfer(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := derivationStub(t)
+	putAsset(t, stub, "cert-halal-2", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-halal-2", BatchID: "batch-2", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.MergeBatches(farm, "batch-m", "B-M", `["batch-1", "batch-2"]`, "QR-m"); err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+	if stub.event["cert_transfer_event_id"] != "batch-m~CERT_TRANSFERRED" {
+		t.Errorf("merge event does not reference the transfer: %v", stub.event)
+	}
+
+	var event LifecycleEventAsset
+	if err := json.Unmarshal(stub.state["batch-m~CERT_TRANSFERRED"], &event); err != nil {
+		t.Fatalf("failed to unmarshal transfer event: %v", err)
+	}
+	var transfers MergeCertificationTransfers
+	if err := json.Unmarshal([]byte(event.Metadata), &transfers); err != nil {
+		t.Fatalf("failed to unmarshal transfer metadata: %v", err)
+	}
+	if event.EventType != "CERT_TRANSFERRED" || event.BatchID != "batch-m" || len(transfers.Transfers) != 1 || transfers.Transfers[0].CertificationID != "batch-m~ORGANIC" || len(transfers.Transfers[0].SourceCertificationIDs) != 2 {
+		t.Errorf("unexpected transfers %+v", transfers.Transfers)
+	}
+	if len(transfers.Conflicts) != 1 || transfers.Conflicts[0].CertType != "HALAL" || transfers.Conflicts[0].HeldBy[0] != "batch-2" || transfers.Conflicts[0].MissingFrom[0] != "batch-1" {
+		t.Errorf("unexpected conflicts %+v", transfers.Conflicts)
+	}
+}
+
+// TestCertificationQualityScoreThreshold checks a processing record scoring
+// below MinQualityScoreForCertification is only certified with a recorded
+// Regulator override, and that batch-scoped certifications skip the check
+func TestCertificationQualityScoreThreshold(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-1", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-1", BatchID: "batch-1", Stage: "SLAUGHTER", YieldKg: 100, QualityScore: 60})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := issueCertification(s, regulator, "cert-1", "proc-1", "HALAL"); err != nil {
+		t.Fatalf("IssueCertification without a threshold failed: %v", err)
+	}
+	if _, err := s.SetThreshold(regulator, ThresholdMinCertQualityScore, 70); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	if _, err := issueCertification(s, regulator, "cert-2", "proc-1", "ORGANIC"); err == nil || !strings.Contains(err.Error(), "below the 70.0 required") {
+		t.Errorf("expected a low quality score to be refused, got %v", err)
+	}
+
+	certification, err := s.IssueCertification(regulator, "cert-2", "proc-1", "", "ORGANIC", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", `{"override_quality_score": true}`)
+	if err != nil {
+		t.Fatalf("IssueCertification with an override failed: %v", err)
+	}
+	if !certification.QualityScoreOverridden || stub.event["quality_score_overridden"] != true {
+		t.Errorf("override not recorded: %+v, %v", certification, stub.event)
+	}
+
+	if _, err := s.IssueCertification(regulator, "cert-3", "", "batch-1", "ORGANIC", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", ""); err != nil {
+		t.Errorf("a batch-scoped certification was checked against the threshold: %v", err)
+	}
+}
+
+// TestStopSaleBlocksTransport checks a batch under stop-sale cannot be put on
+// a transport manifest or processed, and can once the stop-sale is lifted
+func TestStopSaleBlocksTransport(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	createTransport := func(transportID string) (*TransportAsset, error) {
+		return s.CreateTransportManifest(farm, transportID, "batch-1", 10, "farm-1", "plant-1", "truck-1", "driver",
+			"2025-03-02T08:00:00Z", "2025-03-02T12:00:00Z", "Farm", "Plant", false, "")
+	}
+
+	if _, err := s.IssueStopSale(farm, "stop-1", "batch-1", "suspected contamination", ""); err == nil {
+		t.Errorf("a farm issued a stop-sale")
+	}
+	if _, err := s.IssueStopSale(regulator, "stop-1", "batch-1", "suspected contamination", ""); err != nil {
+		t.Fatalf("IssueStopSale failed: %v", err)
+	}
+	if stub.eventName != "StopSaleIssued" || stub.event["regulatory_id"] != "stop-1" || stub.event["lifecycle_event_id"] == "" {
+		t.Errorf("unexpected stop-sale event %s %v", stub.eventName, stub.event)
+	}
+	if _, err := s.IssueStopSale(regulator, "stop-2", "batch-1", "second notice", ""); err == nil || !strings.Contains(err.Error(), "already under stop-sale stop-1") {
+		t.Errorf("expected a second stop-sale to be refused, got %v", err)
+	}
+
+	if _, err := createTransport("transport-1"); err == nil || !strings.Contains(err.Error(), "stop-sale stop-1") {
+		t.Fatalf("transport during stop-sale: expected stop-sale error, got %v", err)
+	}
+	if _, ok := stub.state["transport-1"]; ok {
+		t.Errorf("transport was saved during the stop-sale")
+	}
+	if _, err := recordSlaughter(s, farm, "proc-1", 10, 20, ""); err == nil || !strings.Contains(err.Error(), "stop-sale stop-1") {
+		t.Errorf("processing during stop-sale: expected stop-sale error, got %v", err)
+	}
+	sellability, err := s.IsBatchSellable(farm, "batch-1")
+	if err != nil {
+		t.Fatalf("IsBatchSellable failed: %v", err)
+	}
+	if sellability.Sellable || sellability.StopSaleID != "stop-1" {
+		t.Errorf("batch under stop-sale reported as %+v", sellability)
+	}
+
+	if _, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "STOP_SALE", "", "2026-03-01", "", "", ""); err == nil {
+		t.Errorf("CreateRegulatoryRecord wrote a STOP_SALE record")
+	}
+	if _, err := s.UpdateRegulatoryStatus(regulator, "stop-1", "WITHDRAWN", "lab results clear", ""); err == nil || !strings.Contains(err.Error(), "use LiftStopSale") {
+		t.Errorf("expected a stop-sale status update to be refused, got %v", err)
+	}
+
+	lifted, err := s.LiftStopSale(regulator, "stop-1", "lab results clear")
+	if err != nil {
+		t.Fatalf("LiftStopSale failed: %v", err)
+	}
+	if lifted.Status != "WITHDRAWN" || lifted.Resolution != "lab results clear" {
+		t.Errorf("lift not recorded: %+v", lifted)
+	}
+	if stub.eventName != "StopSaleLifted" || stub.event["resolution"] != "lab results clear" {
+		t.Errorf("unexpected lift event %s %v", stub.eventName, stub.event)
+	}
+	if _, err := s.LiftStopSale(regulator, "stop-1", "again"); err == nil {
+		t.Errorf("a lifted stop-sale was lifted again")
+	}
+
+	if _, err := createTransport("transport-1"); err != nil {
+		t.Fatalf("transport after lift failed: %v", err)
+	}
+	sellability, err = s.IsBatchSellable(farm, "batch-1")
+	if err != nil {
+		t.Fatalf("IsBatchSellable failed: %v", err)
+	}
+	if !sellability.Sellable {
+		t.Errorf("batch still not sellable after lift: %+v", sellability)
+	}
+
+	events, err := s.GetBatchLifecycleEvents(farm, "batch-1")
+	if err != nil {
+		t.Fatalf("GetBatchLifecycleEvents failed: %v", err)
+	}
+	var timeline []string
+	for _, event := range events {
+		timeline = append(timeline, event.EventType)
+	}
+	if strings.Join(timeline, ",") != "STOP_SALE,STOP_SALE_LIFTED" {
+		t.Errorf("unexpected batch timeline %v", timeline)
+	}
+}
+
+// TestGetEventsInDateRangeForBatch checks only the batch's events inside the
+// inclusive window are returned, oldest first, and that bad bounds are refused
+func TestGetEventsInDateRangeForBatch(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	for _, event := range []LifecycleEventAsset{
+		{EventID: "evt-1", BatchID: "batch-1", EventType: "FEEDING", EventDate: "2025-03-03T08:00:00Z"},
+		{EventID: "evt-2", BatchID: "batch-1", EventType: "VACCINATION", EventDate: "2025-03-01T08:00:00Z"},
+		{EventID: "evt-3", BatchID: "batch-1", EventType: "FEEDING", EventDate: "2025-03-10T08:00:00Z"},
+		{EventID: "evt-4", BatchID: "batch-2", EventType: "FEEDING", EventDate: "2025-03-02T08:00:00Z"},
+	} {
+		event.DocType = "LifecycleEventAsset"
+		putAsset(t, stub, event.EventID, event)
+	}
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	events, err := s.GetEventsInDateRangeForBatch(farm, "batch-1", "2025-03-01T08:00:00Z", "2025-03-05T00:00:00Z")
+	if err != nil {
+		t.Fatalf("GetEventsInDateRangeForBatch failed: %v", err)
+	}
+	if len(events) != 2 || events[0].EventID != "evt-2" || events[1].EventID != "evt-1" {
+		t.Errorf("unexpected events in range %v", events)
+	}
+	if events, err := s.GetEventsInDateRangeForBatch(farm, "batch-1", "2025-04-01T00:00:00Z", "2025-04-30T00:00:00Z"); err != nil || events == nil || len(events) != 0 {
+		t.Errorf("expected an empty list for an empty window, got %v, %v", events, err)
+	}
+	if _, err := s.GetEventsInDateRangeForBatch(farm, "batch-1", "2025-03-01", "2025-03-05T00:00:00Z"); err == nil {
+		t.Errorf("expected a non-RFC3339 start to be refused")
+	}
+	if _, err := s.GetEventsInDateRangeForBatch(farm, "batch-1", "2025-03-05T00:00:00Z", "2025-03-01T00:00:00Z"); err == nil || !strings.Contains(err.Error(), "is before startDate") {
+		t.Errorf("expected an inverted window to be refused, got %v", err)
+	}
+}
+
+// completionClearanceStub returns a ledger with product prod-1, which
+// requires a PRE_HARVEST_CLEARANCE before completion, and its IN_PROGRESS
+// batch batch-1
+func completionClearanceStub(t *testing.T) *memStub {
+	t.Helper()
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true})
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", Quantity: 100, Status: "IN_PROGRESS"})
+
+	if _, err := s.AddRegulatoryRecordType(ledgerContext(AdminOrgMSP, stub), "PRE_HARVEST_CLEARANCE"); err != nil {
+		t.Fatalf("AddRegulatoryRecordType failed: %v", err)
+	}
+	if _, err := s.SetProductCompletionClearance(ledgerContext(RegulatorOrgMSP, stub), "prod-1", "pre_harvest_clearance"); err != nil {
+		t.Fatalf("SetProductCompletionClearance failed: %v", err)
+	}
+	return stub
+}
+
+// TestSetProductCompletionClearance checks only the Regulator may set the
+// rule, the type must be an allowed regulatory record type, and an empty
+// type removes the rule
+func TestSetProductCompletionClearance(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.SetProductCompletionClearance(ledgerContext(MinFarmOrgMSP, stub), "prod-1", "MOVEMENT_PERMIT"); err == nil {
+		t.Errorf("farm caller set the completion clearance")
+	}
+	if _, err := s.SetProductCompletionClearance(regulator, "prod-1", "PRE_HARVEST_CLEARANCE"); err == nil {
+		t.Errorf("accepted a record type that is not on the allowed list")
+	}
+
+	product, err := s.SetProductCompletionClearance(regulator, "prod-1", " movement_permit ")
+	if err != nil {
+		t.Fatalf("SetProductCompletionClearance failed: %v", err)
+	}
+	if product.CompletionClearance != "MOVEMENT_PERMIT" {
+		t.Errorf("expected MOVEMENT_PERMIT, got %q", product.CompletionClearance)
+	}
+	stored, err := s.GetProduct(regulator, "prod-1")
+	if err != nil {
+		t.Fatalf("GetProduct failed: %v", err)
+	}
+	if stored.CompletionClearance != "MOVEMENT_PERMIT" {
+		t.Errorf("stored completion clearance is %q", stored.CompletionClearance)
+	}
+
+	product, err = s.SetProductCompletionClearance(regulator, "prod-1", "")
+	if err != nil {
+		t.Fatalf("clearing the completion clearance failed: %v", err)
+	}
+	if product.CompletionClearance != "" {
+		t.Errorf("completion clearance not removed: %q", product.CompletionClearance)
+	}
+}
+
+// TestCompleteBatchRequiresClearance checks CompleteBatch names the missing
+// record type, accepts an approved and unexpired clearance, and lets only the
+// Regulator complete without one
+func TestCompleteBatchRequiresClearance(t *testing.T) {
+	s := &SupplyChainContract{}
+	clearance := func(status, expiryDate string) RegulatoryAsset {
+		return RegulatoryAsset{
+			DocType:      "RegulatoryAsset",
+			RegulatoryID: "reg-1",
+			BatchID:      "batch-1",
+			RecordType:   "PRE_HARVEST_CLEARANCE",
+			Status:       status,
+			ExpiryDate:   expiryDate,
+		}
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		stub := completionClearanceStub(t)
+		_, err := s.CompleteBatch(ledgerContext(MinFarmOrgMSP, stub), "batch-1", "2025-03-01", "")
+		if err == nil || !strings.Contains(err.Error(), "PRE_HARVEST_CLEARANCE") {
+			t.Fatalf("expected an error naming PRE_HARVEST_CLEARANCE, got %v", err)
+		}
+	})
+
+	for name, record := range map[string]RegulatoryAsset{
+		"pending": clearance("PENDING", ""),
+		"expired": clearance("APPROVED", "2025-02-28"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := completionClearanceStub(t)
+			putAsset(t, stub, "reg-1", record)
+			if _, err := s.CompleteBatch(ledgerContext(MinFarmOrgMSP, stub), "batch-1", "2025-03-01", ""); err == nil {
+				t.Fatalf("completed with a %s clearance", name)
+			}
+		})
+	}
+
+	t.Run("approved", func(t *testing.T) {
+		stub := completionClearanceStub(t)
+		putAsset(t, stub, "reg-1", clearance("APPROVED", "2025-03-31"))
+		batch, err := s.CompleteBatch(ledgerContext(MinFarmOrgMSP, stub), "batch-1", "2025-03-01", "")
+		if err != nil {
+			t.Fatalf("CompleteBatch failed: %v", err)
+		}
+		if batch.Status != "COMPLETED" || batch.CompletionOverride != "" {
+			t.Errorf("unexpected batch %+v", batch)
+		}
+	})
+
+	t.Run("farm override refused", func(t *testing.T) {
+		stub := completionClearanceStub(t)
+		if _, err := s.CompleteBatch(ledgerContext(MinFarmOrgMSP, stub), "batch-1", "2025-03-01", "inspector unavailable"); err == nil {
+			t.Fatalf("farm caller overrode the completion clearance")
+		}
+	})
+
+	t.Run("regulator override", func(t *testing.T) {
+		stub := completionClearanceStub(t)
+		batch, err := s.CompleteBatch(ledgerContext(RegulatorOrgMSP, stub), "batch-1", "2025-03-01", "verified on site")
+		if err != nil {
+			t.Fatalf("CompleteBatch failed: %v", err)
+		}
+		var stored BatchAsset
+		if err := json.Unmarshal(stub.state["batch-1"], &stored); err != nil {
+			t.Fatalf("failed to unmarshal stored batch: %v", err)
+		}
+		if batch.Status != "COMPLETED" || stored.CompletionOverride != "verified on site" || stored.CompletionOverrideBy == "" {
+			t.Errorf("override not recorded on the batch: %+v", stored)
+		}
+	})
+
+	t.Run("no rule", func(t *testing.T) {
+		stub := completionClearanceStub(t)
+		if _, err := s.SetProductCompletionClearance(ledgerContext(RegulatorOrgMSP, stub), "prod-1", ""); err != nil {
+			t.Fatalf("clearing the completion clearance failed: %v", err)
+		}
+		if _, err := s.CompleteBatch(ledgerContext(MinFarmOrgMSP, stub), "batch-1", "2025-03-01", ""); err != nil {
+			t.Fatalf("CompleteBatch without a rule failed: %v", err)
+		}
+	})
+}
+
+// TestSetBatchTokenSecret checks the secret is read from the transient map
+// and kept in the private data collection, out of the world state
+func TestSetBatchTokenSecret(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	admin := ledgerContext(AdminOrgMSP, stub)
+	secret := []byte(strings.Repeat("k", MinBatchTokenSecretLength))
+
+	if err := s.SetBatchTokenSecret(admin); err == nil {
+		t.Errorf("accepted a call without the transient secret")
+	}
+	stub.transient = map[string][]byte{BatchTokenSecretTransientKey: secret[:MinBatchTokenSecretLength-1]}
+	if err := s.SetBatchTokenSecret(admin); err == nil {
+		t.Errorf("accepted a short secret")
+	}
+	stub.transient = map[string][]byte{BatchTokenSecretTransientKey: secret}
+	if err := s.SetBatchTokenSecret(ledgerContext(MinFarmOrgMSP, stub)); err == nil {
+		t.Errorf("farm caller set the secret")
+	}
+	if err := s.SetBatchTokenSecret(admin); err != nil {
+		t.Fatalf("SetBatchTokenSecret failed: %v", err)
+	}
+
+	if _, ok := stub.state[BatchTokenSecretKey]; ok {
+		t.Errorf("secret was written to the world state")
+	}
+	if string(stub.privateData[BatchTokenSecretCollection][BatchTokenSecretKey]) != string(secret) {
+		t.Errorf("secret not stored in %s", BatchTokenSecretCollection)
+	}
+
+	token, err := s.batchToken(admin, "batch-1")
+	if err != nil {
+		t.Fatalf("batchToken failed: %v", err)
+	}
+	if valid, err := s.VerifyBatchToken(admin, "batch-1", token); err != nil || !valid {
+		t.Errorf("token derived from the stored secret did not verify: %v, %v", valid, err)
+	}
+	if valid, err := s.VerifyBatchToken(admin, "batch-2", token); err != nil || valid {
+		t.Errorf("another batch's token verified: %v, %v", valid, err)
+	}
+}
+
+// TestRegulatoryDecisionHistory checks status changes and detail edits are
+// kept on the record, oldest first, and that the history is capped
+func TestRegulatoryDecisionHistory(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putRegulatoryRecord(t, stub, "PENDING")
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if history, err := s.GetRegulatoryDecisionHistory(regulator, "reg-1"); err != nil || history == nil || len(history) != 0 {
+		t.Errorf("expected an empty history, got %v, %v", history, err)
+	}
+	if _, err := s.UpdateRegulatoryStatus(regulator, "reg-1", "NEEDS_INFO", "missing export health certificate", ""); err != nil {
+		t.Fatalf("UpdateRegulatoryStatus failed: %v", err)
+	}
+	if _, err := s.UpdateRegulatoryDetails(regulator, "reg-1", "certificate attached", ""); err == nil {
+		t.Errorf("expected a details edit without a reason to be refused")
+	}
+	if _, err := s.UpdateRegulatoryDetails(ledgerContext(MinFarmOrgMSP, stub), "reg-1", "certificate attached", "certificate received"); err == nil {
+		t.Errorf("a farm edited regulatory details")
+	}
+	regulatory, err := s.UpdateRegulatoryDetails(regulator, "reg-1", "certificate attached", "certificate received")
+	if err != nil {
+		t.Fatalf("UpdateRegulatoryDetails failed: %v", err)
+	}
+	if regulatory.Details != "certificate attached" {
+		t.Errorf("details not replaced: %q", regulatory.Details)
+	}
+	if _, err := s.UpdateRegulatoryDetails(regulator, "reg-1", "certificate attached", "again"); err == nil {
+		t.Errorf("expected unchanged details to be refused")
+	}
+
+	history, err := s.GetRegulatoryDecisionHistory(regulator, "reg-1")
+	if err != nil {
+		t.Fatalf("GetRegulatoryDecisionHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 decisions, got %+v", history)
+	}
+	if history[0].OldStatus != "PENDING" || history[0].NewStatus != "NEEDS_INFO" || history[0].Reason != "missing export health certificate" || history[0].Actor == "" || history[0].TxID != "tx-1" {
+		t.Errorf("unexpected status decision %+v", history[0])
+	}
+	if history[1].Action != "DETAILS_EDIT" || history[1].NewStatus != "NEEDS_INFO" || history[1].PreviousDetailsSHA256 != fmt.Sprintf("%x", sha256.Sum256(nil)) {
+		t.Errorf("unexpected details decision %+v", history[1])
+	}
+
+	full := RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "EXPORT", Status: "PENDING"}
+	for i := 0; i < MaxRegulatoryDecisionHistory; i++ {
+		full.DecisionHistory = append(full.DecisionHistory, RegulatoryDecision{Reason: fmt.Sprintf("decision %d", i)})
+	}
+	putAsset(t, stub, "reg-2", full)
+	if _, err := s.UpdateRegulatoryStatus(regulator, "reg-2", "APPROVED", "", ""); err != nil {
+		t.Fatalf("UpdateRegulatoryStatus failed: %v", err)
+	}
+	history, err = s.GetRegulatoryDecisionHistory(regulator, "reg-2")
+	if err != nil {
+		t.Fatalf("GetRegulatoryDecisionHistory failed: %v", err)
+	}
+	if len(history) != MaxRegulatoryDecisionHistory || history[0].Reason != "decision 1" || history[len(history)-1].NewStatus != "APPROVED" {
+		t.Errorf("history not capped to the latest decisions: %d entries, first %+v", len(history), history[0])
+	}
+}
+
+// TestGetProcessingRecordsByQualityBand checks the band is inclusive, sorted
+// highest score first, and that bounds off the 0-100 scale or inverted are
+// refused
+func TestGetProcessingRecordsByQualityBand(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for id, score := range map[string]float64{"proc-1": 70, "proc-2": 85, "proc-3": 80, "proc-4": 60} {
+		putAsset(t, stub, id, ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: id, BatchID: "batch-1", QualityScore: score})
+	}
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	records, err := s.GetProcessingRecordsByQualityBand(ctx, 70, 85)
+	if err != nil {
+		t.Fatalf("GetProcessingRecordsByQualityBand failed: %v", err)
+	}
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, record.ProcessingID)
+	}
+	if strings.Join(ids, ",") != "proc-2,proc-3,proc-1" {
+		t.Errorf("unexpected records in band %v", ids)
+	}
+	if records, err := s.GetProcessingRecordsByQualityBand(ctx, 90, 100); err != nil || records == nil || len(records) != 0 {
+		t.Errorf("expected an empty list for an empty band, got %v, %v", records, err)
+	}
+	for _, band := range [][2]float64{{-1, 50}, {50, 101}, {80, 70}} {
+		if _, err := s.GetProcessingRecordsByQualityBand(ctx, band[0], band[1]); err == nil {
+			t.Errorf("accepted the band %v", band)
+		}
+	}
+}
+
+// TestGetRegulatoryRecordsByStatus checks records across batches are filtered
+// by status and normalized record type, paged, and listed oldest first
+func TestGetRegulatoryRecordsByStatus(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for _, record := range []RegulatoryAsset{
+		{RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "PENDING", CreatedAt: "2025-02-03T00:00:00Z"},
+		{RegulatoryID: "reg-2", BatchID: "batch-2", RecordType: "SANITARY_INSPECTION", Status: "PENDING", CreatedAt: "2025-02-01T00:00:00Z"},
+		{RegulatoryID: "reg-3", BatchID: "batch-3", RecordType: "EXPORT_PERMIT", Status: "APPROVED", CreatedAt: "2025-02-02T00:00:00Z"},
+	} {
+		record.DocType = "RegulatoryAsset"
+		putAsset(t, stub, record.RegulatoryID, record)
+	}
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.GetRegulatoryRecordsByStatus(ledgerContext(MinFarmOrgMSP, stub), "PENDING", "", 10, ""); err == nil {
+		t.Errorf("a farm listed regulatory records")
+	}
+	if _, err := s.GetRegulatoryRecordsByStatus(regulator, "OPEN", "", 10, ""); err == nil {
+		t.Errorf("accepted an unknown status")
+	}
+
+	page, err := s.GetRegulatoryRecordsByStatus(regulator, "PENDING", "", 10, "")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByStatus failed: %v", err)
+	}
+	if page.Count != 2 || page.Records[0].RegulatoryID != "reg-2" || page.Records[1].RegulatoryID != "reg-1" {
+		t.Errorf("unexpected pending records %+v", page.Records)
+	}
+	page, err = s.GetRegulatoryRecordsByStatus(regulator, "", " export permit ", 10, "")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByStatus failed: %v", err)
+	}
+	if page.Count != 2 || page.Records[0].RegulatoryID != "reg-3" {
+		t.Errorf("unexpected export permits %+v", page.Records)
+	}
+
+	first, err := s.GetRegulatoryRecordsByStatus(regulator, "", "", 2, "")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByStatus failed: %v", err)
+	}
+	second, err := s.GetRegulatoryRecordsByStatus(regulator, "", "", 2, first.Bookmark)
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByStatus failed: %v", err)
+	}
+	if first.Count != 2 || second.Count != 1 {
+		t.Errorf("unexpected page sizes %d, %d", first.Count, second.Count)
+	}
+}
+
+// TestRegulatoryHoldBlocksShipment checks a batch flagged for regulatory
+// approval ships only once it holds an approved, unexpired record, and that
+// split children and merge targets inherit the flag
+func TestRegulatoryHoldBlocksShipment(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	createTransport := func(transportID string) (*TransportAsset, error) {
+		return s.CreateTransportManifest(farm, transportID, "batch-1", 10, "farm-1", "plant-1", "truck-1", "driver",
+			"2025-03-02T08:00:00Z", "2025-03-02T12:00:00Z", "Farm", "Plant", false, "")
+	}
+
+	if _, err := s.SetBatchRegulatoryHold(farm, "batch-1", true); err == nil {
+		t.Errorf("a farm set a regulatory hold")
+	}
+	batch, err := s.SetBatchRegulatoryHold(regulator, "batch-1", true)
+	if err != nil {
+		t.Fatalf("SetBatchRegulatoryHold failed: %v", err)
+	}
+	if !batch.RequiresRegulatoryApproval || stub.eventName != "BatchRegulatoryHoldChanged" {
+		t.Errorf("hold not recorded: %+v, %s", batch, stub.eventName)
+	}
+	if _, err := createTransport("transport-1"); err == nil || !strings.Contains(err.Error(), "requires an approved regulatory record") {
+		t.Errorf("expected the held batch to be refused, got %v", err)
+	}
+
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "APPROVED", ExpiryDate: "2025-02-28"})
+	if _, err := createTransport("transport-1"); err == nil {
+		t.Errorf("an expired approval released the hold")
+	}
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "APPROVED", ExpiryDate: "2025-12-31"})
+	if _, err := createTransport("transport-1"); err != nil {
+		t.Errorf("transport with an approval failed: %v", err)
+	}
+
+	if _, err := s.SetBatchRegulatoryHold(regulator, "batch-1", false); err != nil {
+		t.Fatalf("SetBatchRegulatoryHold failed: %v", err)
+	}
+	if stub.event["requires_regulatory_approval"] != false {
+		t.Errorf("unexpected hold event %v", stub.event)
+	}
+
+	derived := derivationStub(t)
+	farm = ledgerContext(MinFarmOrgMSP, derived)
+	if _, err := s.SetBatchRegulatoryHold(ledgerContext(RegulatorOrgMSP, derived), "batch-1", true); err != nil {
+		t.Fatalf("SetBatchRegulatoryHold failed: %v", err)
+	}
+	children, err := s.SplitBatch(farm, "batch-1", `[{"batch_id": "batch-1a", "batch_number": "B-1A", "quantity": 60, "qr_code": "QR-1a"}, {"batch_id": "batch-1b", "batch_number": "B-1B", "quantity": 40, "qr_code": "QR-1b"}]`)
+	if err != nil {
+		t.Fatalf("SplitBatch failed: %v", err)
+	}
+	if !children[0].RequiresRegulatoryApproval || !children[1].RequiresRegulatoryApproval {
+		t.Errorf("split children did not inherit the hold: %+v", children)
+	}
+	target, err := s.MergeBatches(farm, "batch-m", "B-M", `["batch-1a", "batch-2"]`, "QR-m")
+	if err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+	if !target.RequiresRegulatoryApproval {
+		t.Errorf("merge target did not inherit the hold: %+v", target)
+	}
+}
+
+// TestInspectionAndStopSaleBindRegulator checks inspection records and
+// stop-sales name the acting regulator and any admin acting for them
+func TestInspectionAndStopSaleBindRegulator(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "insp-1", InspectionScheduleAsset{DocType: "InspectionScheduleAsset", ScheduleID: "insp-1", TargetType: "BATCH", TargetID: "batch-1", BatchID: "batch-1", AssignedTo: "inspector-9", Status: "SCHEDULED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	admin := ledgerContext(AdminOrgMSP, stub)
+
+	if _, err := s.CompleteInspection(regulator, "insp-1", "PASS", "", "REGULATORY_RECORD", false); err == nil {
+		t.Errorf("a regulator other than the assignee recorded the inspection")
+	}
+	if _, err := s.CompleteInspection(admin, "insp-1", "PASS", "", "REGULATORY_RECORD", false); err != nil {
+		t.Fatalf("CompleteInspection failed: %v", err)
+	}
+	var record RegulatoryAsset
+	if err := json.Unmarshal(stub.state["insp-1~SANITARY_INSPECTION"], &record); err != nil {
+		t.Fatalf("failed to unmarshal inspection record: %v", err)
+	}
+	if record.RegulatorID != "inspector-9" || record.RegulatorMSP != AdminOrgMSP || record.RecordedOnBehalfBy != "x509::CN=test" {
+		t.Errorf("inspection record not bound to the acting officer: %+v", record)
+	}
+	announced, _ := stub.event["regulatory_record"].(map[string]interface{})
+	if stub.eventName != "InspectionCompleted" || announced["regulatory_id"] != "insp-1~SANITARY_INSPECTION" || announced["status"] != "APPROVED" {
+		t.Errorf("inspection event does not announce the created record: %s %v", stub.eventName, stub.event)
+	}
+
+	if _, err := s.IssueStopSale(admin, "stop-1", "batch-1", "suspected contamination", ""); err == nil {
+		t.Errorf("admin issued a stop-sale without naming the regulator")
+	}
+	stopSale, err := s.IssueStopSale(admin, "stop-1", "batch-1", "suspected contamination", "regulator-3")
+	if err != nil {
+		t.Fatalf("IssueStopSale failed: %v", err)
+	}
+	if stopSale.RegulatorID != "regulator-3" || stopSale.RecordedOnBehalfBy != "x509::CN=test" || stub.event["on_behalf_by"] != "x509::CN=test" {
+		t.Errorf("stop-sale not bound to the acting officer: %+v", stopSale)
+	}
+}
+
+// TestRegulatoryRecordsBindRegulator checks regulatorID is bound to the
+// caller, that an admin must name the regulator it acts for, and that a
+// regulator's records are listed by last update
+func TestRegulatoryRecordsBindRegulator(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", Quantity: 100, Status: "COMPLETED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	admin := ledgerContext(AdminOrgMSP, stub)
+
+	if _, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "MOVEMENT_PERMIT", "", "2026-03-01", "officer-2", "", ""); err == nil {
+		t.Errorf("a regulator recorded a decision for another officer")
+	}
+	record, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "MOVEMENT_PERMIT", "", "2026-03-01", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateRegulatoryRecord failed: %v", err)
+	}
+	if record.RegulatorID != "x509::CN=test" || record.RegulatorMSP != RegulatorOrgMSP || record.RecordedOnBehalfBy != "" {
+		t.Errorf("record not bound to the caller: %+v", record)
+	}
+
+	if _, err := s.CreateRegulatoryRecord(admin, "reg-2", "batch-1", "MOVEMENT_PERMIT", "", "2026-03-01", "", "", ""); err == nil {
+		t.Errorf("admin created a record without naming the regulator")
+	}
+	record, err = s.CreateRegulatoryRecord(admin, "reg-2", "batch-1", "MOVEMENT_PERMIT", "", "2026-03-01", "officer-3", "", "")
+	if err != nil {
+		t.Fatalf("CreateRegulatoryRecord failed: %v", err)
+	}
+	if record.RegulatorID != "officer-3" || record.RecordedOnBehalfBy != "x509::CN=test" {
+		t.Errorf("delegation not recorded: %+v", record)
+	}
+
+	record, err = s.UpdateRegulatoryStatus(admin, "reg-1", "APPROVED", "", "officer-3")
+	if err != nil {
+		t.Fatalf("UpdateRegulatoryStatus failed: %v", err)
+	}
+	last := record.DecisionHistory[len(record.DecisionHistory)-1]
+	if record.RegulatorID != "officer-3" || last.ActorMSP != AdminOrgMSP || last.RegulatorID != "officer-3" || last.OnBehalfBy != "x509::CN=test" {
+		t.Errorf("deciding regulator not recorded: %+v, %+v", record, last)
+	}
+
+	if _, err := s.GetRegulatoryRecordsByRegulator(ledgerContext(MinFarmOrgMSP, stub), "officer-3", "2025-03-01", "2025-03-01"); err == nil {
+		t.Errorf("a farm listed a regulator's records")
+	}
+	records, err := s.GetRegulatoryRecordsByRegulator(regulator, "officer-3", "2025-03-01", "2025-03-01")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByRegulator failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("unexpected records for officer-3 %v", records)
+	}
+	if records, err := s.GetRegulatoryRecordsByRegulator(regulator, "officer-3", "2025-02-01", "2025-02-28"); err != nil || len(records) != 0 {
+		t.Errorf("records outside the range were listed: %v, %v", records, err)
+	}
+}
+
+// TestGetUnshippedCompletedBatches checks completed batches are listed
+// oldest completion first unless a transport that was not cancelled carries
+// them
+func TestGetUnshippedCompletedBatches(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for _, batch := range []BatchAsset{
+		{BatchID: "batch-1", Status: "COMPLETED", ActualEndDate: "2025-02-10"},
+		{BatchID: "batch-2", Status: "COMPLETED", ActualEndDate: "2025-02-01"},
+		{BatchID: "batch-3", Status: "COMPLETED", ActualEndDate: "2025-01-20"},
+		{BatchID: "batch-4", Status: "IN_PROGRESS"},
+	} {
+		batch.DocType = "BatchAsset"
+		putAsset(t, stub, batch.BatchID, batch)
+	}
+	putAsset(t, stub, "tr-1", TransportAsset{DocType: "TransportAsset", TransportID: "tr-1", BatchID: "batch-3", Status: "IN_TRANSIT"})
+	putAsset(t, stub, "tr-2", TransportAsset{DocType: "TransportAsset", TransportID: "tr-2", BatchID: "batch-1", Status: "CANCELLED"})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	batches, err := s.GetUnshippedCompletedBatches(ctx)
+	if err != nil {
+		t.Fatalf("GetUnshippedCompletedBatches failed: %v", err)
+	}
+	if len(batches) != 2 || batches[0].BatchID != "batch-2" || batches[1].BatchID != "batch-1" {
+		t.Errorf("unexpected unshipped batches %v", batches)
+	}
+
+	putAsset(t, stub, "tr-3", TransportAsset{DocType: "TransportAsset", TransportID: "tr-3", BatchID: "batch-1", Status: "COMPLETED"})
+	putAsset(t, stub, "tr-4", TransportAsset{DocType: "TransportAsset", TransportID: "tr-4", BatchID: "batch-2", Status: "INITIATED"})
+	if batches, err := s.GetUnshippedCompletedBatches(ctx); err != nil || batches == nil || len(batches) != 0 {
+		t.Errorf("expected an empty list once everything shipped, got %v, %v", batches, err)
+	}
+}
+
+// TestRegulatoryExpiryQueriesAreBounded checks the expiring-records query
+// honours its limit and the sweep expires only records past their expiry
+func TestRegulatoryExpiryQueriesAreBounded(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for id, expiry := range map[string]string{"reg-1": "2025-02-01", "reg-2": "2025-03-10", "reg-3": "2025-03-20", "reg-4": "2026-01-01"} {
+		putAsset(t, stub, id, RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: id, BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "APPROVED", ExpiryDate: expiry})
+	}
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.GetExpiringRegulatoryRecords(regulator, 30, 0); err == nil {
+		t.Errorf("expected a zero limit to be refused")
+	}
+	expiring, err := s.GetExpiringRegulatoryRecords(regulator, 30, 2)
+	if err != nil {
+		t.Fatalf("GetExpiringRegulatoryRecords failed: %v", err)
+	}
+	if len(expiring) != 2 || expiring[0].RegulatoryID != "reg-1" || expiring[1].RegulatoryID != "reg-2" {
+		t.Errorf("expected the two soonest expiries, got %v", expiring)
+	}
+
+	if _, err := s.CheckAndExpireRegulatoryRecords(ledgerContext(MinFarmOrgMSP, stub), 10); err == nil {
+		t.Errorf("a farm ran the regulatory expiry sweep")
+	}
+	sweep, err := s.CheckAndExpireRegulatoryRecords(regulator, 10)
+	if err != nil {
+		t.Fatalf("CheckAndExpireRegulatoryRecords failed: %v", err)
+	}
+	if sweep.ExpiredCount != 1 || sweep.Records[0].RegulatoryID != "reg-1" || sweep.HasMore {
+		t.Errorf("expected only reg-1 to expire, got %+v", sweep)
+	}
+	if stub.eventName != "RegulatoryRecordExpired" {
+		t.Errorf("expected RegulatoryRecordExpired, got %s", stub.eventName)
+	}
+	if _, err := s.UpdateRegulatoryStatus(regulator, "reg-2", "EXPIRED", "", ""); err == nil {
+		t.Errorf("UpdateRegulatoryStatus moved a record to EXPIRED")
+	}
+}
+
+// TestRenewRegulatoryRecord checks a renewal opens PENDING, links both ways
+// with its predecessor, and can be made only once
+func TestRenewRegulatoryRecord(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "EXPIRED", ExpiryDate: "2025-02-01"})
+	putAsset(t, stub, "reg-p", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-p", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "PENDING"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.RenewRegulatoryRecord(regulator, "reg-2", "reg-p", "2026-03-01", ""); err == nil {
+		t.Errorf("a PENDING record was renewed")
+	}
+	if _, err := s.RenewRegulatoryRecord(regulator, "reg-2", "reg-1", "2025-02-15", ""); err == nil {
+		t.Errorf("accepted a renewal expiring before the transaction time")
+	}
+	renewal, err := s.RenewRegulatoryRecord(regulator, "reg-2", "reg-1", "2026-03-01", "")
+	if err != nil {
+		t.Fatalf("RenewRegulatoryRecord failed: %v", err)
+	}
+	if renewal.Status != "PENDING" || renewal.PreviousID != "reg-1" || renewal.RecordType != "EXPORT_PERMIT" || renewal.ExpiryDate != "2026-03-01" {
+		t.Errorf("unexpected renewal %+v", renewal)
+	}
+	previous, err := s.GetRegulatoryRecord(regulator, "reg-1")
+	if err != nil || previous.SupersededBy != "reg-2" || previous.Status != "EXPIRED" {
+		t.Errorf("predecessor not linked: %+v, %v", previous, err)
+	}
+	if _, err := s.RenewRegulatoryRecord(regulator, "reg-3", "reg-1", "2026-03-01", ""); err == nil || !strings.Contains(err.Error(), "already renewed by reg-2") {
+		t.Errorf("expected a second renewal to be refused, got %v", err)
+	}
+}
+
+// TestIsBatchSellableRequiresApprovalWhenHeld checks a batch held for
+// regulatory approval is not sellable until it holds an active approval
+func TestIsBatchSellableRequiresApprovalWhenHeld(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", Quantity: 100, Status: "COMPLETED", RequiresRegulatoryApproval: true})
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "EXPIRED", ExpiryDate: "2025-02-01"})
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	if sellability, err := s.IsBatchSellable(ctx, "batch-1"); err != nil || sellability.Sellable {
+		t.Errorf("held batch without an approval reported as %+v, %v", sellability, err)
+	}
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "APPROVED", ExpiryDate: "2026-03-01"})
+	if sellability, err := s.IsBatchSellable(ctx, "batch-1"); err != nil || !sellability.Sellable {
+		t.Errorf("approved batch reported as %+v, %v", sellability, err)
+	}
+}
+
+// TestTransportCompletesOnlyAtReceipt checks a transport cannot be completed
+// without the receipt's condition rating
+func TestTransportCompletesOnlyAtReceipt(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "transport-1", TransportAsset{DocType: "TransportAsset", TransportID: "transport-1", BatchID: "batch-1", Status: "IN_TRANSIT"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.UpdateTransportStatus(farm, "transport-1", "COMPLETED"); err == nil || !strings.Contains(err.Error(), "ConfirmTransportReceipt") {
+		t.Fatalf("expected completion to be refused, got %v", err)
+	}
+	transport, err := s.ConfirmTransportReceipt(farm, "transport-1", "", "GOOD", "")
+	if err != nil {
+		t.Fatalf("ConfirmTransportReceipt failed: %v", err)
+	}
+	if transport.Status != "COMPLETED" || transport.ConditionRating != "GOOD" {
+		t.Errorf("unexpected transport after receipt: %s, %q", transport.Status, transport.ConditionRating)
+	}
+}
+
+// TestConfirmTransportReceiptRecordsCondition checks the condition rating is
+// validated, a damaged arrival is announced, and damaged transports are
+// listed most recent arrival first
+func TestConfirmTransportReceiptRecordsCondition(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for _, id := range []string{"transport-1", "transport-2", "transport-3"} {
+		putAsset(t, stub, id, TransportAsset{DocType: "TransportAsset", TransportID: id, BatchID: "batch-1", Status: "IN_TRANSIT"})
+	}
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.ConfirmTransportReceipt(farm, "transport-1", "", "BROKEN", ""); err == nil {
+		t.Errorf("accepted an unknown condition rating")
+	}
+	if _, err := s.ConfirmTransportReceipt(farm, "transport-1", "yesterday", "GOOD", ""); err == nil {
+		t.Errorf("accepted an unparseable arrival time")
+	}
+	transport, err := s.ConfirmTransportReceipt(farm, "transport-1", "2025-03-01T09:00:00Z", "DAMAGED", "crates crushed")
+	if err != nil {
+		t.Fatalf("ConfirmTransportReceipt failed: %v", err)
+	}
+	if transport.ArrivalTime != "2025-03-01T09:00:00Z" || transport.ConditionNotes != "crates crushed" {
+		t.Errorf("receipt not recorded: %+v", transport)
+	}
+	if stub.eventName != "TransportDamaged" || stub.event["condition_rating"] != "DAMAGED" {
+		t.Errorf("unexpected receipt event %s %v", stub.eventName, stub.event)
+	}
+	if _, err := s.ConfirmTransportReceipt(farm, "transport-2", "2025-03-01T11:00:00Z", "SPOILED", ""); err != nil {
+		t.Fatalf("ConfirmTransportReceipt failed: %v", err)
+	}
+	stub.eventName = ""
+	if _, err := s.ConfirmTransportReceipt(farm, "transport-3", "", "GOOD", ""); err != nil {
+		t.Fatalf("ConfirmTransportReceipt failed: %v", err)
+	}
+	if stub.eventName != "" {
+		t.Errorf("a GOOD arrival emitted %s", stub.eventName)
+	}
+	if _, err := s.ConfirmTransportReceipt(farm, "transport-3", "", "GOOD", ""); err == nil {
+		t.Errorf("a completed transport was received again")
+	}
+
+	damaged, err := s.GetDamagedTransports(farm)
+	if err != nil {
+		t.Fatalf("GetDamagedTransports failed: %v", err)
+	}
+	if len(damaged) != 2 || damaged[0].TransportID != "transport-2" || damaged[1].TransportID != "transport-1" {
+		t.Errorf("unexpected damaged transports %v", damaged)
+	}
+}
+
+// TestCriticalViolationBlocksCertification checks an unresolved CRITICAL
+// notice against a batch's farm blocks certification of the batch until it
+// is resolved with evidence, and that only the target's organization and the
+// Regulator can read it
+func TestCriticalViolationBlocksCertification(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", FarmerID: "farm-1", Quantity: 100, Status: "COMPLETED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.IssueViolationNotice(ledgerContext(MinFarmOrgMSP, stub), "notice-1", "FARM", "farm-1", "HYG-01",
+		"CRITICAL", "no handwashing station", "install handwashing station", "2025-04-01", 500); err == nil {
+		t.Fatalf("farm issued a violation notice")
+	}
+	if _, err := s.IssueViolationNotice(regulator, "notice-1", "farm", "farm-1", "HYG-01",
+		"critical", "no handwashing station", "install handwashing station", "2025-04-01", 500); err != nil {
+		t.Fatalf("IssueViolationNotice failed: %v", err)
+	}
+	if stub.eventName != "ViolationNoticeIssued" {
+		t.Errorf("expected ViolationNoticeIssued, got %s", stub.eventName)
+	}
+
+	if err := s.checkNoCriticalViolations(regulator, "", "batch-1"); err == nil || !strings.Contains(err.Error(), "notice-1") {
+		t.Fatalf("expected certification to be blocked by notice-1, got %v", err)
+	}
+
+	notices, err := s.GetViolationNoticesByTarget(ledgerContext(MinFarmOrgMSP, stub), "FARM", "farm-1")
+	if err != nil {
+		t.Fatalf("farm could not read its notices: %v", err)
+	}
+	if len(notices) != 1 || notices[0].Status != "OPEN" {
+		t.Errorf("unexpected notices %+v", notices)
+	}
+	if _, err := s.GetViolationNoticesByTarget(ledgerContext(ProcessorOrgMSP, stub), "FARM", "farm-1"); err == nil {
+		t.Errorf("processor read a farm's notices")
+	}
+
+	if _, err := s.ResolveViolationNotice(regulator, "notice-1", "  "); err == nil {
+		t.Fatalf("notice resolved without evidence")
+	}
+	notice, err := s.ResolveViolationNotice(regulator, "notice-1", "photo of installed station, ref IMG-2291")
+	if err != nil {
+		t.Fatalf("ResolveViolationNotice failed: %v", err)
+	}
+	if notice.Status != "RESOLVED" || notice.ResolvedAt == "" {
+		t.Errorf("unexpected resolved notice %+v", notice)
+	}
+	if err := s.checkNoCriticalViolations(regulator, "", "batch-1"); err != nil {
+		t.Errorf("certification still blocked after resolution: %v", err)
+	}
+}
+
+// TestEscalateViolationNotice checks an open notice escalates with a reason,
+// that IssueCertification refuses while it stands, and that a resolved
+// notice cannot be escalated
+func TestEscalateViolationNotice(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", FarmerID: "farm-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "notice-1", ViolationNoticeAsset{DocType: "ViolationNoticeAsset", NoticeID: "notice-1", TargetType: "BATCH", TargetID: "batch-1", Severity: "CRITICAL", Status: "OPEN"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.IssueCertification(regulator, "cert-1", "", "batch-1", "ORGANIC", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", ""); err == nil || !strings.Contains(err.Error(), "notice-1") {
+		t.Errorf("expected the open CRITICAL notice to block certification, got %v", err)
+	}
+	if _, err := s.EscalateViolationNotice(regulator, "notice-1", "late"); err == nil {
+		t.Errorf("accepted an escalation with a short reason")
+	}
+	notice, err := s.EscalateViolationNotice(regulator, "notice-1", "corrective action deadline missed")
+	if err != nil {
+		t.Fatalf("EscalateViolationNotice failed: %v", err)
+	}
+	if notice.Status != "ESCALATED" {
+		t.Errorf("unexpected escalated notice %+v", notice)
+	}
+	if _, err := s.ResolveViolationNotice(regulator, "notice-1", "lab report LR-77 shows compliance"); err != nil {
+		t.Fatalf("ResolveViolationNotice failed: %v", err)
+	}
+	if _, err := s.EscalateViolationNotice(regulator, "notice-1", "corrective action deadline missed"); err == nil {
+		t.Errorf("a resolved notice was escalated")
+	}
+}
+
+// TestGetCertificationRenewalChain checks the chain is returned oldest first
+// and that a renewal cycle is reported instead of followed
+func TestGetCertificationRenewalChain(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", Status: "SUPERSEDED", SupersededBy: "cert-2"})
+	putAsset(t, stub, "cert-2", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-2", Status: "SUPERSEDED", PreviousCertificationID: "cert-1", SupersededBy: "cert-3"})
+	putAsset(t, stub, "cert-3", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-3", Status: "APPROVED", PreviousCertificationID: "cert-2"})
+	ctx := ledgerContext(RegulatorOrgMSP, stub)
+
+	chain, err := s.GetCertificationRenewalChain(ctx, "cert-3")
+	if err != nil {
+		t.Fatalf("GetCertificationRenewalChain failed: %v", err)
+	}
+	var ids []string
+	for _, certification := range chain {
+		ids = append(ids, certification.CertificationID)
+	}
+	if strings.Join(ids, ",") != "cert-1,cert-2,cert-3" {
+		t.Errorf("unexpected renewal chain %v", ids)
+	}
+
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", Status: "SUPERSEDED", PreviousCertificationID: "cert-3"})
+	if _, err := s.GetCertificationRenewalChain(ctx, "cert-3"); err == nil || !strings.Contains(err.Error(), "loops") {
+		t.Errorf("expected a renewal cycle error, got %v", err)
+	}
+}
+
+// TestApprovalRequiresPassingSample checks a record type set to require
+// samples cannot be approved until a passing sample is attached, and that a
+// failed sample emits RegulatorySampleFailed suggesting a stop-sale
+func TestApprovalRequiresPassingSample(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "PENDING"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	lab := ledgerContext(LabOrgMSP, stub)
+
+	if _, err := s.SetRegulatorySampleRequirement(regulator, "export-permit", true); err != nil {
+		t.Fatalf("SetRegulatorySampleRequirement failed: %v", err)
+	}
+	if _, err := s.UpdateRegulatoryStatus(regulator, "reg-1", "APPROVED", "", ""); err == nil || !strings.Contains(err.Error(), "passing sample") {
+		t.Fatalf("expected approval without samples to fail, got %v", err)
+	}
+
+	if _, err := s.AttachRegulatorySample(ledgerContext(MinFarmOrgMSP, stub), "sample-0", "reg-1", "residue", "2025-03-01", "lab-1", "clean", true, ""); err == nil {
+		t.Fatalf("farm attached a regulatory sample")
+	}
+	if _, err := s.AttachRegulatorySample(lab, "sample-1", "reg-1", "residue", "2025-03-01", "lab-1", "enrofloxacin above MRL", false, ""); err != nil {
+		t.Fatalf("AttachRegulatorySample failed: %v", err)
+	}
+	if stub.eventName != "RegulatorySampleFailed" || stub.event["suggested_action"] != "STOP_SALE" || stub.event["batch_id"] != "batch-1" {
+		t.Errorf("unexpected event %s %v", stub.eventName, stub.event)
+	}
+	if _, err := s.UpdateRegulatoryStatus(regulator, "reg-1", "APPROVED", "", ""); err == nil {
+		t.Fatalf("record approved with only a failed sample")
+	}
+
+	if _, err := s.AttachRegulatorySample(lab, "sample-2", "reg-1", "residue", "2025-03-05", "lab-1", "no residues detected", true, ""); err != nil {
+		t.Fatalf("AttachRegulatorySample failed: %v", err)
+	}
+	if _, err := s.UpdateRegulatoryStatus(regulator, "reg-1", "APPROVED", "", ""); err != nil {
+		t.Fatalf("approval with a passing sample failed: %v", err)
+	}
+
+	samples, err := s.GetRegulatorySamples(regulator, "reg-1")
+	if err != nil {
+		t.Fatalf("GetRegulatorySamples failed: %v", err)
+	}
+	if len(samples) != 2 || samples[0].SampleID != "sample-1" || samples[1].SampleID != "sample-2" {
+		t.Errorf("unexpected samples %+v", samples)
+	}
+}
+
+// TestGetFarmerColdChainScore checks readings are aggregated across the
+// farmer's monitored transports only, and that no monitored transports
+// reports NoData
+func TestGetFarmerColdChainScore(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", FarmerID: "farmer-1"})
+	putAsset(t, stub, "batch-2", BatchAsset{DocType: "BatchAsset", BatchID: "batch-2", FarmerID: "farmer-1"})
+	putAsset(t, stub, "batch-3", BatchAsset{DocType: "BatchAsset", BatchID: "batch-3", FarmerID: "farmer-2"})
+	putAsset(t, stub, "transport-1", TransportAsset{DocType: "TransportAsset", TransportID: "transport-1", BatchID: "batch-1", TemperatureMonitored: true})
+	putAsset(t, stub, "transport-2", TransportAsset{DocType: "TransportAsset", TransportID: "transport-2", BatchID: "batch-2", TemperatureMonitored: true})
+	putAsset(t, stub, "transport-3", TransportAsset{DocType: "TransportAsset", TransportID: "transport-3", BatchID: "batch-2"})
+	putAsset(t, stub, "transport-4", TransportAsset{DocType: "TransportAsset", TransportID: "transport-4", BatchID: "batch-3"})
+	readings := []struct {
+		transportID string
+		violation   bool
+	}{
+		{"transport-1", false}, {"transport-1", true}, {"transport-2", false}, {"transport-2", false}, {"transport-3", true},
+	}
+	for i, reading := range readings {
+		logID := fmt.Sprintf("log-%d", i)
+		putAsset(t, stub, logID, TemperatureLogAsset{DocType: "TemperatureLogAsset", LogID: logID, TransportID: reading.transportID, IsViolation: reading.violation})
+	}
+	ctx := ledgerContext(MinFarmOrgMSP, stub)
+
+	score, err := s.GetFarmerColdChainScore(ctx, "farmer-1")
+	if err != nil {
+		t.Fatalf("GetFarmerColdChainScore failed: %v", err)
+	}
+	if score.NoData || score.BatchCount != 2 || score.MonitoredTransports != 2 || score.TotalReadings != 4 || score.ViolationCount != 1 || score.CompliancePercent != 75 {
+		t.Errorf("unexpected score %+v", score)
+	}
+
+	score, err = s.GetFarmerColdChainScore(ctx, "farmer-2")
+	if err != nil {
+		t.Fatalf("GetFarmerColdChainScore failed: %v", err)
+	}
+	if !score.NoData || score.MonitoredTransports != 0 {
+		t.Errorf("expected NoData for a farmer without monitored transports, got %+v", score)
+	}
+}
+
+// TestReassignRegulatoryRecord checks reassignment moves a record between
+// reviewers' queues, is kept in the decision history, and is refused for
+// closed records
+func TestReassignRegulatoryRecord(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "PENDING", RegulatorID: "officer-1"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "EXPORT_PERMIT", Status: "WITHDRAWN", AssignedTo: "officer-1"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.ReassignRegulatoryRecord(ledgerContext(MinFarmOrgMSP, stub), "reg-1", "officer-2", "officer-1 on leave"); err == nil {
+		t.Fatalf("farm reassigned a regulatory record")
+	}
+	regulatory, err := s.ReassignRegulatoryRecord(ledgerContext(AdminOrgMSP, stub), "reg-1", "officer-2", "officer-1 on leave")
+	if err != nil {
+		t.Fatalf("ReassignRegulatoryRecord failed: %v", err)
+	}
+	last := regulatory.DecisionHistory[len(regulatory.DecisionHistory)-1]
+	if regulatory.AssignedTo != "officer-2" || last.Action != "REASSIGNMENT" || last.PreviousAssignee != "officer-1" || last.AssignedTo != "officer-2" {
+		t.Errorf("unexpected reassigned record %+v", regulatory)
+	}
+	if stub.eventName != "RegulatoryRecordReassigned" {
+		t.Errorf("expected RegulatoryRecordReassigned, got %s", stub.eventName)
+	}
+
+	if _, err := s.ReassignRegulatoryRecord(regulator, "reg-2", "officer-2", "officer-1 on leave"); err == nil {
+		t.Errorf("WITHDRAWN record was reassigned")
+	}
+
+	queue, err := s.GetRegulatoryRecordsByAssignee(regulator, "officer-2", "PENDING")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByAssignee failed: %v", err)
+	}
+	if len(queue) != 1 || queue[0].RegulatoryID != "reg-1" {
+		t.Errorf("unexpected queue %+v", queue)
+	}
+	queue, err = s.GetRegulatoryRecordsByAssignee(regulator, "officer-1", "")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByAssignee failed: %v", err)
+	}
+	if len(queue) != 1 || queue[0].RegulatoryID != "reg-2" {
+		t.Errorf("unexpected queue %+v", queue)
+	}
+}
+
+// TestParseISODuration checks calendar and clock components are parsed and
+// malformed durations are rejected
+func TestParseISODuration(t *testing.T) {
+	start := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	valid := map[string]time.Time{
+		"P3D":       time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
+		"P2W":       time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC),
+		"P1Y2M":     time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		"PT36H":     time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC),
+		"P1DT2H30M": time.Date(2025, 2, 1, 2, 30, 0, 0, time.UTC),
+	}
+	for value, want := range valid {
+		duration, err := parseISODuration(value)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", value, err)
+			continue
+		}
+		if got := duration.addTo(start); !got.Equal(want) {
+			t.Errorf("%s: expected %s, got %s", value, want, got)
+		}
+	}
+
+	s := &SupplyChainContract{}
+	for _, value := range []string{"", "P", "PT", "P1DT", "3D", "P1.5D", "P1H", "PT1D", "P-1D", "P99999999999999999999D"} {
+		if err := s.ValidateISODuration(value, "window"); err == nil {
+			t.Errorf("%q was accepted", value)
+		}
+	}
+}
+
+// TestProcessingWindowMakesBatchOverdue checks a batch without an expected
+// end date is reported overdue once its product's processing window has
+// passed, and that zero and malformed windows are refused
+func TestProcessingWindowMakesBatchOverdue(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true})
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", Quantity: 100, Status: "IN_PROGRESS", StartDate: "2025-03-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	for _, window := range []string{"P0D", "3 days"} {
+		if _, err := s.SetProductProcessingWindow(regulator, "prod-1", window); err == nil {
+			t.Errorf("window %q was accepted", window)
+		}
+	}
+	if _, err := s.SetProductProcessingWindow(regulator, "prod-1", "p3d"); err != nil {
+		t.Fatalf("SetProductProcessingWindow failed: %v", err)
+	}
+
+	overdue := func(asOfDate string) bool {
+		t.Helper()
+		alerts, err := s.GetBatchAlerts(regulator, "batch-1", asOfDate)
+		if err != nil {
+			t.Fatalf("GetBatchAlerts failed: %v", err)
+		}
+		for _, alert := range alerts {
+			if alert.Code == "COMPLETION_OVERDUE" {
+				return true
+			}
+		}
+		return false
+	}
+	if overdue("2025-03-04") {
+		t.Errorf("batch overdue on the last day of its window")
+	}
+	if !overdue("2025-03-05") {
+		t.Errorf("batch not overdue after its window")
+	}
+}
+
+// TestExportPermitAndReadiness checks export permits must come through
+// CreateExportPermit with a valid destination, capture the destination's
+// requirements, and that readiness reports the certifications still missing
+func TestExportPermitAndReadiness(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "cert-1", CertificationAsset{DocType: "CertificationAsset", CertificationID: "cert-1", BatchID: "batch-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-01-01", ExpiryDate: "2026-01-01"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	readiness, err := s.VerifyExportReadiness(regulator, "batch-1", "ae")
+	if err != nil {
+		t.Fatalf("VerifyExportReadiness failed: %v", err)
+	}
+	if readiness.Ready || readiness.RequirementsConfigured {
+		t.Errorf("unconfigured destination reported as %+v", readiness)
+	}
+
+	if _, err := s.SetExportRequirements(regulator, "ae", `["halal", "cold-chain-compliant"]`); err != nil {
+		t.Fatalf("SetExportRequirements failed: %v", err)
+	}
+	readiness, err = s.VerifyExportReadiness(regulator, "batch-1", "AE")
+	if err != nil {
+		t.Fatalf("VerifyExportReadiness failed: %v", err)
+	}
+	if readiness.Ready || strings.Join(readiness.MissingCertTypes, ",") != "COLD_CHAIN_COMPLIANT" {
+		t.Errorf("unexpected readiness %+v", readiness)
+	}
+
+	if _, err := s.CreateRegulatoryRecord(regulator, "reg-1", "batch-1", "export_permit", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "", ""); err == nil || !strings.Contains(err.Error(), "CreateExportPermit") {
+		t.Fatalf("expected CreateRegulatoryRecord to refuse export permits, got %v", err)
+	}
+	for _, countryCode := range []string{"", "ARE", "A1"} {
+		if _, err := s.CreateExportPermit(regulator, "reg-1", "batch-1", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", countryCode, "Gulf Foods", "imp-1", "Jebel Ali", "", ""); err == nil {
+			t.Errorf("country code %q was accepted", countryCode)
+		}
+	}
+	if _, err := s.CreateExportPermit(regulator, "reg-1", "batch-1", "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", "AE", "Gulf Foods", "", "Jebel Ali", "", ""); err == nil {
+		t.Errorf("export permit without an importer ID was accepted")
+	}
+	if _, err := s.CreateExportPermit(regulator, "reg-1", "batch-1", "2026-03-01", "2025-03-01", "", "AE", "Gulf Foods", "imp-1", "Jebel Ali", "", ""); err == nil {
+		t.Errorf("export permit expiring before issue was accepted")
+	}
+	permit, err := s.CreateExportPermit(regulator, "reg-1", "batch-1", "2025-03-01", "2026-03-01", "", "ae", "Gulf Foods", "imp-1", "Jebel Ali", "", "")
+	if err != nil {
+		t.Fatalf("CreateExportPermit failed: %v", err)
+	}
+	if permit.RecordType != ExportPermitRecordType || permit.ExportPermit == nil || permit.ExportPermit.DestinationCountry != "AE" ||
+		strings.Join(permit.ExportPermit.RequiredCertTypes, ",") != "HALAL,COLD_CHAIN_COMPLIANT" {
+		t.Errorf("unexpected export permit %+v", permit)
+	}
+}
+
+// TestRegulatoryDetailSchema checks a declared schema makes details a JSON
+// object with every required key, that types without one keep free text,
+// and that details are size-capped
+func TestRegulatoryDetailSchema(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", Quantity: 100, Status: "COMPLETED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	create := func(regulatoryID, recordType, details string) error {
+		_, err := s.CreateRegulatoryRecord(regulator, regulatoryID, "batch-1", recordType, "2025-03-01T00:00:00Z", "2026-03-01T00:00:00Z", "", details, "")
+		return err
+	}
+
+	if _, err := s.SetRegulatoryRecordDetailSchema(regulator, "MOVEMENT_PERMIT", `["origin", "destination"]`); err == nil {
+		t.Fatalf("regulator declared a details schema")
+	}
+	if _, err := s.SetRegulatoryRecordDetailSchema(ledgerContext(AdminOrgMSP, stub), "movement-permit", `["origin", "destination"]`); err != nil {
+		t.Fatalf("SetRegulatoryRecordDetailSchema failed: %v", err)
+	}
+
+	if err := create("reg-1", "MOVEMENT_PERMIT", "moving to plant"); err == nil || !strings.Contains(err.Error(), "JSON object") {
+		t.Errorf("expected free text to be refused, got %v", err)
+	}
+	if err := create("reg-1", "MOVEMENT_PERMIT", `{"origin": "farm-1", "destination": " "}`); err == nil || !strings.HasSuffix(err.Error(), "missing required keys: destination") {
+		t.Errorf("expected a missing destination error, got %v", err)
+	}
+	if err := create("reg-1", "MOVEMENT_PERMIT", `{"origin": "farm-1", "destination": "plant-1"}`); err != nil {
+		t.Fatalf("valid details refused: %v", err)
+	}
+	if _, err := s.UpdateRegulatoryDetails(regulator, "reg-1", `{"origin": "farm-1"}`, "destination unknown"); err == nil || !strings.Contains(err.Error(), "destination") {
+		t.Errorf("expected the edit to be refused, got %v", err)
+	}
+
+	if err := create("reg-2", "SLAUGHTER_CLEARANCE", "cleared for slaughter"); err != nil {
+		t.Errorf("free text refused for a type without a schema: %v", err)
+	}
+	if err := create("reg-3", "SLAUGHTER_CLEARANCE", strings.Repeat("x", MaxRegulatoryDetailsLength+1)); err == nil {
+		t.Errorf("oversized details were accepted")
+	}
+}
+
+// TestStopSaleBlocksSplit checks a batch under stop-sale cannot be split
+// into new batches that would not carry the hold
+func TestStopSaleBlocksSplit(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", BatchNumber: "B-1", Quantity: 100, Status: "COMPLETED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	parts := `[{"batch_id": "batch-1a", "batch_number": "B-1A", "quantity": 60}, {"batch_id": "batch-1b", "batch_number": "B-1B", "quantity": 40}]`
+
+	if _, err := s.IssueStopSale(regulator, "stop-1", "batch-1", "suspected contamination", ""); err != nil {
+		t.Fatalf("IssueStopSale failed: %v", err)
+	}
+	if _, err := s.SplitBatch(farm, "batch-1", parts); err == nil || !strings.Contains(err.Error(), "stop-sale stop-1") {
+		t.Fatalf("expected the split to be refused, got %v", err)
+	}
+	if _, ok := stub.state["batch-1a"]; ok {
+		t.Errorf("child batch was saved during the stop-sale")
+	}
+
+	if _, err := s.LiftStopSale(regulator, "stop-1", "lab results clear"); err != nil {
+		t.Fatalf("LiftStopSale failed: %v", err)
+	}
+	if _, err := s.SplitBatch(farm, "batch-1", parts); err != nil {
+		t.Fatalf("split after lift failed: %v", err)
+	}
+}
+
+// TestRecordProcessingFlagsImplausibleYield checks a yield above
+// MaxYieldKgPerUnit is refused, or flagged in the ProcessingRecorded event
+// when FlagImplausibleYieldInsteadOfReject is on
+func TestRecordProcessingFlagsImplausibleYield(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	admin := ledgerContext(AdminOrgMSP, stub)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	if _, err := s.SetThreshold(admin, ThresholdMaxYieldPerUnit, 3); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+
+	if _, err := recordSlaughter(s, farm, "proc-1", 100, 400, ""); err == nil {
+		t.Fatalf("implausible yield was recorded")
+	}
+
+	if _, err := s.SetFeatureFlag(admin, FeatureFlagImplausibleYield, true); err != nil {
+		t.Fatalf("SetFeatureFlag failed: %v", err)
+	}
+	processing, err := recordSlaughter(s, farm, "proc-1", 100, 400, "")
+	if err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if !processing.YieldImplausible {
+		t.Errorf("record not flagged as implausible")
+	}
+	if stub.eventName != "ProcessingRecorded" || stub.event["yield_implausible"] != true || stub.event["max_yield_kg_per_unit"] != float64(3) {
+		t.Errorf("expected a flagged ProcessingRecorded event, got %s %v", stub.eventName, stub.event)
+	}
+}
+
+// TestGetTxTimestampSortsInTimeOrder checks stored transaction timestamps
+// compare as strings in time order
+func TestGetTxTimestampSortsInTimeOrder(t *testing.T) {
+	earlier := time.Date(2025, 3, 1, 12, 0, 0, 500000000, time.UTC).Format(ledgerTimestampLayout)
+	later := time.Date(2025, 3, 1, 12, 0, 0, 510000000, time.UTC).Format(ledgerTimestampLayout)
+	if !(earlier < later) || len(earlier) != len(later) {
+		t.Errorf("%s and %s do not sort in time order", earlier, later)
+	}
+	if _, err := time.Parse(time.RFC3339, later); err != nil {
+		t.Errorf("timestamp %s is not RFC3339: %v", later, err)
+	}
+}
+
+// TestSampleRequirementCoversEveryApproval checks inspections cannot create
+// APPROVED records of a type that requires samples, and that renewals open
+// PENDING so their approval needs the sample too
+func TestSampleRequirementCoversEveryApproval(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", Quantity: 100, Status: "COMPLETED"})
+	putAsset(t, stub, "insp-1", InspectionScheduleAsset{DocType: "InspectionScheduleAsset", ScheduleID: "insp-1", TargetType: "BATCH", TargetID: "batch-1", BatchID: "batch-1", Status: "SCHEDULED"})
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "APPROVED"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	if _, err := s.SetRegulatorySampleRequirement(regulator, "SANITARY_INSPECTION", true); err != nil {
+		t.Fatalf("SetRegulatorySampleRequirement failed: %v", err)
+	}
+
+	if _, err := s.CompleteInspection(regulator, "insp-1", "PASS", "", "REGULATORY_RECORD", false); err == nil || !strings.Contains(err.Error(), "passing sample") {
+		t.Errorf("expected the inspection record to need a sample, got %v", err)
+	}
+	if _, ok := stub.state["insp-1~SANITARY_INSPECTION"]; ok {
+		t.Errorf("inspection record was saved without a sample")
+	}
+	renewal, err := s.RenewRegulatoryRecord(regulator, "reg-2", "reg-1", "2026-03-01", "")
+	if err != nil {
+		t.Fatalf("RenewRegulatoryRecord failed: %v", err)
+	}
+	if renewal.Status != "PENDING" {
+		t.Errorf("renewal opened as %s", renewal.Status)
+	}
+	if _, err := s.UpdateRegulatoryStatus(regulator, "reg-2", "APPROVED", "", ""); err == nil || !strings.Contains(err.Error(), "passing sample") {
+		t.Errorf("expected the renewal's approval to need a sample, got %v", err)
+	}
+}
+
+// TestSplitBatchCertificationsFollowSource checks a split refuses reused QR
+// codes and that the children's derived certifications follow their source
+// through suspension, reinstatement and expiry
+func TestSplitBatchCertificationsFollowSource(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := derivationStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	split := func(qrA, qrB string) ([]*BatchAsset, error) {
+		return s.SplitBatch(farm, "batch-1", fmt.Sprintf(`[{"batch_id": "batch-1a", "batch_number": "B-1A", "quantity": 60, "qr_code": %q}, {"batch_id": "batch-1b", "batch_number": "B-1B", "quantity": 40, "qr_code": %q}]`, qrA, qrB))
+	}
+
+	if _, err := split("QR-1", "QR-1"); err == nil || !strings.Contains(err.Error(), "duplicate qr_code") {
+		t.Errorf("expected a repeated QR code to be refused, got %v", err)
+	}
+	if _, err := split("QR-used", "QR-1"); err == nil || !strings.Contains(err.Error(), "already used by batch batch-x") {
+		t.Errorf("expected a QR code in use to be refused, got %v", err)
+	}
+	children, err := split("QR-1a", "QR-1b")
+	if err != nil {
+		t.Fatalf("SplitBatch failed: %v", err)
+	}
+	if len(children) != 2 || children[0].Quantity != 60 || children[1].Quantity != 40 {
+		t.Fatalf("unexpected children: %+v", children)
+	}
+	for _, id := range []string{"batch-1a~ORGANIC", "batch-1b~ORGANIC"} {
+		if derived := storedCertification(t, stub, id); derived.Status != "APPROVED" || len(derived.SourceCertificationIDs) != 1 || derived.SourceCertificationIDs[0] != "cert-batch-1" {
+			t.Errorf("certification %s not carried: %+v", id, derived)
+		}
+	}
+
+	if _, err := s.SuspendCertification(regulator, "cert-batch-1", "audit pending"); err != nil {
+		t.Fatalf("SuspendCertification failed: %v", err)
+	}
+	if derived := storedCertification(t, stub, "batch-1a~ORGANIC"); derived.Status != "SUSPENDED" || derived.StatusFromSource != "cert-batch-1" {
+		t.Errorf("derived certification did not follow the suspension: %s from %q", derived.Status, derived.StatusFromSource)
+	}
+
+	if _, err := s.ReinstateCertification(regulator, "cert-batch-1", "audit passed"); err != nil {
+		t.Fatalf("ReinstateCertification failed: %v", err)
+	}
+	if derived := storedCertification(t, stub, "batch-1b~ORGANIC"); derived.Status != "APPROVED" || derived.StatusFromSource != "" {
+		t.Errorf("derived certification not reinstated: %s from %q", derived.Status, derived.StatusFromSource)
+	}
+
+	if _, err := s.UpdateCertificationStatus(regulator, "cert-batch-1", "EXPIRED"); err != nil {
+		t.Fatalf("UpdateCertificationStatus failed: %v", err)
+	}
+	if derived := storedCertification(t, stub, "batch-1a~ORGANIC"); derived.Status != "EXPIRED" {
+		t.Errorf("derived certification did not follow the expiry: %s", derived.Status)
+	}
+}
+
+// TestMergeBatchesCertificationsFollowSources checks a merge refuses a QR
+// code in use and that the target's derived certification is only
+// reinstated once every suspended source is
+func TestMergeBatchesCertificationsFollowSources(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := derivationStub(t)
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	sources := `["batch-1", "batch-2"]`
+
+	if _, err := s.MergeBatches(farm, "batch-m", "B-M", sources, "QR-used"); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Errorf("expected a QR code in use to be refused, got %v", err)
+	}
+	target, err := s.MergeBatches(farm, "batch-m", "B-M", sources, "QR-m")
+	if err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+	if target.Quantity != 200 || target.ProductID != "prod-1" {
+		t.Errorf("unexpected merge target: %+v", target)
+	}
+	derived := storedCertification(t, stub, "batch-m~ORGANIC")
+	if derived.Status != "APPROVED" || len(derived.SourceCertificationIDs) != 2 {
+		t.Fatalf("certification not carried: %+v", derived)
+	}
+
+	for _, id := range []string{"cert-batch-1", "cert-batch-2"} {
+		if _, err := s.SuspendCertification(regulator, id, "audit pending"); err != nil {
+			t.Fatalf("SuspendCertification %s failed: %v", id, err)
+		}
+	}
+	if _, err := s.ReinstateCertification(regulator, "cert-batch-1", "audit passed"); err != nil {
+		t.Fatalf("ReinstateCertification failed: %v", err)
+	}
+	if derived := storedCertification(t, stub, "batch-m~ORGANIC"); derived.Status != "SUSPENDED" || derived.StatusFromSource != "cert-batch-2" {
+		t.Errorf("derived certification should stay suspended by cert-batch-2: %s from %q", derived.Status, derived.StatusFromSource)
+	}
+	if _, err := s.ReinstateCertification(regulator, "cert-batch-2", "audit passed"); err != nil {
+		t.Fatalf("ReinstateCertification failed: %v", err)
+	}
+	if derived := storedCertification(t, stub, "batch-m~ORGANIC"); derived.Status != "APPROVED" {
+		t.Errorf("derived certification not reinstated: %s", derived.Status)
+	}
+
+	if _, err := s.RevokeCertification(regulator, "cert-batch-2", "fraudulent audit"); err != nil {
+		t.Fatalf("RevokeCertification failed: %v", err)
+	}
+	if derived := storedCertification(t, stub, "batch-m~ORGANIC"); derived.Status != "REVOKED" {
+		t.Errorf("derived certification did not follow the revocation: %s", derived.Status)
+	}
+}
+
+// TestGetRegulatoryRecordsByStatusPagesInCreationOrder checks the records
+// are sorted by the query, so consecutive pages continue in creation order
+func TestGetRegulatoryRecordsByStatusPagesInCreationOrder(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	for id, createdAt := range map[string]string{"reg-a": "2025-02-03T00:00:00Z", "reg-b": "2025-02-01T00:00:00Z", "reg-c": "2025-02-02T00:00:00Z"} {
+		putAsset(t, stub, id, RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: id, BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "PENDING", CreatedAt: createdAt})
+	}
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	first, err := s.GetRegulatoryRecordsByStatus(regulator, "PENDING", "", 2, "")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByStatus failed: %v", err)
+	}
+	second, err := s.GetRegulatoryRecordsByStatus(regulator, "PENDING", "", 2, first.Bookmark)
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByStatus failed: %v", err)
+	}
+	got := []string{}
+	for _, page := range []*RegulatoryRecordPage{first, second} {
+		for _, record := range page.Records {
+			got = append(got, record.RegulatoryID)
+		}
+	}
+	if strings.Join(got, ",") != "reg-b,reg-c,reg-a" {
+		t.Errorf("expected records in creation order across pages, got %v", got)
+	}
+}
+
+// TestGetRegulatoryApprovalRateUsesDecisionTime checks records are counted
+// by when they were decided, not last edited, and stop-sales are left out
+func TestGetRegulatoryApprovalRateUsesDecisionTime(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	decided := func(status, changedAt string) []RegulatoryDecision {
+		return []RegulatoryDecision{
+			{Action: "STATUS_CHANGE", OldStatus: "PENDING", NewStatus: status, ChangedAt: changedAt},
+			{Action: "DETAILS_EDIT", OldStatus: status, NewStatus: status, ChangedAt: "2025-03-01T00:00:00Z"},
+		}
+	}
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", RecordType: "SANITARY_INSPECTION", Status: "APPROVED", DecisionHistory: decided("APPROVED", "2025-02-10T00:00:00Z"), UpdatedAt: "2025-03-01T00:00:00Z"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", RecordType: "SANITARY_INSPECTION", Status: "REJECTED", DecisionHistory: decided("REJECTED", "2025-01-10T00:00:00Z"), UpdatedAt: "2025-03-01T00:00:00Z"})
+	putAsset(t, stub, "stop-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "stop-1", RecordType: StopSaleRecordType, Status: "APPROVED", DecisionHistory: decided("APPROVED", "2025-02-11T00:00:00Z"), UpdatedAt: "2025-02-11T00:00:00Z"})
+
+	rate, err := s.GetRegulatoryApprovalRate(ledgerContext(RegulatorOrgMSP, stub), "2025-02-01", "2025-02-28")
+	if err != nil {
+		t.Fatalf("GetRegulatoryApprovalRate failed: %v", err)
+	}
+	if rate.ApprovedCount != 1 || rate.RejectedCount != 0 {
+		t.Errorf("expected only reg-1 decided in February, got %d approved and %d rejected", rate.ApprovedCount, rate.RejectedCount)
+	}
+}
+
+// TestGetRegulatoryRecordsByAssigneeFallsBackToRegulator checks records
+// without an assignee are in their regulator's queue and no one else's
+func TestGetRegulatoryRecordsByAssigneeFallsBackToRegulator(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", RegulatorID: "reg-officer-1", AssignedTo: "reg-officer-2", Status: "PENDING", UpdatedAt: "2025-02-02T00:00:00Z"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", RegulatorID: "reg-officer-1", Status: "PENDING", UpdatedAt: "2025-02-01T00:00:00Z"})
+	stub.state["reg-3"] = []byte(`{"docType": "RegulatoryAsset", "regulatory_id": "reg-3", "regulator_id": "reg-officer-2", "status": "PENDING", "updated_at": "2025-02-03T00:00:00Z"}`)
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	for reviewerID, want := range map[string]string{"reg-officer-1": "reg-2", "reg-officer-2": "reg-1,reg-3"} {
+		records, err := s.GetRegulatoryRecordsByAssignee(regulator, reviewerID, "")
+		if err != nil {
+			t.Fatalf("GetRegulatoryRecordsByAssignee failed: %v", err)
+		}
+		got := []string{}
+		for _, record := range records {
+			got = append(got, record.RegulatoryID)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("queue of %s: expected %s, got %v", reviewerID, want, got)
+		}
+	}
+}
+
+// TestCertificationValidityFailureCodes checks each failed check carries a
+// code and that certificationFailure ranks the codes, whatever the reasons say
+func TestCertificationValidityFailureCodes(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		status   string
+		codes    string
+		expected string
+	}{
+		{"APPROVED", "EXPIRED", "EXPIRED"},
+		{"SUSPENDED", "SUSPENDED,EXPIRED", "SUSPENDED"},
+		{"REVOKED", "REVOKED,EXPIRED", "REVOKED"},
+		{"PENDING", "NOT_APPROVED,EXPIRED", "EXPIRED"},
+	} {
+		certification := &CertificationAsset{
+			CertificationID: "cert-1",
+			CertType:        "HALAL",
+			Status:          tc.status,
+			IssuedDate:      "2024-01-01",
+			ExpiryDate:      "2025-01-01",
+			RevokedReason:   "expired on paper before revocation",
+			SuspendedReason: "revoked licence under review",
+		}
+		validity := certificationValidity(certification, nil, now)
+		if strings.Join(validity.Failures, ",") != tc.codes {
+			t.Errorf("%s: expected failures %s, got %v", tc.status, tc.codes, validity.Failures)
+		}
+		if failure := certificationFailure(validity); failure != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.status, tc.expected, failure)
+		}
+	}
+}
+
+// TestCertificationReadsRedactForConsumers checks GetCertification and
+// GetCertificationsByBatch apply the consumer redaction
+func TestCertificationReadsRedactForConsumers(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	certification := sensitiveCertification()
+	certification.ProcessingID = ""
+	certification.BatchID = "batch-1"
+	putAsset(t, stub, certification.CertificationID, certification)
+
+	for _, mspID := range []string{"ConsumerMSP", RegulatorOrgMSP} {
+		ctx := ledgerContext(mspID, stub)
+		single, err := s.GetCertification(ctx, certification.CertificationID)
+		if err != nil {
+			t.Fatalf("GetCertification failed: %v", err)
+		}
+		byBatch, err := s.GetCertificationsByBatch(ctx, "batch-1")
+		if err != nil {
+			t.Fatalf("GetCertificationsByBatch failed: %v", err)
+		}
+		output := marshalCertifications(t, append(byBatch, single))
+		redacted := !strings.Contains(output, "internal-regulator-note")
+		if redacted != (mspID == "ConsumerMSP") {
+			t.Errorf("%s: expected redaction %v, got %s", mspID, mspID == "ConsumerMSP", output)
+		}
+	}
+}
+
+// TestExpiryBeforeMatchesEveryDueExpiry checks the sweep selector never
+// leaves out an expiry that is due, whatever its format or offset
+func TestExpiryBeforeMatchesEveryDueExpiry(t *testing.T) {
+	cutoff := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	condition := expiryBefore(cutoff)
+	for _, expiry := range []string{"2025-02-28", "2025-03-01T11:59:00Z", "2025-03-01T23:00:00+14:00", "2025-03-01T01:00:00-10:00"} {
+		if ok, err := memCondition(expiry, condition); err != nil || !ok {
+			t.Errorf("due expiry %s not matched: %v", expiry, err)
+		}
+	}
+	for _, expiry := range []string{"", "2025-03-05", "2026-01-01T00:00:00Z"} {
+		if ok, _ := memCondition(expiry, condition); ok {
+			t.Errorf("expiry %q matched", expiry)
+		}
+	}
+}
+
+// TestGetRegulatoryRecordsByTypeFiltersInQuery checks records are selected by
+// type in the query, including types no longer registered
+func TestGetRegulatoryRecordsByTypeFiltersInQuery(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", RecordType: "LEGACY_PERMIT", Status: "APPROVED", CreatedAt: "2025-01-02T00:00:00Z"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", RecordType: "SANITARY_INSPECTION", Status: "APPROVED", CreatedAt: "2025-01-01T00:00:00Z"})
+
+	records, err := s.GetRegulatoryRecordsByType(ledgerContext(RegulatorOrgMSP, stub), "legacy permit", "")
+	if err != nil {
+		t.Fatalf("GetRegulatoryRecordsByType failed: %v", err)
+	}
+	if len(records) != 1 || records[0].RegulatoryID != "reg-1" {
+		t.Errorf("expected only reg-1, got %v", records)
+	}
+}
+
+// TestRecordProcessingKeepsOnlyUsedOverride checks a clearance override is
+// recorded on the processing record only when it stood in for the clearance
+func TestRecordProcessingKeepsOnlyUsedOverride(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "ovr-1", ClearanceOverrideAsset{DocType: "ClearanceOverrideAsset", OverrideID: "ovr-1", BatchID: "batch-1", Reason: "lab backlog"})
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	processing, err := recordSlaughter(s, farm, "proc-1", 40, 60, "ovr-1")
+	if err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if processing.OverrideID != "" {
+		t.Errorf("override %s recorded although no clearance was required", processing.OverrideID)
+	}
+
+	putAsset(t, stub, "prod-1", ProductAsset{DocType: "ProductAsset", ProductID: "prod-1", Name: "Broiler", IsActive: true, ProcessingClearance: "SANITARY_INSPECTION"})
+	processing, err = recordSlaughter(s, farm, "proc-2", 40, 60, "ovr-1")
+	if err != nil {
+		t.Fatalf("RecordProcessing failed: %v", err)
+	}
+	if processing.OverrideID != "ovr-1" {
+		t.Errorf("expected the used override to be recorded, got %q", processing.OverrideID)
+	}
+}
+
+// TestUpdateRegulatoryDetailsOnlyUnderReview checks decided records cannot be
+// edited and that an edit keeps a hash of the replaced details, not a copy
+func TestUpdateRegulatoryDetailsOnlyUnderReview(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "APPROVED", Details: "original findings"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "NEEDS_INFO", Details: "original findings"})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+
+	if _, err := s.UpdateRegulatoryDetails(regulator, "reg-1", "rewritten findings", "typo"); err == nil {
+		t.Errorf("edited an APPROVED record")
+	}
+	regulatory, err := s.UpdateRegulatoryDetails(regulator, "reg-2", "findings with lab report", "lab report attached")
+	if err != nil {
+		t.Fatalf("UpdateRegulatoryDetails failed: %v", err)
+	}
+	edit := regulatory.DecisionHistory[len(regulatory.DecisionHistory)-1]
+	if edit.PreviousDetailsSHA256 != fmt.Sprintf("%x", sha256.Sum256([]byte("original findings"))) {
+		t.Errorf("unexpected previous details hash %q", edit.PreviousDetailsSHA256)
+	}
+	if strings.Contains(string(stub.state["reg-2"]), "original findings") {
+		t.Errorf("stored record still holds the replaced details")
+	}
+}
+
+// TestReopenRegulatoryRecordKeepsDetails checks reopening appends the note to
+// free-text details within the size cap, leaves schema-bound details
+// untouched and always keeps the note in the decision history
+func TestReopenRegulatoryRecordKeepsDetails(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "REJECTED", RejectionReason: "missing lab report", Details: "original findings"})
+	putAsset(t, stub, "reg-2", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-2", BatchID: "batch-1", RecordType: "MOVEMENT_PERMIT", Status: "REJECTED", RejectionReason: "wrong destination", Details: `{"origin": "farm-1", "destination": "plant-1"}`})
+	putAsset(t, stub, "reg-3", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-3", BatchID: "batch-1", RecordType: "SANITARY_INSPECTION", Status: "REJECTED", RejectionReason: "missing lab report", Details: strings.Repeat("x", MaxRegulatoryDetailsLength-10)})
+	regulator := ledgerContext(RegulatorOrgMSP, stub)
+	if _, err := s.SetRegulatoryRecordDetailSchema(ledgerContext(AdminOrgMSP, stub), "MOVEMENT_PERMIT", `["origin", "destination"]`); err != nil {
+		t.Fatalf("SetRegulatoryRecordDetailSchema failed: %v", err)
+	}
+
+	regulatory, err := s.ReopenRegulatoryRecord(regulator, "reg-1", "lab report received")
+	if err != nil {
+		t.Fatalf("ReopenRegulatoryRecord failed: %v", err)
+	}
+	if regulatory.Status != "PENDING" || regulatory.Details != "original findings\nReopened: lab report received" || regulatory.RejectionReason != "" {
+		t.Errorf("unexpected reopened record: %s, %q, %q", regulatory.Status, regulatory.Details, regulatory.RejectionReason)
+	}
+	if last := regulatory.DecisionHistory[len(regulatory.DecisionHistory)-1]; last.Action != "REOPENED" || last.Reason != "lab report received" {
+		t.Errorf("note not kept in the decision history: %+v", last)
+	}
+
+	regulatory, err = s.ReopenRegulatoryRecord(regulator, "reg-2", "destination confirmed")
+	if err != nil {
+		t.Fatalf("ReopenRegulatoryRecord failed: %v", err)
+	}
+	if regulatory.Details != `{"origin": "farm-1", "destination": "plant-1"}` {
+		t.Errorf("schema-bound details changed: %q", regulatory.Details)
+	}
+	if last := regulatory.DecisionHistory[len(regulatory.DecisionHistory)-1]; last.Action != "REOPENED" || last.Reason != "destination confirmed" {
+		t.Errorf("note not kept in the decision history: %+v", last)
+	}
+
+	if _, err := s.ReopenRegulatoryRecord(regulator, "reg-3", "lab report received"); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("expected details over the cap to be refused, got %v", err)
+	}
+}
+
+// TestSetFarmerDisplayNameBindsCaller checks farmers can only name
+// themselves, that an admin names the farmer it acts for, and that the
+// change is announced
+func TestSetFarmerDisplayNameBindsCaller(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+
+	if _, err := s.SetFarmerDisplayName(ledgerContext(MinFarmOrgMSP, stub), "farm-other", "Green Acres"); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected another farmer's name to be refused, got %v", err)
+	}
+	profile, err := s.SetFarmerDisplayName(ledgerContext(MinFarmOrgMSP, stub), "", "Green Acres")
+	if err != nil {
+		t.Fatalf("SetFarmerDisplayName failed: %v", err)
+	}
+	if profile.FarmerID != "x509::CN=test" || stub.eventName != "FarmerDisplayNameSet" {
+		t.Errorf("unexpected profile %+v or event %s", profile, stub.eventName)
+	}
+
+	if _, err := s.SetFarmerDisplayName(ledgerContext(AdminOrgMSP, stub), "", "Green Acres"); err == nil {
+		t.Errorf("admin set a name without naming the farmer")
+	}
+	if _, err := s.SetFarmerDisplayName(ledgerContext(AdminOrgMSP, stub), "farm-other", "Hill Farm"); err != nil {
+		t.Fatalf("admin SetFarmerDisplayName failed: %v", err)
+	}
+	if stub.event["farmer_id"] != "farm-other" || stub.event["on_behalf_by"] != "x509::CN=test" {
+		t.Errorf("unexpected event payload %v", stub.event)
+	}
+}
+
+// TestPurgeCancelledBatchHandlesDependents checks regulatory evidence blocks a
+// purge and that unused clearance overrides are deleted with the batch
+func TestPurgeCancelledBatchHandlesDependents(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "batch-1", BatchAsset{DocType: "BatchAsset", BatchID: "batch-1", ProductID: "prod-1", BatchNumber: "B-1", Quantity: 100, Status: "CANCELLED"})
+	putAsset(t, stub, "ovr-1", ClearanceOverrideAsset{DocType: "ClearanceOverrideAsset", OverrideID: "ovr-1", BatchID: "batch-1", Reason: "lab backlog"})
+	putAsset(t, stub, "notice-1", ViolationNoticeAsset{DocType: "ViolationNoticeAsset", NoticeID: "notice-1", TargetType: "BATCH", TargetID: "batch-1", Severity: "MINOR", Status: "RESOLVED"})
+	admin := ledgerContext(AdminOrgMSP, stub)
+
+	if err := s.PurgeCancelledBatch(admin, "batch-1"); err == nil || !strings.Contains(err.Error(), "violation notices") {
+		t.Fatalf("expected the violation notice to block the purge, got %v", err)
+	}
+	if _, ok := stub.state["batch-1"]; !ok {
+		t.Fatalf("batch was deleted although the purge was refused")
+	}
+
+	delete(stub.state, "notice-1")
+	if err := s.PurgeCancelledBatch(admin, "batch-1"); err != nil {
+		t.Fatalf("PurgeCancelledBatch failed: %v", err)
+	}
+	for _, key := range []string{"batch-1", "ovr-1"} {
+		if _, ok := stub.state[key]; ok {
+			t.Errorf("%s was not deleted", key)
+		}
+	}
+}
+
+// TestGetFacilityProcessingStatsUsesAmendedDate checks records are counted by
+// their approved amended processing date rather than the stored one
+func TestGetFacilityProcessingStatsUsesAmendedDate(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := processingStub(t)
+	putAsset(t, stub, "proc-in", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-in", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-01-20", SlaughterCnt: 10, YieldKg: 20})
+	putAsset(t, stub, "proc-out", ProcessingAsset{DocType: "ProcessingAsset", ProcessingID: "proc-out", BatchID: "batch-1", FacilityID: "fac-1", ProcessDate: "2025-01-10", SlaughterCnt: 30, YieldKg: 60})
+	putAsset(t, stub, "amend-in", ProcessingAmendmentAsset{DocType: "ProcessingAmendmentAsset", AmendmentID: "amend-in", ProcessingID: "proc-in", Status: "APPROVED", Corrections: ProcessingCorrection{Fields: []string{"processing_date"}, ProcessDate: "2025-02-20"}})
+	putAsset(t, stub, "amend-out", ProcessingAmendmentAsset{DocType: "ProcessingAmendmentAsset", AmendmentID: "amend-out", ProcessingID: "proc-out", Status: "APPROVED", Corrections: ProcessingCorrection{Fields: []string{"processing_date"}, ProcessDate: "2025-01-18"}})
+
+	stats, err := s.GetFacilityProcessingStats(ledgerContext(RegulatorOrgMSP, stub), "fac-1", "2025-01-15", "2025-01-31")
+	if err != nil {
+		t.Fatalf("GetFacilityProcessingStats failed: %v", err)
+	}
+	if stats.RecordCount != 1 || stats.TotalSlaughterCount != 30 {
+		t.Errorf("expected only proc-out, amended into the range, got %d records and %d slaughtered", stats.RecordCount, stats.TotalSlaughterCount)
+	}
+}
+
+// TestCertificationValiditySupersededOnlyByIssuedSuccessor checks a renewal
+// dated in the future leaves its predecessor valid until it is issued
+func TestCertificationValiditySupersededOnlyByIssuedSuccessor(t *testing.T) {
+	current := &CertificationAsset{CertificationID: "cert-1", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2024-06-01", ExpiryDate: "2025-06-01"}
+	successor := &CertificationAsset{CertificationID: "cert-2", CertType: "HALAL", Status: "APPROVED", IssuedDate: "2025-04-01", ExpiryDate: "2026-04-01"}
+	siblings := []*CertificationAsset{current, successor}
+
+	if validity := certificationValidity(current, siblings, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)); !validity.Valid {
+		t.Errorf("certification superseded by a successor not yet issued: %v", validity.Reasons)
+	}
+	validity := certificationValidity(current, siblings, time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC))
+	if validity.Valid || strings.Join(validity.Failures, ",") != "SUPERSEDED" {
+		t.Errorf("expected the issued successor to supersede, got %v", validity.Failures)
+	}
+}
+
+// TestIssueCertificationAuthorizesFirst checks a caller without any
+// accreditation is refused before its arguments are validated
+func TestIssueCertificationAuthorizesFirst(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	farm := ledgerContext(MinFarmOrgMSP, stub)
+
+	if _, err := s.IssueCertification(farm, "bad id!", "", "", "NOT_A_TYPE", "", "", "", "{"); err == nil || !strings.HasPrefix(err.Error(), "unauthorized") {
+		t.Errorf("expected an authorization error, got %v", err)
+	}
+	if _, err := s.RenewCertification(farm, "bad id!", "missing-cert", "", "", "", "", ""); err == nil || !strings.HasPrefix(err.Error(), "unauthorized") {
+		t.Errorf("expected an authorization error on renewal, got %v", err)
+	}
+}
+
+// TestGetRegulatoryRecordsByAuditFlagRequiresRegulator checks the audit flag
+// queue is closed to other organizations
+func TestGetRegulatoryRecordsByAuditFlagRequiresRegulator(t *testing.T) {
+	s := &SupplyChainContract{}
+	stub := newMemStub()
+	putAsset(t, stub, "reg-1", RegulatoryAsset{DocType: "RegulatoryAsset", RegulatoryID: "reg-1", BatchID: "batch-1", Status: "PENDING", AuditFlags: AuditFlagList{"SAMPLING_REQUIRED"}})
+
+	if _, err := s.GetRegulatoryRecordsByAuditFlag(ledgerContext(MinFarmOrgMSP, stub), "SAMPLING_REQUIRED"); err == nil || !strings.HasPrefix(err.Error(), "unauthorized") {
+		t.Errorf("expected the farm to be refused, got %v", err)
+	}
+	records, err := s.GetRegulatoryRecordsByAuditFlag(ledgerContext(RegulatorOrgMSP, stub), "SAMPLING_REQUIRED")
+	if err != nil || len(records) != 1 {
+		t.Errorf("expected the regulator to see reg-1, got %d records, %v", len(records), err)
+	}
+}